@@ -49,7 +49,7 @@ func mockAnthropicServer(t *testing.T, responseText string) *httptest.Server {
 // This lets E2E tests verify output without capturing os.Stdout.
 func runContext(t *testing.T, sessionDir string, out, errBuf *bytes.Buffer, args ...string) error {
 	t.Helper()
-	cfg := loadConfig()
+	cfg := loadConfig(os.Stderr, false)
 	st, err := agentstore.NewJSONStore(sessionDir)
 	if err != nil {
 		return fmt.Errorf("runContext: create store: %w", err)
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 
@@ -11,6 +15,7 @@ import (
 	_ "github.com/mrlm-net/cure/internal/agent/openai"
 	"github.com/mrlm-net/cure/internal/commands"
 	"github.com/mrlm-net/cure/internal/commands/completion"
+	configcmd "github.com/mrlm-net/cure/internal/commands/config"
 	ctxcmd "github.com/mrlm-net/cure/internal/commands/context"
 	"github.com/mrlm-net/cure/internal/commands/doctor"
 	"github.com/mrlm-net/cure/internal/commands/generate"
@@ -27,16 +32,32 @@ import (
 
 func main() {
 	if err := run(os.Args[1:]); err != nil {
+		// A plugin has already reported its own failure; pass its status on.
+		var exitErr terminal.ExitCoder
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			os.Exit(exitErr.ExitCode())
+		}
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
 func run(args []string) error {
+	args, global, err := parseGlobalFlags(args, os.Stderr)
+	if err != nil {
+		return err
+	}
+
 	// Load config with precedence: defaults → global → local → env
-	cfg := loadConfig()
+	cfg := loadConfig(os.Stderr, global.logLevel == "debug")
 	template.SetConfig(cfg) // wire custom template directories
 
+	// Logs go to stderr so NDJSON on stdout stays clean.
+	logger, err := newLogger(cfg, global.logLevel, os.Stderr)
+	if err != nil {
+		return err
+	}
+
 	// Initialise the session store for the context command group.
 	storeDir, err := ctxcmd.DefaultStoreDir()
 	if err != nil {
@@ -47,12 +68,32 @@ func run(args []string) error {
 		return fmt.Errorf("failed to initialise session store: %w", err)
 	}
 
-	router := terminal.New(terminal.WithConfig(cfg))
+	routerOpts := []terminal.Option{
+		terminal.WithConfig(cfg),
+		terminal.WithPluginPrefix("cure-"),
+		terminal.WithNonInteractive(global.nonInteractive),
+		terminal.WithAssumeYes(global.yes),
+	}
+	if logger != nil {
+		routerOpts = append(routerOpts, terminal.WithLogger(logger))
+	}
+	if path, _ := cfg.Get("audit.file", "").(string); path != "" {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open audit log: %w", err)
+		}
+		defer f.Close()
+		auditArgs, _ := cfg.Get("audit.args", false).(bool)
+		routerOpts = append(routerOpts, terminal.WithAuditLog(f), terminal.WithAuditArgs(auditArgs))
+	}
+	router := terminal.New(routerOpts...)
 	router.Register(commands.NewVersionCommand())
 	router.Register(terminal.NewHelpCommand(router))
+	commands.RegisterTopics(router)
 	router.Register(trace.NewTraceCommand())
 	router.Register(doctor.NewDoctorCommand())
 	router.Register(generate.NewGenerateCommand())
+	router.Register(configcmd.NewConfigCommand())
 	// Register context command BEFORE completion so it is included in completions.
 	router.Register(ctxcmd.NewContextCommand(sessionStore))
 	// Register init BEFORE completion so it is visible to completion introspection.
@@ -65,7 +106,76 @@ func run(args []string) error {
 	return router.RunArgs(args)
 }
 
-func loadConfig() *config.Config {
+// globalFlags are the flags that precede the command name.
+type globalFlags struct {
+	logLevel       string
+	nonInteractive bool
+	yes            bool
+}
+
+// parseGlobalFlags parses the flags that precede the command name, such as
+// "cure --log-level debug trace http ...", and returns the remaining args.
+// --verbose is shorthand for --log-level debug. -h and --help print the
+// global flags to stderr and return the args of the help command.
+func parseGlobalFlags(args []string, stderr io.Writer) ([]string, globalFlags, error) {
+	var g globalFlags
+	fs := flag.NewFlagSet("cure", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: cure [global flags] <command> [args]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Global flags:")
+		fs.PrintDefaults()
+		fmt.Fprintln(stderr)
+	}
+	verbose := fs.Bool("verbose", false, "Enable debug logging on stderr (same as --log-level debug)")
+	fs.StringVar(&g.logLevel, "log-level", "", "Log to stderr at this level (debug, info, warn, error)")
+	fs.BoolVar(&g.nonInteractive, "non-interactive", false, "Never prompt; commands take their input from flags only")
+	fs.BoolVar(&g.yes, "yes", false, "Answer yes to every confirmation, such as overwriting a file, without prompting")
+	fs.BoolVar(&g.yes, "y", false, "Shorthand for --yes")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return []string{"help"}, g, nil
+		}
+		return nil, globalFlags{}, err
+	}
+	if *verbose && g.logLevel == "" {
+		g.logLevel = "debug"
+	}
+	return fs.Args(), g, nil
+}
+
+// newLogger returns a text logger writing to w at the level given by
+// flagLevel, the "log_level" config key, or "verbose" (debug), in that order.
+// It returns nil when none is set so that nothing is logged.
+func newLogger(cfg *config.Config, flagLevel string, w io.Writer) (*slog.Logger, error) {
+	level := flagLevel
+	if level == "" {
+		level, _ = cfg.Get("log_level", "").(string)
+	}
+	if level == "" {
+		if verbose, _ := cfg.Get("verbose", false).(bool); verbose {
+			level = "debug"
+		}
+	}
+	if level == "" {
+		return nil, nil
+	}
+
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q (want debug, info, warn, or error)", level)
+	}
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: l})), nil
+}
+
+// loadConfig merges the defaults, global and local config files, and CURE_*
+// environment variables, writing warnings about them to stderr.
+//
+// When debug is set, or the merged config has "verbose" true, a report of
+// each source's values and the effective result is written to stderr; see
+// [config.DebugMerge].
+func loadConfig(stderr io.Writer, debug bool) *config.Config {
 	// Defaults (lowest precedence)
 	defaults := config.ConfigObject{
 		"timeout": 30,
@@ -80,12 +190,13 @@ func loadConfig() *config.Config {
 	// Global config (~/.cure.json)
 	homeDir, _ := os.UserHomeDir()
 	var globalCfg config.ConfigObject
+	globalPath := "~/.cure.json"
 	if homeDir != "" {
-		globalPath := filepath.Join(homeDir, ".cure.json")
+		globalPath = filepath.Join(homeDir, ".cure.json")
 		if cfg, err := config.File(globalPath); err == nil {
 			globalCfg = cfg
 		} else if !os.IsNotExist(err) {
-			fmt.Fprintf(os.Stderr, "warning: failed to load %s: %v\n", globalPath, err)
+			fmt.Fprintf(stderr, "warning: failed to load %s: %v\n", globalPath, err)
 		}
 	}
 
@@ -93,7 +204,7 @@ func loadConfig() *config.Config {
 	localPath := ".cure.json"
 	localCfg, err := config.File(localPath)
 	if err != nil && !os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "warning: failed to load %s: %v\n", localPath, err)
+		fmt.Fprintf(stderr, "warning: failed to load %s: %v\n", localPath, err)
 	}
 
 	// Environment variables (highest precedence for file-based config)
@@ -101,5 +212,36 @@ func loadConfig() *config.Config {
 
 	// Merge with precedence: defaults < global < local < env
 	// Note: CLI flags are applied per-command, not here
-	return config.NewConfig(defaults, globalCfg, localCfg, envCfg)
+	cfg := config.NewConfig(defaults, globalCfg, localCfg, envCfg)
+	config.Normalize(cfg, configSchema)
+	if verbose, _ := cfg.Get("verbose", false).(bool); debug || verbose {
+		cfg = config.DebugMerge(stderr,
+			config.Layer{Name: "defaults", Values: defaults},
+			config.Layer{Name: "global (" + globalPath + ")", Values: globalCfg},
+			config.Layer{Name: "local (" + localPath + ")", Values: localCfg},
+			config.Layer{Name: "env (CURE_*)", Values: envCfg},
+		)
+		config.Normalize(cfg, configSchema)
+	}
+
+	// Warn rather than fail so a bad key never blocks unrelated commands.
+	for _, err := range config.Validate(cfg, configSchema) {
+		fmt.Fprintf(stderr, "warning: %v\n", err)
+	}
+	return cfg
+}
+
+// configSchema describes the top-level keys commands read with type
+// assertions. loadConfig normalises the merged values to these types, so
+// JSON numbers and CURE_* strings pass, and reports the rest before they
+// cause a panic.
+var configSchema = config.Schema{
+	"timeout":    {Type: config.TypeInt, Required: true},
+	"format":     {Type: config.TypeString, Required: true, Enum: []string{"json", "json-array", "html", "template"}},
+	"template":   {Type: config.TypeString},
+	"verbose":    {Type: config.TypeBool},
+	"log_level":  {Type: config.TypeString, Enum: []string{"debug", "info", "warn", "error"}},
+	"redact":     {Type: config.TypeBool},
+	"audit.file": {Type: config.TypeString},
+	"audit.args": {Type: config.TypeBool},
 }
@@ -2,10 +2,13 @@ package main
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/mrlm-net/cure/internal/commands"
+	"github.com/mrlm-net/cure/pkg/config"
 	"github.com/mrlm-net/cure/pkg/terminal"
 )
 
@@ -148,3 +151,197 @@ func TestRun_HelpVersionOutput(t *testing.T) {
 		t.Errorf("help version output = %q, want to contain %q", got, want)
 	}
 }
+
+func TestParseGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name               string
+		args               []string
+		wantArgs           []string
+		wantLevel          string
+		wantNonInteractive bool
+		wantYes            bool
+	}{
+		{"no flags", []string{"trace", "http", "--verbose"}, []string{"trace", "http", "--verbose"}, "", false, false},
+		{"verbose", []string{"--verbose", "version"}, []string{"version"}, "debug", false, false},
+		{"log level", []string{"--log-level", "warn", "version"}, []string{"version"}, "warn", false, false},
+		{"log level wins over verbose", []string{"--verbose", "--log-level", "error", "version"}, []string{"version"}, "error", false, false},
+		{"non-interactive", []string{"--non-interactive", "generate", "claude-md"}, []string{"generate", "claude-md"}, "", true, false},
+		{"yes", []string{"--yes", "generate", "claude-md"}, []string{"generate", "claude-md"}, "", false, true},
+		{"y", []string{"-y", "--non-interactive", "generate", "claude-md"}, []string{"generate", "claude-md"}, "", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, global, err := parseGlobalFlags(tt.args, &bytes.Buffer{})
+			if err != nil {
+				t.Fatalf("parseGlobalFlags() error = %v", err)
+			}
+			if strings.Join(args, " ") != strings.Join(tt.wantArgs, " ") {
+				t.Errorf("parseGlobalFlags() args = %q, want %q", args, tt.wantArgs)
+			}
+			if global.logLevel != tt.wantLevel {
+				t.Errorf("parseGlobalFlags() level = %q, want %q", global.logLevel, tt.wantLevel)
+			}
+			if global.nonInteractive != tt.wantNonInteractive {
+				t.Errorf("parseGlobalFlags() nonInteractive = %v, want %v", global.nonInteractive, tt.wantNonInteractive)
+			}
+			if global.yes != tt.wantYes {
+				t.Errorf("parseGlobalFlags() yes = %v, want %v", global.yes, tt.wantYes)
+			}
+		})
+	}
+}
+
+func TestParseGlobalFlags_Help(t *testing.T) {
+	for _, arg := range []string{"-h", "--help"} {
+		t.Run(arg, func(t *testing.T) {
+			var stderr bytes.Buffer
+			args, _, err := parseGlobalFlags([]string{arg}, &stderr)
+			if err != nil {
+				t.Fatalf("parseGlobalFlags() error = %v, want nil", err)
+			}
+			if strings.Join(args, " ") != "help" {
+				t.Errorf("parseGlobalFlags() args = %q, want [help]", args)
+			}
+			if !strings.Contains(stderr.String(), "-non-interactive") {
+				t.Errorf("stderr = %q, want the global flags listed", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_HelpFlag(t *testing.T) {
+	for _, arg := range []string{"-h", "--help"} {
+		if err := run([]string{arg}); err != nil {
+			t.Errorf("run([%s]) = %v, want nil", arg, err)
+		}
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       config.ConfigObject
+		flagLevel string
+		wantNil   bool
+		wantDebug bool
+		wantErr   bool
+	}{
+		{name: "unset", cfg: config.ConfigObject{"verbose": false}, wantNil: true},
+		{name: "verbose config", cfg: config.ConfigObject{"verbose": true}, wantDebug: true},
+		{name: "log_level config", cfg: config.ConfigObject{"log_level": "info"}},
+		{name: "flag wins over config", cfg: config.ConfigObject{"log_level": "error"}, flagLevel: "debug", wantDebug: true},
+		{name: "invalid level", cfg: config.ConfigObject{}, flagLevel: "loud", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := newLogger(config.NewConfig(tt.cfg), tt.flagLevel, &buf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newLogger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (logger == nil) != tt.wantNil {
+				t.Fatalf("newLogger() = %v, wantNil %v", logger, tt.wantNil)
+			}
+			if logger == nil {
+				return
+			}
+
+			logger.Debug("debug message")
+			if got := strings.Contains(buf.String(), "debug message"); got != tt.wantDebug {
+				t.Errorf("debug logged = %v, want %v; output:\n%s", got, tt.wantDebug, buf.String())
+			}
+		})
+	}
+}
+
+func TestLoadConfig_NumericValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		local       string
+		env         map[string]string
+		wantTimeout int
+		wantWarning bool
+	}{
+		{name: "JSON number", local: `{"timeout": 10}`, wantTimeout: 10},
+		{name: "environment string", local: `{}`, env: map[string]string{"CURE_TIMEOUT": "5"}, wantTimeout: 5},
+		{name: "environment wins over file", local: `{"timeout": 10}`, env: map[string]string{"CURE_TIMEOUT": "5", "CURE_VERBOSE": "false"}, wantTimeout: 5},
+		{name: "not a number", local: `{"timeout": "ten"}`, wantTimeout: 0, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("HOME", dir)
+			t.Chdir(dir)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if err := os.WriteFile(filepath.Join(dir, ".cure.json"), []byte(tt.local), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			var stderr bytes.Buffer
+			cfg := loadConfig(&stderr, false)
+			if got := strings.Contains(stderr.String(), "warning:"); got != tt.wantWarning {
+				t.Errorf("warning written = %v, want %v; stderr:\n%s", got, tt.wantWarning, stderr.String())
+			}
+			if tt.wantWarning {
+				return
+			}
+			if got, ok := cfg.Get("timeout").(int); !ok || got != tt.wantTimeout {
+				t.Errorf("timeout = %#v, want int %d", cfg.Get("timeout"), tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestConfigSchema_Formats(t *testing.T) {
+	for _, format := range []string{"json", "json-array", "html", "template"} {
+		cfg := config.NewConfig(config.ConfigObject{"timeout": 30, "format": format})
+		if errs := config.Validate(cfg, configSchema); len(errs) != 0 {
+			t.Errorf("format %q: Validate() = %v, want no errors", format, errs)
+		}
+	}
+}
+
+func TestLoadConfig_MergeReport(t *testing.T) {
+	tests := []struct {
+		name       string
+		local      string
+		debug      bool
+		wantReport bool
+	}{
+		{name: "quiet by default", local: `{"timeout": 60}`},
+		{name: "verbose config", local: `{"timeout": 60, "verbose": true}`, wantReport: true},
+		{name: "debug flag", local: `{"timeout": 60}`, debug: true, wantReport: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("HOME", dir)
+			t.Chdir(dir)
+			if err := os.WriteFile(filepath.Join(dir, ".cure.json"), []byte(tt.local), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			var stderr bytes.Buffer
+			cfg := loadConfig(&stderr, tt.debug)
+			if got := cfg.Get("timeout", 0); got != 60 {
+				t.Errorf("timeout = %v, want 60", got)
+			}
+			report := stderr.String()
+			if got := strings.Contains(report, "config: effective:"); got != tt.wantReport {
+				t.Fatalf("report written = %v, want %v; stderr:\n%s", got, tt.wantReport, report)
+			}
+			if tt.wantReport && !strings.Contains(report, "config:   timeout = 60 (local (.cure.json))") {
+				t.Errorf("report does not attribute timeout to the local file:\n%s", report)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
@@ -13,6 +14,7 @@ import (
 // BashCommand generates a bash completion script by traversing the command registry.
 type BashCommand struct {
 	registry terminal.CommandRegistry
+	program  string
 }
 
 // Name returns "bash".
@@ -59,18 +61,20 @@ func (c *BashCommand) Run(_ context.Context, tc *terminal.Context) error {
 // generateScript builds the bash completion script by introspecting the registry.
 func (c *BashCommand) generateScript() string {
 	var b strings.Builder
+	program := programOrDefault(c.program)
+	fn := "_" + funcName(program) + "_completions"
 
 	// Write bash completion function header
-	b.WriteString("# bash completion for cure\n")
-	b.WriteString("# Generated by: cure completion bash\n\n")
-	b.WriteString("_cure_completions() {\n")
+	b.WriteString(fmt.Sprintf("# bash completion for %s\n", program))
+	b.WriteString(fmt.Sprintf("# Generated by: %s completion bash\n\n", program))
+	b.WriteString(fn + "() {\n")
 	b.WriteString("  local cur prev words cword\n")
 	b.WriteString("  _init_completion || return\n\n")
 
 	// Extract commands and flags from registry
 	commands := c.collectCommands()
 	subcommands := c.collectSubcommands()
-	flags := c.collectFlags()
+	flags, values := c.collectFlags()
 
 	// Generate command completion logic
 	b.WriteString("  # Command completion\n")
@@ -85,7 +89,7 @@ func (c *BashCommand) generateScript() string {
 		b.WriteString("  if [[ ${cword} -eq 2 ]]; then\n")
 		b.WriteString("    case ${words[1]} in\n")
 		for parent, subs := range subcommands {
-			b.WriteString(fmt.Sprintf("      %s)\n", parent))
+			b.WriteString(fmt.Sprintf("      %s)\n", strings.Join(namesFor(c.registry, parent), "|")))
 			b.WriteString(fmt.Sprintf("        COMPREPLY=($(compgen -W '%s' -- \"${cur}\"))\n", strings.Join(subs, " ")))
 			b.WriteString("        return 0\n")
 			b.WriteString("        ;;\n")
@@ -95,12 +99,12 @@ func (c *BashCommand) generateScript() string {
 	}
 
 	// Generate flag value completion logic
-	if len(FlagValues) > 0 {
+	if len(values) > 0 {
 		b.WriteString("  # Flag value completion\n")
 		b.WriteString("  case ${prev} in\n")
-		for flagName, values := range FlagValues {
+		for _, flagName := range sortedKeys(values) {
 			b.WriteString(fmt.Sprintf("    --%s)\n", flagName))
-			b.WriteString(fmt.Sprintf("      COMPREPLY=($(compgen -W '%s' -- \"${cur}\"))\n", strings.Join(values, " ")))
+			b.WriteString(fmt.Sprintf("      COMPREPLY=($(compgen -W '%s' -- \"${cur}\"))\n", strings.Join(values[flagName], " ")))
 			b.WriteString("      return 0\n")
 			b.WriteString("      ;;\n")
 		}
@@ -117,23 +121,24 @@ func (c *BashCommand) generateScript() string {
 	b.WriteString("  fi\n")
 
 	b.WriteString("}\n\n")
-	b.WriteString("complete -F _cure_completions cure\n")
+	b.WriteString(fmt.Sprintf("complete -F %s %s\n", fn, program))
 
 	return b.String()
 }
 
-// collectCommands extracts all top-level command names from the registry.
+// collectCommands extracts all top-level command names and their aliases
+// from the registry.
 func (c *BashCommand) collectCommands() []string {
 	var names []string
 	for _, cmd := range c.registry.Commands() {
-		names = append(names, cmd.Name())
+		names = append(names, namesFor(c.registry, cmd.Name())...)
 	}
 	sort.Strings(names)
 	return names
 }
 
 // collectSubcommands extracts subcommands from nested routers.
-// Returns a map of parent command name -> list of subcommand names.
+// Returns a map of parent command name -> list of subcommand names and aliases.
 func (c *BashCommand) collectSubcommands() map[string][]string {
 	subcommands := make(map[string][]string)
 
@@ -142,10 +147,9 @@ func (c *BashCommand) collectSubcommands() map[string][]string {
 		if router, ok := cmd.(*terminal.Router); ok {
 			var subNames []string
 			for _, subCmd := range router.Commands() {
-				subNames = append(subNames, subCmd.Name())
+				subNames = append(subNames, namesFor(router, subCmd.Name())...)
 			}
 			if len(subNames) > 0 {
-				sort.Strings(subNames)
 				subcommands[cmd.Name()] = subNames
 			}
 		}
@@ -154,15 +158,27 @@ func (c *BashCommand) collectSubcommands() map[string][]string {
 	return subcommands
 }
 
-// collectFlags extracts all flag names from registered commands.
-func (c *BashCommand) collectFlags() []string {
+// collectFlags extracts all flag names from registered commands, skipping
+// hidden flags, and the values to offer for each. The bash script completes
+// values by flag name alone, so the values of same-named flags on different
+// commands are merged.
+func (c *BashCommand) collectFlags() ([]string, map[string][]string) {
 	var flags []string
 	seen := make(map[string]bool)
+	values := make(map[string][]string)
 
 	var collectFromCommand func(cmd terminal.Command)
 	collectFromCommand = func(cmd terminal.Command) {
 		if fs := cmd.Flags(); fs != nil {
 			fs.VisitAll(func(f *flag.Flag) {
+				if hidden(f) {
+					return
+				}
+				for _, v := range flagValues(f) {
+					if !slices.Contains(values[f.Name], v) {
+						values[f.Name] = append(values[f.Name], v)
+					}
+				}
 				flagName := "--" + f.Name
 				if !seen[flagName] {
 					seen[flagName] = true
@@ -184,5 +200,16 @@ func (c *BashCommand) collectFlags() []string {
 	}
 
 	sort.Strings(flags)
-	return flags
+	return flags, values
+}
+
+// sortedKeys returns m's keys in sorted order, so generated scripts are
+// stable.
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -1,18 +1,100 @@
 package completion
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/mrlm-net/cure/pkg/terminal"
 )
 
-// NewCompletionCommand creates the completion command group with bash/zsh subcommands.
+// defaultProgram is the program name used when none is configured and
+// os.Args[0] gives none.
+const defaultProgram = "cure"
+
+// Option configures the completion command group.
+type Option func(*options)
+
+type options struct {
+	program string
+}
+
+// WithProgramName sets the command name the generated scripts complete,
+// for a binary installed or symlinked under a name other than "cure". The
+// default is the base name of os.Args[0]. An empty name keeps the default.
+func WithProgramName(name string) Option {
+	return func(o *options) {
+		if name != "" {
+			o.program = name
+		}
+	}
+}
+
+// NewCompletionCommand creates the completion command group with bash/zsh/fish
+// subcommands and an install helper.
 // The registry parameter is the root Router, used to introspect registered commands
 // and their flags for generating completion scripts.
-func NewCompletionCommand(registry terminal.CommandRegistry) terminal.Command {
+func NewCompletionCommand(registry terminal.CommandRegistry, opts ...Option) terminal.Command {
+	o := options{program: programFromArgs()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	router := terminal.New(
 		terminal.WithName("completion"),
 		terminal.WithDescription("Generate shell completion scripts"),
 	)
-	router.Register(&BashCommand{registry: registry})
-	router.Register(&ZshCommand{registry: registry})
+	router.Register(&BashCommand{registry: registry, program: o.program})
+	router.Register(&ZshCommand{registry: registry, program: o.program})
+	router.Register(&FishCommand{registry: registry, program: o.program})
+	router.Register(&InstallCommand{registry: registry, program: o.program})
 	return router
 }
+
+// programFromArgs returns the base name of os.Args[0] without a ".exe"
+// suffix, or defaultProgram if there is none.
+func programFromArgs() string {
+	if len(os.Args) == 0 {
+		return defaultProgram
+	}
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		return defaultProgram
+	}
+	return name
+}
+
+// programOrDefault returns program, or defaultProgram for a command built
+// without one.
+func programOrDefault(program string) string {
+	if program == "" {
+		return defaultProgram
+	}
+	return program
+}
+
+// funcName returns program as a shell function name suffix, with every
+// character other than a letter, digit, or underscore replaced by "_".
+func funcName(program string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, program)
+}
+
+// aliasesFor returns the aliases registered for name when registry tracks
+// aliases (as [terminal.Router] does), or nil otherwise.
+func aliasesFor(registry terminal.CommandRegistry, name string) []string {
+	if ar, ok := registry.(terminal.AliasRegistry); ok {
+		return ar.AliasesFor(name)
+	}
+	return nil
+}
+
+// namesFor returns name followed by its aliases in registry.
+func namesFor(registry terminal.CommandRegistry, name string) []string {
+	return append([]string{name}, aliasesFor(registry, name)...)
+}
@@ -5,6 +5,9 @@ import (
 	"context"
 	"flag"
 	"io"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -65,10 +68,10 @@ func TestNewCompletionCommand(t *testing.T) {
 		t.Error("Description() is empty")
 	}
 
-	// Should have bash and zsh subcommands
+	// Should have bash, zsh, fish and install subcommands
 	cmds := router.Commands()
-	if len(cmds) != 2 {
-		t.Fatalf("got %d subcommands, want 2", len(cmds))
+	if len(cmds) != 4 {
+		t.Fatalf("got %d subcommands, want 4", len(cmds))
 	}
 
 	names := make(map[string]bool)
@@ -82,6 +85,12 @@ func TestNewCompletionCommand(t *testing.T) {
 	if !names["zsh"] {
 		t.Error("missing zsh subcommand")
 	}
+	if !names["fish"] {
+		t.Error("missing fish subcommand")
+	}
+	if !names["install"] {
+		t.Error("missing install subcommand")
+	}
 }
 
 func TestBashCommand_Metadata(t *testing.T) {
@@ -485,7 +494,7 @@ func TestBashCommand_CollectFlags(t *testing.T) {
 	}
 
 	cmd := &BashCommand{registry: registry}
-	flags := cmd.collectFlags()
+	flags, _ := cmd.collectFlags()
 
 	// Should have 3 unique flags, sorted
 	expected := []string{"--format", "--output", "--verbose"}
@@ -522,7 +531,7 @@ func TestBashCommand_CollectFlags_Recursive(t *testing.T) {
 	}
 
 	cmd := &BashCommand{registry: registry}
-	flags := cmd.collectFlags()
+	flags, _ := cmd.collectFlags()
 
 	// Should collect flags from both top-level and nested commands
 	if len(flags) != 2 {
@@ -548,3 +557,332 @@ func TestBashCommand_CollectFlags_Recursive(t *testing.T) {
 		t.Error("missing --topoption flag from top-level command")
 	}
 }
+
+func TestFishCommand_GenerateScript(t *testing.T) {
+	fs := flag.NewFlagSet("http", flag.ContinueOnError)
+	fs.String("format", "json", "output format")
+
+	traceRouter := terminal.New(
+		terminal.WithName("trace"),
+		terminal.WithDescription("Trace network connections"),
+	)
+	traceRouter.Register(&mockCommand{name: "http", desc: "Trace HTTP", flags: fs})
+
+	registry := &mockRegistry{
+		commands: []terminal.Command{
+			traceRouter,
+			&mockCommand{name: "version", desc: "Print cure's version"},
+		},
+	}
+
+	cmd := &FishCommand{registry: registry}
+	var buf bytes.Buffer
+	tc := &terminal.Context{Stdout: &buf, Stderr: io.Discard}
+
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"complete -c cure -f",
+		"complete -c cure -n '__fish_use_subcommand' -a 'trace' -d 'Trace network connections'",
+		`-a 'version' -d 'Print cure\'s version'`,
+		"complete -c cure -n '__fish_seen_subcommand_from trace' -a 'http' -d 'Trace HTTP'",
+		"-n '__fish_seen_subcommand_from trace; and __fish_seen_subcommand_from http' -l format -d 'output format' -xa 'json html'",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q\n%s", want, output)
+		}
+	}
+}
+
+func TestCompletion_FlagMeta(t *testing.T) {
+	traceFlags := flag.NewFlagSet("trace", flag.ContinueOnError)
+	traceFlags.String("format", "json", "Output format")
+	traceFlags.String("level", "info", "Log level")
+	traceFlags.Bool("internal-debug", false, "Dump internal state")
+	terminal.DescribeFlag(traceFlags, "level", terminal.FlagMeta{Enum: []string{"info", "debug"}})
+	terminal.DescribeFlag(traceFlags, "internal-debug", terminal.FlagMeta{Hidden: true})
+
+	exportFlags := flag.NewFlagSet("export", flag.ContinueOnError)
+	exportFlags.String("format", "markdown", "Output format")
+	terminal.DescribeFlag(exportFlags, "format", terminal.FlagMeta{Enum: []string{"markdown", "ndjson"}})
+
+	registry := &mockRegistry{
+		commands: []terminal.Command{
+			&mockCommand{name: "trace", desc: "Trace", flags: traceFlags},
+			&mockCommand{name: "export", desc: "Export", flags: exportFlags},
+		},
+	}
+
+	tests := []struct {
+		name string
+		cmd  terminal.Command
+		want []string
+	}{
+		{"bash", &BashCommand{registry: registry}, []string{
+			"    --format)\n      COMPREPLY=($(compgen -W 'json html markdown ndjson'",
+			"    --level)\n      COMPREPLY=($(compgen -W 'info debug'",
+		}},
+		{"zsh", &ZshCommand{registry: registry}, []string{
+			"'--level[Log level]:value:(info debug)'",
+			"'--format[Output format]:value:(markdown ndjson)'",
+			"'--format[Output format]:value:(json html)'",
+		}},
+		{"fish", &FishCommand{registry: registry}, []string{
+			"-l level -d 'Log level' -xa 'info debug'",
+			"-l format -d 'Output format' -xa 'markdown ndjson'",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tc := &terminal.Context{Stdout: &buf, Stderr: io.Discard}
+			if err := tt.cmd.Run(context.Background(), tc); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			output := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q, got:\n%s", want, output)
+				}
+			}
+			if strings.Contains(output, "internal-debug") {
+				t.Errorf("hidden flag offered, got:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestInstallCommand_Run(t *testing.T) {
+	registry := &mockRegistry{
+		commands: []terminal.Command{
+			&mockCommand{name: "version", desc: "Print version"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		shellEnv string
+		wantPath string
+		wantHead string
+	}{
+		{"bash explicit", []string{"bash"}, "", ".bash_completion.d/cure", "# bash completion for cure"},
+		{"zsh explicit", []string{"zsh"}, "", ".zsh/completions/_cure", "#compdef cure"},
+		{"fish explicit", []string{"fish"}, "", ".config/fish/completions/cure.fish", "# fish completion for cure"},
+		{"detected from SHELL", nil, "/usr/bin/zsh", ".zsh/completions/_cure", "#compdef cure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", "")
+			t.Setenv("SHELL", tt.shellEnv)
+
+			cmd := &InstallCommand{registry: registry}
+			var buf bytes.Buffer
+			tc := &terminal.Context{Args: tt.args, Stdout: &buf, Stderr: io.Discard}
+
+			if err := cmd.Run(context.Background(), tc); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			path := filepath.Join(home, filepath.FromSlash(tt.wantPath))
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile(%s) error = %v", path, err)
+			}
+			if !strings.HasPrefix(string(data), tt.wantHead) {
+				t.Errorf("script starts with %q, want prefix %q", string(data[:min(len(data), 40)]), tt.wantHead)
+			}
+			if !strings.Contains(buf.String(), path) {
+				t.Errorf("output %q does not mention %s", buf.String(), path)
+			}
+		})
+	}
+}
+
+func TestInstallCommand_Errors(t *testing.T) {
+	registry := &mockRegistry{}
+
+	t.Run("unsupported shell", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		cmd := &InstallCommand{registry: registry}
+		tc := &terminal.Context{Args: []string{"tcsh"}, Stdout: io.Discard, Stderr: io.Discard}
+		err := cmd.Run(context.Background(), tc)
+		if err == nil || !strings.Contains(err.Error(), "unsupported shell") {
+			t.Errorf("Run() error = %v, want unsupported shell error", err)
+		}
+	})
+
+	t.Run("no shell detected", func(t *testing.T) {
+		t.Setenv("SHELL", "")
+		cmd := &InstallCommand{registry: registry}
+		tc := &terminal.Context{Stdout: io.Discard, Stderr: io.Discard}
+		if err := cmd.Run(context.Background(), tc); err == nil {
+			t.Error("Run() error = nil, want detection error")
+		}
+	})
+
+	t.Run("directory not writable", func(t *testing.T) {
+		if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+			t.Skip("permission checks are not enforced for this user/platform")
+		}
+		home := t.TempDir()
+		if err := os.Chmod(home, 0o500); err != nil {
+			t.Fatalf("Chmod: %v", err)
+		}
+		t.Cleanup(func() { _ = os.Chmod(home, 0o755) })
+		t.Setenv("HOME", home)
+
+		cmd := &InstallCommand{registry: registry}
+		tc := &terminal.Context{Args: []string{"bash"}, Stdout: io.Discard, Stderr: io.Discard}
+		err := cmd.Run(context.Background(), tc)
+		if err == nil || !strings.Contains(err.Error(), "not writable") {
+			t.Errorf("Run() error = %v, want not writable error", err)
+		}
+	})
+}
+
+func TestCompletion_Aliases(t *testing.T) {
+	traceRouter := terminal.New(terminal.WithName("trace"), terminal.WithDescription("Trace network connections"))
+	traceRouter.RegisterWithAliases(&mockCommand{name: "http", desc: "Trace HTTP"}, "h")
+
+	root := terminal.New()
+	root.RegisterWithAliases(&mockCommand{name: "version", desc: "Print version"}, "v")
+	root.RegisterWithAliases(traceRouter, "t")
+
+	tests := []struct {
+		name string
+		cmd  terminal.Command
+		want []string
+	}{
+		{"bash", &BashCommand{registry: root}, []string{
+			"compgen -W 't trace v version'",
+			"compgen -W 'http h'",
+			"trace|t)",
+		}},
+		{"zsh", &ZshCommand{registry: root}, []string{
+			"'v:Print version'",
+			"'t:Trace network connections'",
+			"'h:Trace HTTP'",
+			"version|v)",
+			"trace|t)",
+		}},
+		{"fish", &FishCommand{registry: root}, []string{
+			"-a 'v' -d 'Print version'",
+			"__fish_seen_subcommand_from trace t' -a 'h'",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.cmd.Run(context.Background(), &terminal.Context{Stdout: &buf, Stderr: io.Discard}); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCompletion_ProgramName(t *testing.T) {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	fs.String("format", "json", "output format")
+	registry := &mockRegistry{
+		commands: []terminal.Command{
+			&mockCommand{name: "version", desc: "Print version", flags: fs},
+		},
+	}
+
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"bash", []string{
+			"# bash completion for my-cure\n",
+			"_my_cure_completions() {\n",
+			"complete -F _my_cure_completions my-cure\n",
+		}},
+		{"zsh", []string{
+			"#compdef my-cure\n",
+			"_my_cure() {\n",
+			"_describe 'my-cure commands' commands",
+			"\n_my_cure\n",
+		}},
+		{"fish", []string{
+			"complete -c my-cure -f\n",
+			"complete -c my-cure -n '__fish_use_subcommand' -a 'version'",
+			"complete -c my-cure -n '__fish_seen_subcommand_from version' -l format",
+		}},
+	}
+
+	router := NewCompletionCommand(registry, WithProgramName("my-cure")).(*terminal.Router)
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			cmd, ok := router.Lookup(tt.shell)
+			if !ok {
+				t.Fatalf("no %s subcommand", tt.shell)
+			}
+			var buf bytes.Buffer
+			tc := &terminal.Context{Stdout: &buf, Stderr: io.Discard}
+			if err := cmd.Run(context.Background(), tc); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			output := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("script missing %q, got:\n%s", want, output)
+				}
+			}
+			if strings.Contains(output, " cure") || strings.Contains(output, "\n_cure") {
+				t.Errorf("script still refers to cure, got:\n%s", output)
+			}
+		})
+	}
+
+	t.Run("install", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		t.Setenv("XDG_CONFIG_HOME", "")
+		cmd, _ := router.Lookup("install")
+		tc := &terminal.Context{Args: []string{"zsh"}, Stdout: io.Discard, Stderr: io.Discard}
+		if err := cmd.Run(context.Background(), tc); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(home, ".zsh", "completions", "_my-cure"))
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		if !strings.HasPrefix(string(data), "#compdef my-cure\n") {
+			t.Errorf("installed script starts with %q", string(data[:min(len(data), 40)]))
+		}
+	})
+}
+
+func TestProgramFromArgs(t *testing.T) {
+	orig := os.Args
+	t.Cleanup(func() { os.Args = orig })
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"/usr/local/bin/cr"}, "cr"},
+		{[]string{"cure.exe"}, "cure"},
+		{[]string{""}, "cure"},
+		{nil, "cure"},
+	}
+	for _, tt := range tests {
+		os.Args = tt.args
+		if got := programFromArgs(); got != tt.want {
+			t.Errorf("programFromArgs() with os.Args = %q = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
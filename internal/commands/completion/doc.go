@@ -1,6 +1,6 @@
 // Package completion provides shell auto-completion script generation for cure commands.
 //
-// The completion command group generates bash and zsh completion scripts by
+// The completion command group generates bash, zsh, and fish completion scripts by
 // introspecting the command registry at runtime. Generated scripts include:
 //   - Command name completion for top-level commands
 //   - Subcommand completion for nested routers
@@ -16,6 +16,12 @@
 //	// Zsh completion
 //	cure completion zsh > "${fpath[1]}/_cure"
 //
+//	// Fish completion
+//	cure completion fish > ~/.config/fish/completions/cure.fish
+//
+//	// Or let cure pick the location for the shell in $SHELL
+//	cure completion install
+//
 // The completion command requires a CommandRegistry (typically the root Router)
 // to introspect registered commands and their flags. Commands are never hardcoded;
 // the scripts regenerate dynamically based on the current command tree.
@@ -0,0 +1,131 @@
+package completion
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mrlm-net/cure/pkg/terminal"
+)
+
+// FishCommand generates a fish completion script by traversing the command registry.
+type FishCommand struct {
+	registry terminal.CommandRegistry
+	program  string
+}
+
+// Name returns "fish".
+func (c *FishCommand) Name() string { return "fish" }
+
+// Description returns a short description for fish completion.
+func (c *FishCommand) Description() string { return "Generate fish completion script" }
+
+// Usage returns detailed usage information.
+func (c *FishCommand) Usage() string {
+	return `Usage: cure completion fish
+
+Generate fish completion script for cure commands and flags.
+
+Installation:
+  # Install for current session:
+  cure completion fish | source
+
+  # Install permanently:
+  cure completion fish > ~/.config/fish/completions/cure.fish
+
+Test it:
+  cure <TAB>         # shows all commands
+  cure trace <TAB>   # shows http, tcp, udp
+`
+}
+
+// Flags returns nil — the fish command accepts no flags.
+func (c *FishCommand) Flags() *flag.FlagSet { return nil }
+
+// Run executes the fish completion generation.
+func (c *FishCommand) Run(_ context.Context, tc *terminal.Context) error {
+	script := c.generateScript()
+	fmt.Fprint(tc.Stdout, script)
+	return nil
+}
+
+// generateScript builds the fish completion script by introspecting the registry.
+func (c *FishCommand) generateScript() string {
+	var b strings.Builder
+	program := programOrDefault(c.program)
+
+	b.WriteString(fmt.Sprintf("# fish completion for %s\n", program))
+	b.WriteString(fmt.Sprintf("# Generated by: %s completion fish\n\n", program))
+	b.WriteString(fmt.Sprintf("complete -c %s -f\n\n", program))
+
+	cmds := c.registry.Commands()
+	sort.Slice(cmds, func(i, j int) bool {
+		return cmds[i].Name() < cmds[j].Name()
+	})
+
+	// Top-level commands are offered only before any subcommand is typed.
+	for _, cmd := range cmds {
+		for _, name := range namesFor(c.registry, cmd.Name()) {
+			b.WriteString(fmt.Sprintf("complete -c %s -n '__fish_use_subcommand' -a '%s' -d '%s'\n",
+				program, name, escapeFishDesc(cmd.Description())))
+		}
+	}
+
+	for _, cmd := range cmds {
+		cond := fmt.Sprintf("__fish_seen_subcommand_from %s", strings.Join(namesFor(c.registry, cmd.Name()), " "))
+		writeFishFlags(&b, program, cond, cmd.Flags())
+
+		router, ok := cmd.(*terminal.Router)
+		if !ok {
+			continue
+		}
+		subCmds := router.Commands()
+		if len(subCmds) == 0 {
+			continue
+		}
+
+		b.WriteString("\n")
+		for _, subCmd := range subCmds {
+			for _, name := range namesFor(router, subCmd.Name()) {
+				b.WriteString(fmt.Sprintf("complete -c %s -n '%s' -a '%s' -d '%s'\n",
+					program, cond, name, escapeFishDesc(subCmd.Description())))
+			}
+		}
+		for _, subCmd := range subCmds {
+			subCond := fmt.Sprintf("%s; and __fish_seen_subcommand_from %s", cond, strings.Join(namesFor(router, subCmd.Name()), " "))
+			writeFishFlags(&b, program, subCond, subCmd.Flags())
+		}
+	}
+
+	return b.String()
+}
+
+// writeFishFlags emits one complete line for program per flag in fs, gated
+// by cond.
+// Flags with known values get an exclusive value list; hidden flags are
+// skipped.
+func writeFishFlags(b *strings.Builder, program, cond string, fs *flag.FlagSet) {
+	if fs == nil {
+		return
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		if hidden(f) {
+			return
+		}
+		line := fmt.Sprintf("complete -c %s -n '%s' -l %s -d '%s'", program, cond, f.Name, escapeFishDesc(f.Usage))
+		if values := flagValues(f); len(values) > 0 {
+			line += fmt.Sprintf(" -xa '%s'", strings.Join(values, " "))
+		}
+		b.WriteString(line + "\n")
+	})
+}
+
+// escapeFishDesc escapes backslashes and single quotes in fish completion
+// descriptions, which are emitted inside single-quoted strings.
+func escapeFishDesc(desc string) string {
+	desc = strings.ReplaceAll(desc, `\`, `\\`)
+	desc = strings.ReplaceAll(desc, "'", `\'`)
+	return desc
+}
@@ -0,0 +1,113 @@
+package completion
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mrlm-net/cure/pkg/fs"
+	"github.com/mrlm-net/cure/pkg/terminal"
+)
+
+// InstallCommand writes a generated completion script to the conventional
+// per-user location for the target shell.
+type InstallCommand struct {
+	registry terminal.CommandRegistry
+	program  string
+}
+
+// Name returns "install".
+func (c *InstallCommand) Name() string { return "install" }
+
+// Description returns a short description for completion install.
+func (c *InstallCommand) Description() string {
+	return "Install completion script for the current shell"
+}
+
+// Usage returns detailed usage information.
+func (c *InstallCommand) Usage() string {
+	return `Usage: cure completion install [bash|zsh|fish]
+
+Write the completion script to the conventional per-user location, creating
+directories as needed. When no shell is given, it is detected from $SHELL.
+
+Install locations:
+  bash  ~/.bash_completion.d/cure
+  zsh   ~/.zsh/completions/_cure
+  fish  ~/.config/fish/completions/cure.fish  (honours $XDG_CONFIG_HOME)
+
+File names follow the name the binary was invoked as, so a binary
+installed as "cr" gets ~/.zsh/completions/_cr.
+`
+}
+
+// Flags returns nil — the install command accepts no flags.
+func (c *InstallCommand) Flags() *flag.FlagSet { return nil }
+
+// Run generates the script for the selected shell and writes it to disk.
+func (c *InstallCommand) Run(_ context.Context, tc *terminal.Context) error {
+	shell := ""
+	if len(tc.Args) > 0 {
+		shell = tc.Args[0]
+	} else {
+		shell = filepath.Base(os.Getenv("SHELL"))
+		if shell == "." || shell == string(filepath.Separator) {
+			shell = ""
+		}
+	}
+	if shell == "" {
+		return fmt.Errorf("cannot detect shell from $SHELL; pass one of: bash, zsh, fish")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("cannot determine home directory: %w", err)
+	}
+
+	program := programOrDefault(c.program)
+	var script, path, hint string
+	switch shell {
+	case "bash":
+		script = (&BashCommand{registry: c.registry, program: program}).generateScript()
+		path = filepath.Join(home, ".bash_completion.d", program)
+		hint = fmt.Sprintf("Add 'source %s' to ~/.bashrc and restart your shell.", path)
+	case "zsh":
+		script = (&ZshCommand{registry: c.registry, program: program}).generateScript()
+		dir := filepath.Join(home, ".zsh", "completions")
+		path = filepath.Join(dir, "_"+program)
+		hint = fmt.Sprintf("Add 'fpath=(%s $fpath)' before compinit in ~/.zshrc and run 'exec zsh'.", dir)
+	case "fish":
+		script = (&FishCommand{registry: c.registry, program: program}).generateScript()
+		configDir := os.Getenv("XDG_CONFIG_HOME")
+		if configDir == "" {
+			configDir = filepath.Join(home, ".config")
+		}
+		path = filepath.Join(configDir, "fish", "completions", program+".fish")
+		hint = "Restart fish or run 'exec fish' to load completions."
+	default:
+		return fmt.Errorf("unsupported shell %q (supported: bash, zsh, fish)", shell)
+	}
+
+	dir := filepath.Dir(path)
+	if err := fs.EnsureDir(dir, 0755); err != nil {
+		return installError(dir, err)
+	}
+	if err := fs.AtomicWrite(path, []byte(script), 0644); err != nil {
+		return installError(dir, err)
+	}
+
+	fmt.Fprintf(tc.Stdout, "Installed %s completion to %s\n", shell, path)
+	fmt.Fprintln(tc.Stdout, hint)
+	return nil
+}
+
+// installError wraps a write failure, calling out permission problems explicitly.
+func installError(dir string, err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("target directory %s is not writable: %w", dir, err)
+	}
+	return fmt.Errorf("failed to install completion script in %s: %w", dir, err)
+}
@@ -1,9 +1,30 @@
 package completion
 
+import (
+	"flag"
+
+	"github.com/mrlm-net/cure/pkg/terminal"
+)
+
 // FlagValues maps flag names to their valid values for shell completion.
-// This is a static map for v0.4.0. Future versions may make this dynamic
-// via Command metadata or runtime introspection.
+// It is the fallback for flags without enum metadata; commands describe
+// their own flags with terminal.DescribeFlag, which takes precedence.
 var FlagValues = map[string][]string{
 	"format": {"json", "html"},
 	"method": {"GET", "POST", "PUT", "DELETE", "PATCH", "HEAD", "OPTIONS"},
 }
+
+// flagValues returns the values to offer for f: the Enum from its
+// terminal.FlagMeta, or else its entry in FlagValues.
+func flagValues(f *flag.Flag) []string {
+	if meta := terminal.FlagMetaFor(f); len(meta.Enum) > 0 {
+		return meta.Enum
+	}
+	return FlagValues[f.Name]
+}
+
+// hidden reports whether f is described as hidden and should not be
+// offered for completion.
+func hidden(f *flag.Flag) bool {
+	return terminal.FlagMetaFor(f).Hidden
+}
@@ -13,6 +13,7 @@ import (
 // ZshCommand generates a zsh completion script by traversing the command registry.
 type ZshCommand struct {
 	registry terminal.CommandRegistry
+	program  string
 }
 
 // Name returns "zsh".
@@ -58,12 +59,14 @@ func (c *ZshCommand) Run(_ context.Context, tc *terminal.Context) error {
 // generateScript builds the zsh completion script by introspecting the registry.
 func (c *ZshCommand) generateScript() string {
 	var b strings.Builder
+	program := programOrDefault(c.program)
+	fn := "_" + funcName(program)
 
-	b.WriteString("#compdef cure\n")
-	b.WriteString("# zsh completion for cure\n")
-	b.WriteString("# Generated by: cure completion zsh\n\n")
+	b.WriteString(fmt.Sprintf("#compdef %s\n", program))
+	b.WriteString(fmt.Sprintf("# zsh completion for %s\n", program))
+	b.WriteString(fmt.Sprintf("# Generated by: %s completion zsh\n\n", program))
 
-	b.WriteString("_cure() {\n")
+	b.WriteString(fn + "() {\n")
 	b.WriteString("  local -a commands\n")
 	b.WriteString("  commands=(\n")
 
@@ -73,9 +76,12 @@ func (c *ZshCommand) generateScript() string {
 		return cmds[i].Name() < cmds[j].Name()
 	})
 
+	// Aliases are listed alongside their command with the same description.
 	for _, cmd := range cmds {
 		desc := escapeZshDesc(cmd.Description())
-		b.WriteString(fmt.Sprintf("    '%s:%s'\n", cmd.Name(), desc))
+		for _, name := range namesFor(c.registry, cmd.Name()) {
+			b.WriteString(fmt.Sprintf("    '%s:%s'\n", name, desc))
+		}
 	}
 
 	b.WriteString("  )\n\n")
@@ -87,14 +93,14 @@ func (c *ZshCommand) generateScript() string {
 
 	b.WriteString("  case $state in\n")
 	b.WriteString("    command)\n")
-	b.WriteString("      _describe 'cure commands' commands\n")
+	b.WriteString(fmt.Sprintf("      _describe '%s commands' commands\n", program))
 	b.WriteString("      ;;\n")
 	b.WriteString("    args)\n")
 	b.WriteString("      case $words[1] in\n")
 
 	// Generate per-command argument and flag completion
 	for _, cmd := range cmds {
-		b.WriteString(fmt.Sprintf("        %s)\n", cmd.Name()))
+		b.WriteString(fmt.Sprintf("        %s)\n", strings.Join(namesFor(c.registry, cmd.Name()), "|")))
 
 		// Check if this is a router with subcommands
 		if router, ok := cmd.(*terminal.Router); ok {
@@ -102,12 +108,11 @@ func (c *ZshCommand) generateScript() string {
 			if len(subCmds) > 0 {
 				b.WriteString("          local -a subcommands\n")
 				b.WriteString("          subcommands=(\n")
-				sort.Slice(subCmds, func(i, j int) bool {
-					return subCmds[i].Name() < subCmds[j].Name()
-				})
 				for _, subCmd := range subCmds {
 					desc := escapeZshDesc(subCmd.Description())
-					b.WriteString(fmt.Sprintf("            '%s:%s'\n", subCmd.Name(), desc))
+					for _, name := range namesFor(router, subCmd.Name()) {
+						b.WriteString(fmt.Sprintf("            '%s:%s'\n", name, desc))
+					}
 				}
 				b.WriteString("          )\n")
 				b.WriteString("          _describe 'subcommands' subcommands\n")
@@ -118,9 +123,12 @@ func (c *ZshCommand) generateScript() string {
 		if fs := cmd.Flags(); fs != nil {
 			var flagLines []string
 			fs.VisitAll(func(f *flag.Flag) {
+				if hidden(f) {
+					return
+				}
 				desc := escapeZshDesc(f.Usage)
 				// Check if flag has predefined values
-				if values, ok := FlagValues[f.Name]; ok {
+				if values := flagValues(f); len(values) > 0 {
 					valueList := strings.Join(values, " ")
 					flagLines = append(flagLines, fmt.Sprintf("            '--%s[%s]:value:(%s)'", f.Name, desc, valueList))
 				} else {
@@ -142,7 +150,7 @@ func (c *ZshCommand) generateScript() string {
 	b.WriteString("      ;;\n")
 	b.WriteString("  esac\n")
 	b.WriteString("}\n\n")
-	b.WriteString("_cure\n")
+	b.WriteString(fn + "\n")
 
 	return b.String()
 }
@@ -0,0 +1,17 @@
+// Package configcmd provides the "cure config" command group for inspecting
+// configuration files. Package name is configcmd to avoid shadowing
+// pkg/config, which it wraps.
+package configcmd
+
+import "github.com/mrlm-net/cure/pkg/terminal"
+
+// NewConfigCommand returns the "config" command group. It registers the
+// "diff" subcommand.
+func NewConfigCommand() terminal.Command {
+	router := terminal.New(
+		terminal.WithName("config"),
+		terminal.WithDescription("Inspect configuration files"),
+	)
+	router.Register(&DiffCommand{})
+	return router
+}
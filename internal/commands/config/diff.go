@@ -0,0 +1,113 @@
+package configcmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/mrlm-net/cure/pkg/config"
+	"github.com/mrlm-net/cure/pkg/terminal"
+)
+
+// DiffCommand implements "cure config diff <fileA> <fileB>".
+// It prints the keys added, removed, or changed between two config files.
+type DiffCommand struct {
+	// Flags
+	format string
+}
+
+func (c *DiffCommand) Name() string        { return "diff" }
+func (c *DiffCommand) Description() string { return "Show the differences between two config files" }
+
+func (c *DiffCommand) Usage() string {
+	return `Usage: cure config diff [--format table|ndjson] <fileA> <fileB>
+
+Load two JSON config files, resolving their includes, and print every key
+that was added, removed, or changed going from <fileA> to <fileB>. Keys are
+shown in dot notation, such as "agent.claude.model"; array elements are
+addressed by index, such as "servers.0.host". Values are printed as JSON.
+
+Nothing is printed when the files are equivalent.
+
+Flags:
+  --format    Output format: "table" (default) or "ndjson"
+
+Examples:
+  cure config diff staging.json production.json
+  cure config diff --format ndjson ~/.cure.json .cure.json | jq 'select(.kind == "changed")'
+`
+}
+
+func (c *DiffCommand) Flags() *flag.FlagSet {
+	fs := flag.NewFlagSet("config-diff", flag.ContinueOnError)
+	fs.StringVar(&c.format, "format", "table", `Output format: "table" or "ndjson"`)
+	terminal.DescribeFlag(fs, "format", terminal.FlagMeta{Enum: []string{"table", "ndjson"}})
+	return fs
+}
+
+func (c *DiffCommand) Run(_ context.Context, tc *terminal.Context) error {
+	if len(tc.Args) != 2 {
+		return fmt.Errorf("config diff: want exactly two files, got %d arguments", len(tc.Args))
+	}
+	if c.format != "table" && c.format != "ndjson" && c.format != "" {
+		return fmt.Errorf("config diff: unknown format %q (want \"table\" or \"ndjson\")", c.format)
+	}
+
+	var cfgs [2]*config.Config
+	for i, path := range tc.Args {
+		obj, err := config.File(path)
+		if err != nil {
+			return fmt.Errorf("config diff: %s: %w", path, err)
+		}
+		cfgs[i] = config.NewConfig(obj)
+	}
+
+	changes := config.Diff(cfgs[0], cfgs[1])
+	if c.format == "ndjson" {
+		return diffNDJSON(tc, changes)
+	}
+	return diffTable(tc, changes)
+}
+
+// diffTable writes changes as an aligned table with one row per key.
+func diffTable(tc *terminal.Context, changes []config.Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	table := &terminal.Table{}
+	table.SetHeader("KEY", "CHANGE", "VALUE")
+	for _, ch := range changes {
+		var value string
+		switch ch.Kind {
+		case config.Added:
+			value = jsonValue(ch.New)
+		case config.Removed:
+			value = jsonValue(ch.Old)
+		default:
+			value = jsonValue(ch.Old) + " → " + jsonValue(ch.New)
+		}
+		table.AddRow(ch.Path, string(ch.Kind), value)
+	}
+	return table.Render(tc.Stdout)
+}
+
+// diffNDJSON writes one JSON object per change to tc.Stdout.
+func diffNDJSON(tc *terminal.Context, changes []config.Change) error {
+	enc := json.NewEncoder(tc.Stdout)
+	for _, ch := range changes {
+		if err := enc.Encode(ch); err != nil {
+			return fmt.Errorf("config diff: encode: %w", err)
+		}
+	}
+	return nil
+}
+
+// jsonValue formats v compactly as JSON for the table.
+func jsonValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
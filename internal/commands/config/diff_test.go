@@ -0,0 +1,127 @@
+package configcmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrlm-net/cure/pkg/config"
+	"github.com/mrlm-net/cure/pkg/terminal"
+)
+
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDiffCommand(t *testing.T) {
+	dir := t.TempDir()
+	a := writeConfig(t, dir, "a.json", `{"timeout": 30, "format": "json", "agent": {"claude": {"model": "x"}}}`)
+	b := writeConfig(t, dir, "b.json", `{"timeout": 60, "agent": {"claude": {"model": "x", "max_tokens": 100}}}`)
+
+	tests := []struct {
+		name        string
+		format      string
+		args        []string
+		wantErr     string
+		wantLines   []string
+		wantNothing bool
+	}{
+		{
+			name:   "table",
+			format: "table",
+			args:   []string{a, b},
+			wantLines: []string{
+				"KEY                      CHANGE   VALUE",
+				"agent.claude.max_tokens  added    100",
+				`format                   removed  "json"`,
+				"timeout                  changed  30 → 60",
+			},
+		},
+		{
+			name:        "identical files print nothing",
+			format:      "table",
+			args:        []string{a, a},
+			wantNothing: true,
+		},
+		{
+			name:    "missing argument",
+			format:  "table",
+			args:    []string{a},
+			wantErr: "want exactly two files",
+		},
+		{
+			name:    "missing file",
+			format:  "table",
+			args:    []string{a, filepath.Join(dir, "nope.json")},
+			wantErr: "nope.json",
+		},
+		{
+			name:    "unknown format",
+			format:  "yaml",
+			args:    []string{a, b},
+			wantErr: `unknown format "yaml"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			cmd := &DiffCommand{format: tt.format}
+			err := cmd.Run(context.Background(), &terminal.Context{Args: tt.args, Stdout: &stdout})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Run() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if tt.wantNothing && stdout.Len() != 0 {
+				t.Errorf("output = %q, want empty", stdout.String())
+			}
+			out := stdout.String()
+			for _, line := range tt.wantLines {
+				if !strings.Contains(out, line+"\n") {
+					t.Errorf("output missing line %q:\n%s", line, out)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffCommand_NDJSON(t *testing.T) {
+	dir := t.TempDir()
+	a := writeConfig(t, dir, "a.json", `{"servers": [{"host": "a"}], "verbose": false}`)
+	b := writeConfig(t, dir, "b.json", `{"servers": [{"host": "b"}]}`)
+
+	var stdout bytes.Buffer
+	cmd := &DiffCommand{format: "ndjson"}
+	if err := cmd.Run(context.Background(), &terminal.Context{Args: []string{a, b}, Stdout: &stdout}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), stdout.String())
+	}
+	var got config.Change
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != "servers.0.host" || got.Kind != config.Changed || got.Old != "a" || got.New != "b" {
+		t.Errorf("first change = %+v, want servers.0.host changed a → b", got)
+	}
+	if want := `{"path":"verbose","kind":"removed","old":false,"new":null}`; lines[1] != want {
+		t.Errorf("second line = %s, want %s", lines[1], want)
+	}
+}
@@ -78,6 +78,7 @@ Examples:
 func (c *ExportCommand) Flags() *flag.FlagSet {
 	fset := flag.NewFlagSet("context-export", flag.ContinueOnError)
 	fset.StringVar(&c.format, "format", "markdown", `Output format: "markdown" or "ndjson"`)
+	terminal.DescribeFlag(fset, "format", terminal.FlagMeta{Enum: []string{"markdown", "ndjson"}})
 	fset.StringVar(&c.output, "output", "", "Write to file path instead of stdout")
 	return fset
 }
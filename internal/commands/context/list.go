@@ -48,6 +48,7 @@ Examples:
 func (c *ListCommand) Flags() *flag.FlagSet {
 	fs := flag.NewFlagSet("context-list", flag.ContinueOnError)
 	fs.StringVar(&c.format, "format", "text", `Output format: "text" or "ndjson"`)
+	terminal.DescribeFlag(fs, "format", terminal.FlagMeta{Enum: []string{"text", "ndjson"}})
 	fs.StringVar(&c.provider, "provider", "", "Filter by provider name")
 	fs.StringVar(&c.tagFilter, "tag", "", "Filter sessions by tag (exact match, case-sensitive)")
 	return fs
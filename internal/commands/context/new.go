@@ -87,6 +87,7 @@ func (c *NewCommand) Flags() *flag.FlagSet {
 	fs.StringVar(&c.provider, "provider", "", "AI provider name (required)")
 	fs.StringVar(&c.message, "message", "", "Initial user message")
 	fs.StringVar(&c.format, "format", "text", `Output format: "text" or "ndjson"`)
+	terminal.DescribeFlag(fs, "format", terminal.FlagMeta{Enum: []string{"text", "ndjson"}})
 	fs.StringVar(&c.systemPrompt, "system-prompt", "", "System prompt for the session (overridden by --skill)")
 	fs.StringVar(&c.sessionName, "session-name", "", "Human-readable name tag for the session")
 	fs.Var((*stringSliceFlag)(&c.tags), "tag", "Tag for this session (may be repeated)")
@@ -58,6 +58,7 @@ func (c *ResumeCommand) Flags() *flag.FlagSet {
 	fs := flag.NewFlagSet("context-resume", flag.ContinueOnError)
 	fs.StringVar(&c.message, "message", "", "User message to send")
 	fs.StringVar(&c.format, "format", "text", `Output format: "text" or "ndjson"`)
+	terminal.DescribeFlag(fs, "format", terminal.FlagMeta{Enum: []string{"text", "ndjson"}})
 	fs.StringVar(&c.model, "model", "", "Model name override for this turn (provider-specific; uses provider default if empty)")
 	fs.IntVar(&c.maxTokens, "max-tokens", 0, "Maximum tokens override for this turn (uses provider default if 0)")
 	fs.StringVar(&c.skillName, "skill", "", "Named skill to activate (or switch) for this session")
@@ -41,6 +41,7 @@ Examples:
 func (c *SearchCommand) Flags() *flag.FlagSet {
 	fset := flag.NewFlagSet("context-search", flag.ContinueOnError)
 	fset.StringVar(&c.format, "format", "table", `Output format: "table" or "ndjson"`)
+	terminal.DescribeFlag(fset, "format", terminal.FlagMeta{Enum: []string{"table", "ndjson"}})
 	return fset
 }
 
@@ -0,0 +1,179 @@
+package generate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mrlm-net/cure/pkg/terminal"
+)
+
+// bulkNamePattern matches project names that are safe to use as a single
+// directory under --out-dir.
+var bulkNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// bulkStringFields maps JSON record keys to the AIFileOpts field they set.
+// Keys match the generator flag names.
+var bulkStringFields = map[string]func(*AIFileOpts) *string{
+	"name":           func(o *AIFileOpts) *string { return &o.Name },
+	"description":    func(o *AIFileOpts) *string { return &o.Description },
+	"language":       func(o *AIFileOpts) *string { return &o.Language },
+	"build-tool":     func(o *AIFileOpts) *string { return &o.BuildTool },
+	"test-framework": func(o *AIFileOpts) *string { return &o.TestFramework },
+	"repository":     func(o *AIFileOpts) *string { return &o.Repository },
+	"license":        func(o *AIFileOpts) *string { return &o.License },
+	"homepage":       func(o *AIFileOpts) *string { return &o.Homepage },
+}
+
+// aiFileOptsFromRecord overlays the fields of a decoded JSON record onto base.
+// Keys are the generator flag names; "conventions" may be a comma-separated
+// string or an array of strings. Unknown keys, non-string values, and records
+// missing "name", "description", or "language" are errors.
+func aiFileOptsFromRecord(base AIFileOpts, rec map[string]interface{}) (AIFileOpts, error) {
+	opts := base
+	for key, val := range rec {
+		if key == "conventions" {
+			conv, err := recordConventions(val)
+			if err != nil {
+				return AIFileOpts{}, err
+			}
+			opts.Conventions = conv
+			continue
+		}
+		field, ok := bulkStringFields[key]
+		if !ok {
+			return AIFileOpts{}, fmt.Errorf("unknown field %q", key)
+		}
+		s, ok := val.(string)
+		if !ok {
+			return AIFileOpts{}, fmt.Errorf("field %q must be a string, got %T", key, val)
+		}
+		*field(&opts) = s
+	}
+
+	for _, key := range []string{"name", "description", "language"} {
+		if s, _ := rec[key].(string); strings.TrimSpace(s) == "" {
+			return AIFileOpts{}, fmt.Errorf("missing required field %q", key)
+		}
+	}
+	if !bulkNamePattern.MatchString(opts.Name) {
+		return AIFileOpts{}, fmt.Errorf("name %q must be usable as a directory name (letters, digits, '.', '_', '-')", opts.Name)
+	}
+	return opts, nil
+}
+
+// recordConventions converts a "conventions" value to the comma-separated form
+// used by AIFileOpts.
+func recordConventions(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case string:
+		return v, nil
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return "", fmt.Errorf("field \"conventions\" must contain only strings, got %T", item)
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, ","), nil
+	default:
+		return "", fmt.Errorf("field \"conventions\" must be a string or an array of strings, got %T", val)
+	}
+}
+
+// generateBulk reads one JSON object per line from r and calls gen for each
+// record, with OutputPath set to outDir/<name>/fileName, or to base.OutputPath
+// rendered with the record's fields when it is templated. Blank lines are
+// ignored. A record that fails to decode, validate, or generate is reported to
+// stderr and skipped, or aborts the batch when strict is set. It returns the
+// number of records processed and the number that failed.
+func generateBulk(r io.Reader, stderr io.Writer, base AIFileOpts, outDir, fileName string, strict bool, gen func(AIFileOpts) error) (int, int, error) {
+	scanner := bufio.NewScanner(r)
+	lineNo, total, failed := 0, 0, 0
+	for scanner.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		total++
+
+		err := func() error {
+			var rec map[string]interface{}
+			if err := json.Unmarshal(line, &rec); err != nil {
+				return err
+			}
+			opts, err := aiFileOptsFromRecord(base, rec)
+			if err != nil {
+				return err
+			}
+			if strings.Contains(base.OutputPath, "{{") {
+				if opts.OutputPath, err = resolveOutputPath(opts); err != nil {
+					return err
+				}
+			} else {
+				opts.OutputPath = filepath.Join(outDir, opts.Name, fileName)
+			}
+			return gen(opts)
+		}()
+		if err != nil {
+			if strict {
+				return total, failed + 1, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			fmt.Fprintf(stderr, "line %d: %v\n", lineNo, err)
+			failed++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return total, failed, fmt.Errorf("failed to read records: %w", err)
+	}
+	return total, failed, nil
+}
+
+// runBulk generates one CLAUDE.md per record of the --from-json file.
+// Flag and config values act as defaults for optional fields a record
+// leaves out.
+func (c *ClaudeMDCommand) runBulk(ctx context.Context, tc *terminal.Context) error {
+	in := tc.Stdin
+	if c.fromJSON != "-" {
+		f, err := os.Open(c.fromJSON)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", c.fromJSON, err)
+		}
+		defer f.Close()
+		in = f
+	}
+	if in == nil {
+		return fmt.Errorf("no stdin to read records from")
+	}
+
+	base := c.toOpts()
+	base.NonInteractive = true
+	total, failed, err := generateBulk(in, tc.Stderr, base, c.outDir, "CLAUDE.md", c.strict, func(opts AIFileOpts) error {
+		if err := GenerateClaudeMD(ctx, tc.Stdout, ClaudeMDOpts{opts}); err != nil {
+			return err
+		}
+		if !opts.DryRun {
+			fmt.Fprintf(tc.Stdout, "Generated %s\n", filepath.Clean(opts.OutputPath))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(tc.Stderr, "claude-md: %d records, %d generated, %d failed\n", total, total-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d records failed", failed, total)
+	}
+	return nil
+}
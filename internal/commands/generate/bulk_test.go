@@ -0,0 +1,187 @@
+package generate
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrlm-net/cure/pkg/config"
+	"github.com/mrlm-net/cure/pkg/terminal"
+)
+
+const bulkRecords = `{"name":"api","description":"Billing API","language":"go","conventions":["gofmt","go vet"]}
+
+{"name":"web","description":"Storefront"}
+{"name":"worker","description":"Queue worker","language":"python","build-tool":"poetry"}
+not json
+`
+
+// runBulkCommand writes records to a temp file and runs claude-md --from-json
+// against it with args appended.
+func runBulkCommand(t *testing.T, records string, args ...string) (outDir string, stderr string, err error) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	input := filepath.Join(tmpDir, "projects.jsonl")
+	if err := os.WriteFile(input, []byte(records), 0644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	outDir = filepath.Join(tmpDir, "out")
+
+	cmd := &ClaudeMDCommand{}
+	if err := cmd.Flags().Parse(append([]string{"--from-json", input, "--out-dir", outDir}, args...)); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	var stdout, errBuf bytes.Buffer
+	tc := &terminal.Context{Stdout: &stdout, Stderr: &errBuf, Config: config.NewConfig()}
+	err = cmd.Run(context.Background(), tc)
+	return outDir, errBuf.String(), err
+}
+
+func TestClaudeMDCommand_FromJSON(t *testing.T) {
+	outDir, stderr, err := runBulkCommand(t, bulkRecords)
+	if err == nil || !strings.Contains(err.Error(), "2 of 4 records failed") {
+		t.Errorf("Run() error = %v, want 2 of 4 records failed", err)
+	}
+	for _, want := range []string{`line 3: missing required field "language"`, "line 5:", "4 records, 2 generated, 2 failed"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr = %q, want it to contain %q", stderr, want)
+		}
+	}
+
+	api, err := os.ReadFile(filepath.Join(outDir, "api", "CLAUDE.md"))
+	if err != nil {
+		t.Fatalf("api/CLAUDE.md not written: %v", err)
+	}
+	if !strings.Contains(string(api), "# api") || !strings.Contains(string(api), "go vet") {
+		t.Errorf("api/CLAUDE.md missing record fields:\n%s", api)
+	}
+	worker, err := os.ReadFile(filepath.Join(outDir, "worker", "CLAUDE.md"))
+	if err != nil {
+		t.Fatalf("worker/CLAUDE.md not written: %v", err)
+	}
+	if !strings.Contains(string(worker), "poetry") {
+		t.Errorf("worker/CLAUDE.md missing build tool:\n%s", worker)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "web")); !os.IsNotExist(err) {
+		t.Errorf("web/ exists for an invalid record (err = %v)", err)
+	}
+}
+
+func TestClaudeMDCommand_FromJSON_Strict(t *testing.T) {
+	outDir, _, err := runBulkCommand(t, bulkRecords, "--strict")
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("Run() error = %v, want line 3 error", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "api", "CLAUDE.md")); err != nil {
+		t.Errorf("api/CLAUDE.md before the bad record not written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "worker")); !os.IsNotExist(err) {
+		t.Errorf("worker/ written after --strict abort (err = %v)", err)
+	}
+}
+
+func TestClaudeMDCommand_FromJSON_DryRun(t *testing.T) {
+	records := `{"name":"api","description":"Billing API","language":"go"}`
+	outDir, _, err := runBulkCommand(t, records, "--dry-run")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if _, err := os.Stat(outDir); !os.IsNotExist(err) {
+		t.Errorf("dry run created %s (err = %v)", outDir, err)
+	}
+}
+
+func TestClaudeMDCommand_FromJSON_TemplatedOutput(t *testing.T) {
+	records := `{"name":"api","description":"Billing API","language":"go"}`
+	tmpDir := t.TempDir()
+	output := filepath.Join(tmpDir, "projects", "{{.Name}}", "docs", "CLAUDE.md")
+	outDir, _, err := runBulkCommand(t, records, "--output", output)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(tmpDir, "projects", "api", "docs", "CLAUDE.md"))
+	if err != nil {
+		t.Fatalf("projects/api/docs/CLAUDE.md not written: %v", err)
+	}
+	if !strings.Contains(string(got), "# api") {
+		t.Errorf("CLAUDE.md missing record fields:\n%s", got)
+	}
+	if _, err := os.Stat(outDir); !os.IsNotExist(err) {
+		t.Errorf("templated --output still wrote under --out-dir (err = %v)", err)
+	}
+}
+
+func TestClaudeMDCommand_FromJSON_Stdin(t *testing.T) {
+	outDir := filepath.Join(t.TempDir(), "out")
+	cmd := &ClaudeMDCommand{}
+	if err := cmd.Flags().Parse([]string{"--from-json", "-", "--out-dir", outDir}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	tc := &terminal.Context{
+		Stdin:  strings.NewReader(`{"name":"api","description":"Billing API","language":"go"}` + "\n"),
+		Stdout: &bytes.Buffer{},
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "api", "CLAUDE.md")); err != nil {
+		t.Errorf("api/CLAUDE.md not written from stdin: %v", err)
+	}
+
+	cmd = &ClaudeMDCommand{}
+	if err := cmd.Flags().Parse([]string{"--from-json", "-", "--out-dir", outDir}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	tc = &terminal.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Config: config.NewConfig()}
+	if err := cmd.Run(context.Background(), tc); err == nil || !strings.Contains(err.Error(), "no stdin") {
+		t.Errorf("Run() without stdin error = %v, want no stdin error", err)
+	}
+}
+
+func TestAIFileOptsFromRecord(t *testing.T) {
+	base := AIFileOpts{BuildTool: "make"}
+	tests := []struct {
+		name    string
+		rec     map[string]interface{}
+		want    AIFileOpts
+		wantErr string
+	}{
+		{
+			name: "string conventions",
+			rec:  map[string]interface{}{"name": "a", "description": "d", "language": "go", "conventions": "x, y"},
+			want: AIFileOpts{Name: "a", Description: "d", Language: "go", BuildTool: "make", Conventions: "x, y"},
+		},
+		{
+			name: "array conventions and override",
+			rec:  map[string]interface{}{"name": "a", "description": "d", "language": "go", "build-tool": "task", "conventions": []interface{}{"x", "y"}},
+			want: AIFileOpts{Name: "a", Description: "d", Language: "go", BuildTool: "task", Conventions: "x,y"},
+		},
+		{"missing description", map[string]interface{}{"name": "a", "language": "go"}, AIFileOpts{}, `"description"`},
+		{"unknown field", map[string]interface{}{"name": "a", "description": "d", "language": "go", "lang": "go"}, AIFileOpts{}, `unknown field "lang"`},
+		{"non-string field", map[string]interface{}{"name": "a", "description": "d", "language": 1.0}, AIFileOpts{}, "must be a string"},
+		{"unsafe name", map[string]interface{}{"name": "../a", "description": "d", "language": "go"}, AIFileOpts{}, "directory name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := aiFileOptsFromRecord(base, tt.rec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("aiFileOptsFromRecord() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("aiFileOptsFromRecord() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("aiFileOptsFromRecord() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
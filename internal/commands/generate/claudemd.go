@@ -4,11 +4,9 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"os"
 	"path/filepath"
 
 	"github.com/mrlm-net/cure/pkg/fs"
-	"github.com/mrlm-net/cure/pkg/prompt"
 	"github.com/mrlm-net/cure/pkg/terminal"
 )
 
@@ -20,6 +18,11 @@ type ClaudeMDCommand struct {
 	dryRun         bool
 	outputPath     string
 
+	// Bulk mode
+	fromJSON string
+	outDir   string
+	strict   bool
+
 	// Field values (from flags or prompts)
 	name          string
 	description   string
@@ -27,6 +30,9 @@ type ClaudeMDCommand struct {
 	buildTool     string
 	testFramework string
 	conventions   string // comma-separated
+	repository    string
+	license       string
+	homepage      string
 }
 
 func (c *ClaudeMDCommand) Name() string        { return "claude-md" }
@@ -48,6 +54,9 @@ Non-interactive mode (for CI/CD):
     --test-framework testing \
     --conventions "gofmt,go vet"
 
+Bulk mode (one CLAUDE.md per JSON line, written to <out-dir>/<name>/CLAUDE.md):
+  cure generate claude-md --from-json projects.jsonl --out-dir repos
+
 Flags:
   --non-interactive   Disable prompts, require all values via flags
   --dry-run           Preview generated output without writing to disk
@@ -57,8 +66,15 @@ Flags:
   --build-tool        Build tool (default: make)
   --test-framework    Test framework (default: language-specific)
   --conventions       Comma-separated conventions (optional)
-  --output            Output file path (default: ./CLAUDE.md)
+  --repository        Repository URL (optional)
+  --license           License identifier, e.g. MIT (optional)
+  --homepage          Project homepage URL (optional)
+  --output            Output file path, may use template fields such as {{.Name}}
+                      (default: ./CLAUDE.md)
   --force             Overwrite existing file without prompting
+  --from-json         Read project records from a JSON-lines file ("-" for stdin)
+  --out-dir           Root directory for bulk output (default: .)
+  --strict            Abort bulk mode on the first invalid record
 
 Examples:
   # Interactive mode with defaults from config
@@ -79,6 +95,13 @@ Examples:
 
   # Custom output path
   cure generate claude-md --output docs/CLAUDE.md
+
+  # Bulk: each line is an object keyed by flag name, e.g.
+  # {"name":"api","description":"Billing API","language":"go","conventions":["gofmt","go vet"]}
+  cure generate claude-md --from-json projects.jsonl --out-dir repos --dry-run
+
+  # Bulk with a templated path instead of <out-dir>/<name>/CLAUDE.md
+  cure generate claude-md --from-json projects.jsonl --output "out/{{.Name}}/docs/CLAUDE.md"
 `
 }
 
@@ -94,25 +117,43 @@ func (c *ClaudeMDCommand) Flags() *flag.FlagSet {
 	fset.StringVar(&c.buildTool, "build-tool", "", "Build tool (e.g., make, npm, cargo)")
 	fset.StringVar(&c.testFramework, "test-framework", "", "Test framework")
 	fset.StringVar(&c.conventions, "conventions", "", "Comma-separated key conventions")
+	fset.StringVar(&c.repository, "repository", "", "Repository URL")
+	fset.StringVar(&c.license, "license", "", "License identifier (e.g., MIT)")
+	fset.StringVar(&c.homepage, "homepage", "", "Project homepage URL")
+	fset.StringVar(&c.fromJSON, "from-json", "", "Generate one file per record of a JSON-lines file (\"-\" for stdin)")
+	fset.StringVar(&c.outDir, "out-dir", ".", "Root directory for --from-json output")
+	fset.BoolVar(&c.strict, "strict", false, "Abort --from-json on the first invalid record")
 	return fset
 }
 
 func (c *ClaudeMDCommand) Run(ctx context.Context, tc *terminal.Context) error {
 	c.loadDefaults(tc)
 
+	if c.fromJSON != "" {
+		return c.runBulk(ctx, tc)
+	}
+
 	if err := c.gatherInput(tc); err != nil {
 		return err
 	}
 
-	// In interactive mode, prompt the user when the target file already exists.
+	opts, err := resolveOutput(c.toOpts())
+	if err != nil {
+		return err
+	}
+	c.outputPath = opts.OutputPath
+
+	// In interactive mode, prompt the user when the target file already
+	// exists; the global --yes confirms without prompting.
 	// This check runs before Generate*, which will honour opts.Force.
-	if !c.nonInteractive && !c.dryRun {
+	if !c.dryRun && (tc.AssumeYes || !c.nonInteractive && tc.Interactive()) {
 		if err := c.checkOverwrite(tc); err != nil {
 			return err
 		}
 	}
 
-	if err := GenerateClaudeMD(ctx, tc.Stdout, ClaudeMDOpts{c.toOpts()}); err != nil {
+	opts.Force = c.force
+	if err := GenerateClaudeMD(ctx, tc.Stdout, ClaudeMDOpts{opts}); err != nil {
 		return err
 	}
 
@@ -123,7 +164,7 @@ func (c *ClaudeMDCommand) Run(ctx context.Context, tc *terminal.Context) error {
 }
 
 // checkOverwrite prompts for confirmation when the output file already exists
-// and --force has not been set (interactive mode only).
+// and --force has not been set (interactive mode, or with --yes).
 func (c *ClaudeMDCommand) checkOverwrite(tc *terminal.Context) error {
 	exists, err := fs.Exists(c.outputPath)
 	if err != nil {
@@ -132,8 +173,7 @@ func (c *ClaudeMDCommand) checkOverwrite(tc *terminal.Context) error {
 	if !exists || c.force {
 		return nil
 	}
-	prompter := prompt.NewPrompter(tc.Stdout, os.Stdin)
-	confirm, err := prompter.Confirm(fmt.Sprintf("%s already exists. Overwrite?", c.outputPath))
+	confirm, err := tc.Confirm(fmt.Sprintf("%s already exists. Overwrite?", c.outputPath))
 	if err != nil {
 		return err
 	}
@@ -153,6 +193,9 @@ func (c *ClaudeMDCommand) toOpts() AIFileOpts {
 		BuildTool:      c.buildTool,
 		TestFramework:  c.testFramework,
 		Conventions:    c.conventions,
+		Repository:     c.repository,
+		License:        c.license,
+		Homepage:       c.homepage,
 		OutputPath:     c.outputPath,
 		Force:          c.force,
 		DryRun:         c.dryRun,
@@ -198,7 +241,7 @@ func (c *ClaudeMDCommand) loadDefaults(tc *terminal.Context) {
 
 // gatherInput collects values via prompts (interactive) or validates flags (non-interactive).
 func (c *ClaudeMDCommand) gatherInput(tc *terminal.Context) error {
-	if c.nonInteractive {
+	if c.nonInteractive || !tc.Interactive() {
 		return c.validateFlags()
 	}
 	return c.promptUser(tc)
@@ -228,7 +271,7 @@ func (c *ClaudeMDCommand) validateFlags() error {
 
 // promptUser runs interactive prompts to gather input.
 func (c *ClaudeMDCommand) promptUser(tc *terminal.Context) error {
-	prompter := prompt.NewPrompter(tc.Stdout, os.Stdin)
+	prompter := tc.Prompter()
 
 	var err error
 	c.name, err = prompter.Required("What is the project name?", c.name)
@@ -268,6 +311,21 @@ func (c *ClaudeMDCommand) promptUser(tc *terminal.Context) error {
 		return err
 	}
 
+	c.repository, err = prompter.Optional("Repository URL (optional):", c.repository)
+	if err != nil {
+		return err
+	}
+
+	c.license, err = prompter.Optional("License identifier, e.g. MIT (optional):", c.license)
+	if err != nil {
+		return err
+	}
+
+	c.homepage, err = prompter.Optional("Homepage URL (optional):", c.homepage)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
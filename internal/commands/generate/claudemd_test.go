@@ -118,6 +118,75 @@ func TestClaudeMDCommand_NonInteractive(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "with repository, license, and homepage",
+			args: []string{
+				"--non-interactive",
+				"--name", "myapp",
+				"--description", "A test app",
+				"--language", "go",
+				"--repository", "https://github.com/example/myapp",
+				"--license", "MIT",
+				"--homepage", "https://myapp.example.com",
+				"--output", outputPath,
+			},
+			wantErr: false,
+			checkFile: func(t *testing.T, content string) {
+				for _, want := range []string{
+					"## Project",
+					"- **Repository**: https://github.com/example/myapp",
+					"- **License**: MIT",
+					"- **Homepage**: https://myapp.example.com",
+					"git clone https://github.com/example/myapp",
+				} {
+					if !strings.Contains(content, want) {
+						t.Errorf("Output missing %q", want)
+					}
+				}
+			},
+		},
+		{
+			name: "license only",
+			args: []string{
+				"--non-interactive",
+				"--name", "myapp",
+				"--description", "A test app",
+				"--language", "go",
+				"--license", "Apache-2.0",
+				"--output", outputPath,
+			},
+			wantErr: false,
+			checkFile: func(t *testing.T, content string) {
+				if !strings.Contains(content, "## Project\n\n- **License**: Apache-2.0\n\n## Tech Stack") {
+					t.Errorf("Output has malformed Project section:\n%s", content)
+				}
+				if strings.Contains(content, "Repository") || strings.Contains(content, "Homepage") {
+					t.Error("Output contains unset Repository/Homepage fields")
+				}
+			},
+		},
+		{
+			name: "no optional metadata omits Project section",
+			args: []string{
+				"--non-interactive",
+				"--name", "myapp",
+				"--description", "A test app",
+				"--language", "go",
+				"--output", outputPath,
+			},
+			wantErr: false,
+			checkFile: func(t *testing.T, content string) {
+				if strings.Contains(content, "## Project") {
+					t.Error("Output contains empty Project section")
+				}
+				if !strings.Contains(content, "A test app\n\n## Tech Stack") {
+					t.Error("Description should be followed directly by Tech Stack")
+				}
+				if !strings.Contains(content, "git clone <your-repo-url>") {
+					t.Error("Output missing repository placeholder")
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -171,6 +240,54 @@ func TestClaudeMDCommand_Interactive(t *testing.T) {
 	t.Skip("Interactive stdin testing is unreliable in unit tests - covered by Prompter tests and E2E")
 }
 
+// TestClaudeMDCommand_NonTTYStdin checks that piped stdin counts as
+// non-interactive without --non-interactive: flags are required and nothing
+// is read from stdin.
+func TestClaudeMDCommand_NonTTYStdin(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "CLAUDE.md")
+
+	t.Run("missing flags are an error, not a prompt", func(t *testing.T) {
+		cmd := &ClaudeMDCommand{}
+		if err := cmd.Flags().Parse([]string{"--output", outputPath}); err != nil {
+			t.Fatalf("Failed to parse flags: %v", err)
+		}
+		var stdout bytes.Buffer
+		tc := &terminal.Context{
+			Stdin:  strings.NewReader("piped-name\npiped description\ngo\n"),
+			Stdout: &stdout,
+			Stderr: &bytes.Buffer{},
+			Config: config.NewConfig(),
+		}
+		err := cmd.Run(context.Background(), tc)
+		if err == nil || !strings.Contains(err.Error(), "--name is required") {
+			t.Fatalf("Run() error = %v, want --name required", err)
+		}
+		if strings.Contains(stdout.String(), "project name?") {
+			t.Errorf("stdout = %q, want no prompt", stdout.String())
+		}
+	})
+
+	t.Run("flags are enough", func(t *testing.T) {
+		cmd := &ClaudeMDCommand{}
+		args := []string{"--name", "myapp", "--description", "A test app", "--language", "go", "--output", outputPath}
+		if err := cmd.Flags().Parse(args); err != nil {
+			t.Fatalf("Failed to parse flags: %v", err)
+		}
+		tc := &terminal.Context{
+			Stdin:  strings.NewReader(""),
+			Stdout: &bytes.Buffer{},
+			Stderr: &bytes.Buffer{},
+			Config: config.NewConfig(),
+		}
+		if err := cmd.Run(context.Background(), tc); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if _, err := os.Stat(outputPath); err != nil {
+			t.Errorf("output not written: %v", err)
+		}
+	})
+}
+
 func TestClaudeMDCommand_DryRun(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputPath := filepath.Join(tmpDir, "CLAUDE.md")
@@ -269,6 +386,9 @@ func TestClaudeMDCommand_DryRun(t *testing.T) {
 				if !strings.Contains(stdout, "# Dry run mode: would write to") {
 					t.Error("Dry-run output missing header line")
 				}
+				if !strings.Contains(stdout, "already exists. Use --force to overwrite") {
+					t.Error("Dry-run output missing existing-file note")
+				}
 			},
 		},
 		{
@@ -395,9 +515,10 @@ func TestClaudeMDCommand_OverwriteProtection(t *testing.T) {
 	}
 
 	tests := []struct {
-		name    string
-		args    []string
-		wantErr bool
+		name      string
+		args      []string
+		assumeYes bool
+		wantErr   bool
 	}{
 		{
 			name: "non-interactive without force fails",
@@ -422,6 +543,18 @@ func TestClaudeMDCommand_OverwriteProtection(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "non-interactive with --yes succeeds",
+			args: []string{
+				"--non-interactive",
+				"--name", "myapp",
+				"--description", "A test app",
+				"--language", "go",
+				"--output", outputPath,
+			},
+			assumeYes: true,
+			wantErr:   false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -439,9 +572,10 @@ func TestClaudeMDCommand_OverwriteProtection(t *testing.T) {
 			// Create context
 			var stdout, stderr bytes.Buffer
 			tc := &terminal.Context{
-				Stdout: &stdout,
-				Stderr: &stderr,
-				Config: config.NewConfig(),
+				Stdout:    &stdout,
+				Stderr:    &stderr,
+				Config:    config.NewConfig(),
+				AssumeYes: tt.assumeYes,
 			}
 
 			// Run command
@@ -451,6 +585,11 @@ func TestClaudeMDCommand_OverwriteProtection(t *testing.T) {
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Run() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if !tt.wantErr {
+				if got, _ := os.ReadFile(outputPath); string(got) == "existing content" {
+					t.Error("existing file was not overwritten")
+				}
+			}
 		})
 	}
 }
@@ -534,3 +673,57 @@ func TestClaudeMDCommand_E2E(t *testing.T) {
 		}
 	}
 }
+
+func TestClaudeMDCommand_TemplatedOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	cmd := &ClaudeMDCommand{}
+	if err := cmd.Flags().Parse([]string{
+		"--non-interactive",
+		"--name", "cure",
+		"--description", "A Go CLI tool",
+		"--language", "go",
+		"--output", filepath.Join(tmpDir, "out", "{{.Name}}", "CLAUDE.md"),
+	}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	var stdout bytes.Buffer
+	tc := &terminal.Context{Stdout: &stdout, Stderr: &bytes.Buffer{}, Config: config.NewConfig()}
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := filepath.Join(tmpDir, "out", "cure", "CLAUDE.md")
+	content, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatalf("resolved output %s not written: %v", want, err)
+	}
+	if !strings.Contains(string(content), "# cure") {
+		t.Errorf("output missing project name:\n%s", content)
+	}
+	if strings.Contains(stdout.String(), "{{") {
+		t.Errorf("success message names the unrendered path:\n%s", stdout.String())
+	}
+}
+
+func TestClaudeMDCommand_TemplatedOutputRenderedOnce(t *testing.T) {
+	tmpDir := t.TempDir()
+	cmd := &ClaudeMDCommand{}
+	if err := cmd.Flags().Parse([]string{
+		"--non-interactive",
+		"--name", "{{odd}}",
+		"--description", "A Go CLI tool",
+		"--language", "go",
+		"--output", filepath.Join(tmpDir, "{{.Name}}.md"),
+	}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tc := &terminal.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Config: config.NewConfig()}
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "{{odd}}.md")); err != nil {
+		t.Errorf("output not written to the path rendered once: %v", err)
+	}
+}
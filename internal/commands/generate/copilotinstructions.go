@@ -4,11 +4,9 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"os"
 	"path/filepath"
 
 	"github.com/mrlm-net/cure/pkg/fs"
-	"github.com/mrlm-net/cure/pkg/prompt"
 	"github.com/mrlm-net/cure/pkg/terminal"
 )
 
@@ -82,13 +80,20 @@ func (c *CopilotInstructionsCommand) Run(ctx context.Context, tc *terminal.Conte
 		return err
 	}
 
-	if !c.nonInteractive && !c.dryRun {
+	opts, err := resolveOutput(c.toOpts())
+	if err != nil {
+		return err
+	}
+	c.outputPath = opts.OutputPath
+
+	if !c.dryRun && (tc.AssumeYes || !c.nonInteractive && tc.Interactive()) {
 		if err := c.checkOverwrite(tc); err != nil {
 			return err
 		}
 	}
 
-	if err := GenerateCopilotInstructions(ctx, tc.Stdout, CopilotInstructionsOpts{c.toOpts()}); err != nil {
+	opts.Force = c.force
+	if err := GenerateCopilotInstructions(ctx, tc.Stdout, CopilotInstructionsOpts{opts}); err != nil {
 		return err
 	}
 
@@ -106,8 +111,7 @@ func (c *CopilotInstructionsCommand) checkOverwrite(tc *terminal.Context) error
 	if !exists || c.force {
 		return nil
 	}
-	prompter := prompt.NewPrompter(tc.Stdout, os.Stdin)
-	confirm, err := prompter.Confirm(fmt.Sprintf("%s already exists. Overwrite?", c.outputPath))
+	confirm, err := tc.Confirm(fmt.Sprintf("%s already exists. Overwrite?", c.outputPath))
 	if err != nil {
 		return err
 	}
@@ -169,7 +173,7 @@ func (c *CopilotInstructionsCommand) loadDefaults(tc *terminal.Context) {
 }
 
 func (c *CopilotInstructionsCommand) gatherInput(tc *terminal.Context) error {
-	if c.nonInteractive {
+	if c.nonInteractive || !tc.Interactive() {
 		return c.validateFlags()
 	}
 	return c.promptUser(tc)
@@ -195,7 +199,7 @@ func (c *CopilotInstructionsCommand) validateFlags() error {
 }
 
 func (c *CopilotInstructionsCommand) promptUser(tc *terminal.Context) error {
-	prompter := prompt.NewPrompter(tc.Stdout, os.Stdin)
+	prompter := tc.Prompter()
 
 	var err error
 	c.name, err = prompter.Required("What is the project name?", c.name)
@@ -6,13 +6,11 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/mrlm-net/cure/pkg/fs"
-	"github.com/mrlm-net/cure/pkg/prompt"
 	"github.com/mrlm-net/cure/pkg/template"
 	"github.com/mrlm-net/cure/pkg/terminal"
 )
@@ -146,19 +144,23 @@ func GenerateDevcontainer(ctx context.Context, w io.Writer, opts DevcontainerOpt
 
 	// Dry-run: write to writer and return without touching disk.
 	if opts.DryRun {
-		fmt.Fprintf(w, "# Dry run mode: would write to %s\n\n", devcontainerPath)
+		if err := writeDryRunHeader(w, devcontainerPath, opts.Force); err != nil {
+			return err
+		}
 		fmt.Fprintln(w, devcontainerContent)
 
 		if opts.UseDockerfile {
 			dockerfileData := map[string]interface{}{
 				"BaseImage": dockerfileBaseImage,
 			}
-			dockerfileContent, err := template.Render("devcontainer-dockerfile", dockerfileData)
+			dockerfileContent, err := template.RenderWithContext("devcontainer-dockerfile", dockerfileData)
 			if err != nil {
 				return fmt.Errorf("render dockerfile template: %w", err)
 			}
 			dockerfilePath := filepath.Join(opts.OutputDir, "Dockerfile")
-			fmt.Fprintf(w, "# Dry run mode: would write to %s\n\n", dockerfilePath)
+			if err := writeDryRunHeader(w, dockerfilePath, opts.Force); err != nil {
+				return err
+			}
 			fmt.Fprintln(w, dockerfileContent)
 		}
 		return nil
@@ -184,7 +186,7 @@ func GenerateDevcontainer(ctx context.Context, w io.Writer, opts DevcontainerOpt
 		dockerfileData := map[string]interface{}{
 			"BaseImage": dockerfileBaseImage,
 		}
-		dockerfileContent, err := template.Render("devcontainer-dockerfile", dockerfileData)
+		dockerfileContent, err := template.RenderWithContext("devcontainer-dockerfile", dockerfileData)
 		if err != nil {
 			return fmt.Errorf("render dockerfile template: %w", err)
 		}
@@ -329,7 +331,7 @@ func (c *DevcontainerCommand) Run(ctx context.Context, tc *terminal.Context) err
 // gatherInput collects values via interactive prompts or validates non-interactive flags.
 // When stdin is not a TTY (e.g. CI), defaults are used without prompting.
 func (c *DevcontainerCommand) gatherInput(tc *terminal.Context, opts *DevcontainerOpts) error {
-	if c.nonInteractive || !prompt.IsInteractive(os.Stdin) {
+	if c.nonInteractive || !tc.Interactive() {
 		return c.validateFlags(opts)
 	}
 	return c.promptUser(tc, opts)
@@ -345,7 +347,7 @@ func (c *DevcontainerCommand) validateFlags(opts *DevcontainerOpts) error {
 
 // promptUser runs an interactive wizard to populate opts.
 func (c *DevcontainerCommand) promptUser(tc *terminal.Context, opts *DevcontainerOpts) error {
-	p := prompt.NewPrompter(tc.Stdout, os.Stdin)
+	p := tc.Prompter()
 
 	var err error
 
@@ -0,0 +1,209 @@
+package generate
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+
+	"github.com/mrlm-net/cure/pkg/template"
+	"github.com/mrlm-net/cure/pkg/terminal"
+)
+
+var (
+	// binaryNamePattern matches safe binary names for Dockerfile paths.
+	binaryNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+	// mainPackagePattern matches relative Go package paths such as "." or "./cmd/app".
+	mainPackagePattern = regexp.MustCompile(`^\.(/[a-zA-Z0-9._-]+)*$`)
+)
+
+// DockerOpts holds configuration for the Dockerfile and .dockerignore generator.
+type DockerOpts struct {
+	// GoVersion is the Go toolchain version of the build stage (default: "1.25").
+	GoVersion string
+	// Binary is the name of the built executable (default: "app").
+	Binary string
+	// MainPackage is the package to build, relative to the module root (default: ".").
+	MainPackage string
+	// OutDir is the directory the files are written under (default: ".").
+	OutDir string
+	// Force overwrites existing files without prompting.
+	Force bool
+	// DryRun renders the files to w and returns without writing anything.
+	DryRun bool
+	// NonInteractive skips all prompts and uses flag values with defaults.
+	NonInteractive bool
+}
+
+// GenerateDocker renders the docker-go template bundle — a multi-stage
+// Dockerfile and a .dockerignore — and writes the files under opts.OutDir.
+// When DryRun is true the rendered files are written to w instead.
+//
+// Defaults applied when fields are empty:
+//   - GoVersion   → "1.25"
+//   - Binary      → "app"
+//   - MainPackage → "."
+//   - OutDir      → "."
+func GenerateDocker(ctx context.Context, w io.Writer, opts DockerOpts) error {
+	// Apply defaults.
+	if opts.GoVersion == "" {
+		opts.GoVersion = "1.25"
+	}
+	if opts.Binary == "" {
+		opts.Binary = "app"
+	}
+	if opts.MainPackage == "" {
+		opts.MainPackage = "."
+	}
+	if opts.OutDir == "" {
+		opts.OutDir = "."
+	}
+
+	// Validate inputs that end up in Dockerfile instructions.
+	if !goVersionPattern.MatchString(opts.GoVersion) {
+		return fmt.Errorf("invalid --go-version %q: must match MAJOR.MINOR (e.g. \"1.25\")", opts.GoVersion)
+	}
+	if !binaryNamePattern.MatchString(opts.Binary) {
+		return fmt.Errorf("invalid --binary %q: use letters, digits, '.', '_' or '-'", opts.Binary)
+	}
+	if !mainPackagePattern.MatchString(opts.MainPackage) {
+		return fmt.Errorf("invalid --main %q: must be a relative package path such as \".\" or \"./cmd/app\"", opts.MainPackage)
+	}
+
+	data := map[string]interface{}{
+		"GoVersion":   opts.GoVersion,
+		"Binary":      opts.Binary,
+		"MainPackage": opts.MainPackage,
+	}
+	files, err := template.RenderBundle("docker-go", template.ContextData(data))
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	return writeBundle(w, opts.OutDir, files, opts.Force, opts.DryRun)
+}
+
+// DockerCommand implements terminal.Command for `cure generate docker`.
+type DockerCommand struct {
+	nonInteractive bool
+	force          bool
+	dryRun         bool
+	goVersion      string
+	binary         string
+	mainPackage    string
+	outDir         string
+}
+
+// Name returns the subcommand name used in the CLI.
+func (c *DockerCommand) Name() string { return "docker" }
+
+// Description returns a short description shown in help output.
+func (c *DockerCommand) Description() string {
+	return "Generate a Dockerfile and .dockerignore for a Go service"
+}
+
+// Usage returns detailed usage information including flags and examples.
+func (c *DockerCommand) Usage() string {
+	return `Usage: cure generate docker [flags]
+
+Generate a multi-stage Dockerfile and a matching .dockerignore for a Go
+service. Both files are written under --out-dir; existing files are never
+overwritten without --force.
+
+Interactive mode (default):
+  cure generate docker
+
+Non-interactive mode (for CI/CD):
+  cure generate docker --non-interactive --binary api --main ./cmd/api
+
+Flags:
+  --non-interactive   Disable prompts, use flag values with defaults
+  --dry-run           Preview generated files without writing to disk
+  --force             Overwrite existing files without prompting
+  --go-version        Go toolchain version for the build stage (default: 1.25)
+  --binary            Name of the built executable (default: app)
+  --main              Package to build (default: .)
+  --out-dir           Directory to write the files under (default: .)
+
+Examples:
+  # Preview both files
+  cure generate docker --non-interactive --dry-run
+
+  # Write into a service subdirectory
+  cure generate docker --non-interactive --binary worker --out-dir services/worker
+`
+}
+
+// Flags returns the flag set for this command.
+func (c *DockerCommand) Flags() *flag.FlagSet {
+	fset := flag.NewFlagSet("docker", flag.ContinueOnError)
+	fset.BoolVar(&c.nonInteractive, "non-interactive", false, "Disable prompts, use flag values with defaults")
+	fset.BoolVar(&c.force, "force", false, "Overwrite existing files without prompting")
+	fset.BoolVar(&c.dryRun, "dry-run", false, "Preview output without writing files")
+	fset.StringVar(&c.goVersion, "go-version", "1.25", "Go toolchain version for the build stage")
+	fset.StringVar(&c.binary, "binary", "app", "Name of the built executable")
+	fset.StringVar(&c.mainPackage, "main", ".", "Package to build")
+	fset.StringVar(&c.outDir, "out-dir", ".", "Directory to write the files under")
+	return fset
+}
+
+// Run executes the command, either via interactive prompts or using provided flags.
+func (c *DockerCommand) Run(ctx context.Context, tc *terminal.Context) error {
+	if !c.nonInteractive && tc.Interactive() {
+		if err := c.promptUser(tc); err != nil {
+			return err
+		}
+	}
+
+	opts := DockerOpts{
+		GoVersion:      c.goVersion,
+		Binary:         c.binary,
+		MainPackage:    c.mainPackage,
+		OutDir:         c.outDir,
+		Force:          c.force,
+		DryRun:         c.dryRun,
+		NonInteractive: c.nonInteractive,
+	}
+
+	if err := GenerateDocker(ctx, tc.Stdout, opts); err != nil {
+		return err
+	}
+
+	// Print success message only when files were actually written.
+	if !c.dryRun {
+		c.printSuccess(tc)
+	}
+	return nil
+}
+
+// promptUser runs the interactive wizard to collect values from the user.
+func (c *DockerCommand) promptUser(tc *terminal.Context) error {
+	prompter := tc.Prompter()
+
+	var err error
+	c.binary, err = prompter.Optional(fmt.Sprintf("Binary name [%s]:", c.binary), c.binary)
+	if err != nil {
+		return err
+	}
+	c.mainPackage, err = prompter.Optional(fmt.Sprintf("Package to build [%s]:", c.mainPackage), c.mainPackage)
+	if err != nil {
+		return err
+	}
+	c.goVersion, err = prompter.Optional(fmt.Sprintf("Go version [%s]:", c.goVersion), c.goVersion)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// printSuccess writes a success message and next steps to stdout.
+func (c *DockerCommand) printSuccess(tc *terminal.Context) {
+	fmt.Fprintf(tc.Stdout, "Generated %s successfully.\n", filepath.Join(c.outDir, "Dockerfile"))
+	fmt.Fprintf(tc.Stdout, "Generated %s successfully.\n\n", filepath.Join(c.outDir, ".dockerignore"))
+	fmt.Fprintln(tc.Stdout, "Next steps:")
+	fmt.Fprintln(tc.Stdout, "1. Review the Dockerfile and adjust the runtime image if your binary needs cgo")
+	fmt.Fprintf(tc.Stdout, "2. Build the image: docker build -t %s %s\n", c.binary, c.outDir)
+}
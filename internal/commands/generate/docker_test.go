@@ -0,0 +1,109 @@
+package generate
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrlm-net/cure/pkg/terminal"
+)
+
+// runDockerCmd parses args, runs the command, and returns stdout and the error.
+func runDockerCmd(t *testing.T, args []string) (string, error) {
+	t.Helper()
+	cmd := &DockerCommand{}
+	fset := cmd.Flags()
+	if err := fset.Parse(args); err != nil {
+		t.Fatalf("flag parse error: %v", err)
+	}
+	var stdout, stderr bytes.Buffer
+	tc := &terminal.Context{Stdout: &stdout, Stderr: &stderr}
+	err := cmd.Run(context.Background(), tc)
+	return stdout.String(), err
+}
+
+func TestDockerCommand_OutDir(t *testing.T) {
+	outDir := filepath.Join(t.TempDir(), "services", "api")
+
+	if _, err := runDockerCmd(t, []string{
+		"--non-interactive",
+		"--binary", "api",
+		"--main", "./cmd/api",
+		"--out-dir", outDir,
+	}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	dockerfile, err := os.ReadFile(filepath.Join(outDir, "Dockerfile"))
+	if err != nil {
+		t.Fatalf("ReadFile(Dockerfile) error = %v", err)
+	}
+	if !strings.Contains(string(dockerfile), "ENTRYPOINT [\"/usr/local/bin/api\"]") {
+		t.Errorf("Dockerfile missing entrypoint:\n%s", dockerfile)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, ".dockerignore")); err != nil {
+		t.Errorf(".dockerignore not written: %v", err)
+	}
+}
+
+func TestDockerCommand_OverwriteProtection(t *testing.T) {
+	outDir := t.TempDir()
+	ignorePath := filepath.Join(outDir, ".dockerignore")
+	if err := os.WriteFile(ignorePath, []byte("keep\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := runDockerCmd(t, []string{"--non-interactive", "--out-dir", outDir})
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("Run() error = %v, want already exists", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "Dockerfile")); !os.IsNotExist(err) {
+		t.Error("Dockerfile written despite conflicting .dockerignore")
+	}
+
+	if _, err := runDockerCmd(t, []string{"--non-interactive", "--out-dir", outDir, "--force"}); err != nil {
+		t.Fatalf("Run() with --force error = %v", err)
+	}
+	if got, _ := os.ReadFile(ignorePath); string(got) == "keep\n" {
+		t.Error(".dockerignore not overwritten with --force")
+	}
+}
+
+func TestDockerCommand_DryRun(t *testing.T) {
+	outDir := t.TempDir()
+
+	stdout, err := runDockerCmd(t, []string{"--non-interactive", "--dry-run", "--out-dir", outDir})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for _, name := range []string{"Dockerfile", ".dockerignore"} {
+		if !strings.Contains(stdout, "would write to "+filepath.Join(outDir, name)) {
+			t.Errorf("dry-run output missing header for %s:\n%s", name, stdout)
+		}
+		if _, err := os.Stat(filepath.Join(outDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s written in dry-run mode", name)
+		}
+	}
+}
+
+func TestDockerCommand_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"binary with slash", []string{"--binary", "../evil"}},
+		{"absolute main package", []string{"--main", "/etc"}},
+		{"bad go version", []string{"--go-version", "latest"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"--non-interactive", "--out-dir", t.TempDir()}, tt.args...)
+			if _, err := runDockerCmd(t, args); err == nil {
+				t.Error("Run() error = nil, want validation error")
+			}
+		})
+	}
+}
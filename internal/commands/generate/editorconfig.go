@@ -5,7 +5,6 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -112,7 +111,7 @@ func (c *EditorconfigCommand) Run(ctx context.Context, tc *terminal.Context) err
 	}
 
 	// Interactive mode: show MultiSelect menu (only when stdin is a TTY).
-	if !c.nonInteractive && prompt.IsInteractive(os.Stdin) {
+	if !c.nonInteractive && tc.Interactive() {
 		selected, err := c.promptLanguages(tc)
 		if err != nil {
 			return err
@@ -137,7 +136,7 @@ func (c *EditorconfigCommand) promptLanguages(tc *terminal.Context) ([]string, e
 		{Label: "Generic (catch-all)", Value: "generic"},
 	}
 
-	prompter := prompt.NewPrompter(tc.Stdout, os.Stdin)
+	prompter := tc.Prompter()
 	chosen, err := prompter.MultiSelect("Select languages for .editorconfig sections", options)
 	if err != nil {
 		return nil, err
@@ -167,13 +166,15 @@ func GenerateEditorconfig(ctx context.Context, w io.Writer, opts EditorconfigOpt
 		"Sections": sections,
 	}
 
-	output, err := template.Render("editorconfig", data)
+	output, err := template.RenderWithContext("editorconfig", data)
 	if err != nil {
 		return fmt.Errorf("failed to render template: %w", err)
 	}
 
 	if opts.DryRun {
-		fmt.Fprintf(w, "# Dry run mode: would write to %s\n\n", opts.OutputPath)
+		if err := writeDryRunHeader(w, opts.OutputPath, opts.Force); err != nil {
+			return err
+		}
 		fmt.Fprintln(w, output)
 		return nil
 	}
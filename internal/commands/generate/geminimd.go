@@ -4,11 +4,9 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"os"
 	"path/filepath"
 
 	"github.com/mrlm-net/cure/pkg/fs"
-	"github.com/mrlm-net/cure/pkg/prompt"
 	"github.com/mrlm-net/cure/pkg/terminal"
 )
 
@@ -79,13 +77,20 @@ func (c *GeminiMDCommand) Run(ctx context.Context, tc *terminal.Context) error {
 		return err
 	}
 
-	if !c.nonInteractive && !c.dryRun {
+	opts, err := resolveOutput(c.toOpts())
+	if err != nil {
+		return err
+	}
+	c.outputPath = opts.OutputPath
+
+	if !c.dryRun && (tc.AssumeYes || !c.nonInteractive && tc.Interactive()) {
 		if err := c.checkOverwrite(tc); err != nil {
 			return err
 		}
 	}
 
-	if err := GenerateGeminiMD(ctx, tc.Stdout, GeminiMDOpts{c.toOpts()}); err != nil {
+	opts.Force = c.force
+	if err := GenerateGeminiMD(ctx, tc.Stdout, GeminiMDOpts{opts}); err != nil {
 		return err
 	}
 
@@ -103,8 +108,7 @@ func (c *GeminiMDCommand) checkOverwrite(tc *terminal.Context) error {
 	if !exists || c.force {
 		return nil
 	}
-	prompter := prompt.NewPrompter(tc.Stdout, os.Stdin)
-	confirm, err := prompter.Confirm(fmt.Sprintf("%s already exists. Overwrite?", c.outputPath))
+	confirm, err := tc.Confirm(fmt.Sprintf("%s already exists. Overwrite?", c.outputPath))
 	if err != nil {
 		return err
 	}
@@ -166,7 +170,7 @@ func (c *GeminiMDCommand) loadDefaults(tc *terminal.Context) {
 }
 
 func (c *GeminiMDCommand) gatherInput(tc *terminal.Context) error {
-	if c.nonInteractive {
+	if c.nonInteractive || !tc.Interactive() {
 		return c.validateFlags()
 	}
 	return c.promptUser(tc)
@@ -192,7 +196,7 @@ func (c *GeminiMDCommand) validateFlags() error {
 }
 
 func (c *GeminiMDCommand) promptUser(tc *terminal.Context) error {
-	prompter := prompt.NewPrompter(tc.Stdout, os.Stdin)
+	prompter := tc.Prompter()
 
 	var err error
 	c.name, err = prompter.Required("What is the project name?", c.name)
@@ -19,6 +19,8 @@ func NewGenerateCommand() terminal.Command {
 	router.Register(&EditorconfigCommand{})
 	router.Register(&GitignoreCommand{})
 	router.Register(&GithubWorkflowCommand{})
+	router.Register(&DockerCommand{})
+	router.Register(&ListCommand{})
 	// scaffold must be registered last so it can reference all other generators
 	// via the scaffoldGenerators map (which captures the Generate* functions).
 	router.Register(&ScaffoldCommand{})
@@ -5,12 +5,10 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"regexp"
 
 	"github.com/mrlm-net/cure/pkg/fs"
-	"github.com/mrlm-net/cure/pkg/prompt"
 	"github.com/mrlm-net/cure/pkg/template"
 	"github.com/mrlm-net/cure/pkg/terminal"
 )
@@ -72,14 +70,16 @@ func GenerateGithubWorkflow(ctx context.Context, w io.Writer, opts GithubWorkflo
 		"IncludeLint":     opts.IncludeLint,
 		"IncludeCoverage": opts.IncludeCoverage,
 	}
-	output, err := template.Render("github-workflow-go", data)
+	output, err := template.RenderWithContext("github-workflow-go", data)
 	if err != nil {
 		return fmt.Errorf("failed to render template: %w", err)
 	}
 
 	// Dry-run: write to the provided writer and return without touching the filesystem.
 	if opts.DryRun {
-		fmt.Fprintf(w, "# Dry run mode: would write to %s\n\n", opts.OutputPath)
+		if err := writeDryRunHeader(w, opts.OutputPath, opts.Force); err != nil {
+			return err
+		}
 		fmt.Fprintln(w, output)
 		return nil
 	}
@@ -178,7 +178,7 @@ func (c *GithubWorkflowCommand) Flags() *flag.FlagSet {
 
 // Run executes the command, either via interactive prompts or using provided flags.
 func (c *GithubWorkflowCommand) Run(ctx context.Context, tc *terminal.Context) error {
-	if !c.nonInteractive && prompt.IsInteractive(os.Stdin) {
+	if !c.nonInteractive && tc.Interactive() {
 		if err := c.promptUser(tc); err != nil {
 			return err
 		}
@@ -207,7 +207,7 @@ func (c *GithubWorkflowCommand) Run(ctx context.Context, tc *terminal.Context) e
 
 // promptUser runs the interactive wizard to collect values from the user.
 func (c *GithubWorkflowCommand) promptUser(tc *terminal.Context) error {
-	prompter := prompt.NewPrompter(tc.Stdout, os.Stdin)
+	prompter := tc.Prompter()
 
 	var err error
 
@@ -5,7 +5,6 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -139,7 +138,9 @@ func GenerateGitignore(ctx context.Context, w io.Writer, opts GitignoreOpts) err
 	output := sb.String()
 
 	if opts.DryRun {
-		fmt.Fprintf(w, "# Dry run mode: would write to %s\n\n", opts.OutputPath)
+		if err := writeDryRunHeader(w, opts.OutputPath, opts.Force); err != nil {
+			return err
+		}
 		_, err := fmt.Fprint(w, output)
 		return err
 	}
@@ -271,7 +272,7 @@ func (c *GitignoreCommand) resolveProfiles(tc *terminal.Context) ([]string, erro
 
 	// When stdin is not interactive (pipe, redirect, test buffer), fall back to
 	// non-interactive without profiles (universal section only).
-	if !prompt.IsInteractive(os.Stdin) {
+	if !tc.Interactive() {
 		return parseProfileFlag(c.profiles)
 	}
 
@@ -285,7 +286,7 @@ func (c *GitignoreCommand) resolveProfiles(tc *terminal.Context) ([]string, erro
 		})
 	}
 
-	prompter := prompt.NewPrompter(tc.Stdout, os.Stdin)
+	prompter := tc.Prompter()
 	selected, err := prompter.MultiSelect("Select .gitignore profiles", options)
 	if err != nil {
 		return nil, err
@@ -28,6 +28,7 @@ type K8sJobCommand struct {
 	nodeSelector string // comma-separated key=value pairs
 	toleration   string // comma-separated key=value:effect or key:effect specs
 	output       string // "" = stdout
+	dryRun       bool
 }
 
 func (c *K8sJobCommand) Name() string { return "k8s-job" }
@@ -52,6 +53,7 @@ Optional flags:
   --node-selector  Comma-separated key=value node labels (e.g. agentpool=gpupool)
   --toleration     Comma-separated toleration specs: key=value:effect or key:effect
   --output         Output file path; empty = stdout (default: "")
+  --dry-run        Print the manifest that would be written to --output without writing it
 
 Examples:
   # DNS trace — print manifest to stdout
@@ -87,6 +89,7 @@ func (c *K8sJobCommand) Flags() *flag.FlagSet {
 	fs.StringVar(&c.nodeSelector, "node-selector", "", "Comma-separated key=value node labels (e.g. agentpool=gpupool)")
 	fs.StringVar(&c.toleration, "toleration", "", "Comma-separated toleration specs: key=value:effect or key:effect")
 	fs.StringVar(&c.output, "output", "", "Output file path (empty = stdout)")
+	fs.BoolVar(&c.dryRun, "dry-run", false, "Preview output without writing file")
 	return fs
 }
 
@@ -119,7 +122,7 @@ func (c *K8sJobCommand) Run(ctx context.Context, tc *terminal.Context) error {
 		"Tolerations":  tolerations,
 	}
 
-	output, err := template.Render("k8s-job", data)
+	output, err := template.RenderWithContext("k8s-job", data)
 	if err != nil {
 		return fmt.Errorf("failed to render template: %w", err)
 	}
@@ -129,6 +132,15 @@ func (c *K8sJobCommand) Run(ctx context.Context, tc *terminal.Context) error {
 		return err
 	}
 
+	if c.dryRun {
+		// k8s-job replaces existing manifests, so no overwrite note is needed.
+		if err := writeDryRunHeader(tc.Stdout, c.output, true); err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(tc.Stdout, output)
+		return err
+	}
+
 	if err := os.WriteFile(c.output, []byte(output), 0644); err != nil {
 		return fmt.Errorf("failed to write %s: %w", c.output, err)
 	}
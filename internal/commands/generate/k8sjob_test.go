@@ -3,6 +3,8 @@ package generate
 import (
 	"bytes"
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -171,6 +173,31 @@ func TestK8sJobCommand_Run_CustomImage(t *testing.T) {
 	}
 }
 
+func TestK8sJobCommand_Run_DryRun(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "job.yaml")
+	cmd := &K8sJobCommand{
+		cureCommand: "trace dns api.example.com",
+		namespace:   "default",
+		image:       "ghcr.io/mrlm-net/cure:latest",
+		output:      outputPath,
+		dryRun:      true,
+	}
+	var buf bytes.Buffer
+	if err := cmd.Run(context.Background(), newK8sJobContext(&buf)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Error("Dry-run wrote a file to disk — it must not")
+	}
+	out := buf.String()
+	if !strings.Contains(out, "# Dry run mode: would write to "+outputPath) {
+		t.Error("Dry-run output missing header line")
+	}
+	if !strings.Contains(out, "kind: Job") {
+		t.Error("Dry-run output missing manifest")
+	}
+}
+
 func TestDeriveJobName(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -0,0 +1,47 @@
+package generate
+
+import (
+	"context"
+	"flag"
+	"strings"
+
+	"github.com/mrlm-net/cure/pkg/template"
+	"github.com/mrlm-net/cure/pkg/terminal"
+)
+
+// ListCommand prints the available templates with their descriptions and the
+// variables they read.
+type ListCommand struct{}
+
+func (c *ListCommand) Name() string        { return "list" }
+func (c *ListCommand) Description() string { return "List available templates and their variables" }
+func (c *ListCommand) Usage() string {
+	return `Usage: cure generate list
+
+Lists every template cure can render: the embedded ones and any custom
+templates from template.dirs, ~/.cure/templates/, and .cure/templates/.
+Each row shows the template's description and the data variables it reads,
+such as Name for {{.Name}}. Bundle templates also list the files they write.
+
+Custom templates can add a description with a comment on their first line:
+  {{/* cure:description My service README */ -}}
+
+Examples:
+  cure generate list`
+}
+
+// Flags returns nil — the list command accepts no flags.
+func (c *ListCommand) Flags() *flag.FlagSet { return nil }
+
+func (c *ListCommand) Run(_ context.Context, tc *terminal.Context) error {
+	table := &terminal.Table{}
+	table.SetHeader("NAME", "DESCRIPTION", "VARIABLES")
+	for _, info := range template.ListInfo() {
+		desc := info.Description
+		if len(info.Outputs) > 0 {
+			desc += " (writes " + strings.Join(info.Outputs, ", ") + ")"
+		}
+		table.AddRow(info.Name, strings.TrimSpace(desc), strings.Join(info.Variables, ", "))
+	}
+	return table.Render(tc.Stdout)
+}
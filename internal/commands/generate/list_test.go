@@ -0,0 +1,39 @@
+package generate
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mrlm-net/cure/pkg/terminal"
+)
+
+func TestListCommand_Run(t *testing.T) {
+	var stdout bytes.Buffer
+	cmd := &ListCommand{}
+	if err := cmd.Run(context.Background(), &terminal.Context{Stdout: &stdout}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	lines := strings.Split(stdout.String(), "\n")
+	if !strings.HasPrefix(lines[0], "NAME") {
+		t.Errorf("first line = %q, want header", lines[0])
+	}
+	var row string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "claude-md ") {
+			row = line
+		}
+	}
+	if row == "" {
+		t.Fatalf("output missing claude-md:\n%s", stdout.String())
+	}
+	fields := strings.Fields(row)
+	if len(fields) < 3 {
+		t.Errorf("claude-md row has no description: %q", row)
+	}
+	if !strings.Contains(stdout.String(), "(writes Dockerfile, .dockerignore)") {
+		t.Errorf("docker-go row missing its outputs:\n%s", stdout.String())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/mrlm-net/cure/pkg/fs"
@@ -20,10 +21,17 @@ type AIFileOpts struct {
 	BuildTool      string // default: "make"
 	TestFramework  string // default: language-derived
 	Conventions    string // comma-separated; empty is valid
+	Repository     string // optional; omitted from output when empty
+	License        string // optional; omitted from output when empty
+	Homepage       string // optional; omitted from output when empty
 	OutputPath     string // subcommand-specific default
 	Force          bool
 	DryRun         bool
 	NonInteractive bool
+
+	// outputResolved records that OutputPath was already rendered by
+	// resolveOutput, so writeAIFile does not render it a second time.
+	outputResolved bool
 }
 
 // Per-command opts types wrapping AIFileOpts.
@@ -81,35 +89,152 @@ func buildAIFileTemplateData(opts AIFileOpts) map[string]interface{} {
 		"BuildTool":     opts.BuildTool,
 		"TestFramework": opts.TestFramework,
 		"Conventions":   convList,
+		"Repository":    opts.Repository,
+		"License":       opts.License,
+		"Homepage":      opts.Homepage,
 	}
 }
 
-// writeAIFile renders templateName with data derived from opts, then writes the
-// output to opts.OutputPath (or prints a dry-run preview to w).
-//
-// It sets default values for BuildTool, TestFramework, and OutputPath when they
-// are empty, using the supplied fallbackPath as the output path default.
-func writeAIFile(ctx context.Context, w io.Writer, opts AIFileOpts, templateName, fallbackPath string) error {
-	// Apply defaults for optional fields.
+// writeDryRunHeader prints the dry-run preview header for path to w. When path
+// already exists and force is false it adds a note that a real run would be
+// refused, so the preview reports the same overwrite check as a real run.
+func writeDryRunHeader(w io.Writer, path string, force bool) error {
+	exists, err := fs.Exists(path)
+	if err != nil {
+		return fmt.Errorf("failed to check if %s exists: %w", path, err)
+	}
+	fmt.Fprintf(w, "# Dry run mode: would write to %s\n", path)
+	if exists && !force {
+		fmt.Fprintf(w, "# Note: %s already exists. Use --force to overwrite\n", path)
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// writeBundle writes the files returned by template.RenderBundle under outDir,
+// creating subdirectories as needed, or prints a dry-run preview of each file
+// to w. Every destination is checked before anything is written, so an
+// existing file without force leaves the whole bundle unwritten.
+func writeBundle(w io.Writer, outDir string, files map[string]string, force, dryRun bool) error {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	if dryRun {
+		for _, p := range paths {
+			full := filepath.Join(outDir, filepath.FromSlash(p))
+			if err := writeDryRunHeader(w, full, force); err != nil {
+				return err
+			}
+			fmt.Fprintln(w, files[p])
+		}
+		return nil
+	}
+
+	for _, p := range paths {
+		full := filepath.Join(outDir, filepath.FromSlash(p))
+		exists, err := fs.Exists(full)
+		if err != nil {
+			return fmt.Errorf("failed to check if %s exists: %w", full, err)
+		}
+		if exists && !force {
+			return fmt.Errorf("%s already exists. Use --force to overwrite", full)
+		}
+	}
+
+	for _, p := range paths {
+		full := filepath.Join(outDir, filepath.FromSlash(p))
+		if err := fs.EnsureDir(filepath.Dir(full), 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(full), err)
+		}
+		if err := fs.AtomicWrite(full, []byte(files[p]), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", full, err)
+		}
+	}
+	return nil
+}
+
+// withFieldDefaults returns opts with BuildTool and TestFramework set to
+// their defaults when empty.
+func withFieldDefaults(opts AIFileOpts) AIFileOpts {
 	if opts.BuildTool == "" {
 		opts.BuildTool = "make"
 	}
 	if opts.TestFramework == "" {
 		opts.TestFramework = defaultTestFramework(opts.Language)
 	}
+	return opts
+}
+
+// resolveOutputPath returns opts.OutputPath, cleaned. A path containing "{{"
+// is first rendered with template.RenderString and the same data as the file
+// itself, so "out/{{.Name}}/CLAUDE.md" places each project in its own
+// directory.
+func resolveOutputPath(opts AIFileOpts) (string, error) {
+	if !strings.Contains(opts.OutputPath, "{{") {
+		return filepath.Clean(opts.OutputPath), nil
+	}
+	data := buildAIFileTemplateData(withFieldDefaults(opts))
+	path, err := template.RenderString(opts.OutputPath, template.ContextData(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to render output path: %w", err)
+	}
+	if strings.TrimSpace(path) == "" {
+		return "", fmt.Errorf("output path %q renders to an empty path", opts.OutputPath)
+	}
+	return filepath.Clean(path), nil
+}
+
+// resolveOutput renders a templated opts.OutputPath before a command asks
+// about overwriting, so the prompt, the file written, and the success
+// message all name the same file. The path is rendered once: writeAIFile
+// uses the returned opts as they are. An empty OutputPath is left for
+// writeAIFile to default.
+func resolveOutput(opts AIFileOpts) (AIFileOpts, error) {
+	if opts.OutputPath == "" {
+		return opts, nil
+	}
+	path, err := resolveOutputPath(opts)
+	if err != nil {
+		return opts, err
+	}
+	opts.OutputPath = path
+	opts.outputResolved = true
+	return opts, nil
+}
+
+// writeAIFile renders templateName with data derived from opts, then writes the
+// output to opts.OutputPath (or prints a dry-run preview to w).
+//
+// It sets default values for BuildTool, TestFramework, and OutputPath when they
+// are empty, using the supplied fallbackPath as the output path default. A
+// templated OutputPath is resolved by resolveOutputPath unless resolveOutput
+// already did.
+func writeAIFile(ctx context.Context, w io.Writer, opts AIFileOpts, templateName, fallbackPath string) error {
+	opts = withFieldDefaults(opts)
 	if opts.OutputPath == "" {
 		opts.OutputPath = fallbackPath
 	}
-	opts.OutputPath = filepath.Clean(opts.OutputPath)
+	if !opts.outputResolved {
+		path, err := resolveOutputPath(opts)
+		if err != nil {
+			return err
+		}
+		opts.OutputPath = path
+	}
 
 	data := buildAIFileTemplateData(opts)
-	output, err := template.Render(templateName, data)
+	output, err := template.RenderWithContext(templateName, data)
 	if err != nil {
 		return fmt.Errorf("failed to render template: %w", err)
 	}
 
 	if opts.DryRun {
-		fmt.Fprintf(w, "# Dry run mode: would write to %s\n\n", opts.OutputPath)
+		if err := writeDryRunHeader(w, opts.OutputPath, opts.Force); err != nil {
+			return err
+		}
 		fmt.Fprintln(w, output)
 		return nil
 	}
@@ -5,7 +5,6 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
 	"github.com/mrlm-net/cure/pkg/prompt"
@@ -210,12 +209,12 @@ func (c *ScaffoldCommand) resolveSelection(tc *terminal.Context) ([]string, erro
 	}
 
 	// No --select: default to all (both interactive and non-interactive).
-	if c.nonInteractive || !prompt.IsInteractive(os.Stdin) {
+	if c.nonInteractive || !tc.Interactive() {
 		return allScaffoldNames(), nil
 	}
 
 	// Interactive TTY: show MultiSelect menu.
-	prompter := prompt.NewPrompter(tc.Stdout, os.Stdin)
+	prompter := tc.Prompter()
 	chosen, err := prompter.MultiSelect("Select files to generate", scaffoldOptions)
 	if err != nil {
 		return nil, fmt.Errorf("scaffold: failed to read selection: %w", err)
@@ -254,7 +253,7 @@ func (c *ScaffoldCommand) parseSelectFlag() ([]string, error) {
 // gatherSharedInput collects name/description/language via prompts or validates
 // them as flags in non-interactive mode.
 func (c *ScaffoldCommand) gatherSharedInput(tc *terminal.Context) error {
-	if c.nonInteractive {
+	if c.nonInteractive || !tc.Interactive() {
 		return c.validateFlags()
 	}
 	return c.promptUser(tc)
@@ -282,7 +281,7 @@ func (c *ScaffoldCommand) validateFlags() error {
 
 // promptUser runs interactive prompts to gather shared AI file inputs.
 func (c *ScaffoldCommand) promptUser(tc *terminal.Context) error {
-	prompter := prompt.NewPrompter(tc.Stdout, os.Stdin)
+	prompter := tc.Prompter()
 
 	var err error
 	c.name, err = prompter.Required("What is the project name?", c.name)
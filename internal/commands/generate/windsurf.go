@@ -4,11 +4,9 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"os"
 	"path/filepath"
 
 	"github.com/mrlm-net/cure/pkg/fs"
-	"github.com/mrlm-net/cure/pkg/prompt"
 	"github.com/mrlm-net/cure/pkg/terminal"
 )
 
@@ -81,13 +79,20 @@ func (c *WindsurfRulesCommand) Run(ctx context.Context, tc *terminal.Context) er
 		return err
 	}
 
-	if !c.nonInteractive && !c.dryRun {
+	opts, err := resolveOutput(c.toOpts())
+	if err != nil {
+		return err
+	}
+	c.outputPath = opts.OutputPath
+
+	if !c.dryRun && (tc.AssumeYes || !c.nonInteractive && tc.Interactive()) {
 		if err := c.checkOverwrite(tc); err != nil {
 			return err
 		}
 	}
 
-	if err := GenerateWindsurfRules(ctx, tc.Stdout, WindsurfRulesOpts{c.toOpts()}); err != nil {
+	opts.Force = c.force
+	if err := GenerateWindsurfRules(ctx, tc.Stdout, WindsurfRulesOpts{opts}); err != nil {
 		return err
 	}
 
@@ -105,8 +110,7 @@ func (c *WindsurfRulesCommand) checkOverwrite(tc *terminal.Context) error {
 	if !exists || c.force {
 		return nil
 	}
-	prompter := prompt.NewPrompter(tc.Stdout, os.Stdin)
-	confirm, err := prompter.Confirm(fmt.Sprintf("%s already exists. Overwrite?", c.outputPath))
+	confirm, err := tc.Confirm(fmt.Sprintf("%s already exists. Overwrite?", c.outputPath))
 	if err != nil {
 		return err
 	}
@@ -168,7 +172,7 @@ func (c *WindsurfRulesCommand) loadDefaults(tc *terminal.Context) {
 }
 
 func (c *WindsurfRulesCommand) gatherInput(tc *terminal.Context) error {
-	if c.nonInteractive {
+	if c.nonInteractive || !tc.Interactive() {
 		return c.validateFlags()
 	}
 	return c.promptUser(tc)
@@ -194,7 +198,7 @@ func (c *WindsurfRulesCommand) validateFlags() error {
 }
 
 func (c *WindsurfRulesCommand) promptUser(tc *terminal.Context) error {
-	prompter := prompt.NewPrompter(tc.Stdout, os.Stdin)
+	prompter := tc.Prompter()
 
 	var err error
 	c.name, err = prompter.Required("What is the project name?", c.name)
@@ -109,6 +109,35 @@ func TestWindsurfRulesCommand_NonInteractive(t *testing.T) {
 	}
 }
 
+func TestWindsurfRulesCommand_AssumeYes(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), ".windsurfrules")
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(outputPath, []byte("existing content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &WindsurfRulesCommand{}
+	if err := cmd.Flags().Parse([]string{
+		"--non-interactive",
+		"--name", "myapp",
+		"--description", "A test app",
+		"--language", "go",
+		"--output", outputPath,
+	}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	tc := &terminal.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Config: config.NewConfig(), AssumeYes: true}
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() with AssumeYes error = %v", err)
+	}
+	if got, _ := os.ReadFile(outputPath); string(got) == "existing content" {
+		t.Error("existing file was not overwritten")
+	}
+}
+
 func TestWindsurfRulesCommand_DryRun(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputPath := filepath.Join(tmpDir, ".windsurfrules")
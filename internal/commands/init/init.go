@@ -8,7 +8,6 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/mrlm-net/cure/internal/commands/generate"
@@ -133,7 +132,7 @@ func (c *InitCommand) Flags() *flag.FlagSet {
 // Run executes the init wizard, collecting input interactively or from flags,
 // then orchestrating all selected generators.
 func (c *InitCommand) Run(ctx context.Context, tc *terminal.Context) error {
-	if !c.nonInteractive && prompt.IsInteractive(os.Stdin) {
+	if !c.nonInteractive && tc.Interactive() {
 		if err := c.collectInteractive(tc); err != nil {
 			return err
 		}
@@ -152,7 +151,7 @@ func (c *InitCommand) Run(ctx context.Context, tc *terminal.Context) error {
 
 // collectInteractive runs the interactive wizard to gather all inputs.
 func (c *InitCommand) collectInteractive(tc *terminal.Context) error {
-	p := prompt.NewPrompter(tc.Stdout, os.Stdin)
+	p := tc.Prompter()
 
 	var err error
 
@@ -0,0 +1,59 @@
+package commands
+
+import "github.com/mrlm-net/cure/pkg/terminal"
+
+// RegisterTopics adds cure's help topics to router, so "cure help <topic>"
+// documents concepts that span several commands.
+func RegisterTopics(router *terminal.Router) {
+	router.RegisterTopic("formats", "Trace output formats", formatsTopic)
+	router.RegisterTopic("config", "Configuration files and precedence", configTopic)
+	router.RegisterTopic("redaction", "Redaction of sensitive headers", redactionTopic)
+}
+
+const formatsTopic = `The trace commands write events in the format chosen with --format:
+
+  json        Newline-delimited JSON, one event per line (default).
+              Suits jq, log shippers, and other line-based tools.
+  json-array  The same events as one JSON array, for tools that decode
+              the whole output at once.
+  html        A self-contained HTML report, written when the trace ends.
+  template    Each event rendered with the Go template given by
+              --template, one line per event.
+
+--out-file writes to a file instead of stdout and --gzip compresses it.
+--also-html writes an HTML report next to the main output.
+
+Every event has "type", "timestamp" (Unix nanoseconds), "emitted_at"
+(RFC 3339), "trace_id", and "data". Failed steps also carry "error" and
+"failed": true; --label adds "labels".
+
+Examples:
+  cure trace http https://example.com | jq 'select(.failed)'
+  cure trace dns example.com --format html --out-file dns.html
+  cure trace http https://example.com --format template --template '{{.Type}} {{index .Data "duration_ms"}}ms'`
+
+const configTopic = `cure merges its settings from these layers, later ones winning:
+
+  1. Built-in defaults
+  2. ~/.cure.json       global settings for the user
+  3. ./.cure.json       settings for the current project
+  4. CURE_* variables   CURE_TIMEOUT=10 sets "timeout"; CURE_AUDIT_FILE
+                        sets the nested "audit.file"
+
+Command-line flags override all of them. Common keys:
+
+  format   Default --format for trace commands ("json")
+  timeout  Default trace timeout in seconds (30)
+  verbose  Report how each setting was merged, on stderr (false)
+
+Run "cure --log-level debug <command>" to see the merge report once, and
+"cure config diff a.json b.json" to compare two config files.`
+
+const redactionTopic = `trace http replaces the values of sensitive headers with [REDACTED]
+before they reach any output: events, --dump-headers files, and the
+command line printed by --print-curl.
+
+Redacted by default: Authorization, Cookie, Set-Cookie.
+
+Pass --redact=false to see the real values when debugging locally. Keep redaction on for output that
+is shared, archived, or sent to a pipeline.`
@@ -0,0 +1,23 @@
+package commands
+
+import (
+	"io"
+	"testing"
+
+	"github.com/mrlm-net/cure/pkg/terminal"
+)
+
+func TestRegisterTopics(t *testing.T) {
+	router := terminal.New(terminal.WithStdout(io.Discard))
+	RegisterTopics(router)
+	for _, name := range []string{"config", "formats", "redaction"} {
+		topic, ok := router.LookupTopic(name)
+		if !ok {
+			t.Errorf("topic %q not registered", name)
+			continue
+		}
+		if topic.Title == "" || topic.Body == "" {
+			t.Errorf("topic %q has an empty title or body", name)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package trace
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+)
+
+// ErrAssertion is returned, wrapped with the failing expressions, when an
+// --assert expression does not hold for a completed trace.
+var ErrAssertion = errors.New("assertion failed")
+
+// AssertionFailed is the type of the event emitted for each --assert
+// expression that does not hold.
+const AssertionFailed = "assertion_failed"
+
+// assertScopes maps the first segment of an --assert field to the event
+// it is read from. Fields without one of these prefixes are read from
+// http_response_done.
+var assertScopes = map[string]string{
+	"dns":      event.TypeDNSDone,
+	"connect":  event.TypeTCPConnectDone,
+	"tls":      event.TypeTLSHandshakeDone,
+	"response": "http_response_done",
+	"summary":  event.TraceSummary,
+}
+
+// assertOperators lists the comparison operators accepted in --assert
+// expressions. Two-character operators come first so "<=" is not read as
+// "<".
+var assertOperators = []string{"==", "!=", ">=", "<=", ">", "<"}
+
+// assertion is one parsed --assert expression.
+type assertion struct {
+	expr  string
+	typ   string   // event type the field is read from
+	path  []string // keys into that event's data, nested maps in between
+	op    string
+	value string
+}
+
+// parseAssertion parses an --assert expression "<field><op><value>", such
+// as "status==200", "tls.version==TLS 1.3", or "summary.phases.dns<50".
+// The value is the rest of the expression with surrounding spaces trimmed.
+func parseAssertion(expr string) (assertion, error) {
+	at, op := -1, ""
+	for i := range len(expr) {
+		for _, o := range assertOperators {
+			if strings.HasPrefix(expr[i:], o) {
+				at, op = i, o
+				break
+			}
+		}
+		if at >= 0 {
+			break
+		}
+	}
+	if at < 0 {
+		return assertion{}, fmt.Errorf("invalid --assert %q (want <field><op><value> with op one of ==, !=, >=, <=, >, <, e.g. status==200)", expr)
+	}
+	field := strings.TrimSpace(expr[:at])
+	if field == "" {
+		return assertion{}, fmt.Errorf("invalid --assert %q: missing field before %s", expr, op)
+	}
+
+	a := assertion{expr: expr, typ: "http_response_done", op: op, value: strings.TrimSpace(expr[at+len(op):])}
+	a.path = strings.Split(field, ".")
+	if typ, ok := assertScopes[a.path[0]]; ok && len(a.path) > 1 {
+		a.typ, a.path = typ, a.path[1:]
+	}
+	for _, key := range a.path {
+		if key == "" {
+			return assertion{}, fmt.Errorf("invalid --assert %q: empty segment in field %q", expr, field)
+		}
+	}
+	if op != "==" && op != "!=" {
+		if _, err := strconv.ParseFloat(a.value, 64); err != nil {
+			return assertion{}, fmt.Errorf("invalid --assert %q: %s needs a number, got %q", expr, op, a.value)
+		}
+	}
+	return a, nil
+}
+
+// check evaluates a against ev, the last event of type a.typ, or nil if
+// there was none. It returns the value found, formatted, and why the
+// assertion fails, or "" if it holds.
+func (a assertion) check(ev *event.Event) (actual string, reason string) {
+	if ev == nil {
+		return "", "no " + a.typ + " event"
+	}
+	var v interface{} = ev.Data
+	for _, key := range a.path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return "", "field not found"
+		}
+		if v, ok = m[key]; !ok {
+			return "", "field not found"
+		}
+	}
+	actual = fmt.Sprint(v)
+
+	got, gotErr := strconv.ParseFloat(actual, 64)
+	want, wantErr := strconv.ParseFloat(a.value, 64)
+	if gotErr == nil && wantErr == nil {
+		if compareNumbers(a.op, got, want) {
+			return actual, ""
+		}
+		return actual, "comparison is false"
+	}
+	switch a.op {
+	case "==":
+		if actual == a.value {
+			return actual, ""
+		}
+	case "!=":
+		if actual != a.value {
+			return actual, ""
+		}
+	default:
+		return actual, "value is not a number"
+	}
+	return actual, "comparison is false"
+}
+
+// compareNumbers applies the --assert operator op to a and b.
+func compareNumbers(op string, a, b float64) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case ">=":
+		return a >= b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case "<":
+		return a < b
+	}
+	return false
+}
+
+// assertRecorder keeps the last event of each type the assertions read, so
+// they can be checked once the trace ends. Failures are emitted to em.
+type assertRecorder struct {
+	em      event.Emitter
+	asserts []assertion
+
+	mu      sync.Mutex
+	last    map[string]event.Event
+	traceID string
+}
+
+func (a *assertRecorder) observe(ev *event.Event) {
+	a.mu.Lock()
+	a.traceID = ev.TraceID
+	for _, as := range a.asserts {
+		if ev.Type == as.typ {
+			if a.last == nil {
+				a.last = make(map[string]event.Event)
+			}
+			a.last[ev.Type] = *ev
+			break
+		}
+	}
+	a.mu.Unlock()
+}
+
+// result checks every assertion, emits an assertion_failed event for each
+// one that does not hold, and returns traceErr if the trace failed,
+// otherwise an error wrapping ErrAssertion that lists the failures, or nil.
+func (a *assertRecorder) result(traceErr error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var failed []string
+	for _, as := range a.asserts {
+		var ev *event.Event
+		if last, ok := a.last[as.typ]; ok {
+			ev = &last
+		}
+		actual, reason := as.check(ev)
+		if reason == "" {
+			continue
+		}
+		data := map[string]interface{}{
+			"expression": as.expr,
+			"reason":     reason,
+		}
+		desc := fmt.Sprintf("%s (%s)", as.expr, reason)
+		if actual != "" {
+			data["actual"] = actual
+			desc = fmt.Sprintf("%s (got %s)", as.expr, actual)
+		}
+		if err := a.em.Emit(event.NewEvent(AssertionFailed, a.traceID, data)); err != nil {
+			return fmt.Errorf("emit failed: %w", err)
+		}
+		failed = append(failed, desc)
+	}
+	if traceErr != nil {
+		return traceErr
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", ErrAssertion, strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// assertFlags is a repeatable --assert flag.
+type assertFlags []string
+
+func (f *assertFlags) String() string { return strings.Join(*f, ",") }
+
+// Get returns the expressions given, one per --assert.
+func (f *assertFlags) Get() any { return []string(*f) }
+
+func (f *assertFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
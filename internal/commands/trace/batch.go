@@ -0,0 +1,317 @@
+package trace
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mrlm-net/cure/pkg/terminal"
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+	"github.com/mrlm-net/cure/pkg/tracer/ratelimit"
+)
+
+// protocolTracer is implemented by the protocol subcommands so that batch
+// mode can drive them against a shared emitter.
+type protocolTracer interface {
+	terminal.Command
+	trace(ctx context.Context, tc *terminal.Context, target string, em event.Emitter) error
+}
+
+// newProtocolTracer returns a fresh subcommand for protocol, or nil if the
+// protocol is unknown.
+func newProtocolTracer(protocol string) protocolTracer {
+	switch protocol {
+	case "http":
+		return &HTTPCommand{}
+	case "tcp":
+		return &TCPCommand{}
+	case "udp":
+		return &UDPCommand{}
+	case "dns":
+		return &DNSCommand{}
+	default:
+		return nil
+	}
+}
+
+// BatchCommand implements the "cure trace batch" subcommand.
+type BatchCommand struct {
+	format        string
+	template      string
+	outFile       string
+	gzip          bool
+	flushInterval time.Duration
+	alsoHTML      string
+	summary       bool
+	concurrency   int
+	rate          float64
+	quiet         bool
+}
+
+// batchJob is a single parsed line of a batch file.
+type batchJob struct {
+	line     int
+	protocol string
+	target   string
+	tracer   protocolTracer
+}
+
+func (c *BatchCommand) Name() string { return "batch" }
+
+func (c *BatchCommand) Description() string {
+	return "Trace many targets listed in a file"
+}
+
+func (c *BatchCommand) Usage() string {
+	return `Usage: cure trace batch <file> [options]
+
+Runs one trace per line of file. Each line has the form:
+
+  <protocol> <target> [flags]
+
+where protocol is http, tcp, udp, or dns and flags are those accepted by the
+matching "cure trace <protocol>" subcommand. Blank lines and lines starting
+with # are skipped. All traces share one output stream; every event carries a
+"target" field. Per-line --format, --out-file, and --also-html flags are
+ignored.
+
+With --rate, targets are started at no more than that many per second,
+regardless of --concurrency.
+
+A summary of succeeded and failed targets is printed to stderr. While the
+batch runs, a progress counter is shown on stderr when it is a terminal;
+--quiet hides it.
+
+Examples:
+  cure trace batch targets.txt
+  cure trace batch --concurrency 4 --format html --out-file report.html targets.txt
+  cure trace batch --rate 5 targets.txt
+
+Example file:
+  # synthetic checks
+  http https://example.com --method HEAD
+  tcp example.com:443
+  dns example.com --server 1.1.1.1`
+}
+
+func (c *BatchCommand) Flags() *flag.FlagSet {
+	fs := flag.NewFlagSet("trace-batch", flag.ContinueOnError)
+	fs.StringVar(&c.format, "format", "json", formatUsage)
+	terminal.DescribeFlag(fs, "format", terminal.FlagMeta{Enum: formats})
+	fs.StringVar(&c.template, "template", "", templateUsage)
+	fs.StringVar(&c.outFile, "out-file", "", "Output file (default: stdout)")
+	fs.BoolVar(&c.gzip, "gzip", false, "Gzip-compress the --out-file output (adds .gz if missing)")
+	fs.DurationVar(&c.flushInterval, "flush-interval", 0, "With --format json, buffer output and flush it this often, e.g. 1s (0 = flush every event)")
+	fs.StringVar(&c.alsoHTML, "also-html", "", "Also write an HTML report to this file")
+	fs.BoolVar(&c.summary, "summary", false, "Print a one-line tally of events by type and the trace duration to stderr at the end")
+	fs.IntVar(&c.concurrency, "concurrency", 1, "Number of traces to run in parallel")
+	fs.Float64Var(&c.rate, "rate", 0, "Maximum targets started per second (0 = unlimited)")
+	fs.BoolVar(&c.quiet, "quiet", false, "Suppress the progress indicator on stderr")
+	return fs
+}
+
+func (c *BatchCommand) Run(ctx context.Context, tc *terminal.Context) (runErr error) {
+	if len(tc.Args) == 0 {
+		return fmt.Errorf("missing batch file argument")
+	}
+	if c.concurrency < 1 {
+		return fmt.Errorf("--concurrency must be 1 or greater, got %d", c.concurrency)
+	}
+	if c.rate < 0 {
+		return fmt.Errorf("--rate must be 0 (unlimited) or greater, got %g", c.rate)
+	}
+
+	f, err := os.Open(tc.Args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open batch file: %w", err)
+	}
+	jobs, err := parseBatch(f, tc.Stderr)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	tmpl := outputTemplate(tc, c.format, c.template)
+
+	if c.gzip && c.outFile == "" {
+		return fmt.Errorf("--gzip requires --out-file")
+	}
+	var outW io.Writer = tc.Stdout
+	if c.outFile != "" {
+		f, err := createOutFile(c.outFile, c.gzip)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer closeOutFile(f, &runErr)
+		outW = f
+	}
+
+	em, err := newEmitter(c.format, tmpl, outW, tc.Stderr, c.flushInterval)
+	if err != nil {
+		return err
+	}
+	if em, err = withAlsoHTML(em, c.alsoHTML); err != nil {
+		return err
+	}
+	em = withSummary(em, c.summary, tc.Stderr)
+	defer em.Close()
+
+	progress := startProgress(tc, c.quiet, "batch", len(jobs))
+	var completed atomic.Int64
+
+	limiter := ratelimit.New(c.rate)
+	shared := &syncEmitter{em: em}
+	errs := make([]error, len(jobs))
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		if err := limiter.Wait(ctx); err != nil {
+			errs[i] = err
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, job batchJob) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = job.tracer.trace(ctx, tc, job.target, withTarget(shared, job.target))
+			progress.Update(int(completed.Add(1)), len(jobs))
+		}(i, job)
+	}
+	wg.Wait()
+	progress.Stop()
+
+	failed := 0
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		failed++
+		fmt.Fprintf(tc.Stderr, "line %d: %s %s: %v\n", jobs[i].line, jobs[i].protocol, jobs[i].target, err)
+	}
+	fmt.Fprintf(tc.Stderr, "batch: %d targets, %d succeeded, %d failed\n", len(jobs), len(jobs)-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d batch targets failed", failed, len(jobs))
+	}
+	return nil
+}
+
+// parseBatch reads batch lines from r, resolving each to a subcommand with
+// its flags parsed. Flag parse diagnostics are written to stderr.
+// Returns an error naming the first malformed line.
+func parseBatch(r io.Reader, stderr io.Writer) ([]batchJob, error) {
+	var jobs []batchJob
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields, err := splitFields(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected \"<protocol> <target> [flags]\"", lineNo)
+		}
+
+		tracer := newProtocolTracer(fields[0])
+		if tracer == nil {
+			return nil, fmt.Errorf("line %d: unknown protocol %q (want http, tcp, udp, or dns)", lineNo, fields[0])
+		}
+		fs := tracer.Flags()
+		fs.SetOutput(stderr)
+		if err := fs.Parse(fields[2:]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if fs.NArg() > 0 {
+			return nil, fmt.Errorf("line %d: unexpected arguments %q", lineNo, fs.Args())
+		}
+
+		jobs = append(jobs, batchJob{
+			line:     lineNo,
+			protocol: fields[0],
+			target:   fields[1],
+			tracer:   tracer,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+	return jobs, nil
+}
+
+// splitFields splits a batch line on whitespace, honouring single and double
+// quotes so flag values may contain spaces.
+func splitFields(line string) ([]string, error) {
+	var (
+		fields  []string
+		cur     strings.Builder
+		quote   rune
+		inField bool
+	)
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inField = true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				inField = false
+			}
+		default:
+			cur.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inField {
+		fields = append(fields, cur.String())
+	}
+	return fields, nil
+}
+
+// syncEmitter serialises Emit calls so concurrent traces can share an emitter.
+type syncEmitter struct {
+	mu sync.Mutex
+	em event.Emitter
+}
+
+func (s *syncEmitter) Emit(ev event.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.em.Emit(ev)
+}
+
+// Close is a no-op; the owner closes the underlying emitter.
+func (s *syncEmitter) Close() error { return nil }
+
+// withTarget returns em with a "target" field added to every event.
+func withTarget(em event.Emitter, target string) event.Emitter {
+	return event.NewTapEmitter(em, func(ev *event.Event) {
+		if ev.Data == nil {
+			ev.Data = make(map[string]interface{})
+		}
+		ev.Data["target"] = target
+	})
+}
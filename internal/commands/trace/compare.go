@@ -0,0 +1,236 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+
+	"github.com/mrlm-net/cure/pkg/terminal"
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+)
+
+// TraceCompare is the type of the event "trace http --compare" emits after
+// both sides have been traced.
+const TraceCompare = "trace_compare"
+
+// compareSide is one side of a --compare run.
+type compareSide struct {
+	name string
+	url  string
+	cmd  *HTTPCommand
+
+	err     error
+	traceID string
+	summary map[string]interface{} // data of the side's trace_summary, if any
+}
+
+// traceCompare traces url as side "a" with c's settings, then side "b" as
+// described by c.compare: extra flags, a URL, or both, applied on top of
+// c's settings. Every event is tagged with the shared run_id and its side,
+// and a trace_compare event with the per-phase deltas follows.
+func (c *HTTPCommand) traceCompare(ctx context.Context, tc *terminal.Context, url string, em event.Emitter) error {
+	b, urlB, err := c.compareTarget(tc, url)
+	if err != nil {
+		return err
+	}
+	if c.attempts() != 1 || b.attempts() != 1 {
+		return fmt.Errorf("--compare traces one request per side and cannot be combined with --count, --until-status, or --until-success")
+	}
+
+	runID := event.NewTraceID()
+	sides := []*compareSide{
+		{name: "a", url: url, cmd: c},
+		{name: "b", url: urlB, cmd: b},
+	}
+	for _, s := range sides {
+		s.err = s.cmd.trace(ctx, tc, s.url, event.NewTapEmitter(em, s.record(runID)))
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	if err := em.Emit(event.NewEvent(TraceCompare, runID, compareData(runID, sides[0], sides[1]))); err != nil {
+		return fmt.Errorf("emit failed: %w", err)
+	}
+	var errs []error
+	for _, s := range sides {
+		if s.err != nil {
+			errs = append(errs, fmt.Errorf("side %s (%s): %w", s.name, s.url, s.err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compareTarget returns the command and URL for side "b": a new command
+// given c's flag values, then the flags from c.compare, and the URL given
+// there or url.
+func (c *HTTPCommand) compareTarget(tc *terminal.Context, url string) (*HTTPCommand, string, error) {
+	fields, err := splitFields(c.compare)
+	if err != nil {
+		return nil, "", fmt.Errorf("--compare: %w", err)
+	}
+	b := &HTTPCommand{}
+	fs := b.Flags()
+	if err := c.copyFlags(fs); err != nil {
+		return nil, "", fmt.Errorf("--compare: %w", err)
+	}
+	fs.SetOutput(tc.Stderr)
+	if err := fs.Parse(fields); err != nil {
+		return nil, "", fmt.Errorf("--compare: %w", err)
+	}
+	b.compare = ""
+	switch fs.NArg() {
+	case 0:
+		return b, url, nil
+	case 1:
+		return b, fs.Arg(0), nil
+	default:
+		return nil, "", fmt.Errorf("--compare: expected at most one URL, got %q", fs.Args())
+	}
+}
+
+// copyFlags sets every flag of fs, the FlagSet of another HTTPCommand, to
+// c's value. A repeatable flag is set once per value, as if given again on
+// the command line, so the other command collects them in its own slice.
+func (c *HTTPCommand) copyFlags(fs *flag.FlagSet) error {
+	from := &HTTPCommand{}
+	fromFlags := from.Flags()
+	*from = *c // fromFlags now reads c's values
+	var err error
+	fromFlags.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		values, repeated := repeatedValues(f.Value)
+		if !repeated {
+			err = fs.Set(f.Name, f.Value.String())
+			return
+		}
+		for _, v := range values {
+			if err = fs.Set(f.Name, v); err != nil {
+				return
+			}
+		}
+	})
+	return err
+}
+
+// repeatedValues returns the values of a repeatable flag, whose Get returns
+// them as a []string, and false for any other flag.
+func repeatedValues(v flag.Value) ([]string, bool) {
+	g, ok := v.(flag.Getter)
+	if !ok {
+		return nil, false
+	}
+	values, ok := g.Get().([]string)
+	return values, ok
+}
+
+// compareData builds the data of the trace_compare event. Deltas are b
+// minus a, so a positive delta_ms means side b was slower.
+func compareData(runID string, a, b *compareSide) map[string]interface{} {
+	phasesA, phasesB := summaryPhases(a.summary), summaryPhases(b.summary)
+	names := make([]string, 0, len(phasesA)+len(phasesB))
+	for name := range phasesA {
+		names = append(names, name)
+	}
+	for name := range phasesB {
+		if _, ok := phasesA[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	phases := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		msA, msB := phasesA[name], phasesB[name]
+		phases[name] = map[string]interface{}{
+			"a":        msA,
+			"b":        msB,
+			"delta_ms": msB - msA,
+			"slower":   slower(msB - msA),
+		}
+	}
+
+	totalA, totalB := summaryMS(a.summary, "total_ms"), summaryMS(b.summary, "total_ms")
+	return map[string]interface{}{
+		"run_id":         runID,
+		"a":              sideData(a),
+		"b":              sideData(b),
+		"phases":         phases,
+		"total_delta_ms": totalB - totalA,
+		"slower":         slower(totalB - totalA),
+	}
+}
+
+// sideData describes one side in the trace_compare event.
+func sideData(s *compareSide) map[string]interface{} {
+	ok, _ := s.summary["ok"].(bool)
+	data := map[string]interface{}{
+		"url":      s.url,
+		"trace_id": s.traceID,
+		"ok":       ok && s.err == nil,
+		"total_ms": summaryMS(s.summary, "total_ms"),
+	}
+	if s.err != nil {
+		data["error"] = s.err.Error()
+	}
+	return data
+}
+
+// slower names the slower side for a b-minus-a delta, or "" for a tie.
+func slower(delta int64) string {
+	switch {
+	case delta > 0:
+		return "b"
+	case delta < 0:
+		return "a"
+	}
+	return ""
+}
+
+// summaryPhases returns the phase durations of a trace_summary's data.
+func summaryPhases(summary map[string]interface{}) map[string]int64 {
+	phases := make(map[string]int64)
+	raw, _ := summary["phases"].(map[string]interface{})
+	for name := range raw {
+		phases[name] = summaryMS(raw, name)
+	}
+	return phases
+}
+
+// summaryMS reads a millisecond count from data[key]. Values are int64 from
+// event.SummaryData, but other integer and float forms are accepted.
+func summaryMS(data map[string]interface{}, key string) int64 {
+	switch n := data[key].(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case float64:
+		return int64(n)
+	case json.Number:
+		v, _ := n.Int64()
+		return v
+	}
+	return 0
+}
+
+// record returns a tap that tags events with the run ID and side of a
+// --compare run and records the side's trace ID and trace_summary.
+func (s *compareSide) record(runID string) func(*event.Event) {
+	return func(ev *event.Event) {
+		if ev.Data == nil {
+			ev.Data = make(map[string]interface{})
+		}
+		ev.Data["run_id"] = runID
+		ev.Data["side"] = s.name
+		s.traceID = ev.TraceID
+		if ev.Type == event.TraceSummary {
+			s.summary = ev.Data
+		}
+	}
+}
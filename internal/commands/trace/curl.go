@@ -0,0 +1,128 @@
+package trace
+
+import (
+	"fmt"
+	neturl "net/url"
+	"slices"
+	"strings"
+
+	"github.com/mrlm-net/cure/pkg/tracer/http"
+)
+
+// curlCommand returns a curl command line that sends the request c would
+// trace to url: method, headers including --host-header, body, redirects,
+// timeouts, and --resolve pins. Sensitive headers read [REDACTED] while
+// c.redact is set. Headers from --headers-file are passed as curl's
+// -H @file, unread.
+func (c *HTTPCommand) curlCommand(url string) string {
+	args := []string{"curl"}
+	form, _ := c.form.toMap()
+	// curl sends GET, or POST once it has a body, so -X is needed for any
+	// other method, including a GET with a body.
+	method, curlMethod := c.method, "GET"
+	if method == "" {
+		method = "GET"
+	}
+	if c.data != "" || len(form) > 0 {
+		curlMethod = "POST"
+	}
+	if method != curlMethod {
+		args = append(args, "-X", method)
+	}
+
+	headers := map[string]string{"User-Agent": userAgent()}
+	if c.acceptEncoding != "" {
+		headers["Accept-Encoding"] = c.acceptEncoding
+	}
+	if c.hostHeader != "" {
+		headers["Host"] = c.hostHeader
+	}
+	for name, value := range c.headers.toMap() {
+		headers[name] = value
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if c.headersFile != "" {
+		args = append(args, "-H", "@"+c.headersFile)
+	}
+	for _, name := range names {
+		value := headers[name]
+		if c.redact && http.IsSensitiveHeader(name) {
+			value = "[REDACTED]"
+		}
+		args = append(args, "-H", name+": "+value)
+	}
+
+	if c.data != "" {
+		args = append(args, "--data-raw", c.data)
+	}
+	// Sorted like the body the tracer builds; curl encodes each value.
+	for _, key := range sortedFormKeys(form) {
+		args = append(args, "--data-urlencode", key+"="+form[key])
+	}
+
+	// Without -L curl does not follow redirects, matching --max-redirects 0.
+	if c.maxRedirects > 0 {
+		args = append(args, "-L", "--max-redirs", fmt.Sprint(c.maxRedirects))
+	}
+	if c.connectTimeout > 0 {
+		args = append(args, "--connect-timeout", fmt.Sprint(c.connectTimeout))
+	}
+	if c.timeout > 0 {
+		args = append(args, "--max-time", fmt.Sprint(c.timeout))
+	}
+	if port := urlPort(url); port != "" {
+		for _, r := range c.resolve {
+			if host, ip, ok := strings.Cut(r, ":"); ok {
+				if strings.Contains(ip, ":") && !strings.HasPrefix(ip, "[") {
+					ip = "[" + ip + "]"
+				}
+				args = append(args, "--resolve", host+":"+port+":"+ip)
+			}
+		}
+	}
+	args = append(args, url)
+
+	for i, arg := range args[1:] {
+		args[i+1] = shellQuote(arg)
+	}
+	return strings.Join(args, " ")
+}
+
+// sortedFormKeys returns the keys of form in sorted order.
+func sortedFormKeys(form map[string]string) []string {
+	keys := make([]string, 0, len(form))
+	for k := range form {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// urlPort returns the port raw connects to: the explicit one, or the
+// scheme's default. It returns "" if raw does not parse.
+func urlPort(raw string) string {
+	u, err := neturl.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+// shellQuote quotes s for a POSIX shell. Words made only of safe characters
+// are left bare so the command stays readable.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:@=,+%") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -6,25 +6,35 @@ import (
 	"fmt"
 	"io"
 	"net"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/mrlm-net/cure/pkg/terminal"
 	"github.com/mrlm-net/cure/pkg/tracer/dns"
 	"github.com/mrlm-net/cure/pkg/tracer/event"
-	"github.com/mrlm-net/cure/pkg/tracer/formatter"
 )
 
 // DNSCommand implements the "cure trace dns" subcommand.
 type DNSCommand struct {
-	format   string
-	outFile  string
-	dryRun   bool
-	timeout  int
-	server   string
-	count    int
-	interval int
+	format        string
+	template      string
+	outFile       string
+	gzip          bool
+	flushInterval time.Duration
+	alsoHTML      string
+	summary       bool
+	dryRun        bool
+	timeout       int
+	server        string
+	count         int
+	interval      int
+	jitter        float64
+	rate          float64
+	rtype         string
+	edns          bool
+	noCache       bool
+	quiet         bool
+	labels        labelFlags
 }
 
 func (c *DNSCommand) Name() string        { return "dns" }
@@ -35,41 +45,123 @@ func (c *DNSCommand) Usage() string {
 Resolves a hostname and emits structured trace events including all returned
 IP addresses, CNAME chain, resolution time, and RFC 1918 private IP classification.
 
+With --type only records of that type are queried, and every record in the
+answer is reported in an "answers" array along with the response code and
+whether the reply was truncated. --edns adds an EDNS(0) OPT record with the
+DNSSEC OK bit. Both send the query straight to --server, or to the system's
+first nameserver.
+
+--no-dns-cache makes every query a fresh lookup through Go's own resolver
+instead of the system resolver and its caches, and marks each answer after
+the first with addrs_changed, so flapping records stand out. A caching stub
+such as systemd-resolved still answers from its cache; add --server to
+query the upstream resolver directly.
+
 Examples:
   cure trace dns example.com
   cure trace dns --server 168.63.129.16 myservice.privatelink.blob.core.windows.net
+  cure trace dns --type MX example.com
+  cure trace dns --type TXT --edns --server 1.1.1.1 example.com
   cure trace dns --count 10 --interval 5 myservice.blob.core.windows.net
+  cure trace dns --count 10 --interval 5 --jitter 0.2 myservice.blob.core.windows.net
+  cure trace dns --count 20 --interval 3 --no-dns-cache myservice.privatelink.blob.core.windows.net
+  cure trace dns --count 100 --rate 2 myservice.blob.core.windows.net
   cure trace dns --format html --out-file report.html example.com`
 }
 
 func (c *DNSCommand) Flags() *flag.FlagSet {
 	fs := flag.NewFlagSet("trace-dns", flag.ContinueOnError)
-	fs.StringVar(&c.format, "format", "json", "Output format (json, html)")
+	fs.StringVar(&c.format, "format", "json", formatUsage)
+	terminal.DescribeFlag(fs, "format", terminal.FlagMeta{Enum: formats})
+	fs.StringVar(&c.template, "template", "", templateUsage)
 	fs.StringVar(&c.outFile, "out-file", "", "Output file (default: stdout)")
+	fs.BoolVar(&c.gzip, "gzip", false, "Gzip-compress the --out-file output (adds .gz if missing)")
+	fs.DurationVar(&c.flushInterval, "flush-interval", 0, "With --format json, buffer output and flush it this often, e.g. 1s (0 = flush every event)")
+	fs.StringVar(&c.alsoHTML, "also-html", "", "Also write an HTML report to this file")
+	fs.BoolVar(&c.summary, "summary", false, "Print a one-line tally of events by type and the trace duration to stderr at the end")
 	fs.BoolVar(&c.dryRun, "dry-run", false, "Emit events without I/O")
 	fs.IntVar(&c.timeout, "timeout", 0, "Query timeout in seconds (0 = use config default)")
 	fs.StringVar(&c.server, "server", "", "DNS resolver address (IP or IP:port, e.g. 168.63.129.16)")
 	fs.IntVar(&c.count, "count", 1, "Number of times to repeat the query (0 = run until Ctrl+C)")
 	fs.IntVar(&c.interval, "interval", 0, "Seconds to wait between repeated queries (implies --count 0 when count is not set)")
+	fs.Float64Var(&c.jitter, "jitter", 0, "Randomise each --interval wait by up to ±this fraction of it (0-1, default 0)")
+	fs.Float64Var(&c.rate, "rate", 0, "Maximum repeated queries per second (0 = unlimited)")
+	fs.StringVar(&c.rtype, "type", "", "Record type to query (A, AAAA, CNAME, MX, NS, TXT)")
+	fs.BoolVar(&c.edns, "edns", false, "Send an EDNS(0) OPT record with the DNSSEC OK bit")
+	fs.BoolVar(&c.noCache, "no-dns-cache", false, "Make every query a fresh lookup through Go's resolver and report when the answer changes")
+	fs.BoolVar(&c.quiet, "quiet", false, "Suppress the progress indicator shown on stderr for repeated queries")
+	fs.Var(&c.labels, "label", labelUsage)
 	return fs
 }
 
-func (c *DNSCommand) Run(ctx context.Context, tc *terminal.Context) error {
+func (c *DNSCommand) Run(ctx context.Context, tc *terminal.Context) (runErr error) {
 	if len(tc.Args) == 0 {
 		return fmt.Errorf("missing hostname argument")
 	}
 	hostname := tc.Args[0]
 
+	// Validate flags before creating any output so bad input never leaves
+	// an empty report behind.
+	opts, err := c.options(tc)
+	if err != nil {
+		return err
+	}
+
+	tmpl := outputTemplate(tc, c.format, c.template)
+
+	if c.gzip && c.outFile == "" {
+		return fmt.Errorf("--gzip requires --out-file")
+	}
+	var outW io.Writer = tc.Stdout
+	if c.outFile != "" {
+		f, err := createOutFile(c.outFile, c.gzip)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer closeOutFile(f, &runErr)
+		outW = f
+	}
+
+	em, err := newEmitter(c.format, tmpl, outW, tc.Stderr, c.flushInterval)
+	if err != nil {
+		return err
+	}
+	if em, err = withAlsoHTML(em, c.alsoHTML); err != nil {
+		return err
+	}
+	em = withSummary(em, c.summary, tc.Stderr)
+	defer em.Close()
+
+	if count := c.queries(); count != 1 {
+		var stop func()
+		em, stop = withProgress(tc, c.quiet, "dns", "dns_query_done", count, em)
+		defer stop()
+	}
+	return dns.TraceDNS(ctx, hostname, append(opts, dns.WithEmitter(em))...)
+}
+
+// trace runs the DNS tracer against hostname, emitting events to em.
+func (c *DNSCommand) trace(ctx context.Context, tc *terminal.Context, hostname string, em event.Emitter) error {
+	opts, err := c.options(tc)
+	if err != nil {
+		return err
+	}
+	return dns.TraceDNS(ctx, hostname, append(opts, dns.WithEmitter(em))...)
+}
+
+// options validates the parsed flags and converts them into tracer options,
+// merging the timeout with tc.Config. The emitter is supplied by the caller.
+func (c *DNSCommand) options(tc *terminal.Context) ([]dns.Option, error) {
 	// Validate --count; negative values are rejected.
 	if c.count < 0 {
-		return fmt.Errorf("--count must be 0 (infinite) or greater, got %d", c.count)
+		return nil, fmt.Errorf("--count must be 0 (infinite) or greater, got %d", c.count)
 	}
-	// When --interval is set without an explicit --count, run indefinitely (like ping).
-	count := c.count
-	if c.interval > 0 && c.count == 1 {
-		count = 0
+	if c.rate < 0 {
+		return nil, fmt.Errorf("--rate must be 0 (unlimited) or greater, got %g", c.rate)
+	}
+	if !(c.jitter >= 0 && c.jitter <= 1) {
+		return nil, fmt.Errorf("--jitter must be between 0 and 1, got %g", c.jitter)
 	}
-
 	// Merge timeout with config
 	timeout := c.timeout
 	if timeout == 0 && tc.Config != nil {
@@ -79,56 +171,51 @@ func (c *DNSCommand) Run(ctx context.Context, tc *terminal.Context) error {
 		timeout = 30
 	}
 
-	// Merge format with config
-	format := c.format
-	if format == "" && tc.Config != nil {
-		format = tc.Config.Get("format", "json").(string)
-	}
-
 	// Normalize --server (validate IP, default port 53)
 	server := ""
 	if c.server != "" {
 		var err error
 		server, err = normalizeServer(c.server)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	// Create emitter
-	var em event.Emitter
-	var outW io.Writer = tc.Stdout
-	if c.outFile != "" {
-		f, err := os.Create(c.outFile)
-		if err != nil {
-			return fmt.Errorf("failed to create output file: %w", err)
-		}
-		defer f.Close()
-		outW = f
-	}
-	switch format {
-	case "json":
-		em = formatter.NewNDJSONEmitter(outW)
-	case "html":
-		em = formatter.NewHTMLEmitter(outW)
-	default:
-		return fmt.Errorf("unsupported format: %s", format)
-	}
-	defer em.Close()
-
-	// Build options
 	opts := []dns.Option{
-		dns.WithEmitter(em),
 		dns.WithDryRun(c.dryRun),
 		dns.WithTimeout(time.Duration(timeout) * time.Second),
-		dns.WithCount(count),
+		dns.WithCount(c.queries()),
 		dns.WithInterval(time.Duration(c.interval) * time.Second),
+		dns.WithJitter(c.jitter),
+		dns.WithRateLimit(c.rate),
+		dns.WithLabels(c.labels.toMap()),
 	}
 	if server != "" {
 		opts = append(opts, dns.WithServer(server))
 	}
+	if c.rtype != "" {
+		rtype, err := dns.ParseRecordType(c.rtype)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --type: %w", err)
+		}
+		opts = append(opts, dns.WithRecordType(rtype))
+	}
+	if c.edns {
+		opts = append(opts, dns.WithEDNS(true))
+	}
+	if c.noCache {
+		opts = append(opts, dns.WithFreshDNS(true))
+	}
+	return opts, nil
+}
 
-	return dns.TraceDNS(ctx, hostname, opts...)
+// queries returns the number of queries to run. When --interval is set
+// without an explicit --count, it is 0: run indefinitely (like ping).
+func (c *DNSCommand) queries() int {
+	if c.interval > 0 && c.count == 1 {
+		return 0
+	}
+	return c.count
 }
 
 // normalizeServer parses and normalises a --server flag value.
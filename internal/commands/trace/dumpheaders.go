@@ -0,0 +1,58 @@
+package trace
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+)
+
+// headerRecorder keeps the headers of the last http_response_done it
+// observes, for --dump-headers.
+type headerRecorder struct {
+	mu      sync.Mutex
+	headers map[string]interface{}
+}
+
+func (d *headerRecorder) observe(ev *event.Event) {
+	if ev.Type == "http_response_done" {
+		if h, ok := ev.Data["headers"].(map[string]interface{}); ok {
+			d.mu.Lock()
+			d.headers = h
+			d.mu.Unlock()
+		}
+	}
+}
+
+// writeTo writes the recorded headers to w as "Name: Value" lines sorted by
+// name, one line per value of a repeated header. It writes nothing if no
+// response was seen. Values are as the event carried them, so redacted
+// headers read "[REDACTED]".
+func (d *headerRecorder) writeTo(w io.Writer) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	names := make([]string, 0, len(d.headers))
+	for name := range d.headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		var values []string
+		switch v := d.headers[name].(type) {
+		case string:
+			values = []string{v}
+		case []string:
+			values = v
+		default:
+			values = []string{fmt.Sprint(v)}
+		}
+		for _, v := range values {
+			if _, err := fmt.Fprintf(w, "%s: %s\n", name, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package trace
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+	"github.com/mrlm-net/cure/pkg/tracer/http"
+)
+
+// ErrFailOn is returned, wrapped with the matching condition, when an event
+// of a completed trace satisfies a --fail-on condition.
+var ErrFailOn = errors.New("fail-on condition matched")
+
+// failOnCondition is one parsed --fail-on expression.
+type failOnCondition struct {
+	expr  string
+	match func(event.Event) (string, bool) // returns a description of the match
+}
+
+// failOnOperators lists the comparison operators accepted in status
+// conditions. Two-character operators come first so ">=" is not read as ">".
+var failOnOperators = []struct {
+	op  string
+	cmp func(a, b int) bool
+}{
+	{">=", func(a, b int) bool { return a >= b }},
+	{"<=", func(a, b int) bool { return a <= b }},
+	{"==", func(a, b int) bool { return a == b }},
+	{"!=", func(a, b int) bool { return a != b }},
+	{">", func(a, b int) bool { return a > b }},
+	{"<", func(a, b int) bool { return a < b }},
+}
+
+// parseFailOn parses a --fail-on expression: "error", matching any failed
+// event, or "status<op><code>" with op one of
+// >=, <=, ==, !=, >, <, matching a final HTTP response status.
+func parseFailOn(expr string) (failOnCondition, error) {
+	s := strings.ReplaceAll(expr, " ", "")
+	if s == "error" {
+		return failOnCondition{expr: expr, match: func(ev event.Event) (string, bool) {
+			if !ev.Failed {
+				return "", false
+			}
+			return fmt.Sprintf("%s: %s", ev.Type, ev.Error), true
+		}}, nil
+	}
+
+	rest, ok := strings.CutPrefix(s, "status")
+	if !ok {
+		return failOnCondition{}, fmt.Errorf("invalid --fail-on %q (want \"error\" or status<op><code>, e.g. status>=500)", expr)
+	}
+	for _, o := range failOnOperators {
+		codeStr, ok := strings.CutPrefix(rest, o.op)
+		if !ok {
+			continue
+		}
+		code, err := strconv.Atoi(codeStr)
+		if err != nil || code < 100 || code > 599 {
+			return failOnCondition{}, fmt.Errorf("invalid --fail-on %q: status code must be between 100 and 599", expr)
+		}
+		cmp := o.cmp
+		return failOnCondition{expr: expr, match: func(ev event.Event) (string, bool) {
+			status, ok := http.ResponseStatus(ev)
+			if !ok || !cmp(status, code) {
+				return "", false
+			}
+			return fmt.Sprintf("status %d", status), true
+		}}, nil
+	}
+	return failOnCondition{}, fmt.Errorf("invalid --fail-on %q: unknown operator (want >=, <=, ==, !=, >, or <)", expr)
+}
+
+// failOnRecorder records the first event it observes that satisfies any
+// of conds.
+type failOnRecorder struct {
+	conds []failOnCondition
+
+	mu  sync.Mutex
+	err error
+}
+
+func (f *failOnRecorder) observe(ev *event.Event) {
+	f.mu.Lock()
+	if f.err == nil {
+		for _, c := range f.conds {
+			if desc, ok := c.match(*ev); ok {
+				f.err = fmt.Errorf("%w: %s (%s)", ErrFailOn, c.expr, desc)
+				break
+			}
+		}
+	}
+	f.mu.Unlock()
+}
+
+// result returns traceErr if the trace failed, otherwise the recorded
+// fail-on match or nil.
+func (f *failOnRecorder) result(traceErr error) error {
+	if traceErr != nil {
+		return traceErr
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+// failOnFlags is a repeatable --fail-on flag.
+type failOnFlags []string
+
+func (f *failOnFlags) String() string { return strings.Join(*f, ",") }
+
+// Get returns the conditions given, one per --fail-on.
+func (f *failOnFlags) Get() any { return []string(*f) }
+
+func (f *failOnFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
@@ -1,30 +1,74 @@
 package trace
 
 import (
+	"bufio"
 	"context"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/mrlm-net/cure/internal/commands"
 	"github.com/mrlm-net/cure/pkg/terminal"
 	"github.com/mrlm-net/cure/pkg/tracer/event"
-	"github.com/mrlm-net/cure/pkg/tracer/formatter"
 	"github.com/mrlm-net/cure/pkg/tracer/http"
 )
 
 type HTTPCommand struct {
 	// Flags
-	format  string
-	outFile string
-	dryRun  bool
-	method  string
-	data    string
-	headers headerFlags
-	redact  bool
+	format        string
+	template      string
+	outFile       string
+	gzip          bool
+	flushInterval time.Duration
+	alsoHTML      string
+	summary       bool
+	dryRun        bool
+	method        string
+	data          string
+	headers       headerFlags
+	form          formFlags
+	headersFile   string
+	redact        bool
+
+	noKeepAlive     bool
+	sharedTransport bool
+	acceptEncoding  string
+	headOnly        bool
+	maxRedirects    int
+	resolve         resolveFlags
+	hostHeader      string
+
+	connectTimeout  int
+	timeout         int
+	maxTimePerPhase time.Duration
+
+	count        int
+	interval     int
+	jitter       float64
+	untilStatus  int
+	untilSuccess bool
+
+	retry      int
+	retryOn    string
+	traceRetry int
+
+	failOn      failOnFlags
+	asserts     assertFlags
+	quiet       bool
+	labels      labelFlags
+	compare     string
+	dumpHeaders string
+	printCurl   bool
 }
 
+// defaultUntilCount caps the attempts made by --until-status/--until-success
+// when --count is not set.
+const defaultUntilCount = 10
+
 func (c *HTTPCommand) Name() string { return "http" }
 
 func (c *HTTPCommand) Description() string {
@@ -32,79 +76,450 @@ func (c *HTTPCommand) Description() string {
 }
 
 func (c *HTTPCommand) Usage() string {
-	return `Usage: cure trace http <url> [options]
+	return `Usage: cure trace http <url|-> [options]
 
 Traces an HTTP request to the specified URL, emitting lifecycle events for
 DNS resolution, TCP connection, TLS handshake, request/response.
 
+With "-" as the URL, URLs are read from stdin, one per line, and traced in
+turn. Blank lines and lines starting with # are skipped, and every event
+carries a "target" field. A failing URL is reported on stderr without
+stopping the rest.
+
 Examples:
   cure trace http https://example.com
   cure trace http --method POST --data '{"key":"value"}' https://api.example.com
-  cure trace http --format html --out-file report.html https://example.com`
+  cure trace http --method POST --form user=alice --form role=admin https://example.com/login
+  cure trace http --format html --out-file report.html https://example.com
+  cure trace http --no-keepalive https://example.com
+  cure trace http --shared-transport --count 10 https://example.com
+  cure trace http --resolve example.com:203.0.113.10 https://example.com
+  cure trace http --host-header shop.example.com http://203.0.113.10/
+  cure trace http --accept-encoding br https://cdn.example.com/app.js
+  cure trace http --head-only https://example.com/large.iso
+  cure trace http --until-success --interval 2 https://example.com/healthz
+  cure trace http --count 20 --interval 5 --jitter 0.3 https://example.com
+  cure trace http --until-status 200 --count 30 https://example.com/healthz
+  cure trace http --retry 5 https://api.example.com/rate-limited
+  cure trace http --connect-timeout 2 --timeout 10 https://example.com
+  cure trace http --dump-headers headers.txt https://example.com
+  cure trace http --print-curl -H 'Authorization: Bearer x' https://example.com
+  cat urls.txt | cure trace http -
+
+--dump-headers writes the final response's headers to a file as "Name: Value"
+lines, like curl -D, while events still go to stdout. Sensitive headers are
+redacted unless --redact=false. With several requests the file holds the
+headers of the last response.
+
+--print-curl writes a curl command line that sends the same request to
+stderr before tracing, for reproducing it or sharing it in a bug report.
+Sensitive headers read [REDACTED] unless --redact=false.
+
+--connect-timeout limits establishing the TCP connection; --timeout limits the
+whole trace. --max-time-per-phase (a duration such as 500ms) fails the trace
+as soon as DNS, connect, TLS, or the wait for the first byte outlasts it,
+emitting a phase_timeout event. When any of them fires, the trace_error event
+names it.
+
+With --until-status or --until-success the request is repeated until a
+response matches, up to --count attempts (default 10 when --count is not set).
+A repeat_stopped event reports why the loop ended.
+
+With --retry a response whose status is in --retry-on (default 429,503) is
+retried up to that many times. Each retry waits as long as the response's
+Retry-After header asks, capped at a minute, or one second without one, and
+is announced by an http_retry event. A request and its retries count as one
+of --count's attempts.
+
+With --trace-retry a request that gets no response because of a transient
+network error (refused or reset connection, temporary DNS failure, timeout)
+is traced again, up to that many times in all, waiting 250ms and doubling
+between attempts. A trace_retry event with the reason announces each one.
+
+With --fail-on the command exits non-zero when an emitted event matches,
+even though the trace itself completed. Conditions are "error" (any event
+with an error field) or status<op><code> with op one of >=, <=, ==, !=, >, <.
+Repeat --fail-on to check several conditions:
+  cure trace http --fail-on 'status>=500' --fail-on error https://example.com
+
+With --assert the command exits non-zero, after an assertion_failed event
+naming the expression, unless every expression holds. A field is read from
+http_response_done, or from another event by prefix: dns., connect., tls.,
+response., or summary. (for trace_summary, e.g. summary.phases.ttfb):
+  cure trace http --assert status==200 --assert 'tls.version==TLS 1.3' \
+    --assert 'duration_ms<500' https://example.com
+
+With --compare the URL is traced twice, as side "a" with the given options
+and as side "b" with --compare's flags and optional URL applied on top, then
+a trace_compare event reports the per-phase deltas (b minus a):
+  cure trace http --compare https://origin.example.com https://cdn.example.com
+  cure trace http --compare --no-keepalive https://example.com
+  cure trace http --compare '--method HEAD https://b.example.com' https://a.example.com`
 }
 
 func (c *HTTPCommand) Flags() *flag.FlagSet {
 	fs := flag.NewFlagSet("trace-http", flag.ContinueOnError)
-	fs.StringVar(&c.format, "format", "json", "Output format (json, html)")
+	fs.StringVar(&c.format, "format", "json", formatUsage)
+	terminal.DescribeFlag(fs, "format", terminal.FlagMeta{Enum: formats})
+	fs.StringVar(&c.template, "template", "", templateUsage)
 	fs.StringVar(&c.outFile, "out-file", "", "Output file (default: stdout)")
+	fs.BoolVar(&c.gzip, "gzip", false, "Gzip-compress the --out-file output (adds .gz if missing)")
+	fs.DurationVar(&c.flushInterval, "flush-interval", 0, "With --format json, buffer output and flush it this often, e.g. 1s (0 = flush every event)")
+	fs.StringVar(&c.alsoHTML, "also-html", "", "Also write an HTML report to this file")
+	fs.BoolVar(&c.summary, "summary", false, "Print a one-line tally of events by type and the trace duration to stderr at the end")
 	fs.BoolVar(&c.dryRun, "dry-run", false, "Emit events without I/O")
 	fs.StringVar(&c.method, "method", "GET", "HTTP method")
 	fs.StringVar(&c.data, "data", "", "Request body")
+	fs.Var(&c.form, "form", "Add a form field as key=value and send the body form-encoded (repeatable)")
 	fs.Var(&c.headers, "H", "Add header (repeatable)")
+	fs.StringVar(&c.headersFile, "headers-file", "", "Read headers from a file, one \"Name: Value\" per line (-H wins on conflict)")
 	fs.BoolVar(&c.redact, "redact", true, "Redact sensitive headers")
+	fs.BoolVar(&c.noKeepAlive, "no-keepalive", false, "Disable keep-alives (fresh connection per request)")
+	fs.BoolVar(&c.sharedTransport, "shared-transport", false, "Share one connection pool across repeated requests and end with a pool_stats event")
+	fs.StringVar(&c.acceptEncoding, "accept-encoding", "", "Accept-Encoding to request (e.g. gzip, br, identity); body is not decoded")
+	fs.BoolVar(&c.printCurl, "print-curl", false, "Also print the equivalent curl command line to stderr")
+	fs.StringVar(&c.dumpHeaders, "dump-headers", "", "Write the final response headers to this file as \"Name: Value\" lines")
+	fs.BoolVar(&c.headOnly, "head-only", false, "Stop after the response headers without downloading the body")
+	fs.StringVar(&c.hostHeader, "host-header", "", "Send this Host instead of the URL's, to reach a virtual host; SNI stays the URL's host")
+	fs.Var(&c.resolve, "resolve", "Connect to host at ip, keeping Host and SNI, as host:ip (repeatable)")
+	fs.IntVar(&c.maxRedirects, "max-redirects", http.DefaultMaxRedirects, "Maximum number of redirects to follow (0 = do not follow)")
+	fs.IntVar(&c.connectTimeout, "connect-timeout", 0, "TCP connect timeout in seconds (0 = transport default of 30s)")
+	fs.IntVar(&c.timeout, "timeout", 0, "Total trace timeout in seconds (0 = use config default, or no limit)")
+	fs.DurationVar(&c.maxTimePerPhase, "max-time-per-phase", 0, "Fail if DNS, connect, TLS, or time to first byte takes longer than this, e.g. 500ms (0 = no limit)")
+	fs.IntVar(&c.count, "count", 1, "Maximum number of requests (0 = run until the condition is met or Ctrl+C)")
+	fs.IntVar(&c.interval, "interval", 0, "Seconds to wait between repeated requests")
+	fs.Float64Var(&c.jitter, "jitter", 0, "Randomise each --interval wait by up to ±this fraction of it (0-1, default 0)")
+	fs.IntVar(&c.untilStatus, "until-status", 0, "Repeat until a response has this status code")
+	fs.BoolVar(&c.untilSuccess, "until-success", false, "Repeat until a response has a 2xx status code")
+	fs.IntVar(&c.retry, "retry", 0, "Retry a request up to this many times when the status is in --retry-on, honouring Retry-After")
+	fs.StringVar(&c.retryOn, "retry-on", "429,503", "Comma-separated statuses that --retry retries")
+	fs.IntVar(&c.traceRetry, "trace-retry", 0, "Run the whole trace up to this many times while it fails with a transient network error")
+	fs.BoolVar(&c.quiet, "quiet", false, "Suppress the progress indicator shown on stderr for repeated requests")
+	fs.Var(&c.labels, "label", labelUsage)
+	fs.StringVar(&c.compare, "compare", "", "Also trace a side \"b\" with these flags and/or URL, then emit per-phase deltas")
+	fs.Var(&c.asserts, "assert", "Exit non-zero unless the trace satisfies <field><op><value>, e.g. status==200, tls.version==TLS 1.3, duration_ms<500 (repeatable)")
+	fs.Var(&c.failOn, "fail-on", "Exit non-zero when an event matches: error, or status<op><code> such as status>=500 (repeatable)")
 	return fs
 }
 
-func (c *HTTPCommand) Run(ctx context.Context, tc *terminal.Context) error {
+func (c *HTTPCommand) Run(ctx context.Context, tc *terminal.Context) (runErr error) {
 	if len(tc.Args) == 0 {
 		return fmt.Errorf("missing URL argument")
 	}
 	url := tc.Args[0]
 
-	// Merge flags with config (flags take precedence)
-	format := c.format
-	if format == "" && tc.Config != nil {
-		format = tc.Config.Get("format", "json").(string)
-	}
+	tmpl := outputTemplate(tc, c.format, c.template)
 
-	// Create emitter
-	var em event.Emitter
+	if c.gzip && c.outFile == "" {
+		return fmt.Errorf("--gzip requires --out-file")
+	}
+	if err := c.validate(); err != nil {
+		return err
+	}
 	var outW io.Writer = tc.Stdout
 	if c.outFile != "" {
-		f, err := os.Create(c.outFile)
+		f, err := createOutFile(c.outFile, c.gzip)
 		if err != nil {
 			return fmt.Errorf("failed to create output file: %w", err)
 		}
-		defer f.Close()
+		defer closeOutFile(f, &runErr)
 		outW = f
 	}
 
-	switch format {
-	case "json":
-		em = formatter.NewNDJSONEmitter(outW)
-	case "html":
-		em = formatter.NewHTMLEmitter(outW)
-	default:
-		return fmt.Errorf("unsupported format: %s", format)
+	em, err := newEmitter(c.format, tmpl, outW, tc.Stderr, c.flushInterval)
+	if err != nil {
+		return err
+	}
+	if em, err = withAlsoHTML(em, c.alsoHTML); err != nil {
+		return err
 	}
+	em = withSummary(em, c.summary, tc.Stderr)
 	defer em.Close()
 
-	// Build tracer options
+	if c.dumpHeaders != "" {
+		f, err := os.Create(c.dumpHeaders)
+		if err != nil {
+			return fmt.Errorf("failed to create headers file: %w", err)
+		}
+		defer f.Close()
+		dump := &headerRecorder{}
+		em = event.NewTapEmitter(em, dump.observe)
+		defer func() {
+			if err := dump.writeTo(f); err != nil && runErr == nil {
+				runErr = fmt.Errorf("failed to write headers file: %w", err)
+			}
+		}()
+	}
+
+	if count := c.attempts(); count != 1 {
+		var stop func()
+		em, stop = withProgress(tc, c.quiet, "http", event.TraceSummary, count, em)
+		defer stop()
+	}
+	if url == "-" {
+		if c.compare != "" {
+			return fmt.Errorf("--compare cannot be combined with reading URLs from stdin")
+		}
+		return c.traceTargets(ctx, tc, em)
+	}
+	if c.compare != "" {
+		return c.traceCompare(ctx, tc, url, em)
+	}
+	return c.trace(ctx, tc, url, em)
+}
+
+// traceTargets traces each URL read from tc.Stdin, one per line, skipping
+// blank lines and # comments. Events are tagged with their URL as in batch
+// mode. A failing URL is reported on stderr and the next one is traced; an
+// emit failure stops the loop, since every later trace would fail the same
+// way, as does cancelling ctx.
+func (c *HTTPCommand) traceTargets(ctx context.Context, tc *terminal.Context, em event.Emitter) error {
+	if tc.Stdin == nil {
+		return fmt.Errorf("no stdin to read URLs from")
+	}
+	ctx, guard := event.Guard(ctx, em)
+	defer guard.Close()
+
+	total, failed := 0, 0
+	scanner := bufio.NewScanner(tc.Stdin)
+	for scanner.Scan() {
+		target := strings.TrimSpace(scanner.Text())
+		if target == "" || strings.HasPrefix(target, "#") {
+			continue
+		}
+		total++
+		err := c.trace(ctx, tc, target, withTarget(guard, target))
+		if emitErr := guard.Err(); emitErr != nil {
+			return emitErr
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(tc.Stderr, "%s: %v\n", target, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read URLs from stdin: %w", err)
+	}
+	if total == 0 {
+		return fmt.Errorf("no URLs read from stdin")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d targets failed", failed, total)
+	}
+	return nil
+}
+
+// validate checks the flag values that do not depend on the URL, so Run can
+// reject them before it creates --out-file, --also-html, or --dump-headers.
+func (c *HTTPCommand) validate() error {
+	if c.connectTimeout < 0 {
+		return fmt.Errorf("--connect-timeout must be 0 or greater, got %d", c.connectTimeout)
+	}
+	if c.timeout < 0 {
+		return fmt.Errorf("--timeout must be 0 or greater, got %d", c.timeout)
+	}
+	if c.maxTimePerPhase < 0 {
+		return fmt.Errorf("--max-time-per-phase must be 0 or greater, got %s", c.maxTimePerPhase)
+	}
+	if c.count < 0 {
+		return fmt.Errorf("--count must be 0 (infinite) or greater, got %d", c.count)
+	}
+	if c.maxRedirects < 0 {
+		return fmt.Errorf("--max-redirects must be 0 (do not follow) or greater, got %d", c.maxRedirects)
+	}
+	if !(c.jitter >= 0 && c.jitter <= 1) {
+		return fmt.Errorf("--jitter must be between 0 and 1, got %g", c.jitter)
+	}
+	if len(c.form) > 0 && c.data != "" {
+		return fmt.Errorf("--form and --data are mutually exclusive")
+	}
+	if c.retry < 0 {
+		return fmt.Errorf("--retry must be 0 or greater, got %d", c.retry)
+	}
+	// Checked even without --retry, so a typo is not silently ignored.
+	if _, err := parseStatuses(c.retryOn); err != nil {
+		return err
+	}
+	if c.traceRetry < 0 {
+		return fmt.Errorf("--trace-retry must be 0 or greater, got %d", c.traceRetry)
+	}
+	if c.untilStatus != 0 && c.untilSuccess {
+		return fmt.Errorf("--until-status and --until-success are mutually exclusive")
+	}
+	for _, expr := range c.failOn {
+		if _, err := parseFailOn(expr); err != nil {
+			return err
+		}
+	}
+	for _, expr := range c.asserts {
+		if _, err := parseAssertion(expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trace runs the HTTP tracer against url, emitting events to em. It
+// validates the flags itself, since batch jobs and the --compare side b
+// reach it without going through Run.
+func (c *HTTPCommand) trace(ctx context.Context, tc *terminal.Context, url string, em event.Emitter) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	// Servers commonly ignore a GET body, so a form sent that way is more
+	// likely a forgotten --method than intended.
+	if len(c.form) > 0 && (c.method == "" || strings.EqualFold(c.method, "GET")) {
+		fmt.Fprintln(tc.Stderr, "warning: --form sends a request body with GET; pass --method POST for a form submission")
+	}
+	if c.printCurl {
+		fmt.Fprintln(tc.Stderr, c.curlCommand(url))
+	}
+
+	var failOn *failOnRecorder
+	if len(c.failOn) > 0 {
+		failOn = &failOnRecorder{}
+		for _, expr := range c.failOn {
+			cond, err := parseFailOn(expr)
+			if err != nil {
+				return err
+			}
+			failOn.conds = append(failOn.conds, cond)
+		}
+		em = event.NewTapEmitter(em, failOn.observe)
+	}
+	var asserts *assertRecorder
+	if len(c.asserts) > 0 {
+		asserts = &assertRecorder{em: em}
+		for _, expr := range c.asserts {
+			a, err := parseAssertion(expr)
+			if err != nil {
+				return err
+			}
+			asserts.asserts = append(asserts.asserts, a)
+		}
+		em = event.NewTapEmitter(em, asserts.observe)
+	}
+
 	opts := []http.Option{
 		http.WithEmitter(em),
 		http.WithDryRun(c.dryRun),
 		http.WithMethod(c.method),
 		http.WithRedact(c.redact),
+		http.WithDisableKeepAlives(c.noKeepAlive),
+		http.WithSharedTransport(c.sharedTransport),
+		http.WithUserAgent(userAgent()),
+		http.WithLabels(c.labels.toMap()),
+		http.WithMaxRedirects(c.maxRedirects),
+		http.WithHeadOnly(c.headOnly),
 	}
 	if c.data != "" {
 		opts = append(opts, http.WithBodyString(c.data))
 	}
+	if len(c.form) > 0 {
+		form, err := c.form.toMap()
+		if err != nil {
+			return err
+		}
+		opts = append(opts, http.WithFormData(form))
+	}
 	if len(c.headers) > 0 {
 		opts = append(opts, http.WithHeaders(c.headers.toMap()))
 	}
+	if c.headersFile != "" {
+		opts = append(opts, http.WithHeadersFromFile(c.headersFile))
+	}
+	if c.acceptEncoding != "" {
+		opts = append(opts, http.WithAcceptEncoding(c.acceptEncoding))
+	}
+	if c.hostHeader != "" {
+		opts = append(opts, http.WithHostHeader(c.hostHeader))
+	}
+	for _, r := range c.resolve {
+		host, ip, ok := strings.Cut(r, ":")
+		if !ok || host == "" || ip == "" {
+			return fmt.Errorf("invalid --resolve %q (want host:ip)", r)
+		}
+		opts = append(opts, http.WithResolveOverride(host, ip))
+	}
+	if c.retry > 0 {
+		statuses, err := parseStatuses(c.retryOn)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, http.WithRetryOn(statuses...), http.WithMaxRetries(c.retry))
+	}
+	if c.traceRetry > 1 {
+		opts = append(opts, http.WithTraceRetry(c.traceRetry))
+	}
+	if c.connectTimeout > 0 {
+		opts = append(opts, http.WithConnectTimeout(time.Duration(c.connectTimeout)*time.Second))
+	}
+	if c.maxTimePerPhase > 0 {
+		opts = append(opts, http.WithMaxTimePerPhase(c.maxTimePerPhase))
+	}
+
+	// Merge timeout with config
+	timeout := c.timeout
+	if timeout == 0 && tc.Config != nil {
+		timeout = tc.Config.Get("timeout", 0).(int)
+	}
+	if timeout > 0 {
+		opts = append(opts, http.WithTotalTimeout(time.Duration(timeout)*time.Second))
+	}
 
-	// Execute trace
-	return http.TraceURL(ctx, url, opts...)
+	switch {
+	case c.untilStatus != 0:
+		opts = append(opts, http.WithRepeatUntil(http.UntilStatus(c.untilStatus)))
+	case c.untilSuccess:
+		opts = append(opts, http.WithRepeatUntil(http.UntilSuccess()))
+	}
+	opts = append(opts,
+		http.WithCount(c.attempts()),
+		http.WithInterval(time.Duration(c.interval)*time.Second),
+		http.WithJitter(c.jitter),
+	)
+
+	err := http.TraceURL(ctx, url, opts...)
+	if asserts != nil {
+		err = asserts.result(err)
+	}
+	if failOn != nil {
+		return failOn.result(err)
+	}
+	return err
+}
+
+// parseStatuses parses a comma-separated --retry-on list of HTTP status
+// codes.
+func parseStatuses(list string) ([]int, error) {
+	var statuses []int
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		code, err := strconv.Atoi(field)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid --retry-on status %q (want codes such as 429,503)", field)
+		}
+		statuses = append(statuses, code)
+	}
+	return statuses, nil
+}
+
+// attempts returns the maximum number of requests to make: --count, or
+// defaultUntilCount when a repeat condition is set without --count.
+func (c *HTTPCommand) attempts() int {
+	if (c.untilStatus != 0 || c.untilSuccess) && c.count == 1 {
+		return defaultUntilCount
+	}
+	return c.count
+}
+
+// userAgent returns the default User-Agent for traced requests, stamped with
+// the version set at link time.
+func userAgent() string {
+	return http.DefaultUserAgent + "/" + commands.Version
 }
 
 // headerFlags is a custom flag type for repeatable -H flags.
@@ -112,6 +527,9 @@ type headerFlags []string
 
 func (h *headerFlags) String() string { return "" }
 
+// Get returns the headers given, one per -H.
+func (h *headerFlags) Get() any { return []string(*h) }
+
 func (h *headerFlags) Set(value string) error {
 	*h = append(*h, value)
 	return nil
@@ -127,3 +545,42 @@ func (h headerFlags) toMap() map[string]string {
 	}
 	return m
 }
+
+// formFlags is a custom flag type for repeatable --form key=value flags.
+type formFlags []string
+
+func (f *formFlags) String() string { return "" }
+
+// Get returns the key=value fields given, one per --form.
+func (f *formFlags) Get() any { return []string(*f) }
+
+func (f *formFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// resolveFlags is a custom flag type for repeatable --resolve host:ip flags.
+type resolveFlags []string
+
+func (r *resolveFlags) String() string { return "" }
+
+// Get returns the host:ip pins given, one per --resolve.
+func (r *resolveFlags) Get() any { return []string(*r) }
+
+func (r *resolveFlags) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+// toMap parses the collected fields. A later field with the same key wins.
+func (f formFlags) toMap() (map[string]string, error) {
+	m := make(map[string]string, len(f))
+	for _, field := range f {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --form %q (want key=value)", field)
+		}
+		m[key] = value
+	}
+	return m, nil
+}
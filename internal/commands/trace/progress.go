@@ -0,0 +1,52 @@
+package trace
+
+import (
+	"io"
+	"sync"
+
+	"github.com/mrlm-net/cure/pkg/terminal"
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+)
+
+// startProgress starts a progress indicator on tc.Stderr. With quiet set, or
+// when stderr is not a terminal, the returned Progress draws nothing.
+func startProgress(tc *terminal.Context, quiet bool, label string, total int) *terminal.Progress {
+	var w io.Writer = tc.Stderr
+	if quiet {
+		w = io.Discard
+	}
+	p := terminal.NewProgress(w, label)
+	p.Update(0, total)
+	p.Start()
+	return p
+}
+
+// progressCounter advances p each time it observes an event of type step.
+// total is passed through to p unchanged; 0 means the number of steps is
+// unknown.
+type progressCounter struct {
+	p     *terminal.Progress
+	step  string
+	total int
+
+	mu   sync.Mutex
+	done int
+}
+
+func (e *progressCounter) observe(ev *event.Event) {
+	if ev.Type == e.step {
+		e.mu.Lock()
+		e.done++
+		e.p.Update(e.done, e.total)
+		e.mu.Unlock()
+	}
+}
+
+// withProgress wraps em so that a progress indicator labelled label advances
+// on every step event. The returned stop function must be called once the
+// trace finishes to erase the indicator.
+func withProgress(tc *terminal.Context, quiet bool, label, step string, total int, em event.Emitter) (event.Emitter, func()) {
+	p := startProgress(tc, quiet, label, total)
+	counter := &progressCounter{p: p, step: step, total: total}
+	return event.NewTapEmitter(em, counter.observe), p.Stop
+}
@@ -0,0 +1,285 @@
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mrlm-net/cure/pkg/terminal"
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+)
+
+// maxReplayLineSize caps the length of a single NDJSON line. Events carrying
+// HTTP bodies can exceed bufio.Scanner's 64 KiB default.
+const maxReplayLineSize = 16 * 1024 * 1024
+
+// ReplayCommand implements the "cure trace replay" subcommand.
+type ReplayCommand struct {
+	format   string
+	template string
+	outFile  string
+	gzip     bool
+	strict   bool
+	since    string
+	until    string
+}
+
+func (c *ReplayCommand) Name() string { return "replay" }
+
+func (c *ReplayCommand) Description() string {
+	return "Re-render a captured NDJSON trace in another format"
+}
+
+func (c *ReplayCommand) Usage() string {
+	return `Usage: cure trace replay <file.ndjson> [options]
+
+Reads events from an NDJSON file written by a previous "cure trace" run and
+re-emits them through the chosen formatter. Use "-" to read from stdin.
+Original timestamps and trace IDs are preserved.
+
+Malformed lines are reported on stderr with their line number and skipped.
+With --strict, the first malformed line aborts the replay; the input is
+read in full before --out-file is written.
+
+--since and --until keep only the events in a time window, bounds included.
+Each takes an RFC 3339 time, a duration before now such as 15m, or a
+duration after the capture's first event with a leading +, such as +30s.
+Events without a timestamp are skipped when either is set.
+
+Examples:
+  cure trace http https://example.com > trace.ndjson
+  cure trace replay --out-file report.html trace.ndjson
+  cure trace replay --format json --since +1m --until +2m trace.ndjson
+  cure trace replay --since 2026-01-02T03:00:00Z --until 2026-01-02T03:05:00Z trace.ndjson
+  cat trace.ndjson | cure trace replay --strict -`
+}
+
+func (c *ReplayCommand) Flags() *flag.FlagSet {
+	fs := flag.NewFlagSet("trace-replay", flag.ContinueOnError)
+	fs.StringVar(&c.format, "format", "html", formatUsage)
+	terminal.DescribeFlag(fs, "format", terminal.FlagMeta{Enum: formats})
+	fs.StringVar(&c.template, "template", "", templateUsage)
+	fs.StringVar(&c.outFile, "out-file", "", "Output file (default: stdout)")
+	fs.BoolVar(&c.gzip, "gzip", false, "Gzip-compress the --out-file output (adds .gz if missing)")
+	fs.BoolVar(&c.strict, "strict", false, "Fail on the first malformed line instead of skipping it")
+	fs.StringVar(&c.since, "since", "", "Skip events before this time: RFC 3339, a duration ago (15m), or +offset from the first event")
+	fs.StringVar(&c.until, "until", "", "Skip events after this time: RFC 3339, a duration ago (15m), or +offset from the first event")
+	return fs
+}
+
+func (c *ReplayCommand) Run(ctx context.Context, tc *terminal.Context) (runErr error) {
+	if len(tc.Args) == 0 {
+		return fmt.Errorf("missing NDJSON file argument")
+	}
+	window, err := newTimeWindow(c.since, c.until, time.Now())
+	if err != nil {
+		return err
+	}
+
+	in := tc.Stdin
+	if path := tc.Args[0]; path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open trace file: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	if in == nil {
+		return fmt.Errorf("no stdin to read events from")
+	}
+
+	if c.gzip && c.outFile == "" {
+		return fmt.Errorf("--gzip requires --out-file")
+	}
+
+	// With --strict, check the whole input before --out-file is created, so
+	// a malformed line fails the replay without leaving an empty file behind.
+	var checked *event.SliceEmitter
+	if c.strict {
+		checked = event.NewSliceEmitter(nil)
+		if err := replay(ctx, in, checked, tc.Stderr, true); err != nil {
+			return err
+		}
+	}
+
+	var outW io.Writer = tc.Stdout
+	if c.outFile != "" {
+		f, err := createOutFile(c.outFile, c.gzip)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer closeOutFile(f, &runErr)
+		outW = f
+	}
+
+	em, err := newEmitter(c.format, c.template, outW, tc.Stderr, 0)
+	if err != nil {
+		return err
+	}
+
+	out := event.Emitter(em)
+	var filter *event.FilterEmitter
+	if window != nil {
+		filter = event.NewFilterEmitter(em, window.keep)
+		out = filter
+	}
+
+	// Buffered formatters render on Close; skip it on failure so a partial
+	// report is never written.
+	if checked != nil {
+		for _, ev := range checked.Events() {
+			if err := out.Emit(ev); err != nil {
+				return err
+			}
+		}
+	} else if err := replay(ctx, in, out, tc.Stderr, false); err != nil {
+		return err
+	}
+	if filter != nil && filter.Dropped() > 0 {
+		fmt.Fprintf(tc.Stderr, "replay: skipped %d event(s) outside --since/--until\n", filter.Dropped())
+	}
+	return em.Close()
+}
+
+// replay decodes NDJSON events from r and emits them to em in order.
+// Blank lines are ignored. Malformed lines are reported to stderr and skipped,
+// or returned as an error when strict is set.
+func replay(ctx context.Context, r io.Reader, em event.Emitter, stderr io.Writer, strict bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxReplayLineSize)
+
+	lineNo, skipped := 0, 0
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		lineNo++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var ev event.Event
+		err := json.Unmarshal(line, &ev)
+		if err == nil && ev.Type == "" {
+			err = fmt.Errorf("missing event type")
+		}
+		if err != nil {
+			if strict {
+				return fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			fmt.Fprintf(stderr, "line %d: skipping malformed event: %v\n", lineNo, err)
+			skipped++
+			continue
+		}
+
+		if err := em.Emit(ev); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read trace file: %w", err)
+	}
+
+	if skipped > 0 {
+		fmt.Fprintf(stderr, "replay: skipped %d malformed line(s)\n", skipped)
+	}
+	return nil
+}
+
+// timeBound is a --since or --until value: an absolute time, or an offset
+// from the first event of the capture.
+type timeBound struct {
+	at        time.Time
+	offset    time.Duration
+	fromStart bool
+}
+
+// parseTimeBound parses the value of flag as an RFC 3339 time, a duration
+// before now, or "+" and a duration after the first event.
+func parseTimeBound(flag, value string, now time.Time) (*timeBound, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if rest, ok := strings.CutPrefix(value, "+"); ok {
+		d, err := time.ParseDuration(rest)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid --%s %q (want +duration such as +30s)", flag, value)
+		}
+		return &timeBound{offset: d, fromStart: true}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return &timeBound{at: now.Add(-d)}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s %q (want an RFC 3339 time, a duration such as 15m, or +duration)", flag, value)
+	}
+	return &timeBound{at: t}, nil
+}
+
+// resolve returns the bound's time for a capture whose first event is at
+// start.
+func (b *timeBound) resolve(start time.Time) time.Time {
+	if b.fromStart {
+		return start.Add(b.offset)
+	}
+	return b.at
+}
+
+// timeWindow keeps the events whose time is within [since, until].
+type timeWindow struct {
+	since, until *timeBound
+	start        time.Time // time of the first timestamped event
+}
+
+// newTimeWindow returns the window for the --since and --until values, or
+// nil if neither is set. It fails if the window is empty whatever the
+// capture holds: since after until, both absolute or both offsets.
+func newTimeWindow(since, until string, now time.Time) (*timeWindow, error) {
+	s, err := parseTimeBound("since", since, now)
+	if err != nil {
+		return nil, err
+	}
+	u, err := parseTimeBound("until", until, now)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil && u == nil {
+		return nil, nil
+	}
+	if s != nil && u != nil && s.fromStart == u.fromStart {
+		var zero time.Time
+		if s.resolve(zero).After(u.resolve(zero)) {
+			return nil, fmt.Errorf("--since %s is after --until %s, so no event can match", since, until)
+		}
+	}
+	return &timeWindow{since: s, until: u}, nil
+}
+
+// keep reports whether ev is inside the window. Events without a time are
+// never kept.
+func (w *timeWindow) keep(ev event.Event) bool {
+	t := ev.Time()
+	if t.IsZero() {
+		return false
+	}
+	if w.start.IsZero() {
+		w.start = t
+	}
+	if w.since != nil && t.Before(w.since.resolve(w.start)) {
+		return false
+	}
+	if w.until != nil && t.After(w.until.resolve(w.start)) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package trace
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/mrlm-net/cure/pkg/terminal"
+	"github.com/mrlm-net/cure/pkg/tracer/http"
+)
+
+// SelftestCommand implements the "cure trace selftest" subcommand.
+type SelftestCommand struct {
+	format   string
+	template string
+	outFile  string
+	gzip     bool
+	alsoHTML string
+	delay    int
+	status   int
+	tls      bool
+}
+
+func (c *SelftestCommand) Name() string { return "selftest" }
+
+func (c *SelftestCommand) Description() string {
+	return "Trace a built-in local HTTP server to check that tracing works"
+}
+
+func (c *SelftestCommand) Usage() string {
+	return `Usage: cure trace selftest [options]
+
+Starts an HTTP server on 127.0.0.1 with a random port, traces one request to
+it, and shuts it down. No external network access is needed, so it is a quick
+way to check that cure works in a new environment or in a CI smoke test.
+
+The events are the same as for "cure trace http". --delay holds the response
+back to show up in ttfb, --status sets the response code, and --tls serves
+HTTPS with a throwaway self-signed certificate, which the trace trusts, to
+exercise the TLS handshake phase.
+
+A one-line result is printed to stderr. The command fails if the trace fails.
+
+Examples:
+  cure trace selftest
+  cure trace selftest --tls --delay 250
+  cure trace selftest --status 503 --format html --out-file selftest.html`
+}
+
+func (c *SelftestCommand) Flags() *flag.FlagSet {
+	fs := flag.NewFlagSet("trace-selftest", flag.ContinueOnError)
+	fs.StringVar(&c.format, "format", "json", formatUsage)
+	terminal.DescribeFlag(fs, "format", terminal.FlagMeta{Enum: formats})
+	fs.StringVar(&c.template, "template", "", templateUsage)
+	fs.StringVar(&c.outFile, "out-file", "", "Output file (default: stdout)")
+	fs.BoolVar(&c.gzip, "gzip", false, "Gzip-compress the --out-file output (adds .gz if missing)")
+	fs.StringVar(&c.alsoHTML, "also-html", "", "Also write an HTML report to this file")
+	fs.IntVar(&c.delay, "delay", 0, "Milliseconds the server waits before responding")
+	fs.IntVar(&c.status, "status", 200, "Status code the server responds with")
+	fs.BoolVar(&c.tls, "tls", false, "Serve HTTPS with a self-signed certificate")
+	return fs
+}
+
+func (c *SelftestCommand) Run(ctx context.Context, tc *terminal.Context) (runErr error) {
+	if c.delay < 0 {
+		return fmt.Errorf("--delay must be 0 or greater, got %d", c.delay)
+	}
+	if c.status < 200 || c.status > 599 {
+		return fmt.Errorf("--status must be between 200 and 599, got %d", c.status)
+	}
+
+	tmpl := outputTemplate(tc, c.format, c.template)
+
+	if c.gzip && c.outFile == "" {
+		return fmt.Errorf("--gzip requires --out-file")
+	}
+	var outW io.Writer = tc.Stdout
+	if c.outFile != "" {
+		f, err := createOutFile(c.outFile, c.gzip)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer closeOutFile(f, &runErr)
+		outW = f
+	}
+
+	em, err := newEmitter(c.format, tmpl, outW, tc.Stderr, 0)
+	if err != nil {
+		return err
+	}
+	if em, err = withAlsoHTML(em, c.alsoHTML); err != nil {
+		return err
+	}
+	defer em.Close()
+
+	srv := httptest.NewUnstartedServer(c.handler())
+	opts := []http.Option{
+		http.WithEmitter(em),
+		http.WithUserAgent(userAgent()),
+	}
+	if c.tls {
+		srv.StartTLS()
+		roots := x509.NewCertPool()
+		roots.AddCert(srv.Certificate())
+		opts = append(opts, http.WithTLSConfig(&tls.Config{RootCAs: roots}))
+	} else {
+		srv.Start()
+	}
+	defer srv.Close()
+
+	url := srv.URL + "/selftest"
+	if err := http.TraceURL(ctx, url, opts...); err != nil {
+		fmt.Fprintf(tc.Stderr, "selftest: FAIL %s: %v\n", url, err)
+		return err
+	}
+	fmt.Fprintf(tc.Stderr, "selftest: ok %s (status %d)\n", url, c.status)
+	return nil
+}
+
+// handler returns the selftest server's handler: it waits for c.delay, or
+// until the client goes away, then replies with c.status and a short body.
+func (c *SelftestCommand) handler() nethttp.Handler {
+	delay := time.Duration(c.delay) * time.Millisecond
+	status := c.status
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "cure selftest: %d %s\n", status, nethttp.StatusText(status))
+	})
+}
@@ -10,16 +10,46 @@ import (
 
 	"github.com/mrlm-net/cure/pkg/terminal"
 	"github.com/mrlm-net/cure/pkg/tracer/event"
-	"github.com/mrlm-net/cure/pkg/tracer/formatter"
 	"github.com/mrlm-net/cure/pkg/tracer/tcp"
 )
 
 type TCPCommand struct {
-	format  string
-	outFile string
-	dryRun  bool
-	data    string
-	timeout int
+	format        string
+	template      string
+	outFile       string
+	gzip          bool
+	flushInterval time.Duration
+	alsoHTML      string
+	summary       bool
+	dryRun        bool
+	data          string
+	timeout       int
+
+	dnsTimeout int
+
+	rttProbe  bool
+	parseHTTP bool
+
+	hexdump      bool
+	hexdumpBytes int
+
+	tls      bool
+	startTLS string
+
+	keepAlive bool
+	interval  int
+	count     int
+
+	socks5     string
+	socks5User string
+	proxyDNS   bool
+
+	netns string
+
+	traceRetry int
+
+	quiet  bool
+	labels labelFlags
 }
 
 func (c *TCPCommand) Name() string { return "tcp" }
@@ -33,66 +63,176 @@ func (c *TCPCommand) Usage() string {
 
 Traces a TCP connection to addr (host:port format).
 
+With --socks5 the connection is tunnelled through a SOCKS5 proxy. The
+password for --socks5-user is read from the CURE_SOCKS5_PASSWORD environment
+variable. Add --proxy-dns to have the proxy resolve addr's hostname.
+
+With --netns the trace runs inside a Linux network namespace, such as
+/proc/<pid>/ns/net for a process in a pod, so the lookup and connection use
+the namespace's interfaces, routes, and nameserver. It needs CAP_SYS_ADMIN.
+Only the TCP tracer supports --netns; the udp, dns, and http commands
+always trace from the host's namespace.
+
+With --keepalive the connection stays open and a 1-byte probe is sent every
+--interval seconds, emitting tcp_probe events and a final tcp_summary with the
+number of drops. Dropped connections are re-established before the next
+probe. Without --count the loop runs until Ctrl+C.
+
+With --tls a TLS handshake follows the connect and --data is sent over the
+encrypted connection. --starttls smtp or --starttls imap first asks the
+server to upgrade in-band, as mail servers with explicit TLS expect, and
+implies --tls.
+
+With --trace-retry the whole trace is run again, up to that many times in
+all, while it fails with a transient network error such as a refused
+connection, so a service that is still starting can be waited for.
+
+With --hexdump the tcp_send and tcp_receive events carry a hex and ASCII
+dump of the first --hexdump-bytes bytes (default 256) of --data and of the
+reply, for inspecting binary protocols.
+
+With --parse-http the --data bytes are treated as a raw HTTP/1.x request: the
+reply is read up to the end of its headers and an http_raw_response event
+reports the status code and header block size.
+
 Examples:
   cure trace tcp example.com:443
-  cure trace tcp --data "GET / HTTP/1.0\r\n\r\n" example.com:80`
+  cure trace tcp --data "GET / HTTP/1.0\r\n\r\n" example.com:80
+  cure trace tcp --parse-http --data "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n" example.com:80
+  cure trace tcp --rtt-probe example.com:443
+  cure trace tcp --hexdump --data $'*1\r\n$4\r\nPING\r\n' redis.internal:6379
+  cure trace tcp --tls --data "PING\r\n" redis.example.com:6380
+  cure trace tcp --starttls smtp --data "QUIT\r\n" mail.example.com:587
+  cure trace tcp --keepalive --interval 5 --count 10 example.com:443
+  cure trace tcp --socks5 127.0.0.1:1080 --proxy-dns db.internal:5432
+  cure trace tcp --trace-retry 10 localhost:8080
+  sudo cure trace tcp --netns /proc/4321/ns/net db.internal:5432`
 }
 
 func (c *TCPCommand) Flags() *flag.FlagSet {
 	fs := flag.NewFlagSet("trace-tcp", flag.ContinueOnError)
-	fs.StringVar(&c.format, "format", "json", "Output format (json, html)")
+	fs.StringVar(&c.format, "format", "json", formatUsage)
+	terminal.DescribeFlag(fs, "format", terminal.FlagMeta{Enum: formats})
+	fs.StringVar(&c.template, "template", "", templateUsage)
 	fs.StringVar(&c.outFile, "out-file", "", "Output file (default: stdout)")
+	fs.BoolVar(&c.gzip, "gzip", false, "Gzip-compress the --out-file output (adds .gz if missing)")
+	fs.DurationVar(&c.flushInterval, "flush-interval", 0, "With --format json, buffer output and flush it this often, e.g. 1s (0 = flush every event)")
+	fs.StringVar(&c.alsoHTML, "also-html", "", "Also write an HTML report to this file")
+	fs.BoolVar(&c.summary, "summary", false, "Print a one-line tally of events by type and the trace duration to stderr at the end")
 	fs.BoolVar(&c.dryRun, "dry-run", false, "Emit events without I/O")
 	fs.StringVar(&c.data, "data", "", "Data to send after connection")
 	fs.IntVar(&c.timeout, "timeout", 0, "Connection timeout in seconds")
+	fs.IntVar(&c.dnsTimeout, "dns-timeout", 0, "DNS lookup timeout in seconds (0 = no separate limit)")
+	fs.BoolVar(&c.rttProbe, "rtt-probe", false, "Measure round-trip time with a 1-byte probe after connecting")
+	fs.BoolVar(&c.parseHTTP, "parse-http", false, "Parse the reply to --data as an HTTP response")
+	fs.BoolVar(&c.hexdump, "hexdump", false, "Add a hex and ASCII dump of the sent and received bytes to the send and receive events")
+	fs.IntVar(&c.hexdumpBytes, "hexdump-bytes", 256, "Dump at most this many bytes of each payload with --hexdump")
+	fs.BoolVar(&c.tls, "tls", false, "Perform a TLS handshake after connecting and send --data over TLS")
+	fs.StringVar(&c.startTLS, "starttls", "", "Upgrade to TLS in-band first (implies --tls)")
+	terminal.DescribeFlag(fs, "starttls", terminal.FlagMeta{Enum: []string{"smtp", "imap"}})
+	fs.BoolVar(&c.keepAlive, "keepalive", false, "Keep the connection open and probe it periodically")
+	fs.IntVar(&c.interval, "interval", 5, "Seconds between keep-alive probes")
+	fs.IntVar(&c.count, "count", 0, "Number of keep-alive probes (0 = run until Ctrl+C)")
+	fs.BoolVar(&c.quiet, "quiet", false, "Suppress the progress indicator shown on stderr for keep-alive probes")
+	fs.StringVar(&c.socks5, "socks5", "", "Connect through the SOCKS5 proxy at host:port")
+	fs.StringVar(&c.socks5User, "socks5-user", "", "SOCKS5 username (password from CURE_SOCKS5_PASSWORD)")
+	fs.BoolVar(&c.proxyDNS, "proxy-dns", false, "Let the SOCKS5 proxy resolve the target hostname")
+	fs.Var(&c.labels, "label", labelUsage)
+	fs.StringVar(&c.netns, "netns", "", "Trace from the Linux network namespace at this path (e.g. /proc/<pid>/ns/net); TCP only")
+	fs.IntVar(&c.traceRetry, "trace-retry", 0, "Run the whole trace up to this many times while it fails with a transient network error")
 	return fs
 }
 
-func (c *TCPCommand) Run(ctx context.Context, tc *terminal.Context) error {
+func (c *TCPCommand) Run(ctx context.Context, tc *terminal.Context) (runErr error) {
 	if len(tc.Args) == 0 {
 		return fmt.Errorf("missing address argument (host:port)")
 	}
 	addr := tc.Args[0]
 
-	// Merge flags with config
-	format := c.format
-	if format == "" && tc.Config != nil {
-		format = tc.Config.Get("format", "json").(string)
-	}
+	tmpl := outputTemplate(tc, c.format, c.template)
 
-	// Create emitter
-	var em event.Emitter
+	if c.gzip && c.outFile == "" {
+		return fmt.Errorf("--gzip requires --out-file")
+	}
 	var outW io.Writer = tc.Stdout
 	if c.outFile != "" {
-		f, err := os.Create(c.outFile)
+		f, err := createOutFile(c.outFile, c.gzip)
 		if err != nil {
 			return fmt.Errorf("failed to create output file: %w", err)
 		}
-		defer f.Close()
+		defer closeOutFile(f, &runErr)
 		outW = f
 	}
 
-	switch format {
-	case "json":
-		em = formatter.NewNDJSONEmitter(outW)
-	case "html":
-		em = formatter.NewHTMLEmitter(outW)
-	default:
-		return fmt.Errorf("unsupported format: %s", format)
+	em, err := newEmitter(c.format, tmpl, outW, tc.Stderr, c.flushInterval)
+	if err != nil {
+		return err
+	}
+	if em, err = withAlsoHTML(em, c.alsoHTML); err != nil {
+		return err
 	}
+	em = withSummary(em, c.summary, tc.Stderr)
 	defer em.Close()
 
-	// Build tracer options
+	if c.keepAlive {
+		var stop func()
+		em, stop = withProgress(tc, c.quiet, "tcp", "tcp_probe", c.count, em)
+		defer stop()
+	}
+	return c.trace(ctx, tc, addr, em)
+}
+
+// trace runs the TCP tracer against addr, emitting events to em.
+func (c *TCPCommand) trace(ctx context.Context, _ *terminal.Context, addr string, em event.Emitter) error {
+	if c.keepAlive && c.interval < 1 {
+		return fmt.Errorf("--interval must be 1 or greater, got %d", c.interval)
+	}
+	if c.count < 0 {
+		return fmt.Errorf("--count must be 0 (infinite) or greater, got %d", c.count)
+	}
+	if c.dnsTimeout < 0 {
+		return fmt.Errorf("--dns-timeout must be 0 or greater, got %d", c.dnsTimeout)
+	}
+	if c.traceRetry < 0 {
+		return fmt.Errorf("--trace-retry must be 0 or greater, got %d", c.traceRetry)
+	}
+
 	opts := []tcp.Option{
 		tcp.WithEmitter(em),
 		tcp.WithDryRun(c.dryRun),
+		tcp.WithRTTProbe(c.rttProbe),
+		tcp.WithParseHTTP(c.parseHTTP),
+		tcp.WithTLS(c.tls),
+		tcp.WithStartTLS(c.startTLS),
+		tcp.WithLabels(c.labels.toMap()),
+		tcp.WithHexdump(c.hexdump),
+		tcp.WithHexdumpBytes(c.hexdumpBytes),
 	}
 	if c.data != "" {
 		opts = append(opts, tcp.WithDataString(c.data))
 	}
+	if c.socks5 != "" {
+		var auth *tcp.ProxyAuth
+		if c.socks5User != "" {
+			auth = &tcp.ProxyAuth{User: c.socks5User, Password: os.Getenv("CURE_SOCKS5_PASSWORD")}
+		}
+		opts = append(opts, tcp.WithSOCKS5(c.socks5, auth), tcp.WithProxyDNS(c.proxyDNS))
+	}
+	if c.netns != "" {
+		opts = append(opts, tcp.WithNetns(c.netns))
+	}
+	if c.keepAlive {
+		opts = append(opts, tcp.WithKeepAlive(time.Duration(c.interval)*time.Second, c.count))
+	}
 	if c.timeout > 0 {
 		opts = append(opts, tcp.WithTimeout(time.Duration(c.timeout)*time.Second))
 	}
+	if c.dnsTimeout > 0 {
+		opts = append(opts, tcp.WithDNSTimeout(time.Duration(c.dnsTimeout)*time.Second))
+	}
+	if c.traceRetry > 1 {
+		opts = append(opts, tcp.WithTraceRetry(c.traceRetry))
+	}
 
 	return tcp.TraceAddr(ctx, addr, opts...)
 }
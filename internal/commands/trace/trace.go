@@ -1,10 +1,20 @@
 package trace
 
 import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/mrlm-net/cure/pkg/terminal"
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+	"github.com/mrlm-net/cure/pkg/tracer/formatter"
 )
 
-// NewTraceCommand creates the trace command group with http/tcp/udp/dns subcommands.
+// NewTraceCommand creates the trace command group with http/tcp/udp/dns/batch/replay/selftest subcommands.
 func NewTraceCommand() terminal.Command {
 	router := terminal.New(
 		terminal.WithName("trace"),
@@ -14,5 +24,195 @@ func NewTraceCommand() terminal.Command {
 	router.Register(&TCPCommand{})
 	router.Register(&UDPCommand{})
 	router.Register(&DNSCommand{})
+	router.Register(&BatchCommand{})
+	router.Register(&ReplayCommand{})
+	router.Register(&SelftestCommand{})
 	return router
 }
+
+// formats are the --format values newEmitter accepts.
+var formats = []string{"json", "json-array", "html", "template"}
+
+// formatUsage is the usage of the --format flag, naming the formats.
+var formatUsage = "Output format (" + strings.Join(formats, ", ") + ")"
+
+// newEmitter returns the emitter for the named output format writing to w.
+// A flushInterval above zero, from --flush-interval, buffers NDJSON output
+// and flushes it at that cadence; it is rejected for other formats. tmpl,
+// from --template, is the per-event template of the "template" format and
+// is required by it and rejected otherwise; its errors are reported to errW.
+func newEmitter(format, tmpl string, w, errW io.Writer, flushInterval time.Duration) (event.Emitter, error) {
+	if tmpl != "" && format != "template" {
+		return nil, fmt.Errorf("--template requires --format template, got %s", format)
+	}
+	if flushInterval < 0 {
+		return nil, fmt.Errorf("--flush-interval must be 0 or greater, got %s", flushInterval)
+	}
+	if flushInterval > 0 && format != "json" {
+		return nil, fmt.Errorf("--flush-interval requires --format json, got %s", format)
+	}
+	switch format {
+	case "json":
+		if flushInterval == 0 {
+			return formatter.NewNDJSONEmitter(w), nil
+		}
+		// A gzip stream buffers by itself, so the interval flushes it
+		// directly; anything else, such as stdout, gets a buffer so the
+		// interval has something to flush.
+		switch f := w.(type) {
+		case *gzipFile:
+			w = f.zw
+		case interface{ Flush() error }:
+		default:
+			w = bufio.NewWriter(w)
+		}
+		return formatter.NewNDJSONEmitter(w, formatter.WithFlushInterval(flushInterval)), nil
+	case "json-array":
+		return formatter.NewJSONArrayEmitter(w), nil
+	case "html":
+		return formatter.NewHTMLEmitter(w), nil
+	case "template":
+		if tmpl == "" {
+			return nil, fmt.Errorf("--format template requires --template")
+		}
+		em, err := formatter.NewTemplateEmitter(w, tmpl, errW)
+		if err != nil {
+			return nil, fmt.Errorf("--template: %w", err)
+		}
+		return em, nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// withAlsoHTML returns em with every event also written to an HTML report
+// at path, for --also-html, or em itself when path is empty. Closing the
+// returned emitter renders the report and closes its file.
+func withAlsoHTML(em event.Emitter, path string) (event.Emitter, error) {
+	if path == "" {
+		return em, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTML report file: %w", err)
+	}
+	return &htmlReport{Emitter: formatter.MultiEmitter(em, formatter.NewHTMLEmitter(f)), f: f}, nil
+}
+
+// withSummary returns em with a one-line summary written to w when the trace
+// ends, for --summary, or em itself when summary is false.
+func withSummary(em event.Emitter, summary bool, w io.Writer) event.Emitter {
+	if !summary {
+		return em
+	}
+	return formatter.NewSummaryEmitter(em, w)
+}
+
+// htmlReport is an emitter teeing to an HTML report in a file it owns.
+type htmlReport struct {
+	event.Emitter
+	f *os.File
+}
+
+// Close closes the emitters, rendering the report, then closes the file.
+func (r *htmlReport) Close() error {
+	err := r.Emitter.Close()
+	if cerr := r.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// createOutFile creates the --out-file output at path. With gzipped set the
+// output is gzip-compressed and ".gz" is appended to path unless it already
+// ends that way; closing the returned writer flushes the gzip stream before
+// closing the file. The gzip writer has no Flush method, so an NDJSON
+// emitter does not flush, and worsen the compression, after every event.
+func createOutFile(path string, gzipped bool) (io.WriteCloser, error) {
+	if gzipped && !strings.HasSuffix(path, ".gz") {
+		path += ".gz"
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if !gzipped {
+		return f, nil
+	}
+	return &gzipFile{zw: gzip.NewWriter(f), f: f}, nil
+}
+
+// closeOutFile closes f, the --out-file output, and stores the error in
+// *errp unless it already holds one, so output that failed to flush, such
+// as a truncated gzip stream, fails the command. Defer it before the
+// emitter's Close so the emitter writes its last output first.
+func closeOutFile(f io.Closer, errp *error) {
+	if err := f.Close(); err != nil && *errp == nil {
+		*errp = fmt.Errorf("failed to close output file: %w", err)
+	}
+}
+
+// gzipFile is a gzip stream written to a file it owns.
+type gzipFile struct {
+	zw *gzip.Writer
+	f  *os.File
+}
+
+// Write compresses p into the gzip stream.
+func (g *gzipFile) Write(p []byte) (int, error) { return g.zw.Write(p) }
+
+// Close flushes and closes the gzip stream, then closes the file.
+func (g *gzipFile) Close() error {
+	err := g.zw.Close()
+	if cerr := g.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// labelFlags is a custom flag type for repeatable --label key=value flags,
+// shared by the trace subcommands.
+type labelFlags []string
+
+func (l *labelFlags) String() string { return "" }
+
+// Get returns the key=value labels given, one per --label.
+func (l *labelFlags) Get() any { return []string(*l) }
+
+func (l *labelFlags) Set(value string) error {
+	key, _, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("want key=value")
+	}
+	*l = append(*l, value)
+	return nil
+}
+
+// toMap returns the labels, or nil if there are none. A later label with the
+// same key wins.
+func (l labelFlags) toMap() map[string]string {
+	if len(l) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(l))
+	for _, label := range l {
+		key, value, _ := strings.Cut(label, "=")
+		m[key] = value
+	}
+	return m
+}
+
+// labelUsage is the usage of the --label flag.
+const labelUsage = "Attach a key=value label to every event (repeatable)"
+
+// templateUsage is the usage of the --template flag.
+const templateUsage = `Go template rendered per event with --format template (default: config "template"), e.g. '{{.Type}} {{index .Data "duration_ms"}}ms'`
+
+// outputTemplate returns the template to use with format: tmpl when set,
+// otherwise, for the template format, the config's "template".
+func outputTemplate(tc *terminal.Context, format, tmpl string) string {
+	if tmpl == "" && format == "template" && tc.Config != nil {
+		tmpl, _ = tc.Config.Get("template", "").(string)
+	}
+	return tmpl
+}
@@ -2,13 +2,24 @@ package trace
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/mrlm-net/cure/internal/commands"
 	"github.com/mrlm-net/cure/pkg/config"
 	"github.com/mrlm-net/cure/pkg/terminal"
 	"github.com/mrlm-net/cure/pkg/tracer/event"
@@ -96,224 +107,1968 @@ func TestHTTPCommand_OutputFile(t *testing.T) {
 	// but we can check that no error occurred
 }
 
-func TestTCPCommand_Run(t *testing.T) {
-	var stdout bytes.Buffer
-	cfg := config.NewConfig(config.ConfigObject{
-		"format": "json",
-	})
+func TestHTTPCommand_AlsoHTML(t *testing.T) {
+	reportFile := filepath.Join(t.TempDir(), "report.html")
 
+	var stdout bytes.Buffer
 	tc := &terminal.Context{
-		Args:   []string{"example.com:443"},
+		Args:   []string{"https://example.com"},
 		Stdout: &stdout,
 		Stderr: &bytes.Buffer{},
-		Config: cfg,
+		Config: config.NewConfig(),
 	}
 
-	cmd := &TCPCommand{
-		dryRun: true, // Use dry-run to avoid actual connection
+	cmd := &HTTPCommand{}
+	if err := cmd.Flags().Parse([]string{"--dry-run", "--also-html", reportFile}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
 	}
-	cmd.Flags().Parse([]string{})
-
-	err := cmd.Run(context.Background(), tc)
-	if err != nil {
+	if err := cmd.Run(context.Background(), tc); err != nil {
 		t.Fatalf("Run() error = %v", err)
 	}
 
-	// Validate output
-	if stdout.Len() == 0 {
-		t.Error("expected output, got none")
+	if !strings.Contains(stdout.String(), `"type":"http_request_start"`) {
+		t.Errorf("stdout missing NDJSON events, got %q", stdout.String())
+	}
+	report, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(report), "Network Trace Report") {
+		t.Error("HTML report missing title")
+	}
+	if !strings.Contains(string(report), "http_request_start") {
+		t.Error("HTML report missing events")
 	}
 }
 
-func TestUDPCommand_Run(t *testing.T) {
-	var stdout bytes.Buffer
-	cfg := config.NewConfig(config.ConfigObject{
-		"format": "json",
-	})
+func TestHTTPCommand_Run_UserAgent(t *testing.T) {
+	got := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.UserAgent()
+	}))
+	defer ts.Close()
 
 	tc := &terminal.Context{
-		Args:   []string{"1.1.1.1:53"},
-		Stdout: &stdout,
+		Args:   []string{ts.URL},
+		Stdout: &bytes.Buffer{},
 		Stderr: &bytes.Buffer{},
-		Config: cfg,
-	}
-
-	cmd := &UDPCommand{
-		dryRun: true, // Use dry-run to avoid actual connection
+		Config: config.NewConfig(),
 	}
+	cmd := &HTTPCommand{}
 	cmd.Flags().Parse([]string{})
-
-	err := cmd.Run(context.Background(), tc)
-	if err != nil {
+	if err := cmd.Run(context.Background(), tc); err != nil {
 		t.Fatalf("Run() error = %v", err)
 	}
-
-	// Validate output
-	if stdout.Len() == 0 {
-		t.Error("expected output, got none")
+	if ua, want := <-got, "cure/"+commands.Version; ua != want {
+		t.Errorf("User-Agent = %q, want %q", ua, want)
 	}
 }
 
-func TestDNSCommand_Run_DryRun(t *testing.T) {
-	var stdout bytes.Buffer
-	cfg := config.NewConfig(config.ConfigObject{
-		"format": "json",
-	})
+func TestHTTPCommand_Run_Resolve(t *testing.T) {
+	var gotHost string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
 
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+	var stdout bytes.Buffer
 	tc := &terminal.Context{
-		Args:   []string{"example.com"},
+		Args:   []string{"http://backend.invalid:" + port + "/"},
 		Stdout: &stdout,
 		Stderr: &bytes.Buffer{},
-		Config: cfg,
+		Config: config.NewConfig(),
 	}
-
-	cmd := &DNSCommand{
-		dryRun: true,
-		count:  1,
+	cmd := &HTTPCommand{}
+	cmd.Flags().Parse([]string{"--resolve", "backend.invalid:127.0.0.1"})
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
 	}
-	cmd.Flags().Parse([]string{})
+	if gotHost != "backend.invalid:"+port {
+		t.Errorf("Host = %q, want %q", gotHost, "backend.invalid:"+port)
+	}
+	if !strings.Contains(stdout.String(), `"dns_override"`) {
+		t.Error("output has no dns_override event")
+	}
+}
 
-	err := cmd.Run(context.Background(), tc)
-	if err != nil {
+func TestHTTPCommand_Run_HostHeader(t *testing.T) {
+	var gotHost string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	var stdout bytes.Buffer
+	tc := &terminal.Context{
+		Args:   []string{ts.URL + "/"},
+		Stdout: &stdout,
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	cmd := &HTTPCommand{}
+	cmd.Flags().Parse([]string{"--host-header", "shop.example.com"})
+	if err := cmd.Run(context.Background(), tc); err != nil {
 		t.Fatalf("Run() error = %v", err)
 	}
-
-	if stdout.Len() == 0 {
-		t.Error("expected output, got none")
+	if gotHost != "shop.example.com" {
+		t.Errorf("Host = %q, want shop.example.com", gotHost)
 	}
-
-	// Validate NDJSON: 2 events (dns_query_start + dns_query_done)
-	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
-	if len(lines) != 2 {
-		t.Fatalf("got %d lines, want 2", len(lines))
+	if !strings.Contains(stdout.String(), `"host":"shop.example.com"`) {
+		t.Errorf("http_request_start does not report the Host sent, got:\n%s", stdout.String())
 	}
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		var ev event.Event
-		if err := json.Unmarshal([]byte(line), &ev); err != nil {
-			t.Fatalf("json.Unmarshal() error = %v", err)
-		}
-		if ev.Type == "" {
-			t.Error("event Type is empty")
+}
+
+func TestHTTPCommand_Run_ResolveInvalid(t *testing.T) {
+	for _, value := range []string{"example.com", ":127.0.0.1", "example.com:nope"} {
+		tc := &terminal.Context{
+			Args:   []string{"http://example.com"},
+			Stdout: &bytes.Buffer{},
+			Stderr: &bytes.Buffer{},
+			Config: config.NewConfig(),
 		}
-		if ev.TraceID == "" {
-			t.Error("event TraceID is empty")
+		cmd := &HTTPCommand{}
+		cmd.Flags().Parse([]string{"--dry-run", "--resolve", value})
+		if err := cmd.Run(context.Background(), tc); err == nil {
+			t.Errorf("--resolve %q: Run() error = nil, want error", value)
 		}
 	}
 }
 
-func TestDNSCommand_Run_MissingHostname(t *testing.T) {
+func TestHTTPCommand_Run_DumpHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "abc123")
+		w.Header().Add("Vary", "Accept")
+		w.Header().Add("Vary", "Origin")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "headers.txt")
+	var stdout bytes.Buffer
 	tc := &terminal.Context{
-		Args:   []string{},
-		Stdout: &bytes.Buffer{},
+		Args:   []string{ts.URL},
+		Stdout: &stdout,
 		Stderr: &bytes.Buffer{},
 		Config: config.NewConfig(),
 	}
-	cmd := &DNSCommand{count: 1}
-	cmd.Flags().Parse([]string{})
-	err := cmd.Run(context.Background(), tc)
-	if err == nil {
-		t.Fatal("expected error for missing hostname, got nil")
+	cmd := &HTTPCommand{}
+	cmd.Flags().Parse([]string{"--dump-headers", path})
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
 	}
-}
 
-func TestDNSCommand_Run_InvalidCount(t *testing.T) {
-	tc := &terminal.Context{
-		Args:   []string{"example.com"},
-		Stdout: &bytes.Buffer{},
-		Stderr: &bytes.Buffer{},
-		Config: config.NewConfig(),
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
 	}
-	cmd := &DNSCommand{}
-	cmd.Flags().Parse([]string{"--count=-1"})
-	err := cmd.Run(context.Background(), tc)
-	if err == nil {
-		t.Fatal("expected error for count=-1, got nil")
+	got := string(data)
+	for _, want := range []string{
+		"X-Request-Id: abc123\n",
+		"Vary: Accept\nVary: Origin\n",
+		"Set-Cookie: [REDACTED]\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("headers file missing %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "secret") {
+		t.Errorf("headers file leaks a redacted value:\n%s", got)
+	}
+	if !strings.Contains(stdout.String(), `"http_response_done"`) {
+		t.Error("events no longer written to stdout")
 	}
 }
 
-func TestDNSCommand_Run_InvalidServer(t *testing.T) {
-	tc := &terminal.Context{
-		Args:   []string{"example.com"},
-		Stdout: &bytes.Buffer{},
-		Stderr: &bytes.Buffer{},
-		Config: config.NewConfig(),
+func TestHTTPCommand_Run_InvalidFlagsCreateNoFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "negative timeout", args: []string{"--timeout", "-1"}, wantErr: "--timeout"},
+		{name: "bad assertion", args: []string{"--assert", "status~200"}, wantErr: "status~200"},
+		{name: "bad fail-on", args: []string{"--fail-on", "latency>1"}, wantErr: "latency>1"},
+		{name: "bad retry-on without retry", args: []string{"--retry-on", "429,5O3"}, wantErr: "--retry-on"},
 	}
-	cmd := &DNSCommand{}
-	cmd.Flags().Parse([]string{"--server=not-an-ip"})
-	err := cmd.Run(context.Background(), tc)
-	if err == nil {
-		t.Fatal("expected error for hostname --server value, got nil")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			out := filepath.Join(dir, "out.ndjson")
+			headers := filepath.Join(dir, "headers.txt")
+			tc := &terminal.Context{Args: []string{"https://example.com"}, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+			cmd := &HTTPCommand{}
+			args := append([]string{"--dry-run", "--out-file", out, "--dump-headers", headers}, tt.args...)
+			if err := cmd.Flags().Parse(args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if err := cmd.Run(context.Background(), tc); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Run() error = %v, want error containing %q", err, tt.wantErr)
+			}
+			for _, path := range []string{out, headers} {
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("Stat(%s) error = %v, want not exist", filepath.Base(path), err)
+				}
+			}
+		})
 	}
 }
 
-func TestNormalizeServer(t *testing.T) {
+func TestHTTPCommand_Run_PrintCurl(t *testing.T) {
 	tests := []struct {
 		name    string
-		input   string
-		want    string
-		wantErr bool
+		args    []string
+		want    []string
+		notWant []string
 	}{
-		{"plain IPv4 — port defaults to 53", "8.8.8.8", "8.8.8.8:53", false},
-		{"IPv4 with port", "8.8.8.8:53", "8.8.8.8:53", false},
-		{"IPv4 with custom port", "1.1.1.1:5353", "1.1.1.1:5353", false},
-		{"IPv6 with port and brackets", "[::1]:53", "[::1]:53", false},
-		{"hostname without port — rejected", "dns.google", "", true},
-		{"hostname with port — rejected", "dns.google:53", "", true},
-		{"bare IPv6 — rejected (ambiguous colons)", "::1", "", true},
+		{
+			name: "redacted",
+			args: []string{"--dry-run", "--print-curl", "--method", "POST", "--data", `{"k":"it's"}`, "-H", "Authorization: Bearer s3cret"},
+			want: []string{
+				"-H 'Authorization: [REDACTED]'",
+				`--data-raw '{"k":"it'\''s"}'`,
+				" 'https://example.com/a?b=1'\n",
+			},
+			notWant: []string{"s3cret", "-X "},
+		},
+		{
+			name: "unredacted",
+			args: []string{"--dry-run", "--print-curl", "--redact=false", "-H", "Authorization: Bearer s3cret", "--resolve", "example.com:127.0.0.1"},
+			want: []string{
+				"-H 'Authorization: Bearer s3cret'",
+				"--resolve example.com:443:127.0.0.1",
+			},
+			notWant: []string{"-X "},
+		},
+		{
+			name: "host header",
+			args: []string{"--dry-run", "--print-curl", "--host-header", "shop.example.com"},
+			want: []string{"-H 'Host: shop.example.com'"},
+		},
+		{
+			name: "GET with data",
+			args: []string{"--dry-run", "--print-curl", "--data", "q=1"},
+			want: []string{"curl -X GET ", "--data-raw q=1"},
+		},
+		{
+			name: "GET with form",
+			args: []string{"--dry-run", "--print-curl", "--form", "q=1"},
+			want: []string{"warning: --form sends a request body with GET", "curl -X GET ", "--data-urlencode q=1"},
+		},
+		{
+			name:    "POST with form",
+			args:    []string{"--dry-run", "--print-curl", "--method", "POST", "--form", "q=1"},
+			want:    []string{"--data-urlencode q=1"},
+			notWant: []string{"warning:", "-X "},
+		},
+		{
+			name:    "POST with data",
+			args:    []string{"--dry-run", "--print-curl", "--method", "POST", "--data", "q=1"},
+			notWant: []string{"-X "},
+		},
+		{
+			name: "default redirects",
+			args: []string{"--dry-run", "--print-curl"},
+			want: []string{"-L --max-redirs 10 "},
+		},
+		{
+			name:    "redirects disabled",
+			args:    []string{"--dry-run", "--print-curl", "--max-redirects", "0"},
+			notWant: []string{"-L ", "--max-redirs"},
+		},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := normalizeServer(tt.input)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("normalizeServer(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
-				return
+			var stderr bytes.Buffer
+			tc := &terminal.Context{
+				Args:   []string{"https://example.com/a?b=1"},
+				Stdout: &bytes.Buffer{},
+				Stderr: &stderr,
+				Config: config.NewConfig(),
 			}
-			if !tt.wantErr && got != tt.want {
-				t.Errorf("normalizeServer(%q) = %q, want %q", tt.input, got, tt.want)
+			cmd := &HTTPCommand{}
+			cmd.Flags().Parse(tt.args)
+			if err := cmd.Run(context.Background(), tc); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			got := stderr.String()
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("curl line missing %q, got: %s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("curl line contains %q, got: %s", notWant, got)
+				}
 			}
 		})
 	}
 }
 
-func TestTrace_E2E(t *testing.T) {
-	// Start local HTTP server
+func TestHTTPCommand_Run_Retry(t *testing.T) {
+	var served int
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(200)
-		w.Write([]byte("ok"))
+		served++
+		if served == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
 	}))
 	defer ts.Close()
 
-	// Run cure trace http <url>
 	var stdout bytes.Buffer
-	router := terminal.New(
-		terminal.WithStdout(&stdout),
-		terminal.WithConfig(config.NewConfig()),
-	)
-	router.Register(NewTraceCommand())
-
-	err := router.RunArgs([]string{"trace", "http", ts.URL})
-	if err != nil {
-		t.Fatalf("RunArgs() error = %v", err)
+	tc := &terminal.Context{
+		Args:   []string{ts.URL},
+		Stdout: &stdout,
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	cmd := &HTTPCommand{}
+	cmd.Flags().Parse([]string{"--retry", "2"})
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if served != 2 {
+		t.Errorf("server got %d requests, want 2", served)
 	}
+	if !strings.Contains(stdout.String(), `"http_retry"`) {
+		t.Errorf("output has no http_retry event:\n%s", stdout.String())
+	}
+}
 
-	// Validate NDJSON output
-	for line := range strings.SplitSeq(strings.TrimSpace(stdout.String()), "\n") {
-		if line == "" {
-			continue
+func TestHTTPCommand_Run_RetryInvalid(t *testing.T) {
+	for _, args := range [][]string{
+		{"--retry", "-1"},
+		{"--retry", "1", "--retry-on", "429,abc"},
+		{"--retry", "1", "--retry-on", "42"},
+		{"--trace-retry", "-1"},
+	} {
+		tc := &terminal.Context{
+			Args:   []string{"http://example.com"},
+			Stdout: &bytes.Buffer{},
+			Stderr: &bytes.Buffer{},
+			Config: config.NewConfig(),
 		}
+		cmd := &HTTPCommand{}
+		cmd.Flags().Parse(append([]string{"--dry-run"}, args...))
+		if err := cmd.Run(context.Background(), tc); err == nil {
+			t.Errorf("%v: Run() error = nil, want error", args)
+		}
+	}
+}
+
+func TestHTTPCommand_Run_Labels(t *testing.T) {
+	var stdout bytes.Buffer
+	tc := &terminal.Context{Args: []string{"https://example.com"}, Stdout: &stdout, Stderr: &bytes.Buffer{}, Config: config.NewConfig()}
+	cmd := &HTTPCommand{}
+	if err := cmd.Flags().Parse([]string{"--dry-run", "--label", "env=prod", "--label", "region=eu", "--label", "env=staging"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	for _, line := range lines {
 		var ev event.Event
 		if err := json.Unmarshal([]byte(line), &ev); err != nil {
 			t.Fatalf("json.Unmarshal() error = %v, line = %q", err, line)
 		}
-		if ev.Type == "" {
-			t.Error("event Type is empty")
+		if ev.Labels["env"] != "staging" || ev.Labels["region"] != "eu" || len(ev.Labels) != 2 {
+			t.Errorf("%s labels = %v, want env=staging (last wins) and region=eu", ev.Type, ev.Labels)
 		}
-		if ev.TraceID == "" {
-			t.Error("event TraceID is empty")
+	}
+
+	for _, bad := range []string{"env", "=prod"} {
+		fs := (&HTTPCommand{}).Flags()
+		fs.SetOutput(io.Discard)
+		if err := fs.Parse([]string{"--label", bad}); err == nil {
+			t.Errorf("Parse(--label %q) error = nil, want key=value error", bad)
+		}
+	}
+}
+
+func TestHTTPCommand_Run_MaxTimePerPhase(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
 		}
+	}))
+	defer ts.Close()
+
+	var stdout bytes.Buffer
+	tc := &terminal.Context{Args: []string{ts.URL}, Stdout: &stdout, Stderr: &bytes.Buffer{}, Config: config.NewConfig()}
+	cmd := &HTTPCommand{}
+	if err := cmd.Flags().Parse([]string{"--max-time-per-phase", "50ms"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := cmd.Run(context.Background(), tc); err == nil {
+		t.Fatal("Run() error = nil, want a ttfb phase timeout")
+	}
+	if !strings.Contains(stdout.String(), `"type":"phase_timeout"`) || !strings.Contains(stdout.String(), `"phase":"ttfb"`) {
+		t.Errorf("output has no ttfb phase_timeout event:\n%s", stdout.String())
+	}
+
+	neg := &HTTPCommand{}
+	if err := neg.Flags().Parse([]string{"--max-time-per-phase", "-1s"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	tc = &terminal.Context{Args: []string{ts.URL}, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Config: config.NewConfig()}
+	if err := neg.Run(context.Background(), tc); err == nil || !strings.Contains(err.Error(), "--max-time-per-phase") {
+		t.Errorf("Run() error = %v, want --max-time-per-phase validation error", err)
+	}
+}
+
+func TestHTTPCommand_Run_Summary(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	tc := &terminal.Context{
+		Args:   []string{"https://example.com"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Config: config.NewConfig(),
+	}
+	cmd := &HTTPCommand{}
+	cmd.Flags().Parse([]string{"--dry-run", "--summary"})
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	counts := map[string]int{}
+	total := 0
+	dec := json.NewDecoder(&stdout)
+	for dec.More() {
+		var ev event.Event
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		counts[ev.Type]++
+		total++
+	}
+
+	line := strings.TrimSpace(stderr.String())
+	if strings.Count(stderr.String(), "\n") != 1 {
+		t.Fatalf("stderr = %q, want one summary line", stderr.String())
+	}
+	head, tally, ok := strings.Cut(line, ": ")
+	if !ok || !strings.HasPrefix(head, fmt.Sprintf("%d events in ", total)) {
+		t.Fatalf("summary = %q, want it to start with %d events", line, total)
+	}
+	got := map[string]int{}
+	for _, field := range strings.Fields(tally) {
+		typ, n, _ := strings.Cut(field, "=")
+		var count int
+		fmt.Sscan(n, &count)
+		got[typ] = count
+	}
+	if !maps.Equal(got, counts) {
+		t.Errorf("summary counts = %v, want %v", got, counts)
+	}
+}
+
+func TestHTTPCommand_Run_Compare(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	var stdout bytes.Buffer
+	tc := &terminal.Context{
+		Args:   []string{ts.URL + "/a"},
+		Stdout: &stdout,
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	cmd := &HTTPCommand{}
+	cmd.Flags().Parse([]string{"-H", "X-Side: a", "--compare", "-H 'X-Extra: b' --method HEAD " + ts.URL + "/b"})
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(cmd.headers) != 1 || cmd.method != "GET" {
+		t.Errorf("side a settings changed: headers = %q, method = %q", cmd.headers, cmd.method)
+	}
+
+	var runID string
+	methods := map[string]string{}
+	var cmp *event.Event
+	dec := json.NewDecoder(&stdout)
+	for dec.More() {
+		var ev event.Event
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		if ev.Type == TraceCompare {
+			cmp = &ev
+			continue
+		}
+		id, _ := ev.Data["run_id"].(string)
+		if runID == "" {
+			runID = id
+		}
+		if id == "" || id != runID {
+			t.Errorf("%s event run_id = %q, want shared %q", ev.Type, id, runID)
+		}
+		side, _ := ev.Data["side"].(string)
+		if ev.Type == "http_request_start" {
+			methods[side], _ = ev.Data["method"].(string)
+		}
+	}
+	if methods["a"] != "GET" || methods["b"] != "HEAD" {
+		t.Errorf("request methods = %v, want a=GET b=HEAD", methods)
+	}
+	if cmp == nil {
+		t.Fatal("no trace_compare event")
+	}
+	if cmp.TraceID != runID || cmp.Data["run_id"] != runID {
+		t.Errorf("trace_compare run ID = %q/%v, want %q", cmp.TraceID, cmp.Data["run_id"], runID)
+	}
+	b, _ := cmp.Data["b"].(map[string]interface{})
+	if b["url"] != ts.URL+"/b" || b["ok"] != true {
+		t.Errorf("trace_compare b = %v", b)
+	}
+	phases, _ := cmp.Data["phases"].(map[string]interface{})
+	if len(phases) == 0 {
+		t.Fatal("trace_compare has no phases")
+	}
+	for name, p := range phases {
+		d, _ := p.(map[string]interface{})
+		if d["delta_ms"] != d["b"].(float64)-d["a"].(float64) {
+			t.Errorf("phase %s delta = %v, want b - a", name, d)
+		}
+	}
+}
+
+func TestHTTPCommand_CompareTarget(t *testing.T) {
+	cmd := &HTTPCommand{}
+	if err := cmd.Flags().Parse([]string{
+		"-H", "X-Side: a", "--label", "env=prod", "--timeout", "7", "--no-keepalive",
+		"--compare", "-H 'X-Extra: b' --method HEAD",
+	}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	b, url, err := cmd.compareTarget(&terminal.Context{Stderr: io.Discard}, "http://a.example.com")
+	if err != nil {
+		t.Fatalf("compareTarget() error = %v", err)
+	}
+	if url != "http://a.example.com" {
+		t.Errorf("url = %q, want side a's", url)
+	}
+	if !slices.Equal(b.headers, headerFlags{"X-Side: a", "X-Extra: b"}) || !slices.Equal(b.labels, labelFlags{"env=prod"}) {
+		t.Errorf("side b headers = %q, labels = %q, want a's plus its own", b.headers, b.labels)
+	}
+	if b.method != "HEAD" || b.timeout != 7 || !b.noKeepAlive || b.compare != "" {
+		t.Errorf("side b = method %q timeout %d no-keepalive %v compare %q", b.method, b.timeout, b.noKeepAlive, b.compare)
+	}
+	if len(cmd.headers) != 1 || cmd.method != "GET" {
+		t.Errorf("side a changed: headers = %q, method = %q", cmd.headers, cmd.method)
+	}
+}
+
+func TestHTTPCommand_Run_CompareInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		url  string
+	}{
+		{"stdin", []string{"--compare", "--no-keepalive"}, "-"},
+		{"count", []string{"--count", "3", "--compare", "--no-keepalive"}, "http://example.com"},
+		{"count in spec", []string{"--compare", "--count 2"}, "http://example.com"},
+		{"two urls", []string{"--compare", "http://a.example.com http://b.example.com"}, "http://example.com"},
+		{"unknown flag", []string{"--compare", "--bogus"}, "http://example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := &terminal.Context{
+				Args:   []string{tt.url},
+				Stdin:  strings.NewReader(""),
+				Stdout: &bytes.Buffer{},
+				Stderr: &bytes.Buffer{},
+				Config: config.NewConfig(),
+			}
+			cmd := &HTTPCommand{}
+			cmd.Flags().Parse(tt.args)
+			if err := cmd.Run(context.Background(), tc); err == nil {
+				t.Error("Run() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestHTTPCommand_Run_Stdin(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	urls := []string{ts.URL + "/a", ts.URL + "/b"}
+	var stdout bytes.Buffer
+	tc := &terminal.Context{
+		Args:   []string{"-"},
+		Stdin:  strings.NewReader("# health checks\n" + urls[0] + "\n\n  " + urls[1] + "\n"),
+		Stdout: &stdout,
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	cmd := &HTTPCommand{}
+	cmd.Flags().Parse([]string{})
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	done := map[string]bool{}
+	dec := json.NewDecoder(&stdout)
+	for dec.More() {
+		var ev event.Event
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		target, _ := ev.Data["target"].(string)
+		if target == "" {
+			t.Errorf("%s event has no target", ev.Type)
+		}
+		if ev.Type == "http_response_done" {
+			done[target] = true
+		}
+	}
+	for _, u := range urls {
+		if !done[u] {
+			t.Errorf("no http_response_done for %s", u)
+		}
+	}
+}
+
+func TestHTTPCommand_Run_StdinEmpty(t *testing.T) {
+	tc := &terminal.Context{
+		Args:   []string{"-"},
+		Stdin:  strings.NewReader("\n# nothing here\n"),
+		Stdout: &bytes.Buffer{},
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	cmd := &HTTPCommand{}
+	cmd.Flags().Parse([]string{"--dry-run"})
+	if err := cmd.Run(context.Background(), tc); err == nil || !strings.Contains(err.Error(), "no URLs") {
+		t.Errorf("Run() error = %v, want no URLs read from stdin", err)
+	}
+}
+
+func TestHTTPCommand_Run_InvalidTimeout(t *testing.T) {
+	for _, args := range [][]string{{"--connect-timeout=-1"}, {"--timeout=-1"}, {"--max-redirects=-1"}, {"--jitter=1.5"}, {"--jitter=-0.1"}} {
+		tc := &terminal.Context{
+			Args:   []string{"https://example.com"},
+			Stdout: &bytes.Buffer{},
+			Stderr: &bytes.Buffer{},
+			Config: config.NewConfig(),
+		}
+		cmd := &HTTPCommand{}
+		cmd.Flags().Parse(append(args, "--dry-run"))
+		if err := cmd.Run(context.Background(), tc); err == nil {
+			t.Errorf("Run(%v) error = nil, want error", args)
+		}
+	}
+}
+
+func TestTCPCommand_Run(t *testing.T) {
+	var stdout bytes.Buffer
+	cfg := config.NewConfig(config.ConfigObject{
+		"format": "json",
+	})
+
+	tc := &terminal.Context{
+		Args:   []string{"example.com:443"},
+		Stdout: &stdout,
+		Stderr: &bytes.Buffer{},
+		Config: cfg,
+	}
+
+	cmd := &TCPCommand{
+		dryRun: true, // Use dry-run to avoid actual connection
+	}
+	cmd.Flags().Parse([]string{})
+
+	err := cmd.Run(context.Background(), tc)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Validate output
+	if stdout.Len() == 0 {
+		t.Error("expected output, got none")
+	}
+}
+
+func TestTCPCommand_Run_StartTLS(t *testing.T) {
+	var stdout bytes.Buffer
+	tc := &terminal.Context{Args: []string{"mail.example.com:587"}, Stdout: &stdout, Stderr: &bytes.Buffer{}, Config: config.NewConfig()}
+	cmd := &TCPCommand{}
+	if err := cmd.Flags().Parse([]string{"--dry-run", "--starttls", "smtp"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for _, want := range []string{`"type":"starttls"`, `"protocol":"smtp"`, `"type":"tls_handshake_done"`} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output missing %s:\n%s", want, stdout.String())
+		}
+	}
+
+	bad := &TCPCommand{}
+	if err := bad.Flags().Parse([]string{"--dry-run", "--starttls", "pop3"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	tc.Stdout = &bytes.Buffer{}
+	if err := bad.Run(context.Background(), tc); err == nil || !strings.Contains(err.Error(), "pop3") {
+		t.Errorf("Run() error = %v, want unsupported protocol", err)
+	}
+}
+
+func TestUDPCommand_Run(t *testing.T) {
+	var stdout bytes.Buffer
+	cfg := config.NewConfig(config.ConfigObject{
+		"format": "json",
+	})
+
+	tc := &terminal.Context{
+		Args:   []string{"1.1.1.1:53"},
+		Stdout: &stdout,
+		Stderr: &bytes.Buffer{},
+		Config: cfg,
+	}
+
+	cmd := &UDPCommand{
+		dryRun: true, // Use dry-run to avoid actual connection
+	}
+	cmd.Flags().Parse([]string{})
+
+	err := cmd.Run(context.Background(), tc)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Validate output
+	if stdout.Len() == 0 {
+		t.Error("expected output, got none")
+	}
+}
+
+func TestUDPCommand_Run_DNSQuery(t *testing.T) {
+	var stdout bytes.Buffer
+	tc := &terminal.Context{Args: []string{"1.1.1.1:53"}, Stdout: &stdout, Stderr: &bytes.Buffer{}, Config: config.NewConfig()}
+	cmd := &UDPCommand{}
+	if err := cmd.Flags().Parse([]string{"--dry-run", "--dns-query", "example.com", "--dns-type", "AAAA"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(stdout.String(), `"type":"udp_dns_answer"`) {
+		t.Errorf("output has no udp_dns_answer event:\n%s", stdout.String())
+	}
+
+	bad := &UDPCommand{}
+	if err := bad.Flags().Parse([]string{"--dry-run", "--dns-query", "example.com", "--data", "x"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	tc = &terminal.Context{Args: []string{"1.1.1.1:53"}, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Config: config.NewConfig()}
+	if err := bad.Run(context.Background(), tc); err == nil {
+		t.Error("Run() with --data and --dns-query error = nil, want error")
+	}
+}
+
+func TestDNSCommand_Run_DryRun(t *testing.T) {
+	var stdout bytes.Buffer
+	cfg := config.NewConfig(config.ConfigObject{
+		"format": "json",
+	})
+
+	tc := &terminal.Context{
+		Args:   []string{"example.com"},
+		Stdout: &stdout,
+		Stderr: &bytes.Buffer{},
+		Config: cfg,
+	}
+
+	cmd := &DNSCommand{
+		dryRun: true,
+		count:  1,
+	}
+	cmd.Flags().Parse([]string{})
+
+	err := cmd.Run(context.Background(), tc)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if stdout.Len() == 0 {
+		t.Error("expected output, got none")
+	}
+
+	// Validate NDJSON: 2 events (dns_query_start + dns_query_done)
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		var ev event.Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		if ev.Type == "" {
+			t.Error("event Type is empty")
+		}
+		if ev.TraceID == "" {
+			t.Error("event TraceID is empty")
+		}
+	}
+}
+
+func TestDNSCommand_Run_MissingHostname(t *testing.T) {
+	tc := &terminal.Context{
+		Args:   []string{},
+		Stdout: &bytes.Buffer{},
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	cmd := &DNSCommand{count: 1}
+	cmd.Flags().Parse([]string{})
+	err := cmd.Run(context.Background(), tc)
+	if err == nil {
+		t.Fatal("expected error for missing hostname, got nil")
+	}
+}
+
+func TestDNSCommand_Run_InvalidCount(t *testing.T) {
+	tc := &terminal.Context{
+		Args:   []string{"example.com"},
+		Stdout: &bytes.Buffer{},
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	cmd := &DNSCommand{}
+	cmd.Flags().Parse([]string{"--count=-1"})
+	err := cmd.Run(context.Background(), tc)
+	if err == nil {
+		t.Fatal("expected error for count=-1, got nil")
+	}
+}
+
+func TestDNSCommand_Run_InvalidServer(t *testing.T) {
+	tc := &terminal.Context{
+		Args:   []string{"example.com"},
+		Stdout: &bytes.Buffer{},
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	cmd := &DNSCommand{}
+	cmd.Flags().Parse([]string{"--server=not-an-ip"})
+	err := cmd.Run(context.Background(), tc)
+	if err == nil {
+		t.Fatal("expected error for hostname --server value, got nil")
+	}
+}
+
+func TestDNSCommand_Run_InvalidType(t *testing.T) {
+	tc := &terminal.Context{
+		Args:   []string{"example.com"},
+		Stdout: &bytes.Buffer{},
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	cmd := &DNSCommand{}
+	cmd.Flags().Parse([]string{"--type=SRV"})
+	err := cmd.Run(context.Background(), tc)
+	if err == nil || !strings.Contains(err.Error(), "--type") {
+		t.Fatalf("Run() error = %v, want --type error", err)
+	}
+}
+
+func TestDNSCommand_Run_TypeDryRun(t *testing.T) {
+	var stdout bytes.Buffer
+	tc := &terminal.Context{
+		Args:   []string{"example.com"},
+		Stdout: &stdout,
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	cmd := &DNSCommand{}
+	cmd.Flags().Parse([]string{"--dry-run", "--type=aaaa", "--edns"})
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for _, want := range []string{`"record_type":"AAAA"`, `"answers":`, `"edns":true`} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output missing %s:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestDNSCommand_Run_NoDNSCache(t *testing.T) {
+	var stdout bytes.Buffer
+	tc := &terminal.Context{
+		Args:   []string{"example.com"},
+		Stdout: &stdout,
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	cmd := &DNSCommand{}
+	// Nothing listens on port 1, so the query fails fast; only the start
+	// event matters here.
+	cmd.Flags().Parse([]string{"--no-dns-cache", "--server", "127.0.0.1:1", "--timeout", "1"})
+	cmd.Run(context.Background(), tc)
+
+	var ev event.Event
+	if err := json.NewDecoder(&stdout).Decode(&ev); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	if ev.Type != "dns_query_start" || ev.Data["fresh"] != true {
+		t.Errorf("first event = %s %v, want dns_query_start with fresh: true", ev.Type, ev.Data)
+	}
+}
+
+func TestDNSCommand_Run_Gzip(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "trace.ndjson")
+	tc := &terminal.Context{
+		Args:   []string{"example.com"},
+		Stdout: &bytes.Buffer{},
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	cmd := &DNSCommand{}
+	cmd.Flags().Parse([]string{"--dry-run", "--out-file", out, "--gzip"})
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	f, err := os.Open(out + ".gz")
+	if err != nil {
+		t.Fatalf("open gzipped output: %v", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	var types []string
+	dec := json.NewDecoder(zr)
+	for dec.More() {
+		var ev event.Event
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		types = append(types, ev.Type)
+	}
+	if err := zr.Close(); err != nil {
+		t.Fatalf("gzip stream: %v", err)
+	}
+	if got := strings.Join(types, ","); got != "dns_query_start,dns_query_done" {
+		t.Errorf("events = %s, want dns_query_start,dns_query_done", got)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("uncompressed %s should not exist, stat err = %v", out, err)
+	}
+}
+
+func TestDNSCommand_Run_GzipRequiresOutFile(t *testing.T) {
+	tc := &terminal.Context{
+		Args:   []string{"example.com"},
+		Stdout: &bytes.Buffer{},
+		Stderr: &bytes.Buffer{},
+		Config: config.NewConfig(),
+	}
+	cmd := &DNSCommand{}
+	cmd.Flags().Parse([]string{"--dry-run", "--gzip"})
+	err := cmd.Run(context.Background(), tc)
+	if err == nil || !strings.Contains(err.Error(), "--gzip requires --out-file") {
+		t.Errorf("Run() error = %v, want --gzip requires --out-file", err)
+	}
+}
+
+func TestNormalizeServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"plain IPv4 — port defaults to 53", "8.8.8.8", "8.8.8.8:53", false},
+		{"IPv4 with port", "8.8.8.8:53", "8.8.8.8:53", false},
+		{"IPv4 with custom port", "1.1.1.1:5353", "1.1.1.1:5353", false},
+		{"IPv6 with port and brackets", "[::1]:53", "[::1]:53", false},
+		{"hostname without port — rejected", "dns.google", "", true},
+		{"hostname with port — rejected", "dns.google:53", "", true},
+		{"bare IPv6 — rejected (ambiguous colons)", "::1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeServer(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("normalizeServer(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("normalizeServer(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrace_E2E(t *testing.T) {
+	// Start local HTTP server
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	// Run cure trace http <url>
+	var stdout bytes.Buffer
+	router := terminal.New(
+		terminal.WithStdout(&stdout),
+		terminal.WithConfig(config.NewConfig()),
+	)
+	router.Register(NewTraceCommand())
+
+	err := router.RunArgs([]string{"trace", "http", ts.URL})
+	if err != nil {
+		t.Fatalf("RunArgs() error = %v", err)
+	}
+
+	// Validate NDJSON output
+	for line := range strings.SplitSeq(strings.TrimSpace(stdout.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var ev event.Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v, line = %q", err, line)
+		}
+		if ev.Type == "" {
+			t.Error("event Type is empty")
+		}
+		if ev.TraceID == "" {
+			t.Error("event TraceID is empty")
+		}
+	}
+}
+
+func TestBatchCommand_Run(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	batchFile := t.TempDir() + "/targets.txt"
+	content := "# comment line\n\n" +
+		"http " + ts.URL + " --method HEAD\n" +
+		"tcp example.com:443 --dry-run\n" +
+		"dns example.com --dry-run\n"
+	if err := os.WriteFile(batchFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	for _, concurrency := range []string{"1", "3"} {
+		t.Run("concurrency="+concurrency, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			tc := &terminal.Context{
+				Stdout: &stdout,
+				Stderr: &stderr,
+				Config: config.NewConfig(),
+			}
+			cmd := &BatchCommand{}
+			fs := cmd.Flags()
+			if err := fs.Parse([]string{"--concurrency", concurrency, batchFile}); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			tc.Args = fs.Args()
+
+			if err := cmd.Run(context.Background(), tc); err != nil {
+				t.Fatalf("Run() error = %v, stderr = %s", err, stderr.String())
+			}
+
+			targets := make(map[string]bool)
+			for line := range strings.SplitSeq(strings.TrimSpace(stdout.String()), "\n") {
+				var ev event.Event
+				if err := json.Unmarshal([]byte(line), &ev); err != nil {
+					t.Fatalf("json.Unmarshal() error = %v, line = %q", err, line)
+				}
+				target, _ := ev.Data["target"].(string)
+				if target == "" {
+					t.Fatalf("event %q missing target field", ev.Type)
+				}
+				targets[target] = true
+			}
+			for _, want := range []string{ts.URL, "example.com:443", "example.com"} {
+				if !targets[want] {
+					t.Errorf("no events tagged with target %q", want)
+				}
+			}
+			if !strings.Contains(stderr.String(), "3 targets, 3 succeeded, 0 failed") {
+				t.Errorf("stderr summary = %q", stderr.String())
+			}
+		})
+	}
+}
+
+func TestBatchCommand_Run_Failures(t *testing.T) {
+	// Grab a free port and close it so the TCP connect is refused.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	closedAddr := ln.Addr().String()
+	ln.Close()
+
+	batchFile := t.TempDir() + "/targets.txt"
+	content := "tcp " + closedAddr + " --timeout 1\n" + "dns example.com --dry-run\n"
+	if err := os.WriteFile(batchFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var stderr bytes.Buffer
+	tc := &terminal.Context{
+		Args:   []string{batchFile},
+		Stdout: &bytes.Buffer{},
+		Stderr: &stderr,
+		Config: config.NewConfig(),
+	}
+	cmd := &BatchCommand{}
+	cmd.Flags().Parse([]string{})
+
+	if err := cmd.Run(context.Background(), tc); err == nil {
+		t.Fatal("Run() error = nil, want aggregated failure")
+	}
+	if !strings.Contains(stderr.String(), "2 targets, 1 succeeded, 1 failed") {
+		t.Errorf("stderr summary = %q", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "line 1: tcp "+closedAddr) {
+		t.Errorf("stderr missing failure detail: %q", stderr.String())
+	}
+}
+
+func TestBatchCommand_Run_RateLimit(t *testing.T) {
+	batchFile := t.TempDir() + "/targets.txt"
+	content := "dns example.com --dry-run\n" +
+		"dns example.org --dry-run\n" +
+		"dns example.net --dry-run\n"
+	if err := os.WriteFile(batchFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var stderr bytes.Buffer
+	tc := &terminal.Context{
+		Stdout: &bytes.Buffer{},
+		Stderr: &stderr,
+		Config: config.NewConfig(),
+	}
+	cmd := &BatchCommand{}
+	fs := cmd.Flags()
+	if err := fs.Parse([]string{"--concurrency", "3", "--rate", "10", batchFile}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	tc.Args = fs.Args()
+
+	start := time.Now()
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v; stderr = %q", err, stderr.String())
+	}
+	// Three targets at 10/s: the first starts at once, the others 100ms apart.
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("batch took %v, want at least 200ms under --rate 10", elapsed)
+	}
+}
+
+func TestParseBatch_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"unknown protocol", "ftp example.com\n"},
+		{"missing target", "http\n"},
+		{"unknown flag", "http https://example.com --bogus\n"},
+		{"unterminated quote", "http https://example.com --data 'abc\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseBatch(strings.NewReader(tt.input), io.Discard); err == nil {
+				t.Errorf("parseBatch(%q) error = nil, want error", tt.input)
+			}
+		})
+	}
+}
+
+func TestSplitFields(t *testing.T) {
+	got, err := splitFields(`http https://a.com --data '{"k": "v"}' -H "X-A: b"`)
+	if err != nil {
+		t.Fatalf("splitFields() error = %v", err)
+	}
+	want := []string{"http", "https://a.com", "--data", `{"k": "v"}`, "-H", "X-A: b"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("splitFields() = %q, want %q", got, want)
+	}
+}
+
+func TestReplayCommand_Run(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "trace.ndjson")
+	content := `{"type":"dns_start","timestamp":1700000000000000000,"trace_id":"abc123","data":{"host":"example.com"}}` + "\n" +
+		"not json\n" +
+		"\n" +
+		`{"type":"dns_done","timestamp":1700000000100000000,"trace_id":"abc123","data":{"ip":"93.184.216.34"}}` + "\n"
+	if err := os.WriteFile(input, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	t.Run("json round trip preserves events", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		tc := &terminal.Context{Args: []string{input}, Stdout: &stdout, Stderr: &stderr}
+		cmd := &ReplayCommand{}
+		if err := cmd.Flags().Parse([]string{"--format", "json"}); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if err := cmd.Run(context.Background(), tc); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+
+		var got []event.Event
+		for line := range strings.SplitSeq(strings.TrimSpace(stdout.String()), "\n") {
+			var ev event.Event
+			if err := json.Unmarshal([]byte(line), &ev); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v, line = %q", err, line)
+			}
+			got = append(got, ev)
+		}
+		if len(got) != 2 {
+			t.Fatalf("replayed %d events, want 2", len(got))
+		}
+		if got[0].Type != "dns_start" || got[0].Timestamp != 1700000000000000000 || got[0].TraceID != "abc123" {
+			t.Errorf("first event = %+v, want original type, timestamp, and trace ID", got[0])
+		}
+		if !strings.Contains(stderr.String(), "line 2:") {
+			t.Errorf("stderr = %q, want malformed line 2 reported", stderr.String())
+		}
+	})
+
+	t.Run("html report", func(t *testing.T) {
+		report := filepath.Join(dir, "report.html")
+		tc := &terminal.Context{Args: []string{input}, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+		cmd := &ReplayCommand{}
+		if err := cmd.Flags().Parse([]string{"--out-file", report}); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if err := cmd.Run(context.Background(), tc); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		html, err := os.ReadFile(report)
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		for _, want := range []string{"Network Trace Report", "dns_start", "dns_done", "93.184.216.34"} {
+			if !strings.Contains(string(html), want) {
+				t.Errorf("HTML report missing %q", want)
+			}
+		}
+	})
+
+	t.Run("strict fails on malformed line", func(t *testing.T) {
+		tc := &terminal.Context{Args: []string{input}, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+		cmd := &ReplayCommand{}
+		if err := cmd.Flags().Parse([]string{"--strict"}); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		err := cmd.Run(context.Background(), tc)
+		if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("Run() error = %v, want error naming line 2", err)
+		}
+	})
+
+	t.Run("strict creates no out-file for malformed input", func(t *testing.T) {
+		out := filepath.Join(dir, "strict.html")
+		tc := &terminal.Context{Args: []string{input}, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+		cmd := &ReplayCommand{}
+		if err := cmd.Flags().Parse([]string{"--strict", "--out-file", out}); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if err := cmd.Run(context.Background(), tc); err == nil {
+			t.Fatal("Run() error = nil, want malformed line error")
+		}
+		if _, err := os.Stat(out); !os.IsNotExist(err) {
+			t.Errorf("Stat(out-file) error = %v, want not exist", err)
+		}
+	})
+
+	t.Run("dash reads stdin", func(t *testing.T) {
+		var stdout bytes.Buffer
+		tc := &terminal.Context{
+			Args:   []string{"-"},
+			Stdin:  strings.NewReader(content),
+			Stdout: &stdout,
+			Stderr: &bytes.Buffer{},
+		}
+		cmd := &ReplayCommand{}
+		if err := cmd.Flags().Parse([]string{"--format", "json"}); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if err := cmd.Run(context.Background(), tc); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if got := strings.Count(stdout.String(), "\n"); got != 2 {
+			t.Errorf("replayed %d events from stdin, want 2:\n%s", got, stdout.String())
+		}
+
+		tc = &terminal.Context{Args: []string{"-"}, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+		if err := (&ReplayCommand{}).Run(context.Background(), tc); err == nil || !strings.Contains(err.Error(), "no stdin") {
+			t.Errorf("Run() without stdin error = %v, want no stdin error", err)
+		}
+	})
+
+	t.Run("missing file argument", func(t *testing.T) {
+		tc := &terminal.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+		if err := (&ReplayCommand{}).Run(context.Background(), tc); err == nil {
+			t.Error("Run() error = nil, want missing argument error")
+		}
+	})
+}
+
+func TestReplayCommand_Run_TimeWindow(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "trace.ndjson")
+	content := `{"type":"dns_start","timestamp":1700000000000000000,"trace_id":"abc123","data":{}}` + "\n" +
+		`{"type":"dns_done","timestamp":1700000000100000000,"trace_id":"abc123","data":{}}` + "\n" +
+		`{"type":"trace_summary","timestamp":1700000001000000000,"trace_id":"abc123","data":{}}` + "\n"
+	if err := os.WriteFile(input, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	first := time.Unix(0, 1700000000000000000).UTC().Format(time.RFC3339Nano)
+	second := time.Unix(0, 1700000000100000000).UTC().Format(time.RFC3339Nano)
+
+	tests := []struct {
+		name      string
+		args      []string
+		want      []string
+		wantSkip  string
+		wantError string
+	}{
+		{name: "bounds are inclusive", args: []string{"--since", first, "--until", second}, want: []string{"dns_start", "dns_done"}, wantSkip: "skipped 1 event(s)"},
+		{name: "since only", args: []string{"--since", second}, want: []string{"dns_done", "trace_summary"}},
+		{name: "offset from first event", args: []string{"--since", "+50ms", "--until", "+500ms"}, want: []string{"dns_done"}},
+		{name: "empty window", args: []string{"--until", "2000-01-01T00:00:00Z"}, want: nil, wantSkip: "skipped 3 event(s)"},
+		{name: "since after until", args: []string{"--since", second, "--until", first}, wantError: "no event can match"},
+		{name: "invalid bound", args: []string{"--since", "yesterday"}, wantError: "invalid --since"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			tc := &terminal.Context{Args: []string{input}, Stdout: &stdout, Stderr: &stderr}
+			cmd := &ReplayCommand{}
+			if err := cmd.Flags().Parse(append([]string{"--format", "json"}, tt.args...)); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			err := cmd.Run(context.Background(), tc)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("Run() error = %v, want containing %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			var got []string
+			for line := range strings.SplitSeq(strings.TrimSpace(stdout.String()), "\n") {
+				if line == "" {
+					continue
+				}
+				var ev event.Event
+				if err := json.Unmarshal([]byte(line), &ev); err != nil {
+					t.Fatalf("json.Unmarshal() error = %v, line = %q", err, line)
+				}
+				got = append(got, ev.Type)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("replayed %q, want %q", got, tt.want)
+			}
+			if tt.wantSkip != "" && !strings.Contains(stderr.String(), tt.wantSkip) {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantSkip)
+			}
+		})
+	}
+}
+
+func TestHTTPCommand_Run_FailOn(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantFail bool
+		wantErr  bool
+	}{
+		{"no condition", nil, false, false},
+		{"status matches", []string{"--fail-on", "status>=500"}, true, false},
+		{"status does not match", []string{"--fail-on", "status==200"}, false, false},
+		{"any condition matches", []string{"--fail-on", "error", "--fail-on", "status != 200"}, true, false},
+		{"invalid condition", []string{"--fail-on", "latency>1s"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			tc := &terminal.Context{
+				Args:   []string{ts.URL},
+				Stdout: &stdout,
+				Stderr: &bytes.Buffer{},
+				Config: config.NewConfig(),
+			}
+			cmd := &HTTPCommand{}
+			if err := cmd.Flags().Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			err := cmd.Run(context.Background(), tc)
+			switch {
+			case tt.wantFail:
+				if !errors.Is(err, ErrFailOn) {
+					t.Fatalf("Run() error = %v, want ErrFailOn", err)
+				}
+				if !strings.Contains(err.Error(), "status 503") {
+					t.Errorf("Run() error = %q, want it to name status 503", err)
+				}
+			case tt.wantErr:
+				if err == nil || errors.Is(err, ErrFailOn) {
+					t.Fatalf("Run() error = %v, want a validation error", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+			}
+			if !tt.wantErr && !strings.Contains(stdout.String(), "http_response_done") {
+				t.Error("events not forwarded to the output")
+			}
+		})
+	}
+}
+
+func TestParseFailOn(t *testing.T) {
+	response := func(status interface{}) event.Event {
+		return event.NewEvent("http_response_done", "t", map[string]interface{}{"status": status})
+	}
+	failed := event.NewEvent("tcp_connect_done", "t", map[string]interface{}{"error": "refused"})
+
+	tests := []struct {
+		expr    string
+		ev      event.Event
+		want    bool
+		wantErr bool
+	}{
+		{expr: "status>=400", ev: response(404), want: true},
+		{expr: "status>=400", ev: response(204), want: false},
+		{expr: "status<300", ev: response(float64(200)), want: true},
+		{expr: "status > 499", ev: response(500), want: true},
+		{expr: "status!=200", ev: failed, want: false},
+		{expr: "error", ev: failed, want: true},
+		{expr: "error", ev: response(500), want: false},
+		{expr: "status>=abc", wantErr: true},
+		{expr: "status>=700", wantErr: true},
+		{expr: "status~200", wantErr: true},
+		{expr: "latency>100", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cond, err := parseFailOn(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFailOn(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if _, got := cond.match(tt.ev); got != tt.want {
+				t.Errorf("match(%s) = %v, want %v", tt.ev.Type, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPCommand_Run_Assert(t *testing.T) {
+	tests := []struct {
+		name       string
+		asserts    []string
+		wantFailed []string // expressions named by assertion_failed events
+		wantErr    bool
+	}{
+		{"all hold", []string{"status==200", "tls.version==TLS 1.3", "duration_ms<500", "summary.phases.tls<=100", "connect.duration_ms>0"}, nil, false},
+		{"status fails", []string{"status==200", "status!=200"}, []string{"status!=200"}, false},
+		{"string fails", []string{"tls.version == TLS 1.2"}, []string{"tls.version == TLS 1.2"}, false},
+		{"several fail", []string{"duration_ms<100", "tls.version==TLS 1.3", "summary.ok==false"}, []string{"duration_ms<100", "summary.ok==false"}, false},
+		{"missing field", []string{"response.nope==1"}, []string{"response.nope==1"}, false},
+		{"invalid expression", []string{"status=200"}, nil, true},
+		{"ordering needs a number", []string{"tls.version>TLS 1.2"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			tc := &terminal.Context{
+				Args:   []string{"https://example.com"},
+				Stdout: &stdout,
+				Stderr: &bytes.Buffer{},
+				Config: config.NewConfig(),
+			}
+			args := []string{"--dry-run"}
+			for _, a := range tt.asserts {
+				args = append(args, "--assert", a)
+			}
+			cmd := &HTTPCommand{}
+			if err := cmd.Flags().Parse(args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			err := cmd.Run(context.Background(), tc)
+			switch {
+			case tt.wantErr:
+				if err == nil || errors.Is(err, ErrAssertion) {
+					t.Fatalf("Run() error = %v, want a validation error", err)
+				}
+				return
+			case tt.wantFailed != nil:
+				if !errors.Is(err, ErrAssertion) {
+					t.Fatalf("Run() error = %v, want ErrAssertion", err)
+				}
+				for _, expr := range tt.wantFailed {
+					if !strings.Contains(err.Error(), expr) {
+						t.Errorf("Run() error = %q, want it to name %q", err, expr)
+					}
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+			}
+
+			var failed []string
+			dec := json.NewDecoder(&stdout)
+			for dec.More() {
+				var ev event.Event
+				if err := dec.Decode(&ev); err != nil {
+					t.Fatalf("decode event: %v", err)
+				}
+				if ev.Type == AssertionFailed {
+					expr, _ := ev.Data["expression"].(string)
+					failed = append(failed, expr)
+				}
+			}
+			if !slices.Equal(failed, tt.wantFailed) {
+				t.Errorf("assertion_failed expressions = %q, want %q", failed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestAssertion_Check(t *testing.T) {
+	response := event.NewEvent("http_response_done", "t", map[string]interface{}{"status": 503, "duration_ms": int64(42)})
+	summary := event.NewEvent(event.TraceSummary, "t", event.SummaryData(event.Phases{"dns": 7}, 0, true))
+
+	tests := []struct {
+		expr      string
+		ev        *event.Event
+		wantTyp   string
+		wantValid bool // whether the assertion holds
+	}{
+		{"status==503", &response, "http_response_done", true},
+		{"status>=500", &response, "http_response_done", true},
+		{"status<500", &response, "http_response_done", false},
+		{"response.status != 200", &response, "http_response_done", true},
+		{"duration_ms>41.5", &response, "http_response_done", true},
+		{"summary.phases.dns==7", &summary, event.TraceSummary, true},
+		{"summary.phases.dns>7", &summary, event.TraceSummary, false},
+		{"summary.ok==true", &summary, event.TraceSummary, true},
+		{"summary.phases.dns.x==1", &summary, event.TraceSummary, false},
+		{"tls.version==TLS 1.3", nil, event.TypeTLSHandshakeDone, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			a, err := parseAssertion(tt.expr)
+			if err != nil {
+				t.Fatalf("parseAssertion() error = %v", err)
+			}
+			if a.typ != tt.wantTyp {
+				t.Errorf("typ = %q, want %q", a.typ, tt.wantTyp)
+			}
+			if _, reason := a.check(tt.ev); (reason == "") != tt.wantValid {
+				t.Errorf("check() reason = %q, want holds = %v", reason, tt.wantValid)
+			}
+		})
+	}
+
+	for _, expr := range []string{"", "status", "==200", "status.==200", "duration_ms<fast"} {
+		if _, err := parseAssertion(expr); err == nil {
+			t.Errorf("parseAssertion(%q) error = nil, want an error", expr)
+		}
+	}
+}
+
+func TestRun_NoProgressOnNonTTY(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  terminal.Command
+		args []string
+	}{
+		{"dns", &DNSCommand{}, []string{"--dry-run", "--count", "3", "example.com"}},
+		{"http", &HTTPCommand{}, []string{"--dry-run", "--count", "3", "https://example.com"}},
+		{"tcp", &TCPCommand{}, []string{"--dry-run", "--keepalive", "--count", "2", "example.com:443"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			tc := &terminal.Context{Stdout: &stdout, Stderr: &stderr, Config: config.NewConfig()}
+			fs := tt.cmd.Flags()
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			tc.Args = fs.Args()
+
+			if err := tt.cmd.Run(context.Background(), tc); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if stderr.Len() != 0 {
+				t.Errorf("stderr = %q, want no progress output on a non-terminal", stderr.String())
+			}
+			for line := range strings.SplitSeq(strings.TrimSpace(stdout.String()), "\n") {
+				if !json.Valid([]byte(line)) {
+					t.Errorf("stdout line is not JSON: %q", line)
+				}
+			}
+		})
+	}
+}
+
+func TestNewEmitter_FlushInterval(t *testing.T) {
+	var buf bytes.Buffer
+	em, err := newEmitter("json", "", &buf, io.Discard, time.Hour)
+	if err != nil {
+		t.Fatalf("newEmitter() error = %v", err)
+	}
+	if err := em.Emit(event.NewEvent("dns_start", "t1", nil)); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("output %q written before the flush interval, want it buffered", buf.String())
+	}
+	if err := em.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"type":"dns_start"`) {
+		t.Errorf("output after Close = %q, want the buffered event", buf.String())
+	}
+
+	if _, err := newEmitter("html", "", &buf, io.Discard, time.Second); err == nil {
+		t.Error("newEmitter(html) with a flush interval error = nil, want error")
+	}
+	if _, err := newEmitter("json", "", &buf, io.Discard, -time.Second); err == nil {
+		t.Error("newEmitter() with a negative flush interval error = nil, want error")
+	}
+}
+
+func TestNewEmitter_GzipFlush(t *testing.T) {
+	dir := t.TempDir()
+	emit := func(path string, flushInterval time.Duration) (*gzipFile, int64) {
+		t.Helper()
+		f, err := createOutFile(path, true)
+		if err != nil {
+			t.Fatalf("createOutFile() error = %v", err)
+		}
+		em, err := newEmitter("json", "", f, io.Discard, flushInterval)
+		if err != nil {
+			t.Fatalf("newEmitter() error = %v", err)
+		}
+		for i := 0; i < 10; i++ {
+			if err := em.Emit(event.NewEvent("dns_start", "t1", nil)); err != nil {
+				t.Fatalf("Emit() error = %v", err)
+			}
+		}
+		if err := em.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		info, err := os.Stat(path + ".gz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f.(*gzipFile), info.Size()
+	}
+
+	g, size := emit(filepath.Join(dir, "events"), 0)
+	defer g.Close()
+	if _, ok := io.Writer(g).(interface{ Flush() error }); ok {
+		t.Error("gzip output has a Flush method, want none so events are not flushed one by one")
+	}
+	if size > 20 {
+		t.Errorf("gzip output is %d bytes before Close, want only the header", size)
+	}
+
+	g, size = emit(filepath.Join(dir, "flushed"), time.Hour)
+	defer g.Close()
+	if size <= 20 {
+		t.Errorf("gzip output is %d bytes after Close with --flush-interval, want the flushed events", size)
+	}
+}
+
+// failingCloser fails every Close.
+type failingCloser struct{}
+
+func (failingCloser) Close() error { return errors.New("disk full") }
+
+func TestCloseOutFile(t *testing.T) {
+	var err error
+	closeOutFile(failingCloser{}, &err)
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("closeOutFile() error = %v, want the close error", err)
+	}
+
+	runErr := errors.New("trace failed")
+	err = runErr
+	closeOutFile(failingCloser{}, &err)
+	if err != runErr {
+		t.Errorf("closeOutFile() error = %v, want the earlier error kept", err)
+	}
+}
+
+func TestWithAlsoHTML(t *testing.T) {
+	var primary event.SliceEmitter
+	if em, err := withAlsoHTML(&primary, ""); err != nil || em != event.Emitter(&primary) {
+		t.Errorf("withAlsoHTML(em, \"\") = %v, %v; want em unchanged", em, err)
+	}
+
+	report := filepath.Join(t.TempDir(), "report.html")
+	em, err := withAlsoHTML(&primary, report)
+	if err != nil {
+		t.Fatalf("withAlsoHTML() error = %v", err)
+	}
+	if err := em.Emit(event.NewEvent("dns_start", "t1", nil)); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if err := em.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if len(primary.Events()) != 1 {
+		t.Errorf("primary emitter got %d events, want 1", len(primary.Events()))
+	}
+	html, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(html), "dns_start") {
+		t.Error("HTML report missing the event")
+	}
+
+	if _, err := withAlsoHTML(&primary, filepath.Join(t.TempDir(), "missing", "report.html")); err == nil {
+		t.Error("withAlsoHTML() into a missing directory error = nil, want error")
+	}
+}
+
+func TestNewEmitter_Template(t *testing.T) {
+	tests := []struct {
+		name, format, tmpl string
+		wantErr            string
+	}{
+		{"template", "template", "{{.Type}}", ""},
+		{"missing template", "template", "", "requires --template"},
+		{"template without format", "json", "{{.Type}}", "requires --format template"},
+		{"invalid template", "template", "{{.Type", "--template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newEmitter(tt.format, tt.tmpl, io.Discard, io.Discard, 0)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("newEmitter() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newEmitter() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSelftestCommand_Template(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	tc := &terminal.Context{Stdout: &stdout, Stderr: &stderr, Config: config.NewConfig()}
+	cmd := &SelftestCommand{}
+	if err := cmd.Flags().Parse([]string{"--format", "template", "--template", "{{.Type}} {{.Data.status}}"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v, stderr = %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "http_response_done 200\n") {
+		t.Errorf("stdout = %q, want a rendered http_response_done line", stdout.String())
+	}
+	if n := strings.Count(stderr.String(), "further errors are not reported"); n != 1 {
+		t.Errorf("stderr reports %d template errors, want 1: %q", n, stderr.String())
+	}
+}
+
+func TestSelftestCommand_ConfigTemplate(t *testing.T) {
+	var stdout bytes.Buffer
+	tc := &terminal.Context{
+		Stdout: &stdout,
+		Stderr: io.Discard,
+		Config: config.NewConfig(config.ConfigObject{"template": `{{.Type}} {{index .Data "status"}}`}),
+	}
+	cmd := &SelftestCommand{}
+	if err := cmd.Flags().Parse([]string{"--format", "template"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(stdout.String(), "http_response_done 200\n") {
+		t.Errorf("stdout = %q, want lines rendered with the config template", stdout.String())
+	}
+}
+
+func TestProgressCounter(t *testing.T) {
+	var buf bytes.Buffer
+	em, err := newEmitter("json", "", &buf, io.Discard, 0)
+	if err != nil {
+		t.Fatalf("newEmitter() error = %v", err)
+	}
+	counter := &progressCounter{p: terminal.NewProgress(io.Discard, ""), step: "dns_query_done", total: 2}
+	pe := event.NewTapEmitter(em, counter.observe)
+	for _, typ := range []string{"dns_query_start", "dns_query_done", "dns_query_start", "dns_query_done"} {
+		if err := pe.Emit(event.NewEvent(typ, "t", nil)); err != nil {
+			t.Fatalf("Emit() error = %v", err)
+		}
+	}
+	if counter.done != 2 {
+		t.Errorf("done = %d, want 2", counter.done)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 4 {
+		t.Errorf("forwarded %d events, want 4", n)
+	}
+}
+
+func TestSelftestCommand_Run(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		want  []string
+		notIn []string
+	}{
+		{"plain", nil, []string{"tcp_connect_done", "ttfb", "http_response_done"}, []string{"tls_handshake_done"}},
+		{"tls", []string{"--tls"}, []string{"tls_handshake_done", "http_response_done"}, nil},
+		{"status and delay", []string{"--status", "503", "--delay", "20"}, []string{"http_response_done"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			tc := &terminal.Context{Stdout: &stdout, Stderr: &stderr, Config: config.NewConfig()}
+			cmd := &SelftestCommand{}
+			if err := cmd.Flags().Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if err := cmd.Run(context.Background(), tc); err != nil {
+				t.Fatalf("Run() error = %v, stderr = %s", err, stderr.String())
+			}
+
+			types := make(map[string]event.Event)
+			for line := range strings.SplitSeq(strings.TrimSpace(stdout.String()), "\n") {
+				var ev event.Event
+				if err := json.Unmarshal([]byte(line), &ev); err != nil {
+					t.Fatalf("json.Unmarshal() error = %v, line = %q", err, line)
+				}
+				types[ev.Type] = ev
+			}
+			for _, want := range tt.want {
+				if _, ok := types[want]; !ok {
+					t.Errorf("missing %s event", want)
+				}
+			}
+			for _, not := range tt.notIn {
+				if _, ok := types[not]; ok {
+					t.Errorf("unexpected %s event", not)
+				}
+			}
+			status := fmt.Sprint(types["http_response_done"].Data["status"])
+			if want := cmd.status; status != fmt.Sprint(want) {
+				t.Errorf("http_response_done status = %s, want %d", status, want)
+			}
+			if !strings.Contains(stderr.String(), "selftest: ok") {
+				t.Errorf("stderr = %q, want selftest: ok", stderr.String())
+			}
+		})
+	}
+}
+
+func TestSelftestCommand_Run_InvalidFlags(t *testing.T) {
+	for _, args := range [][]string{{"--delay=-1"}, {"--status", "99"}, {"--status", "600"}} {
+		tc := &terminal.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Config: config.NewConfig()}
+		cmd := &SelftestCommand{}
+		cmd.Flags().Parse(args)
+		if err := cmd.Run(context.Background(), tc); err == nil {
+			t.Errorf("Run(%v) error = nil, want error", args)
+		}
+	}
+}
+
+func TestHTTPCommand_Run_Form(t *testing.T) {
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"fields", []string{"--method", "POST", "--form", "b=2", "--form", "a=1"}, ""},
+		{"conflict with --data", []string{"--form", "a=1", "--data", "raw"}, "mutually exclusive"},
+		{"missing equals", []string{"--form", "a"}, "want key=value"},
+		{"empty key", []string{"--form", "=1"}, "want key=value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body = ""
+			tc := &terminal.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Config: config.NewConfig()}
+			cmd := &HTTPCommand{}
+			fs := cmd.Flags()
+			if err := fs.Parse(append(tt.args, ts.URL)); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			tc.Args = fs.Args()
+
+			err := cmd.Run(context.Background(), tc)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Run() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if body != "a=1&b=2" {
+				t.Errorf("server body = %q, want a=1&b=2", body)
+			}
+		})
 	}
 }
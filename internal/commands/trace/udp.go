@@ -5,20 +5,31 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"os"
+	"time"
 
 	"github.com/mrlm-net/cure/pkg/terminal"
 	"github.com/mrlm-net/cure/pkg/tracer/event"
-	"github.com/mrlm-net/cure/pkg/tracer/formatter"
 	"github.com/mrlm-net/cure/pkg/tracer/udp"
 )
 
 type UDPCommand struct {
-	format     string
-	outFile    string
-	dryRun     bool
-	data       string
-	recvBuffer int
+	format        string
+	template      string
+	outFile       string
+	gzip          bool
+	flushInterval time.Duration
+	alsoHTML      string
+	summary       bool
+	dryRun        bool
+	data          string
+	recvBuffer    int
+	dnsTimeout    int
+	dnsQuery      string
+	dnsType       string
+	hexdump       bool
+	hexdumpBytes  int
+	traceRetry    int
+	labels        labelFlags
 }
 
 func (c *UDPCommand) Name() string { return "udp" }
@@ -33,63 +44,109 @@ func (c *UDPCommand) Usage() string {
 Traces a UDP exchange with addr (host:port format).
 
 Examples:
-  cure trace udp 1.1.1.1:53 --data <dns-query-bytes>`
+  cure trace udp 1.1.1.1:53 --dns-query example.com
+  cure trace udp 1.1.1.1:53 --dns-query example.com --dns-type AAAA
+  cure trace udp 10.0.0.5:9999 --data ping
+  cure trace udp 10.0.0.5:9999 --data ping --hexdump
+
+--dns-query sends a DNS query for the name instead of --data and decodes the
+reply into a udp_dns_answer event with its records.
+
+--hexdump adds a hex and ASCII dump of the first --hexdump-bytes bytes
+(default 256) of each datagram to udp_send and udp_receive.`
 }
 
 func (c *UDPCommand) Flags() *flag.FlagSet {
 	fs := flag.NewFlagSet("trace-udp", flag.ContinueOnError)
-	fs.StringVar(&c.format, "format", "json", "Output format (json, html)")
+	fs.StringVar(&c.format, "format", "json", formatUsage)
+	terminal.DescribeFlag(fs, "format", terminal.FlagMeta{Enum: formats})
+	fs.StringVar(&c.template, "template", "", templateUsage)
 	fs.StringVar(&c.outFile, "out-file", "", "Output file (default: stdout)")
+	fs.BoolVar(&c.gzip, "gzip", false, "Gzip-compress the --out-file output (adds .gz if missing)")
+	fs.DurationVar(&c.flushInterval, "flush-interval", 0, "With --format json, buffer output and flush it this often, e.g. 1s (0 = flush every event)")
+	fs.StringVar(&c.alsoHTML, "also-html", "", "Also write an HTML report to this file")
+	fs.BoolVar(&c.summary, "summary", false, "Print a one-line tally of events by type and the trace duration to stderr at the end")
 	fs.BoolVar(&c.dryRun, "dry-run", false, "Emit events without I/O")
 	fs.StringVar(&c.data, "data", "", "Data to send")
+	fs.StringVar(&c.dnsQuery, "dns-query", "", "Send a DNS query for this name instead of --data and decode the reply")
+	fs.StringVar(&c.dnsType, "dns-type", "A", "Record type for --dns-query")
+	terminal.DescribeFlag(fs, "dns-type", terminal.FlagMeta{Enum: []string{"A", "AAAA", "CNAME", "MX", "NS", "TXT"}})
 	fs.IntVar(&c.recvBuffer, "recv-buffer", 4096, "Receive buffer size in bytes")
+	fs.BoolVar(&c.hexdump, "hexdump", false, "Add a hex and ASCII dump of the sent and received bytes to the send and receive events")
+	fs.IntVar(&c.hexdumpBytes, "hexdump-bytes", 256, "Dump at most this many bytes of each payload with --hexdump")
+	fs.IntVar(&c.dnsTimeout, "dns-timeout", 0, "DNS lookup timeout in seconds (0 = no separate limit)")
+	fs.IntVar(&c.traceRetry, "trace-retry", 0, "Run the whole trace up to this many times while it fails with a transient network error")
+	fs.Var(&c.labels, "label", labelUsage)
 	return fs
 }
 
-func (c *UDPCommand) Run(ctx context.Context, tc *terminal.Context) error {
+func (c *UDPCommand) Run(ctx context.Context, tc *terminal.Context) (runErr error) {
 	if len(tc.Args) == 0 {
 		return fmt.Errorf("missing address argument (host:port)")
 	}
 	addr := tc.Args[0]
 
-	// Merge flags with config
-	format := c.format
-	if format == "" && tc.Config != nil {
-		format = tc.Config.Get("format", "json").(string)
-	}
+	tmpl := outputTemplate(tc, c.format, c.template)
 
-	// Create emitter
-	var em event.Emitter
+	if c.gzip && c.outFile == "" {
+		return fmt.Errorf("--gzip requires --out-file")
+	}
 	var outW io.Writer = tc.Stdout
 	if c.outFile != "" {
-		f, err := os.Create(c.outFile)
+		f, err := createOutFile(c.outFile, c.gzip)
 		if err != nil {
 			return fmt.Errorf("failed to create output file: %w", err)
 		}
-		defer f.Close()
+		defer closeOutFile(f, &runErr)
 		outW = f
 	}
 
-	switch format {
-	case "json":
-		em = formatter.NewNDJSONEmitter(outW)
-	case "html":
-		em = formatter.NewHTMLEmitter(outW)
-	default:
-		return fmt.Errorf("unsupported format: %s", format)
+	em, err := newEmitter(c.format, tmpl, outW, tc.Stderr, c.flushInterval)
+	if err != nil {
+		return err
 	}
+	if em, err = withAlsoHTML(em, c.alsoHTML); err != nil {
+		return err
+	}
+	em = withSummary(em, c.summary, tc.Stderr)
 	defer em.Close()
 
+	return c.trace(ctx, tc, addr, em)
+}
+
+// trace runs the UDP tracer against addr, emitting events to em.
+func (c *UDPCommand) trace(ctx context.Context, _ *terminal.Context, addr string, em event.Emitter) error {
+	if c.dnsTimeout < 0 {
+		return fmt.Errorf("--dns-timeout must be 0 or greater, got %d", c.dnsTimeout)
+	}
+	if c.traceRetry < 0 {
+		return fmt.Errorf("--trace-retry must be 0 or greater, got %d", c.traceRetry)
+	}
 	opts := []udp.Option{
 		udp.WithEmitter(em),
 		udp.WithDryRun(c.dryRun),
+		udp.WithLabels(c.labels.toMap()),
+		udp.WithHexdump(c.hexdump),
+		udp.WithHexdumpBytes(c.hexdumpBytes),
+	}
+	if c.data != "" && c.dnsQuery != "" {
+		return fmt.Errorf("--data and --dns-query cannot be combined")
 	}
 	if c.data != "" {
 		opts = append(opts, udp.WithDataString(c.data))
 	}
+	if c.dnsQuery != "" {
+		opts = append(opts, udp.WithDNSQuery(c.dnsQuery, c.dnsType))
+	}
 	if c.recvBuffer > 0 {
 		opts = append(opts, udp.WithRecvBuffer(c.recvBuffer))
 	}
+	if c.dnsTimeout > 0 {
+		opts = append(opts, udp.WithDNSTimeout(time.Duration(c.dnsTimeout)*time.Second))
+	}
+	if c.traceRetry > 1 {
+		opts = append(opts, udp.WithTraceRetry(c.traceRetry))
+	}
 
 	return udp.TraceAddr(ctx, addr, opts...)
 }
@@ -2,19 +2,45 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"runtime"
 
 	"github.com/mrlm-net/cure/pkg/terminal"
 )
 
+// Build metadata injected at link time, e.g.:
+//
+//	go build -ldflags "-X github.com/mrlm-net/cure/internal/commands.Version=v1.2.3"
+var (
+	// Version is the released version of cure. Default: "dev".
+	Version = "dev"
+
+	// Commit is the git commit the binary was built from.
+	Commit = "unknown"
+
+	// BuildDate is the build timestamp in RFC 3339 format.
+	BuildDate = "unknown"
+)
+
 // NewVersionCommand creates a new version command.
 func NewVersionCommand() terminal.Command {
 	return &VersionCommand{}
 }
 
 // VersionCommand prints the cure version.
-type VersionCommand struct{}
+type VersionCommand struct {
+	json bool
+}
+
+// versionInfo is the structured output of "cure version --json".
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
 
 // Name returns "version".
 func (c *VersionCommand) Name() string { return "version" }
@@ -23,13 +49,26 @@ func (c *VersionCommand) Name() string { return "version" }
 func (c *VersionCommand) Description() string { return "Print version information" }
 
 // Usage returns detailed usage information.
-func (c *VersionCommand) Usage() string { return "Usage: cure version" }
+func (c *VersionCommand) Usage() string { return "Usage: cure version [--json]" }
 
-// Flags returns nil — the version command accepts no flags.
-func (c *VersionCommand) Flags() *flag.FlagSet { return nil }
+// Flags returns the flag set for the version command.
+func (c *VersionCommand) Flags() *flag.FlagSet {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	fs.BoolVar(&c.json, "json", false, "Print version information as JSON")
+	return fs
+}
 
 // Run executes the version command, printing version information to stdout.
 func (c *VersionCommand) Run(_ context.Context, tc *terminal.Context) error {
-	fmt.Fprintln(tc.Stdout, "cure version dev")
-	return nil
+	if !c.json {
+		fmt.Fprintf(tc.Stdout, "cure version %s\n", Version)
+		return nil
+	}
+	enc := json.NewEncoder(tc.Stdout)
+	return enc.Encode(versionInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	})
 }
@@ -3,6 +3,8 @@ package commands
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"runtime"
 	"testing"
 
 	"github.com/mrlm-net/cure/pkg/terminal"
@@ -34,8 +36,41 @@ func TestVersionCommand_Usage(t *testing.T) {
 
 func TestVersionCommand_Flags(t *testing.T) {
 	cmd := &VersionCommand{}
-	if got := cmd.Flags(); got != nil {
-		t.Errorf("Flags() = %v, want nil", got)
+	fs := cmd.Flags()
+	if fs == nil {
+		t.Fatal("Flags() = nil, want flag set")
+	}
+	if fs.Lookup("json") == nil {
+		t.Error("Flags() missing --json flag")
+	}
+}
+
+func TestVersionCommand_RunJSON(t *testing.T) {
+	cmd := &VersionCommand{}
+	if err := cmd.Flags().Parse([]string{"--json"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	var stdout bytes.Buffer
+	tc := &terminal.Context{Stdout: &stdout}
+
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var got map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v (%q)", err, stdout.String())
+	}
+	if got["version"] != Version {
+		t.Errorf("version = %q, want %q", got["version"], Version)
+	}
+	if got["go_version"] != runtime.Version() {
+		t.Errorf("go_version = %q, want %q", got["go_version"], runtime.Version())
+	}
+	for _, key := range []string{"commit", "build_date"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("missing %q key in %v", key, got)
+		}
 	}
 }
 
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strconv"
 	"strings"
 )
 
@@ -17,7 +18,8 @@ type Config struct {
 
 // NewConfig creates a Config by deep merging zero or more ConfigObjects.
 // Later objects override earlier ones. Map keys merge recursively,
-// slices concatenate, primitives are replaced.
+// slices concatenate, primitives are replaced. The Config holds its own
+// copy, so later changes to objs, or Set calls, do not affect each other.
 //
 // Example:
 //
@@ -29,7 +31,7 @@ type Config struct {
 func NewConfig(objs ...ConfigObject) *Config {
 	result := make(ConfigObject)
 	for _, obj := range objs {
-		result = DeepMerge(result, obj)
+		mergeInto(result, obj)
 	}
 	return &Config{data: result}
 }
@@ -54,10 +56,15 @@ func (c *Config) Data() ConfigObject {
 // Returns fallback if key is missing or if multiple fallbacks are provided,
 // returns the first one.
 //
+// A numeric segment indexes into a slice ([]interface{}), so arrays of
+// objects can be addressed directly. A negative, non-numeric, or out-of-range
+// index against a slice is treated as a missing key.
+//
 // Dot notation examples:
 //
 //	cfg.Get("timeout", 10)           // top-level key
 //	cfg.Get("database.host", "localhost") // nested key
+//	cfg.Get("servers.0.host", "")    // first element of an array
 //
 // Type assertion is the caller's responsibility:
 //
@@ -74,13 +81,23 @@ func (c *Config) Get(key string, fallback ...interface{}) interface{} {
 	current := interface{}(c.data)
 
 	for _, part := range parts {
-		// Try to convert current to a map
+		// Try to convert current to a map, or index into a slice
 		var m map[string]interface{}
 		switch v := current.(type) {
 		case map[string]interface{}:
 			m = v
 		case ConfigObject:
 			m = map[string]interface{}(v)
+		case []interface{}:
+			idx, ok := sliceIndex(part, len(v))
+			if !ok {
+				if len(fallback) > 0 {
+					return fallback[0]
+				}
+				return nil
+			}
+			current = v[idx]
+			continue
 		default:
 			if len(fallback) > 0 {
 				return fallback[0]
@@ -103,10 +120,16 @@ func (c *Config) Get(key string, fallback ...interface{}) interface{} {
 // Set stores a value at the specified key path, creating nested maps
 // as needed. Uses dot notation for path segments.
 //
+// A numeric segment addresses an existing element of a slice
+// ([]interface{}). Set does not grow slices: if the index is negative,
+// non-numeric, or out of range, the configuration is left unchanged.
+//
 // Example:
 //
 //	cfg.Set("database.host", "localhost")
 //	// creates: {"database": {"host": "localhost"}}
+//	cfg.Set("servers.1.port", 8443)
+//	// updates the second element of an existing "servers" array
 func (c *Config) Set(key string, value interface{}) {
 	if c == nil {
 		return
@@ -116,25 +139,63 @@ func (c *Config) Set(key string, value interface{}) {
 	}
 
 	parts := strings.Split(key, ".")
-	current := c.data
+	current := interface{}(map[string]interface{}(c.data))
 
-	// Walk/create nested maps for all segments except the last
-	for i := 0; i < len(parts)-1; i++ {
-		part := parts[i]
-		next, exists := current[part]
-		if !exists {
-			next = make(map[string]interface{})
-			current[part] = next
-		}
-		nextMap, ok := next.(map[string]interface{})
-		if !ok {
-			// Type conflict: replace with map
-			nextMap = make(map[string]interface{})
-			current[part] = nextMap
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch node := current.(type) {
+		case map[string]interface{}:
+			if last {
+				node[part] = value
+				return
+			}
+			next, ok := container(node[part])
+			if !ok {
+				// Missing or type conflict: replace with map
+				next = make(map[string]interface{})
+				node[part] = next
+			}
+			current = next
+		case []interface{}:
+			idx, ok := sliceIndex(part, len(node))
+			if !ok {
+				return
+			}
+			if last {
+				node[idx] = value
+				return
+			}
+			next, ok := container(node[idx])
+			if !ok {
+				next = make(map[string]interface{})
+				node[idx] = next
+			}
+			current = next
 		}
-		current = nextMap
 	}
+}
+
+// container returns v as a map or slice that Set can descend into. A
+// ConfigObject is converted to its underlying map, which shares storage.
+func container(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}, []interface{}:
+		return v, true
+	case ConfigObject:
+		return map[string]interface{}(v), true
+	}
+	return nil, false
+}
 
-	// Set the final segment
-	current[parts[len(parts)-1]] = value
+// sliceIndex parses a path segment as an index into a slice of length n.
+// Only plain decimal digits are accepted, so "-1" and "+1" are rejected.
+func sliceIndex(part string, n int) (int, bool) {
+	if part == "" || part[0] < '0' || part[0] > '9' {
+		return 0, false
+	}
+	idx, err := strconv.Atoi(part)
+	if err != nil || idx >= n {
+		return 0, false
+	}
+	return idx, true
 }
@@ -61,6 +61,20 @@ func TestNewConfig(t *testing.T) {
 	}
 }
 
+func TestNewConfig_CopiesInputs(t *testing.T) {
+	base := ConfigObject{"database": map[string]interface{}{"host": "localhost"}}
+	cfg := NewConfig(base)
+
+	cfg.Set("database.host", "db.internal")
+	if got := base["database"].(map[string]interface{})["host"]; got != "localhost" {
+		t.Errorf("Set changed the input object: host = %v", got)
+	}
+	base["database"].(map[string]interface{})["host"] = "changed"
+	if got := cfg.Get("database.host", ""); got != "db.internal" {
+		t.Errorf("changing the input changed the config: host = %v", got)
+	}
+}
+
 func TestConfig_Get(t *testing.T) {
 	cfg := NewConfig(ConfigObject{
 		"timeout": 30,
@@ -219,6 +233,91 @@ func TestConfig_Set_Nil(t *testing.T) {
 	cfg.Set("key", "value") // Should not panic
 }
 
+// newServersConfig returns a Config holding an array of objects.
+func newServersConfig() *Config {
+	return NewConfig(ConfigObject{
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a.example.com", "port": 443},
+			map[string]interface{}{"host": "b.example.com", "tags": []interface{}{"eu", "primary"}},
+		},
+	})
+}
+
+func TestConfig_Get_ArrayIndex(t *testing.T) {
+	cfg := newServersConfig()
+
+	tests := []struct {
+		name string
+		key  string
+		want interface{}
+	}{
+		{"first element field", "servers.0.host", "a.example.com"},
+		{"second element field", "servers.1.host", "b.example.com"},
+		{"nested array", "servers.1.tags.1", "primary"},
+		{"out of range", "servers.2.host", "fallback"},
+		{"negative index", "servers.-1.host", "fallback"},
+		{"plus sign", "servers.+1.host", "fallback"},
+		{"non-numeric index", "servers.first.host", "fallback"},
+		{"empty index", "servers..host", "fallback"},
+		{"index into scalar", "servers.0.port.0", "fallback"},
+		{"missing field in element", "servers.1.port", "fallback"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.Get(tt.key, "fallback"); got != tt.want {
+				t.Errorf("Get(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := cfg.Get("servers.0").(map[string]interface{}); !ok {
+		t.Errorf("Get(servers.0) = %T, want map[string]interface{}", cfg.Get("servers.0"))
+	}
+}
+
+func TestConfig_Set_ArrayIndex(t *testing.T) {
+	t.Run("updates element field", func(t *testing.T) {
+		cfg := newServersConfig()
+		cfg.Set("servers.1.port", 8443)
+		if got := cfg.Get("servers.1.port"); got != 8443 {
+			t.Errorf("servers.1.port = %v, want 8443", got)
+		}
+		if got := cfg.Get("servers.1.host"); got != "b.example.com" {
+			t.Errorf("servers.1.host = %v, want it unchanged", got)
+		}
+	})
+
+	t.Run("replaces element", func(t *testing.T) {
+		cfg := newServersConfig()
+		cfg.Set("servers.1.tags.0", "us")
+		if got := cfg.Get("servers.1.tags.0"); got != "us" {
+			t.Errorf("servers.1.tags.0 = %v, want us", got)
+		}
+	})
+
+	t.Run("creates map in scalar element", func(t *testing.T) {
+		cfg := NewConfig(ConfigObject{"list": []interface{}{"x"}})
+		cfg.Set("list.0.name", "y")
+		if got := cfg.Get("list.0.name"); got != "y" {
+			t.Errorf("list.0.name = %v, want y", got)
+		}
+	})
+
+	for _, key := range []string{"servers.2.host", "servers.-1.host", "servers.x.host", "servers.5"} {
+		t.Run("ignores "+key, func(t *testing.T) {
+			cfg := newServersConfig()
+			cfg.Set(key, "changed")
+			servers, ok := cfg.Get("servers").([]interface{})
+			if !ok || len(servers) != 2 {
+				t.Fatalf("servers = %v, want the original two-element array", cfg.Get("servers"))
+			}
+			if got := cfg.Get("servers.0.host"); got != "a.example.com" {
+				t.Errorf("servers.0.host = %v, want it unchanged", got)
+			}
+		})
+	}
+}
+
 func TestConfig_Data(t *testing.T) {
 	t.Run("returns shallow copy of merged data", func(t *testing.T) {
 		cfg := NewConfig(ConfigObject{
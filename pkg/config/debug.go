@@ -0,0 +1,119 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Layer is one named configuration source passed to [DebugMerge], such as
+// "defaults" or "local (.cure.json)". Values may be nil for a source that
+// was not found.
+type Layer struct {
+	Name   string
+	Values ConfigObject
+}
+
+// DebugMerge merges the layers' values in order, exactly like [NewConfig],
+// and writes a report of the merge to w: the keys each layer sets, then
+// every effective value with the name of the layer it came from. Nested
+// maps are shown as dot-separated keys. Values of keys that look like
+// credentials, ending in "password", "secret", "token", or "key" (as in
+// "api_key"), are shown as [REDACTED].
+//
+// It is meant for a verbose or debug mode that explains why a setting has
+// the value it does; write errors are ignored.
+//
+// Example output:
+//
+//	config: defaults: 2 keys
+//	config:   format = "json"
+//	config:   timeout = 30
+//	config: local (.cure.json): 1 key
+//	config:   timeout = 60
+//	config: effective:
+//	config:   format = "json" (defaults)
+//	config:   timeout = 60 (local (.cure.json))
+func DebugMerge(w io.Writer, layers ...Layer) *Config {
+	objs := make([]ConfigObject, len(layers))
+	origin := make(map[string]string)
+	for i, layer := range layers {
+		objs[i] = layer.Values
+		flat := flatten(layer.Values)
+		keys := sortedKeys(flat)
+		switch len(keys) {
+		case 0:
+			fmt.Fprintf(w, "config: %s: no values\n", layer.Name)
+		case 1:
+			fmt.Fprintf(w, "config: %s: 1 key\n", layer.Name)
+		default:
+			fmt.Fprintf(w, "config: %s: %d keys\n", layer.Name, len(keys))
+		}
+		for _, k := range keys {
+			fmt.Fprintf(w, "config:   %s = %s\n", k, reportValue(k, flat[k]))
+			origin[k] = layer.Name
+		}
+	}
+
+	cfg := NewConfig(objs...)
+	flat := flatten(cfg.data)
+	fmt.Fprintln(w, "config: effective:")
+	for _, k := range sortedKeys(flat) {
+		fmt.Fprintf(w, "config:   %s = %s (%s)\n", k, reportValue(k, flat[k]), origin[k])
+	}
+	return cfg
+}
+
+// flatten returns obj's leaf values keyed by their dot-separated path.
+// Slices are leaves.
+func flatten(obj ConfigObject) map[string]interface{} {
+	out := make(map[string]interface{})
+	var walk func(prefix string, m ConfigObject)
+	walk = func(prefix string, m ConfigObject) {
+		for k, v := range m {
+			path := prefix + k
+			if sub, ok := asMap(v); ok && len(sub) > 0 {
+				walk(path+".", sub)
+				continue
+			}
+			out[path] = v
+		}
+	}
+	walk("", obj)
+	return out
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// reportValue formats v as JSON for the DebugMerge report, hiding values of
+// credential-like keys.
+func reportValue(key string, v interface{}) string {
+	if isSecretKey(key) {
+		return "[REDACTED]"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// isSecretKey reports whether the last segment of key names a credential.
+func isSecretKey(key string) bool {
+	name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	for _, s := range []string{"password", "secret", "token", "key"} {
+		if strings.HasSuffix(name, s) {
+			return true
+		}
+	}
+	return false
+}
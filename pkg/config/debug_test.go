@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDebugMerge(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := DebugMerge(&buf,
+		Layer{Name: "defaults", Values: ConfigObject{"timeout": 30, "format": "json"}},
+		Layer{Name: "global", Values: nil},
+		Layer{Name: "local", Values: ConfigObject{
+			"timeout": 60,
+			"agent":   map[string]interface{}{"api_key": "sk-123", "max_tokens": 100},
+		}},
+	)
+
+	if got := cfg.Get("timeout", 0); got != 60 {
+		t.Errorf("timeout = %v, want 60", got)
+	}
+	want := `config: defaults: 2 keys
+config:   format = "json"
+config:   timeout = 30
+config: global: no values
+config: local: 3 keys
+config:   agent.api_key = [REDACTED]
+config:   agent.max_tokens = 100
+config:   timeout = 60
+config: effective:
+config:   agent.api_key = [REDACTED] (local)
+config:   agent.max_tokens = 100 (local)
+config:   format = "json" (defaults)
+config:   timeout = 60 (local)
+`
+	if buf.String() != want {
+		t.Errorf("report =\n%s\nwant\n%s", buf.String(), want)
+	}
+	if strings.Contains(buf.String(), "sk-123") {
+		t.Error("report leaks a credential value")
+	}
+}
+
+func TestIsSecretKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"agent.claude.api_key", true},
+		{"github.TOKEN", true},
+		{"db.password", true},
+		{"oauth.client_secret", true},
+		{"agent.claude.max_tokens", false},
+		{"timeout", false},
+	}
+	for _, tt := range tests {
+		if got := isSecretKey(tt.key); got != tt.want {
+			t.Errorf("isSecretKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
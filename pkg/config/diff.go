@@ -0,0 +1,109 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind describes how a key differs between two configurations.
+type ChangeKind string
+
+const (
+	// Added marks a key present only in the second configuration.
+	Added ChangeKind = "added"
+	// Removed marks a key present only in the first configuration.
+	Removed ChangeKind = "removed"
+	// Changed marks a key present in both with different values.
+	Changed ChangeKind = "changed"
+)
+
+// Change is one difference reported by [Diff]. Path uses the same dot
+// notation as [Config.Get], with slice elements addressed by index, such as
+// "servers.0.host". Old is nil for Added and New is nil for Removed.
+type Change struct {
+	Path string      `json:"path"`
+	Kind ChangeKind  `json:"kind"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// Diff compares a with b and returns the keys that were added, removed, or
+// changed, sorted by path. Maps and slices present on both sides are
+// compared recursively, so only the differing leaves are reported; a value
+// whose type differs, such as a map replaced by a string, is a single
+// Changed entry. Slices are compared element by element, so extra elements
+// are Added or Removed at their index. A nil Config is treated as empty.
+//
+// Example:
+//
+//	a := config.NewConfig(config.ConfigObject{"timeout": 30, "db": map[string]interface{}{"host": "a"}})
+//	b := config.NewConfig(config.ConfigObject{"db": map[string]interface{}{"host": "b", "port": 5432}})
+//	config.Diff(a, b)
+//	// [{db.host changed a b} {db.port added <nil> 5432} {timeout removed 30 <nil>}]
+func Diff(a, b *Config) []Change {
+	var changes []Change
+	diffMaps(&changes, "", configData(a), configData(b))
+	slices.SortFunc(changes, func(x, y Change) int {
+		return strings.Compare(x.Path, y.Path)
+	})
+	return changes
+}
+
+func configData(c *Config) ConfigObject {
+	if c == nil {
+		return nil
+	}
+	return c.data
+}
+
+// diffMaps appends the differences between the maps a and b to changes,
+// prefixing each key with prefix.
+func diffMaps(changes *[]Change, prefix string, a, b ConfigObject) {
+	for k, av := range a {
+		bv, ok := b[k]
+		if !ok {
+			*changes = append(*changes, Change{Path: prefix + k, Kind: Removed, Old: av})
+			continue
+		}
+		diffValues(changes, prefix+k, av, bv)
+	}
+	for k, bv := range b {
+		if _, ok := a[k]; !ok {
+			*changes = append(*changes, Change{Path: prefix + k, Kind: Added, New: bv})
+		}
+	}
+}
+
+// diffValues compares two values found at path, descending into maps and
+// slices when both sides have the same shape.
+func diffValues(changes *[]Change, path string, a, b interface{}) {
+	am, aIsMap := asMap(a)
+	bm, bIsMap := asMap(b)
+	if aIsMap && bIsMap {
+		diffMaps(changes, path+".", am, bm)
+		return
+	}
+
+	as, aIsSlice := a.([]interface{})
+	bs, bIsSlice := b.([]interface{})
+	if aIsSlice && bIsSlice {
+		for i := 0; i < max(len(as), len(bs)); i++ {
+			p := path + "." + strconv.Itoa(i)
+			switch {
+			case i >= len(bs):
+				*changes = append(*changes, Change{Path: p, Kind: Removed, Old: as[i]})
+			case i >= len(as):
+				*changes = append(*changes, Change{Path: p, Kind: Added, New: bs[i]})
+			default:
+				diffValues(changes, p, as[i], bs[i])
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: path, Kind: Changed, Old: a, New: b})
+	}
+}
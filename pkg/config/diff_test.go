@@ -0,0 +1,105 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b ConfigObject
+		want []Change
+	}{
+		{
+			name: "identical",
+			a:    ConfigObject{"timeout": 30, "db": map[string]interface{}{"host": "a"}},
+			b:    ConfigObject{"timeout": 30, "db": map[string]interface{}{"host": "a"}},
+			want: nil,
+		},
+		{
+			name: "added",
+			a:    ConfigObject{},
+			b:    ConfigObject{"timeout": 30},
+			want: []Change{{Path: "timeout", Kind: Added, New: 30}},
+		},
+		{
+			name: "removed",
+			a:    ConfigObject{"verbose": false},
+			b:    ConfigObject{},
+			want: []Change{{Path: "verbose", Kind: Removed, Old: false}},
+		},
+		{
+			name: "changed",
+			a:    ConfigObject{"format": "json"},
+			b:    ConfigObject{"format": "html"},
+			want: []Change{{Path: "format", Kind: Changed, Old: "json", New: "html"}},
+		},
+		{
+			name: "type change",
+			a:    ConfigObject{"db": map[string]interface{}{"host": "a"}},
+			b:    ConfigObject{"db": "postgres://a"},
+			want: []Change{{Path: "db", Kind: Changed, Old: map[string]interface{}{"host": "a"}, New: "postgres://a"}},
+		},
+		{
+			name: "nested map",
+			a: ConfigObject{"agent": map[string]interface{}{
+				"claude": map[string]interface{}{"model": "x", "max_tokens": 10.0},
+			}},
+			b: ConfigObject{"agent": map[string]interface{}{
+				"claude": map[string]interface{}{"model": "y", "temperature": 0.5},
+			}},
+			want: []Change{
+				{Path: "agent.claude.max_tokens", Kind: Removed, Old: 10.0},
+				{Path: "agent.claude.model", Kind: Changed, Old: "x", New: "y"},
+				{Path: "agent.claude.temperature", Kind: Added, New: 0.5},
+			},
+		},
+		{
+			name: "nested slice",
+			a: ConfigObject{"servers": []interface{}{
+				map[string]interface{}{"host": "a"},
+				map[string]interface{}{"host": "b"},
+			}},
+			b: ConfigObject{"servers": []interface{}{
+				map[string]interface{}{"host": "a", "port": 80.0},
+			}},
+			want: []Change{
+				{Path: "servers.0.port", Kind: Added, New: 80.0},
+				{Path: "servers.1", Kind: Removed, Old: map[string]interface{}{"host": "b"}},
+			},
+		},
+		{
+			name: "slice grows",
+			a:    ConfigObject{"tags": []interface{}{"a"}},
+			b:    ConfigObject{"tags": []interface{}{"a", "b"}},
+			want: []Change{{Path: "tags.1", Kind: Added, New: "b"}},
+		},
+		{
+			name: "config object and map compare by content",
+			a:    ConfigObject{"db": ConfigObject{"host": "a"}},
+			b:    ConfigObject{"db": map[string]interface{}{"host": "a"}},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Diff(NewConfig(tt.a), NewConfig(tt.b))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff_NilConfig(t *testing.T) {
+	got := Diff(nil, NewConfig(ConfigObject{"timeout": 30}))
+	want := []Change{{Path: "timeout", Kind: Added, New: 30}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff(nil, b) = %#v, want %#v", got, want)
+	}
+	if got := Diff(nil, nil); got != nil {
+		t.Errorf("Diff(nil, nil) = %#v, want nil", got)
+	}
+}
@@ -28,4 +28,22 @@
 //	source := ConfigObject{"a": map[string]interface{}{"c": 2}}
 //	result := DeepMerge(target, source)
 //	// result: {"a": {"b": 1, "c": 2}}
+//
+// DeepMerge returns a new object and never modifies target or source.
+//
+// # Includes
+//
+// A config file may list other files under "include"; File merges them
+// first so the including file's keys win:
+//
+//	{"include": ["base.json", "env/prod.json"], "timeout": 10}
+//
+// # Validation
+//
+// Validate checks a Config against a Schema and returns all violations:
+//
+//	errs := config.Validate(cfg, config.Schema{
+//	    "timeout": {Type: config.TypeInt, Required: true},
+//	    "format":  {Type: config.TypeString, Enum: []string{"json", "html"}},
+//	})
 package config
@@ -5,21 +5,51 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
+// IncludeKey is the top-level key listing other files a configuration file
+// builds on.
+const IncludeKey = "include"
+
+// FileOption configures File.
+type FileOption func(*fileConfig)
+
+type fileConfig struct {
+	includeRoot string
+}
+
+// WithIncludeRoot restricts include paths to files inside dir. An include that
+// resolves outside dir, for example via "../", is an error. Default: the
+// directory of the file passed to File.
+func WithIncludeRoot(dir string) FileOption {
+	return func(cfg *fileConfig) {
+		cfg.includeRoot = dir
+	}
+}
+
 // File loads a JSON configuration file and returns the parsed ConfigObject.
 // Returns an error if the file cannot be read or parsed.
 //
 // Supports tilde expansion for home directory paths.
 //
+// A file may build on others by listing them under the "include" key:
+//
+//	{"include": ["base.json", "env/prod.json"], "timeout": 10}
+//
+// Includes are resolved relative to the including file, loaded recursively,
+// and deep-merged in order before the file's own keys, so the including file
+// wins. The include key itself is removed from the result. Include cycles and
+// paths outside the include root (see [WithIncludeRoot]) are errors.
+//
 // Example:
 //
 //	cfg, err := File("~/.cure.json")
 //	if err != nil {
 //	    // handle error
 //	}
-func File(path string) (ConfigObject, error) {
+func File(path string, opts ...FileOption) (ConfigObject, error) {
 	// Expand tilde to home directory
 	if strings.HasPrefix(path, "~") {
 		homeDir, err := os.UserHomeDir()
@@ -29,10 +59,33 @@ func File(path string) (ConfigObject, error) {
 		path = filepath.Clean(filepath.Join(homeDir, path[1:]))
 	}
 
+	cfg := &fileConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve config path %s: %w", path, err)
+	}
+	root := cfg.includeRoot
+	if root == "" {
+		root = filepath.Dir(abs)
+	}
+	if root, err = filepath.Abs(root); err != nil {
+		return nil, fmt.Errorf("cannot resolve include root: %w", err)
+	}
+
+	return loadFile(abs, root, nil)
+}
+
+// loadFile reads the file at the absolute path and merges its includes.
+// stack holds the files currently being loaded, for cycle detection.
+func loadFile(path, root string, stack []string) (ConfigObject, error) {
 	// Read file
 	data, err := os.ReadFile(path)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if os.IsNotExist(err) && len(stack) == 0 {
 			return nil, err // Caller can check with os.IsNotExist
 		}
 		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
@@ -44,5 +97,56 @@ func File(path string) (ConfigObject, error) {
 		return nil, fmt.Errorf("invalid JSON in %s: %w", path, err)
 	}
 
-	return result, nil
+	raw, ok := result[IncludeKey]
+	if !ok {
+		return result, nil
+	}
+	delete(result, IncludeKey)
+
+	includes, err := includePaths(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q in %s: %w", IncludeKey, path, err)
+	}
+
+	stack = append(stack, path)
+	merged := make(ConfigObject)
+	for _, inc := range includes {
+		incPath := inc
+		if !filepath.IsAbs(incPath) {
+			incPath = filepath.Join(filepath.Dir(path), incPath)
+		}
+		incPath = filepath.Clean(incPath)
+
+		if rel, err := filepath.Rel(root, incPath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("include %q in %s is outside the include root %s", inc, path, root)
+		}
+		if slices.Contains(stack, incPath) {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, incPath), " -> "))
+		}
+
+		incCfg, err := loadFile(incPath, root, stack)
+		if err != nil {
+			return nil, err
+		}
+		merged = DeepMerge(merged, incCfg)
+	}
+
+	return DeepMerge(merged, result), nil
+}
+
+// includePaths converts the decoded include value into a list of paths.
+func includePaths(raw interface{}) ([]string, error) {
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array of paths, got %T", raw)
+	}
+	paths := make([]string, 0, len(list))
+	for _, v := range list {
+		s, ok := v.(string)
+		if !ok || s == "" {
+			return nil, fmt.Errorf("expected a non-empty path string, got %v", v)
+		}
+		paths = append(paths, s)
+	}
+	return paths, nil
 }
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -103,3 +104,73 @@ func TestFile_NotFoundIsExist(t *testing.T) {
 		t.Errorf("error should be IsNotExist, got %v", err)
 	}
 }
+
+func TestFile_Include(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		p := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	write("shared/base.json", `{"format": "json", "timeout": 30, "db": {"host": "localhost", "port": 5432}}`)
+	write("app/env/prod.json", `{"include": ["../../shared/base.json"], "db": {"host": "prod-db"}}`)
+	app := write("app/config.json", `{"include": ["env/prod.json"], "timeout": 10}`)
+	write("cycle/a.json", `{"include": ["b.json"]}`)
+	cycle := write("cycle/b.json", `{"include": ["a.json"]}`)
+	escape := write("escape/config.json", `{"include": ["../shared/base.json"]}`)
+	missing := write("missing/config.json", `{"include": ["nope.json"]}`)
+	badType := write("bad/config.json", `{"include": "base.json"}`)
+
+	t.Run("merges includes before own keys", func(t *testing.T) {
+		cfg, err := File(app, WithIncludeRoot(tmpDir))
+		if err != nil {
+			t.Fatalf("File() error = %v", err)
+		}
+		if _, ok := cfg[IncludeKey]; ok {
+			t.Error("include key not removed from result")
+		}
+		c := NewConfig(cfg)
+		want := map[string]interface{}{
+			"timeout": float64(10),
+			"format":  "json",
+			"db.host": "prod-db",
+			"db.port": float64(5432),
+		}
+		for key, v := range want {
+			if got := c.Get(key); got != v {
+				t.Errorf("Get(%q) = %v, want %v", key, got, v)
+			}
+		}
+	})
+
+	errTests := []struct {
+		name    string
+		path    string
+		opts    []FileOption
+		wantErr string
+	}{
+		{"cycle", cycle, nil, "include cycle"},
+		{"outside default root", escape, nil, "outside the include root"},
+		{"outside explicit root", app, []FileOption{WithIncludeRoot(filepath.Join(tmpDir, "app"))}, "outside the include root"},
+		{"missing include", missing, nil, "nope.json"},
+		{"include not an array", badType, nil, "expected an array"},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := File(tt.path, tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("File() error = %v, want error containing %q", err, tt.wantErr)
+			}
+			if os.IsNotExist(err) {
+				t.Error("File() error satisfies os.IsNotExist; only a missing top-level file should")
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package config
 
-// DeepMerge recursively merges source into target.
-// Returns the merged result. Target is mutated in-place for performance.
+// DeepMerge recursively merges source into target and returns the result as
+// a new ConfigObject. Neither argument is modified, and the result shares no
+// maps or slices with them, so either input can be reused or changed
+// afterwards without affecting the other or the result.
 //
 // Merge rules:
 //   - Maps: recursively merge keys (source overwrites target for shared keys)
@@ -9,7 +11,10 @@ package config
 //   - Primitives: source replaces target
 //   - Type conflicts: source type takes precedence
 //   - nil target treated as empty map
-//   - nil source is a no-op (returns target unchanged)
+//   - nil source returns a copy of target
+//
+// Only map[string]interface{}, ConfigObject and []interface{} values are
+// copied; other values, such as a []string set from Go code, are shared.
 //
 // Example:
 //
@@ -18,68 +23,87 @@ package config
 //	result := DeepMerge(target, source)
 //	// result: {"a": {"b": 1, "c": 2}}
 func DeepMerge(target, source ConfigObject) ConfigObject {
-	if source == nil {
-		if target == nil {
-			return make(ConfigObject)
-		}
-		return target
-	}
-	if target == nil {
-		target = make(ConfigObject)
+	result := make(ConfigObject, len(target)+len(source))
+	for key, value := range target {
+		result[key] = copyValue(value)
 	}
+	mergeInto(result, source)
+	return result
+}
 
+// mergeInto merges a copy of source into dst in place, following the
+// DeepMerge rules. dst and the maps nested in it must not be shared with
+// any caller; NewConfig uses it to build its result without copying the
+// accumulated layers again for every object.
+func mergeInto(dst, source ConfigObject) {
 	for key, sourceValue := range source {
-		targetValue, exists := target[key]
+		targetValue, exists := dst[key]
 		if !exists {
-			target[key] = sourceValue
+			dst[key] = copyValue(sourceValue)
 			continue
 		}
 
 		// Both values exist - check types
-		// Handle both map[string]interface{} and ConfigObject
-		var targetMap, sourceMap map[string]interface{}
-		targetIsMap := false
-		sourceIsMap := false
-
-		switch tv := targetValue.(type) {
-		case map[string]interface{}:
-			targetMap = tv
-			targetIsMap = true
-		case ConfigObject:
-			targetMap = map[string]interface{}(tv)
-			targetIsMap = true
-		}
-
-		switch sv := sourceValue.(type) {
-		case map[string]interface{}:
-			sourceMap = sv
-			sourceIsMap = true
-		case ConfigObject:
-			sourceMap = map[string]interface{}(sv)
-			sourceIsMap = true
-		}
-
+		targetMap, targetIsMap := asMap(targetValue)
+		sourceMap, sourceIsMap := asMap(sourceValue)
 		if targetIsMap && sourceIsMap {
-			// Both are maps - recursively merge
-			target[key] = DeepMerge(targetMap, sourceMap)
+			// Both are maps - recursively merge into dst's own copy
+			mergeInto(targetMap, sourceMap)
 			continue
 		}
 
 		targetSlice, targetIsSlice := targetValue.([]interface{})
 		sourceSlice, sourceIsSlice := sourceValue.([]interface{})
-
 		if targetIsSlice && sourceIsSlice {
 			// Both are slices - concatenate
-			merged := make([]interface{}, len(targetSlice)+len(sourceSlice))
+			merged := make([]interface{}, len(targetSlice), len(targetSlice)+len(sourceSlice))
 			copy(merged, targetSlice)
-			copy(merged[len(targetSlice):], sourceSlice)
-			target[key] = merged
+			for _, v := range sourceSlice {
+				merged = append(merged, copyValue(v))
+			}
+			dst[key] = merged
 			continue
 		}
 
 		// Type conflict or primitives - source wins
-		target[key] = sourceValue
+		dst[key] = copyValue(sourceValue)
 	}
+}
+
+// asMap reports whether v is a map[string]interface{} or a ConfigObject and
+// returns it as a ConfigObject sharing the same storage.
+func asMap(v interface{}) (ConfigObject, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return ConfigObject(m), true
+	case ConfigObject:
+		return m, true
+	}
+	return nil, false
+}
 
-	return target
+// copyValue returns a deep copy of v's maps and []interface{} slices,
+// keeping their types. Other values are returned as they are.
+func copyValue(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(tv))
+		for k, e := range tv {
+			out[k] = copyValue(e)
+		}
+		return out
+	case ConfigObject:
+		out := make(ConfigObject, len(tv))
+		for k, e := range tv {
+			out[k] = copyValue(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(tv))
+		for i, e := range tv {
+			out[i] = copyValue(e)
+		}
+		return out
+	}
+	return v
 }
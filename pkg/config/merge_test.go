@@ -228,3 +228,59 @@ func TestDeepMerge(t *testing.T) {
 		})
 	}
 }
+
+func TestDeepMerge_DoesNotMutateInputs(t *testing.T) {
+	newTarget := func() ConfigObject {
+		return ConfigObject{
+			"name": "base",
+			"database": map[string]interface{}{
+				"host": "localhost",
+				"pool": ConfigObject{"size": 5},
+			},
+			"tags": []interface{}{"dev", map[string]interface{}{"k": "v"}},
+		}
+	}
+	newSource := func() ConfigObject {
+		return ConfigObject{
+			"database": map[string]interface{}{
+				"port": 5432,
+				"pool": ConfigObject{"idle": 2},
+			},
+			"tags":  []interface{}{"prod"},
+			"extra": map[string]interface{}{"nested": []interface{}{1, 2}},
+		}
+	}
+	target, source := newTarget(), newSource()
+
+	got := DeepMerge(target, source)
+
+	if !configEqual(target, newTarget()) {
+		t.Errorf("target mutated: %v", target)
+	}
+	if !configEqual(source, newSource()) {
+		t.Errorf("source mutated: %v", source)
+	}
+
+	// Changing the result must not reach either input.
+	got["database"].(map[string]interface{})["host"] = "changed"
+	got["database"].(map[string]interface{})["pool"].(ConfigObject)["size"] = 99
+	got["tags"].([]interface{})[0] = "changed"
+	got["tags"].([]interface{})[1].(map[string]interface{})["k"] = "changed"
+	got["tags"].([]interface{})[2] = "changed"
+	got["extra"].(map[string]interface{})["nested"].([]interface{})[0] = 99
+	if !configEqual(target, newTarget()) {
+		t.Errorf("target shares storage with result: %v", target)
+	}
+	if !configEqual(source, newSource()) {
+		t.Errorf("source shares storage with result: %v", source)
+	}
+}
+
+func TestDeepMerge_NilSourceCopiesTarget(t *testing.T) {
+	target := ConfigObject{"a": map[string]interface{}{"b": 1}}
+	got := DeepMerge(target, nil)
+	got["a"].(map[string]interface{})["b"] = 2
+	if target["a"].(map[string]interface{})["b"] != 1 {
+		t.Errorf("DeepMerge(target, nil) shares nested maps with target")
+	}
+}
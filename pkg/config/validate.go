@@ -0,0 +1,218 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Type is the expected Go type of a configuration value.
+type Type int
+
+const (
+	// TypeAny accepts any non-nil value.
+	TypeAny Type = iota
+	// TypeString requires a string.
+	TypeString
+	// TypeInt requires an int. JSON numbers decode as float64 and environment
+	// values are strings, so neither satisfies TypeInt until [Normalize]
+	// converts them.
+	TypeInt
+	// TypeFloat requires a float64 or an int.
+	TypeFloat
+	// TypeBool requires a bool.
+	TypeBool
+	// TypeObject requires a nested object.
+	TypeObject
+)
+
+// String returns the lowercase name of t.
+func (t Type) String() string {
+	switch t {
+	case TypeAny:
+		return "any"
+	case TypeString:
+		return "string"
+	case TypeInt:
+		return "int"
+	case TypeFloat:
+		return "float"
+	case TypeBool:
+		return "bool"
+	case TypeObject:
+		return "object"
+	default:
+		return fmt.Sprintf("Type(%d)", int(t))
+	}
+}
+
+// Rule constrains the value stored under one key.
+type Rule struct {
+	// Type is the expected value type.
+	Type Type
+
+	// Required reports a violation when the key is missing or null.
+	Required bool
+
+	// Enum, when non-empty, lists the allowed values for a TypeString key.
+	Enum []string
+}
+
+// Schema maps dot-notation keys to the rule their value must satisfy.
+// Keys not listed in the schema are ignored.
+//
+// Example:
+//
+//	schema := config.Schema{
+//	    "timeout": {Type: config.TypeInt, Required: true},
+//	    "format":  {Type: config.TypeString, Enum: []string{"json", "html"}},
+//	}
+type Schema map[string]Rule
+
+// ValidationError describes a single schema violation.
+type ValidationError struct {
+	Key     string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("config key %q: %s", e.Key, e.Message)
+}
+
+// Validate checks cfg against schema and returns every violation, ordered by
+// key. It returns nil when cfg satisfies the schema.
+//
+// Validate catches values that would panic on a later type assertion, such as
+// a "timeout" of "ten" where the code expects an int. Call [Normalize] first,
+// so that numbers from JSON files and values from the environment are
+// converted to the types the schema asks for rather than reported.
+func Validate(cfg *Config, schema Schema) []error {
+	keys := make([]string, 0, len(schema))
+	for k := range schema {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		rule := schema[key]
+		value := cfg.Get(key)
+		if value == nil {
+			if rule.Required {
+				errs = append(errs, &ValidationError{Key: key, Message: "required but not set"})
+			}
+			continue
+		}
+		if !rule.Type.matches(value) {
+			errs = append(errs, &ValidationError{
+				Key:     key,
+				Message: fmt.Sprintf("expected %s, got %T (%v)", rule.Type, value, value),
+			})
+			continue
+		}
+		if len(rule.Enum) > 0 {
+			if s, ok := value.(string); ok && !slices.Contains(rule.Enum, s) {
+				errs = append(errs, &ValidationError{
+					Key:     key,
+					Message: fmt.Sprintf("%q is not one of [%s]", s, strings.Join(rule.Enum, ", ")),
+				})
+			}
+		}
+	}
+	return errs
+}
+
+// Normalize converts the values of cfg to the types schema asks for where
+// the conversion loses nothing, so code can assert the schema's types:
+//
+//   - TypeInt: a whole float64, as JSON numbers decode, or a string holding
+//     an integer, as environment variables arrive, becomes an int
+//   - TypeFloat: a string holding a number becomes a float64
+//   - TypeBool: a string such as "true" or "0" becomes a bool
+//
+// Values that cannot be converted are left as they are for [Validate] to
+// report.
+//
+// Example:
+//
+//	cfg := config.NewConfig(defaults, fileCfg, config.Environment("CURE_", "_"))
+//	config.Normalize(cfg, schema) // {"timeout": 10} and CURE_TIMEOUT=5 become ints
+//	errs := config.Validate(cfg, schema)
+func Normalize(cfg *Config, schema Schema) {
+	if cfg == nil {
+		return
+	}
+	for key, rule := range schema {
+		value := cfg.Get(key)
+		if value == nil || rule.Type.matches(value) {
+			continue
+		}
+		if v, ok := rule.Type.convert(value); ok {
+			cfg.Set(key, v)
+		}
+	}
+}
+
+// convert returns v as type t when that loses nothing.
+func (t Type) convert(v interface{}) (interface{}, bool) {
+	switch t {
+	case TypeInt:
+		switch v := v.(type) {
+		case float64:
+			if v == math.Trunc(v) && v >= math.MinInt && v <= math.MaxInt {
+				return int(v), true
+			}
+		case string:
+			if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				return n, true
+			}
+		}
+	case TypeFloat:
+		if s, ok := v.(string); ok {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				return f, true
+			}
+		}
+	case TypeBool:
+		if s, ok := v.(string); ok {
+			if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+				return b, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// matches reports whether v has type t.
+func (t Type) matches(v interface{}) bool {
+	switch t {
+	case TypeAny:
+		return true
+	case TypeString:
+		_, ok := v.(string)
+		return ok
+	case TypeInt:
+		_, ok := v.(int)
+		return ok
+	case TypeFloat:
+		switch v.(type) {
+		case float64, int:
+			return true
+		}
+		return false
+	case TypeBool:
+		_, ok := v.(bool)
+		return ok
+	case TypeObject:
+		switch v.(type) {
+		case map[string]interface{}, ConfigObject:
+			return true
+		}
+		return false
+	default:
+		return false
+	}
+}
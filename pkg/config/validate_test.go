@@ -0,0 +1,176 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	schema := Schema{
+		"timeout":     {Type: TypeInt, Required: true},
+		"format":      {Type: TypeString, Enum: []string{"json", "html"}},
+		"verbose":     {Type: TypeBool},
+		"ratio":       {Type: TypeFloat},
+		"agent":       {Type: TypeObject},
+		"agent.model": {Type: TypeString, Required: true},
+	}
+
+	tests := []struct {
+		name    string
+		data    ConfigObject
+		wantErr []string // substrings, in key order
+	}{
+		{
+			name: "valid config",
+			data: ConfigObject{
+				"timeout": 30,
+				"format":  "html",
+				"verbose": true,
+				"ratio":   2,
+				"agent":   map[string]interface{}{"model": "m"},
+			},
+		},
+		{
+			name: "missing required keys",
+			data: ConfigObject{},
+			wantErr: []string{
+				`"agent.model": required but not set`,
+				`"timeout": required but not set`,
+			},
+		},
+		{
+			name: "string timeout from environment",
+			data: ConfigObject{"timeout": "30", "agent": map[string]interface{}{"model": "m"}},
+			wantErr: []string{
+				`"timeout": expected int, got string (30)`,
+			},
+		},
+		{
+			name: "float timeout from JSON file",
+			data: ConfigObject{"timeout": float64(30), "agent": map[string]interface{}{"model": "m"}},
+			wantErr: []string{
+				`"timeout": expected int, got float64 (30)`,
+			},
+		},
+		{
+			name: "all violations reported",
+			data: ConfigObject{
+				"timeout": "soon",
+				"format":  "xml",
+				"verbose": "yes",
+				"ratio":   "high",
+				"agent":   "claude",
+			},
+			wantErr: []string{
+				`"agent": expected object`,
+				`"agent.model": required but not set`,
+				`"format": "xml" is not one of [json, html]`,
+				`"ratio": expected float`,
+				`"timeout": expected int`,
+				`"verbose": expected bool`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Validate(NewConfig(tt.data), schema)
+			if len(errs) != len(tt.wantErr) {
+				t.Fatalf("Validate() returned %d errors %v, want %d", len(errs), errs, len(tt.wantErr))
+			}
+			for i, err := range errs {
+				if !strings.Contains(err.Error(), tt.wantErr[i]) {
+					t.Errorf("errs[%d] = %q, want it to contain %q", i, err, tt.wantErr[i])
+				}
+				var vErr *ValidationError
+				if !errors.As(err, &vErr) {
+					t.Errorf("errs[%d] is %T, want *ValidationError", i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_NilConfig(t *testing.T) {
+	errs := Validate(nil, Schema{"timeout": {Type: TypeInt, Required: true}})
+	if len(errs) != 1 {
+		t.Fatalf("Validate(nil) returned %d errors, want 1", len(errs))
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	schema := Schema{
+		"timeout":    {Type: TypeInt},
+		"ratio":      {Type: TypeFloat},
+		"verbose":    {Type: TypeBool},
+		"audit.args": {Type: TypeBool},
+		"retries":    {Type: TypeInt},
+		"name":       {Type: TypeString},
+	}
+	cfg := NewConfig(ConfigObject{
+		"timeout": 10.0,
+		"ratio":   "0.5",
+		"verbose": "true",
+		"audit":   map[string]interface{}{"args": "0"},
+		"retries": 2.5,
+		"name":    "cure",
+	})
+	Normalize(cfg, schema)
+
+	want := map[string]interface{}{
+		"timeout":    10,
+		"ratio":      0.5,
+		"verbose":    true,
+		"audit.args": false,
+		"retries":    2.5, // not whole, left for Validate to report
+		"name":       "cure",
+	}
+	for key, w := range want {
+		if got := cfg.Get(key); got != w {
+			t.Errorf("%s = %#v, want %#v", key, got, w)
+		}
+	}
+	if errs := Validate(cfg, schema); len(errs) != 1 || !strings.Contains(errs[0].Error(), "retries") {
+		t.Errorf("Validate() = %v, want only the retries error", errs)
+	}
+}
+
+func TestNormalize_FileAndEnvironment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cure.json")
+	if err := os.WriteFile(path, []byte(`{"timeout": 10, "format": "html"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fileCfg, err := File(path)
+	if err != nil {
+		t.Fatalf("File() error = %v", err)
+	}
+	t.Setenv("CURETEST_RETRIES", "5")
+	t.Setenv("CURETEST_VERBOSE", "true")
+
+	schema := Schema{
+		"timeout": {Type: TypeInt, Required: true},
+		"retries": {Type: TypeInt},
+		"verbose": {Type: TypeBool},
+		"format":  {Type: TypeString, Enum: []string{"json", "html"}},
+	}
+	cfg := NewConfig(fileCfg, Environment("CURETEST_", "_"))
+	if errs := Validate(cfg, schema); len(errs) == 0 {
+		t.Fatal("Validate() before Normalize = nil, want type errors for the JSON number and env strings")
+	}
+	Normalize(cfg, schema)
+	if errs := Validate(cfg, schema); errs != nil {
+		t.Errorf("Validate() after Normalize = %v, want nil", errs)
+	}
+	if got, ok := cfg.Get("timeout").(int); !ok || got != 10 {
+		t.Errorf("timeout = %#v, want int 10", cfg.Get("timeout"))
+	}
+	if got, ok := cfg.Get("retries").(int); !ok || got != 5 {
+		t.Errorf("retries = %#v, want int 5", cfg.Get("retries"))
+	}
+	if got, ok := cfg.Get("verbose").(bool); !ok || !got {
+		t.Errorf("verbose = %#v, want true", cfg.Get("verbose"))
+	}
+}
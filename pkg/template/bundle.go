@@ -0,0 +1,79 @@
+package template
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// outputDirective matches {{/* cure:output PATH TEMPLATE */}} comments.
+var outputDirective = regexp.MustCompile(`\{\{-?\s*/\*\s*cure:output\s+(\S+)\s+(\S+)\s*\*/\s*-?\}\}`)
+
+// OutputDirective is the template comment that declares one output file of a
+// bundle. A bundle template lists one directive per file, naming the relative
+// output path and the template that renders it:
+//
+//	{{/* cure:output Dockerfile dockerfile-go */}}
+//	{{/* cure:output .dockerignore dockerignore */}}
+//
+// Directives are ordinary template comments, so they never appear in rendered
+// output. See [RenderBundle].
+const OutputDirective = "{{/* cure:output PATH TEMPLATE */}}"
+
+// bundleOutput is one file declared by an OutputDirective.
+type bundleOutput struct {
+	path     string
+	template string
+}
+
+// parseOutputs returns the outputs declared in content, in source order.
+func parseOutputs(content string) []bundleOutput {
+	var outputs []bundleOutput
+	for _, m := range outputDirective.FindAllStringSubmatch(content, -1) {
+		outputs = append(outputs, bundleOutput{path: m[1], template: m[2]})
+	}
+	return outputs
+}
+
+// RenderBundle renders every output declared by the named bundle template and
+// returns the contents keyed by relative output path. Each output is rendered
+// with [Render], so Format and the NoFormatDirective apply per file.
+//
+// Returns an error if the template declares no outputs, an output path is
+// absolute or escapes the output directory, or any output fails to render.
+//
+// Example:
+//
+//	files, err := template.RenderBundle("docker-go", data)
+//	// files: {"Dockerfile": "...", ".dockerignore": "..."}
+func RenderBundle(name string, data interface{}) (map[string]string, error) {
+	reg, err := getRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("template registry: %w", err)
+	}
+	if reg.Lookup(name) == nil {
+		return nil, fmt.Errorf("template %q not found (available: %s)", name, strings.Join(List(), ", "))
+	}
+
+	mu.Lock()
+	outputs := bundles[name]
+	mu.Unlock()
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("template %q declares no outputs", name)
+	}
+
+	files := make(map[string]string, len(outputs))
+	for _, out := range outputs {
+		path := filepath.ToSlash(filepath.Clean(out.path))
+		if filepath.IsAbs(out.path) || path == ".." || strings.HasPrefix(path, "../") {
+			return nil, fmt.Errorf("template %q: output path %q must stay inside the output directory", name, out.path)
+		}
+		content, err := Render(out.template, data)
+		if err != nil {
+			return nil, fmt.Errorf("template %q: output %s: %w", name, path, err)
+		}
+		files[path] = content
+	}
+	return files, nil
+}
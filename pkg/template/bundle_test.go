@@ -0,0 +1,89 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderBundle_Embedded(t *testing.T) {
+	resetRegistry()
+
+	files, err := RenderBundle("docker-go", map[string]interface{}{
+		"GoVersion":   "1.25",
+		"Binary":      "api",
+		"MainPackage": "./cmd/api",
+	})
+	if err != nil {
+		t.Fatalf("RenderBundle() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("RenderBundle() returned %d files, want 2: %v", len(files), files)
+	}
+	if !strings.Contains(files["Dockerfile"], "-o /out/api ./cmd/api") {
+		t.Errorf("Dockerfile missing build line:\n%s", files["Dockerfile"])
+	}
+	if !strings.Contains(files[".dockerignore"], ".git") {
+		t.Errorf(".dockerignore missing .git:\n%s", files[".dockerignore"])
+	}
+}
+
+func TestRenderBundle_Errors(t *testing.T) {
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd: %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("Chdir: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(origDir)
+		resetRegistry()
+	})
+
+	templateDir := filepath.Join(tmpDir, ".cure", "templates")
+	if err := os.MkdirAll(templateDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	files := map[string]string{
+		"escape.tmpl":  "{{/* cure:output ../outside.txt part */}}",
+		"missing.tmpl": "{{/* cure:output a.txt no-such-template */}}",
+		"nested.tmpl":  "{{/* cure:output conf/app.txt part */}}",
+		"part.tmpl":    "hello {{.Name}}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	resetRegistry()
+
+	got, err := RenderBundle("nested", map[string]interface{}{"Name": "world"})
+	if err != nil {
+		t.Fatalf("RenderBundle(nested) error = %v", err)
+	}
+	if got["conf/app.txt"] != "hello world\n" {
+		t.Errorf("RenderBundle(nested) = %q", got)
+	}
+
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{"escape", "must stay inside"},
+		{"missing", "no-such-template"},
+		{"part", "declares no outputs"},
+		{"unknown-bundle", "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := RenderBundle(tt.name, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("RenderBundle(%q) error = %v, want error containing %q", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
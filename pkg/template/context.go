@@ -0,0 +1,102 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Reserved data keys filled in by [ContextData] and [RenderWithContext].
+const (
+	// EnvKey holds the process environment as a map[string]string, so
+	// templates can write {{.Env.HOME}}.
+	EnvKey = "Env"
+
+	// NowKey holds the render time formatted as RFC 3339, for stamping a
+	// generation date into output.
+	NowKey = "Now"
+
+	// UserKey holds the name of the user running the process.
+	UserKey = "User"
+)
+
+// now is the clock used for NowKey. Tests replace it.
+var now = time.Now
+
+// ContextData returns a copy of data with the reserved context keys
+// [EnvKey], [NowKey], and [UserKey] added. data itself is not modified.
+//
+// Caller values win: when data already sets a reserved key its value is kept
+// and a warning naming the key is printed to stderr, so a collision is never
+// silent. A nil data is treated as empty.
+//
+// Use ContextData to add the context to data for [RenderBundle] or [RenderTo];
+// [RenderWithContext] is shorthand for Render(name, ContextData(data)).
+func ContextData(data map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(data)+3)
+	for k, v := range data {
+		merged[k] = v
+	}
+
+	reserved := map[string]interface{}{
+		EnvKey:  environ(),
+		NowKey:  now().Format(time.RFC3339),
+		UserKey: currentUser(),
+	}
+	keys := make([]string, 0, len(reserved))
+	for k := range reserved {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, ok := merged[k]; ok {
+			fmt.Fprintf(os.Stderr, "warning: template data key %q is reserved; keeping the caller's value\n", k)
+			continue
+		}
+		merged[k] = reserved[k]
+	}
+	return merged
+}
+
+// RenderWithContext is like [Render] but first adds the reserved context keys
+// to data via [ContextData]. Templates can then use {{.Env.HOME}}, {{.Now}},
+// and {{.User}} without the caller wiring them.
+//
+// Example:
+//
+//	output, err := template.RenderWithContext("claude-md", map[string]interface{}{
+//	    "Name": "cure",
+//	})
+//	// A template line "Generated by {{.User}} on {{.Now}}" is filled in.
+func RenderWithContext(name string, data map[string]interface{}) (string, error) {
+	return Render(name, ContextData(data))
+}
+
+// environ returns the process environment as a map. When a variable appears
+// more than once the last value wins, matching os.Getenv on most platforms.
+func environ() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			continue
+		}
+		env[k] = v
+	}
+	return env
+}
+
+// currentUser returns the current user's login name, falling back to the
+// USER and USERNAME environment variables, or "" when none is known.
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
@@ -0,0 +1,117 @@
+package template
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureStderr redirects os.Stderr while fn runs and returns what was written.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	orig := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = orig }()
+
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	return string(out)
+}
+
+func TestContextData(t *testing.T) {
+	fixed := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	now = func() time.Time { return fixed }
+	t.Cleanup(func() { now = time.Now })
+	t.Setenv("CURE_TEMPLATE_TEST", "from-env")
+
+	data := map[string]interface{}{"Name": "cure"}
+	got := ContextData(data)
+
+	if len(data) != 1 {
+		t.Errorf("ContextData() modified its input: %v", data)
+	}
+	if got["Name"] != "cure" {
+		t.Errorf("Name = %v, want %q", got["Name"], "cure")
+	}
+	if got[NowKey] != "2026-03-01T12:30:00Z" {
+		t.Errorf("Now = %v, want %q", got[NowKey], "2026-03-01T12:30:00Z")
+	}
+	env, ok := got[EnvKey].(map[string]string)
+	if !ok || env["CURE_TEMPLATE_TEST"] != "from-env" {
+		t.Errorf("Env[CURE_TEMPLATE_TEST] = %v, want %q", got[EnvKey], "from-env")
+	}
+	if _, ok := got[UserKey].(string); !ok {
+		t.Errorf("User = %#v, want a string", got[UserKey])
+	}
+
+	if n := len(ContextData(nil)); n != 3 {
+		t.Errorf("ContextData(nil) has %d keys, want 3", n)
+	}
+}
+
+func TestContextData_Collision(t *testing.T) {
+	var got map[string]interface{}
+	stderr := captureStderr(t, func() {
+		got = ContextData(map[string]interface{}{"User": "release-bot"})
+	})
+
+	if got[UserKey] != "release-bot" {
+		t.Errorf("User = %v, want caller value %q", got[UserKey], "release-bot")
+	}
+	if !strings.Contains(stderr, `"User" is reserved`) {
+		t.Errorf("stderr = %q, want a warning about the User key", stderr)
+	}
+	if strings.Contains(stderr, `"Now"`) || strings.Contains(stderr, `"Env"`) {
+		t.Errorf("stderr = %q, want warnings only for colliding keys", stderr)
+	}
+}
+
+func TestRenderWithContext(t *testing.T) {
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd: %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("Chdir: %v", err)
+	}
+	fixed := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	now = func() time.Time { return fixed }
+	t.Cleanup(func() {
+		_ = os.Chdir(origDir)
+		now = time.Now
+		resetRegistry()
+	})
+	t.Setenv("CURE_TEMPLATE_TEST", "from-env")
+
+	templateDir := filepath.Join(tmpDir, ".cure", "templates")
+	if err := os.MkdirAll(templateDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	content := "{{.Name}} {{.Now}} {{.Env.CURE_TEMPLATE_TEST}}"
+	if err := os.WriteFile(filepath.Join(templateDir, "stamp.tmpl"), []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	resetRegistry()
+
+	got, err := RenderWithContext("stamp", map[string]interface{}{"Name": "cure"})
+	if err != nil {
+		t.Fatalf("RenderWithContext() error = %v", err)
+	}
+	want := "cure 2026-03-01T12:30:00Z from-env\n"
+	if got != want {
+		t.Errorf("RenderWithContext() = %q, want %q", got, want)
+	}
+}
@@ -30,6 +30,9 @@
 //	names := template.List()
 //	fmt.Println("Available templates:", names)
 //
+// [ListInfo] also reports each template's description, taken from its
+// [DescriptionDirective], and the data variables it reads.
+//
 // # Template Development
 //
 // Templates are stored in pkg/template/templates/ with .tmpl extension.
@@ -40,6 +43,24 @@
 //   - templates/claude-md.tmpl → "claude-md"
 //   - templates/devcontainer.tmpl → "devcontainer"
 //
+// # Whitespace-Sensitive Templates
+//
+// Rendered output is normalized by [Format]. Templates whose whitespace is
+// significant (Makefiles, YAML) can opt out by including the directive
+// {{/* cure:noformat */}}, or callers can use [RenderRaw] directly.
+//
+// # Multi-File Bundles
+//
+// A bundle template lists its output files with {{/* cure:output PATH TEMPLATE */}}
+// directives; [RenderBundle] renders each one and returns path → content.
+//
+// # Context Data
+//
+// [RenderWithContext] adds the reserved keys Env, Now, and User to the data
+// so templates can write {{.Env.HOME}}, {{.Now}}, or {{.User}}. Caller values
+// for those keys are kept, with a warning on stderr. [ContextData] performs
+// the merge on its own for use with [RenderBundle].
+//
 // # Text Template Syntax
 //
 // This package uses text/template, which supports:
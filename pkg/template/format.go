@@ -11,8 +11,27 @@ var (
 
 	// multipleBlankLines matches 3+ consecutive blank lines
 	multipleBlankLines = regexp.MustCompile(`\n{3,}`)
+
+	// noFormatDirective matches the {{/* cure:noformat */}} opt-out comment,
+	// including optional trim markers and the line break that follows it.
+	noFormatDirective = regexp.MustCompile(`\{\{-?\s*/\*\s*cure:noformat\s*\*/\s*-?\}\}\r?\n?`)
 )
 
+// NoFormatDirective is the template comment that opts a template out of
+// [Format] post-processing. Place it anywhere in the template source (usually
+// the first line); it is removed together with its trailing newline before
+// parsing, so it never appears in rendered output.
+const NoFormatDirective = "{{/* cure:noformat */}}"
+
+// stripNoFormat removes every noformat directive from content and reports
+// whether at least one was present.
+func stripNoFormat(content string) (string, bool) {
+	if !noFormatDirective.MatchString(content) {
+		return content, false
+	}
+	return noFormatDirective.ReplaceAllString(content, ""), true
+}
+
 // Format cleans up rendered template output by:
 //   - Normalizing line endings to \n
 //   - Removing trailing whitespace from each line
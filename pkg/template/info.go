@@ -0,0 +1,149 @@
+package template
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// descriptionDirective matches {{/* cure:description TEXT */}} comments.
+var descriptionDirective = regexp.MustCompile(`\{\{-?\s*/\*\s*cure:description\s+(.*?)\s*\*/\s*-?\}\}`)
+
+// DescriptionDirective is the template comment that gives a template the
+// one-line description shown by [ListInfo] and "cure generate list". Put it
+// first in the file, trimming the newline after it so it leaves no blank
+// line in rendered output:
+//
+//	{{/* cure:description CLAUDE.md project instructions */ -}}
+const DescriptionDirective = "{{/* cure:description TEXT */}}"
+
+// parseDescription returns the text of the first DescriptionDirective in
+// content, or "".
+func parseDescription(content string) string {
+	if m := descriptionDirective.FindStringSubmatch(content); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Info describes an available template.
+type Info struct {
+	// Name is the name passed to Render.
+	Name string
+
+	// Description is the text of the template's DescriptionDirective, or ""
+	// if it has none.
+	Description string
+
+	// Variables are the top-level data keys the template references, such
+	// as "Name" for {{.Name}}, sorted. For a bundle they are those of all
+	// its outputs.
+	Variables []string
+
+	// Outputs are the file paths a bundle template declares with
+	// OutputDirective, in source order; nil for other templates.
+	Outputs []string
+}
+
+// ListInfo returns an [Info] for every available template (embedded and
+// custom), sorted by name. Variables are found by walking the parsed
+// template, so they include keys used only in conditionals; keys read
+// inside {{range}} or {{with}} refer to the element, not the data, and are
+// left out.
+func ListInfo() []Info {
+	reg, err := getRegistry()
+	if err != nil || reg == nil {
+		return nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var infos []Info
+	for _, tmpl := range reg.Templates() {
+		name := tmpl.Name()
+		if name == "" {
+			continue
+		}
+		info := Info{Name: name, Description: descriptions[name]}
+		vars := templateVariables(tmpl)
+		for _, out := range bundles[name] {
+			info.Outputs = append(info.Outputs, out.path)
+			if t := reg.Lookup(out.template); t != nil {
+				vars = append(vars, templateVariables(t)...)
+			}
+		}
+		slices.Sort(vars)
+		info.Variables = slices.Compact(vars)
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(a, b Info) int { return strings.Compare(a.Name, b.Name) })
+	return infos
+}
+
+// templateVariables returns the top-level data keys t references, unsorted
+// and possibly repeated.
+func templateVariables(t *template.Template) []string {
+	if t.Tree == nil {
+		return nil
+	}
+	var vars []string
+	var walk func(n parse.Node, dot bool)
+	walkPipe := func(p *parse.PipeNode, dot bool) {
+		if p != nil {
+			walk(p, dot)
+		}
+	}
+	walkList := func(l *parse.ListNode, dot bool) {
+		if l != nil {
+			walk(l, dot)
+		}
+	}
+	// dot reports whether "." is still the template's data at n.
+	walk = func(n parse.Node, dot bool) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			for _, c := range n.Nodes {
+				walk(c, dot)
+			}
+		case *parse.ActionNode:
+			walkPipe(n.Pipe, dot)
+		case *parse.PipeNode:
+			for _, c := range n.Cmds {
+				walk(c, dot)
+			}
+		case *parse.CommandNode:
+			for _, a := range n.Args {
+				walk(a, dot)
+			}
+		case *parse.ChainNode:
+			walk(n.Node, dot)
+		case *parse.FieldNode:
+			if dot {
+				vars = append(vars, n.Ident[0])
+			}
+		case *parse.VariableNode:
+			// $ is always the data; $.Name reads a top-level key.
+			if len(n.Ident) > 1 && n.Ident[0] == "$" {
+				vars = append(vars, n.Ident[1])
+			}
+		case *parse.IfNode:
+			walkPipe(n.Pipe, dot)
+			walkList(n.List, dot)
+			walkList(n.ElseList, dot)
+		case *parse.RangeNode:
+			walkPipe(n.Pipe, dot)
+			walkList(n.List, false)
+			walkList(n.ElseList, dot)
+		case *parse.WithNode:
+			walkPipe(n.Pipe, dot)
+			walkList(n.List, false)
+			walkList(n.ElseList, dot)
+		case *parse.TemplateNode:
+			walkPipe(n.Pipe, dot)
+		}
+	}
+	walk(t.Tree.Root, true)
+	return vars
+}
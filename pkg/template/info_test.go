@@ -0,0 +1,77 @@
+package template
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestListInfo(t *testing.T) {
+	resetRegistry()
+
+	infos := ListInfo()
+	byName := make(map[string]Info, len(infos))
+	for _, info := range infos {
+		byName[info.Name] = info
+	}
+	if !slices.IsSortedFunc(infos, func(a, b Info) int { return strings.Compare(a.Name, b.Name) }) {
+		t.Error("ListInfo() is not sorted by name")
+	}
+
+	claude, ok := byName["claude-md"]
+	if !ok {
+		t.Fatal("ListInfo() missing claude-md")
+	}
+	if claude.Description == "" {
+		t.Error("claude-md has no description")
+	}
+	if !slices.Contains(claude.Variables, "Name") {
+		t.Errorf("claude-md Variables = %v, want Name", claude.Variables)
+	}
+	if claude.Outputs != nil {
+		t.Errorf("claude-md Outputs = %v, want nil", claude.Outputs)
+	}
+
+	docker, ok := byName["docker-go"]
+	if !ok {
+		t.Fatal("ListInfo() missing docker-go")
+	}
+	if want := []string{"Dockerfile", ".dockerignore"}; !reflect.DeepEqual(docker.Outputs, want) {
+		t.Errorf("docker-go Outputs = %v, want %v", docker.Outputs, want)
+	}
+	if !slices.Contains(docker.Variables, "Binary") {
+		t.Errorf("docker-go Variables = %v, want Binary from its outputs", docker.Variables)
+	}
+}
+
+func TestTemplateVariables(t *testing.T) {
+	tmpl := template.Must(template.New("t").Parse(
+		`{{if .Enabled}}{{.Name}}{{end}}{{range .Items}}{{.Elem}} {{$.Owner}}{{end}}{{with .Meta}}{{.Inner}}{{end}}{{.Name | printf "%s"}}`))
+
+	got := templateVariables(tmpl)
+	slices.Sort(got)
+	got = slices.Compact(got)
+	want := []string{"Enabled", "Items", "Meta", "Name", "Owner"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("templateVariables() = %v, want %v", got, want)
+	}
+}
+
+func TestParseDescription(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"{{/* cure:description CLAUDE.md instructions */ -}}\nbody", "CLAUDE.md instructions"},
+		{"{{- /* cure:description  spaced  */}}", "spaced"},
+		{"{{/* just a comment */}}", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := parseDescription(tt.content); got != tt.want {
+			t.Errorf("parseDescription(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -22,6 +22,15 @@ var (
 	globalConfig *config.Config
 	// registry is rebuilt lazily; nil means stale (needs rebuild).
 	registry *template.Template
+	// noFormat records templates carrying the NoFormatDirective. Rebuilt
+	// together with registry.
+	noFormat map[string]bool
+	// bundles records the outputs declared by OutputDirective comments,
+	// keyed by template name. Rebuilt together with registry.
+	bundles map[string][]bundleOutput
+	// descriptions records the text of DescriptionDirective comments, keyed
+	// by template name. Rebuilt together with registry.
+	descriptions map[string]string
 )
 
 // SetConfig wires config from the application entry point.
@@ -59,6 +68,9 @@ func getRegistry() (*template.Template, error) {
 // Later-loaded templates with the same name override earlier ones.
 // Must be called with mu held.
 func buildRegistry() (*template.Template, error) {
+	noFormat = make(map[string]bool)
+	bundles = make(map[string][]bundleOutput)
+	descriptions = make(map[string]string)
 	root, err := parseEmbeddedTemplates()
 	if err != nil {
 		return nil, err
@@ -114,7 +126,11 @@ func parseEmbeddedTemplates() (*template.Template, error) {
 			root = root.New(name)
 		}
 
-		if _, err := root.Parse(string(content)); err != nil {
+		src, raw := stripNoFormat(string(content))
+		noFormat[name] = raw
+		bundles[name] = parseOutputs(src)
+		descriptions[name] = parseDescription(src)
+		if _, err := root.Parse(src); err != nil {
 			return nil, fmt.Errorf("parse %s: %w", path, err)
 		}
 	}
@@ -158,11 +174,15 @@ func loadFromDir(root *template.Template, dir string) error {
 		}
 
 		// Parse into the root template set. Same name overrides any existing template.
-		if _, err := root.New(templateName).Parse(string(content)); err != nil {
+		src, raw := stripNoFormat(string(content))
+		if _, err := root.New(templateName).Parse(src); err != nil {
 			// Template syntax error — warn to stderr, don't fail the entire load.
 			fmt.Fprintf(os.Stderr, "warning: template %s: %v\n", fullPath, err)
 			continue
 		}
+		noFormat[templateName] = raw
+		bundles[templateName] = parseOutputs(src)
+		descriptions[templateName] = parseDescription(src)
 	}
 
 	return nil
@@ -172,7 +192,9 @@ func loadFromDir(root *template.Template, dir string) error {
 // the formatted output as a string.
 //
 // The output is automatically post-processed via Format to normalize
-// whitespace and line endings.
+// whitespace and line endings, unless the template contains the
+// [NoFormatDirective] — use that for whitespace-sensitive files such as
+// Makefiles or YAML.
 //
 // Returns an error if the template name is not found or if template
 // execution fails. Template syntax errors include line numbers.
@@ -192,6 +214,44 @@ func loadFromDir(root *template.Template, dir string) error {
 //	}
 //	fmt.Println(output)
 func Render(name string, data interface{}) (string, error) {
+	output, err := render(name, data)
+	if err != nil {
+		return "", err
+	}
+	if isNoFormat(name) {
+		return output, nil
+	}
+	return Format(output), nil
+}
+
+// RenderRaw is like Render but never applies Format, returning the template
+// output byte-for-byte as executed.
+func RenderRaw(name string, data interface{}) (string, error) {
+	return render(name, data)
+}
+
+// RenderString parses text as an unnamed template and executes it with
+// data, without applying Format. It suits short strings built from the
+// same data as a registered template, such as output paths.
+//
+// Example:
+//
+//	path, err := template.RenderString("out/{{.Name}}/CLAUDE.md", data)
+//	// path == "out/cure/CLAUDE.md"
+func RenderString(text string, data interface{}) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse %q: %w", text, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("execute %q: %w", text, err)
+	}
+	return buf.String(), nil
+}
+
+// render looks up and executes the named template without post-processing.
+func render(name string, data interface{}) (string, error) {
 	reg, err := getRegistry()
 	if err != nil {
 		return "", fmt.Errorf("template registry: %w", err)
@@ -207,7 +267,15 @@ func Render(name string, data interface{}) (string, error) {
 		return "", fmt.Errorf("execute template %q: %w", name, err)
 	}
 
-	return Format(buf.String()), nil
+	return buf.String(), nil
+}
+
+// isNoFormat reports whether the named template opted out of Format via
+// the NoFormatDirective.
+func isNoFormat(name string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return noFormat[name]
 }
 
 // MustRender is like Render but panics on error.
@@ -234,6 +234,32 @@ func TestRenderToError(t *testing.T) {
 	}
 }
 
+func TestRenderString(t *testing.T) {
+	data := map[string]interface{}{"Name": "api"}
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "field", text: "out/{{.Name}}/CLAUDE.md", want: "out/api/CLAUDE.md"},
+		{name: "literal", text: "CLAUDE.md", want: "CLAUDE.md"},
+		{name: "missing field", text: "out/{{.Nmae}}/CLAUDE.md", wantErr: true},
+		{name: "syntax error", text: "out/{{.Name/CLAUDE.md", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderString(tt.text, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RenderString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 // TestSetConfigNil verifies nil config is valid (no custom dirs, no panic).
 func TestSetConfigNil(t *testing.T) {
 	resetRegistry()
@@ -603,6 +629,69 @@ func TestNonTemplateFilesIgnored(t *testing.T) {
 	}
 }
 
+// TestNoFormatDirectivePreservesWhitespace verifies templates marked with the
+// noformat directive bypass Format and keep their exact whitespace.
+func TestNoFormatDirectivePreservesWhitespace(t *testing.T) {
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd: %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("Chdir: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(origDir)
+		resetRegistry()
+	})
+
+	templateDir := filepath.Join(tmpDir, ".cure", "templates")
+	if err := os.MkdirAll(templateDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	body := "build:\n\tgo build ./...  \n\n\n\n\ntest:\n\tgo test {{.Pkg}}\t\n\n"
+	files := map[string]string{
+		"makefile.tmpl":  NoFormatDirective + "\n" + body,
+		"formatted.tmpl": body,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	resetRegistry()
+
+	data := map[string]interface{}{"Pkg": "./..."}
+	want := strings.ReplaceAll(body, "{{.Pkg}}", "./...")
+
+	got, err := Render("makefile", data)
+	if err != nil {
+		t.Fatalf("Render(makefile) error = %v", err)
+	}
+	if got != want {
+		t.Errorf("Render(makefile) = %q, want %q", got, want)
+	}
+
+	formatted, err := Render("formatted", data)
+	if err != nil {
+		t.Fatalf("Render(formatted) error = %v", err)
+	}
+	if formatted != Format(want) {
+		t.Errorf("Render(formatted) = %q, want %q", formatted, Format(want))
+	}
+
+	raw, err := RenderRaw("formatted", data)
+	if err != nil {
+		t.Fatalf("RenderRaw(formatted) error = %v", err)
+	}
+	if raw != want {
+		t.Errorf("RenderRaw(formatted) = %q, want %q", raw, want)
+	}
+}
+
 func BenchmarkRender(b *testing.B) {
 	resetRegistry()
 
@@ -0,0 +1,131 @@
+package terminal
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// AuditRecord is one entry of the audit log: a single dispatched command.
+type AuditRecord struct {
+	// Command is the command path, including subcommands of nested
+	// routers, e.g. "trace http".
+	Command string `json:"command"`
+
+	// Argc is the number of arguments that followed the command name.
+	Argc int `json:"argc"`
+
+	// Args holds the arguments themselves. It is only set when the Router
+	// was configured with WithAuditArgs(true).
+	Args []string `json:"args,omitempty"`
+
+	// Start and End bracket flag parsing and execution.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// DurationMS is End - Start in milliseconds.
+	DurationMS int64 `json:"duration_ms"`
+
+	// Status is "ok" when the command succeeded and "error" otherwise.
+	Status string `json:"status"`
+
+	// Error is the error message of a failed command. Like Args it may
+	// contain user input, so it is only set with WithAuditArgs(true).
+	Error string `json:"error,omitempty"`
+}
+
+// AuditLogger writes one NDJSON line per [AuditRecord] to an io.Writer.
+// It is independent of the slog logger set with [WithLogger]: the audit log
+// is a plain append-only record of what ran, written whatever the log level.
+// An AuditLogger is safe for concurrent use.
+type AuditLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewAuditLogger returns an AuditLogger that writes to w. Open files with
+// os.O_APPEND so that existing entries are never overwritten.
+func NewAuditLogger(w io.Writer) *AuditLogger {
+	return &AuditLogger{enc: json.NewEncoder(w)}
+}
+
+// Record writes rec as a single JSON line.
+func (a *AuditLogger) Record(rec AuditRecord) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.enc.Encode(rec)
+}
+
+// WithAuditLog records every command the Router dispatches as an NDJSON
+// [AuditRecord] written to w: the command path, the argument count, start
+// and end times, and whether it succeeded. Commands that are not found are
+// not recorded. Arguments and error messages are left out unless
+// [WithAuditArgs] is also given, since they may contain secrets.
+//
+// A failure to write the audit log does not fail the command.
+//
+// Example:
+//
+//	f, _ := os.OpenFile("audit.ndjson", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+//	router := terminal.New(terminal.WithAuditLog(f))
+func WithAuditLog(w io.Writer) Option {
+	return func(r *Router) {
+		r.audit = NewAuditLogger(w)
+	}
+}
+
+// WithAuditArgs includes the command arguments and error messages in the
+// audit log set up with [WithAuditLog]. Default: false, only the argument
+// count is logged.
+func WithAuditArgs(enabled bool) Option {
+	return func(r *Router) {
+		r.auditArgs = enabled
+	}
+}
+
+// commandPath returns name followed by the subcommands that nested routers
+// will dispatch args to, e.g. "trace http" for name "trace" and args
+// ["http", "example.com"].
+func commandPath(name string, cmd Command, args []string) string {
+	for len(args) > 0 {
+		sub, ok := cmd.(*Router)
+		if !ok {
+			break
+		}
+		next, found := sub.root.search(args[0])
+		if !found {
+			break
+		}
+		name += " " + args[0]
+		cmd, args = next, args[1:]
+	}
+	return name
+}
+
+// recordAudit writes the audit record for a dispatched command, if an audit
+// log is configured.
+func (r *Router) recordAudit(name string, cmd Command, args []string, start time.Time, err error) {
+	if r.audit == nil {
+		return
+	}
+	end := time.Now()
+	rec := AuditRecord{
+		Command:    commandPath(name, cmd, args),
+		Argc:       len(args),
+		Start:      start,
+		End:        end,
+		DurationMS: end.Sub(start).Milliseconds(),
+		Status:     "ok",
+	}
+	if r.auditArgs {
+		rec.Args = append([]string{}, args...)
+	}
+	if err != nil {
+		rec.Status = "error"
+		if r.auditArgs {
+			rec.Error = err.Error()
+		}
+	}
+	_ = r.audit.Record(rec)
+}
@@ -49,3 +49,16 @@ type Command interface {
 	// Return nil on success, or an error describing the failure.
 	Run(ctx context.Context, c *Context) error
 }
+
+// ExampleProvider is an optional interface that commands can implement to
+// list example invocations separately from [Command.Usage]. [HelpCommand]
+// renders them under an "Examples:" header after the usage text, and tools
+// that generate help in other formats can list them one by one.
+//
+// Commands that do not implement ExampleProvider keep any examples in their
+// Usage string, which is shown unchanged.
+type ExampleProvider interface {
+	// Examples returns complete command lines, one per entry, without
+	// indentation or a leading "$ ".
+	Examples() []string
+}
@@ -1,11 +1,14 @@
 package terminal
 
 import (
+	"errors"
 	"flag"
 	"io"
 	"log/slog"
+	"strings"
 
 	"github.com/mrlm-net/cure/pkg/config"
+	"github.com/mrlm-net/cure/pkg/prompt"
 )
 
 // Context provides the execution environment for a command, including parsed
@@ -25,10 +28,10 @@ type Context struct {
 	// May be nil if the command declared no flags.
 	Flags *flag.FlagSet
 
-	// Stdin is the standard input stream for the command.
-	// Only populated by [PipelineRunner] for piped input. May be nil
-	// for all other runners. Commands that support pipeline input
-	// should check for nil before reading.
+	// Stdin is the standard input stream for the command. The Router
+	// sets it to os.Stdin unless [WithStdin] is given, and [PipelineRunner]
+	// replaces it with the previous command's output. It may be nil when a
+	// Context is built by hand, so commands should check before reading.
 	Stdin io.Reader
 
 	// Stdout is the standard output stream for the command.
@@ -48,4 +51,84 @@ type Context struct {
 	// May be nil if no config was set via WithConfig.
 	// Commands should check for nil before accessing.
 	Config *config.Config
+
+	// Values carries request-scoped data (e.g., an authenticated client)
+	// injected by middleware such as a wrapping [Runner]. Values live for a
+	// single invocation and are never persisted. Use [Context.Get] and
+	// [Context.Set] rather than accessing the map directly.
+	Values map[string]interface{}
+
+	// NonInteractive is set when prompting is disabled for the whole run,
+	// as by [WithNonInteractive]. Commands should use [Context.Interactive]
+	// rather than reading it directly.
+	NonInteractive bool
+
+	// AssumeYes is set when every confirmation should be answered yes
+	// without asking, as by [WithAssumeYes]. Commands should ask through
+	// [Context.Confirm] rather than reading it directly.
+	AssumeYes bool
+}
+
+// ErrConfirmationRequired is returned by [Context.Confirm] when a command
+// needs a confirmation it may not prompt for and AssumeYes is not set.
+var ErrConfirmationRequired = errors.New("confirmation required but prompting is disabled")
+
+// Interactive reports whether the command may prompt the user: Stdin is a
+// terminal and NonInteractive is not set. When it returns false, commands
+// should take their input from flags and fail if a required one is missing.
+func (c *Context) Interactive() bool {
+	return c != nil && !c.NonInteractive && prompt.IsInteractive(c.Stdin)
+}
+
+// Confirm asks prompt as a yes/no question on Stdout and reads the answer
+// from Stdin. With AssumeYes set it returns true without asking, so scripts
+// can confirm destructive steps up front. Otherwise, when the command may
+// not prompt (see [Context.Interactive]), it returns
+// [ErrConfirmationRequired]. AssumeYes takes precedence over
+// NonInteractive: a non-interactive run with AssumeYes confirms everything.
+//
+// Example:
+//
+//	ok, err := tc.Confirm(path + " already exists. Overwrite?")
+//	if err != nil {
+//	    return err
+//	}
+func (c *Context) Confirm(prompt string) (bool, error) {
+	if c.AssumeYes {
+		return true, nil
+	}
+	if !c.Interactive() {
+		return false, ErrConfirmationRequired
+	}
+	return c.Prompter().Confirm(prompt)
+}
+
+// Prompter returns a [prompt.Prompter] that writes to Stdout and reads
+// answers from Stdin, so tests can supply answers through Stdin. With a nil
+// Stdin every prompt fails with an EOF error.
+func (c *Context) Prompter() *prompt.Prompter {
+	in := c.Stdin
+	if in == nil {
+		in = strings.NewReader("")
+	}
+	return prompt.NewPrompter(c.Stdout, in)
+}
+
+// Get returns the value stored under key and whether it was present.
+// Safe to call on a Context with no values.
+func (c *Context) Get(key string) (interface{}, bool) {
+	if c == nil || c.Values == nil {
+		return nil, false
+	}
+	v, ok := c.Values[key]
+	return v, ok
+}
+
+// Set stores value under key, allocating Values on first use.
+// Set is not safe for concurrent use; populate values before commands run.
+func (c *Context) Set(key string, value interface{}) {
+	if c.Values == nil {
+		c.Values = make(map[string]interface{})
+	}
+	c.Values[key] = value
 }
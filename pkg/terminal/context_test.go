@@ -2,8 +2,12 @@ package terminal
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"io"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -151,3 +155,196 @@ func TestContext_WriterCapture(t *testing.T) {
 		t.Errorf("Stderr = %q, want %q", got, wantErr)
 	}
 }
+
+func TestContext_GetSet(t *testing.T) {
+	var c Context
+
+	if _, ok := c.Get("missing"); ok {
+		t.Error("Get() on empty Context reported ok = true")
+	}
+
+	c.Set("client", "authed")
+	got, ok := c.Get("client")
+	if !ok || got != "authed" {
+		t.Errorf("Get(client) = %v, %v; want authed, true", got, ok)
+	}
+
+	var nilCtx *Context
+	if _, ok := nilCtx.Get("client"); ok {
+		t.Error("Get() on nil Context reported ok = true")
+	}
+}
+
+// valueMiddleware is a Runner that injects a request-scoped value before
+// delegating to the wrapped Runner.
+type valueMiddleware struct {
+	next       Runner
+	key, value string
+}
+
+func (m *valueMiddleware) Execute(ctx context.Context, commands []Command, execCtx *Context) error {
+	execCtx.Set(m.key, m.value)
+	return m.next.Execute(ctx, commands, execCtx)
+}
+
+// valueReaderCommand records the value stored under key in its Context.
+type valueReaderCommand struct {
+	mockCommand
+	key string
+	got interface{}
+}
+
+func (c *valueReaderCommand) Run(_ context.Context, tc *Context) error {
+	c.called = true
+	c.got, _ = tc.Get(c.key)
+	return nil
+}
+
+func TestContext_ValuesFromMiddleware(t *testing.T) {
+	cmd := &valueReaderCommand{mockCommand: mockCommand{name: "whoami"}, key: "user"}
+	router := New(
+		WithStdout(io.Discard),
+		WithStderr(io.Discard),
+		WithRunner(&valueMiddleware{next: &SerialRunner{}, key: "user", value: "alice"}),
+	)
+	router.Register(cmd)
+
+	if err := router.RunArgs([]string{"whoami"}); err != nil {
+		t.Fatalf("RunArgs() error = %v", err)
+	}
+	if !cmd.called {
+		t.Fatal("command was not called")
+	}
+	if cmd.got != "alice" {
+		t.Errorf("command read %v, want %q", cmd.got, "alice")
+	}
+}
+
+func TestContext_Interactive(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatalf("CreateTemp: %v", err)
+	}
+	defer file.Close()
+
+	tests := []struct {
+		name string
+		tc   *Context
+	}{
+		{"nil context", nil},
+		{"nil stdin", &Context{}},
+		{"buffer", &Context{Stdin: strings.NewReader("answer\n")}},
+		{"regular file", &Context{Stdin: file}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.tc.Interactive() {
+				t.Error("Interactive() = true, want false for a non-terminal stdin")
+			}
+		})
+	}
+
+	t.Run("terminal", func(t *testing.T) {
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			t.Skipf("no terminal available: %v", err)
+		}
+		defer tty.Close()
+		if !(&Context{Stdin: tty}).Interactive() {
+			t.Error("Interactive() = false, want true for a terminal")
+		}
+		if (&Context{Stdin: tty, NonInteractive: true}).Interactive() {
+			t.Error("Interactive() = true with NonInteractive set, want false")
+		}
+	})
+}
+
+func TestContext_Prompter(t *testing.T) {
+	var stdout bytes.Buffer
+	tc := &Context{Stdin: strings.NewReader("alice\n"), Stdout: &stdout}
+	got, err := tc.Prompter().Required("Name?", "")
+	if err != nil {
+		t.Fatalf("Required() error = %v", err)
+	}
+	if got != "alice" {
+		t.Errorf("Required() = %q, want %q read from Stdin", got, "alice")
+	}
+	if !strings.Contains(stdout.String(), "Name?") {
+		t.Errorf("stdout = %q, want the prompt", stdout.String())
+	}
+
+	tc = &Context{Stdout: io.Discard}
+	if _, err := tc.Prompter().Required("Name?", ""); err == nil {
+		t.Error("Required() with nil Stdin error = nil, want EOF error")
+	}
+}
+
+// interactiveCommand records the NonInteractive and AssumeYes flags of its
+// Context.
+type interactiveCommand struct {
+	mockCommand
+	nonInteractive bool
+	assumeYes      bool
+}
+
+func (c *interactiveCommand) Run(_ context.Context, tc *Context) error {
+	c.called = true
+	c.nonInteractive = tc.NonInteractive
+	c.assumeYes = tc.AssumeYes
+	return nil
+}
+
+func TestWithNonInteractive(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		cmd := &interactiveCommand{mockCommand: mockCommand{name: "ask"}}
+		sub := New(WithName("group"))
+		sub.Register(cmd)
+		router := New(WithStdout(io.Discard), WithStderr(io.Discard), WithNonInteractive(enabled))
+		router.Register(sub)
+
+		if err := router.RunArgs([]string{"group", "ask"}); err != nil {
+			t.Fatalf("RunArgs() error = %v", err)
+		}
+		if !cmd.called {
+			t.Fatal("command was not called")
+		}
+		if cmd.nonInteractive != enabled {
+			t.Errorf("WithNonInteractive(%v): sub-router command saw NonInteractive = %v", enabled, cmd.nonInteractive)
+		}
+	}
+}
+
+func TestWithAssumeYes(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		cmd := &interactiveCommand{mockCommand: mockCommand{name: "ask"}}
+		sub := New(WithName("group"))
+		sub.Register(cmd)
+		router := New(WithStdout(io.Discard), WithStderr(io.Discard), WithAssumeYes(enabled))
+		router.Register(sub)
+
+		if err := router.RunArgs([]string{"group", "ask"}); err != nil {
+			t.Fatalf("RunArgs() error = %v", err)
+		}
+		if cmd.assumeYes != enabled {
+			t.Errorf("WithAssumeYes(%v): sub-router command saw AssumeYes = %v", enabled, cmd.assumeYes)
+		}
+	}
+}
+
+func TestContext_Confirm(t *testing.T) {
+	var stdout bytes.Buffer
+	tc := &Context{Stdin: strings.NewReader(""), Stdout: &stdout, AssumeYes: true, NonInteractive: true}
+	ok, err := tc.Confirm("Overwrite?")
+	if err != nil || !ok {
+		t.Errorf("Confirm() with AssumeYes = %v, %v, want true, nil", ok, err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Confirm() with AssumeYes wrote %q, want no prompt", stdout.String())
+	}
+
+	tc = &Context{Stdin: strings.NewReader("y\n"), Stdout: &stdout}
+	ok, err = tc.Confirm("Overwrite?")
+	if !errors.Is(err, ErrConfirmationRequired) || ok {
+		t.Errorf("Confirm() without a terminal = %v, %v, want false, ErrConfirmationRequired", ok, err)
+	}
+}
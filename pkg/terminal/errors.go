@@ -27,6 +27,14 @@ func (e *CommandNotFoundError) Error() string {
 	return msg
 }
 
+// ExitCoder is implemented by errors that carry a process exit code, such
+// as [*PluginExitError]. A main function can check for it with errors.As
+// and exit with that code instead of the usual 1.
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
 // CommandError wraps an error returned by a command's Run method,
 // preserving the command name for structured error handling.
 type CommandError struct {
@@ -69,10 +77,18 @@ func (e *FlagParseError) Unwrap() error {
 
 // NoCommandError is returned when Run or RunContext is called with
 // an empty argument list.
-type NoCommandError struct{}
+type NoCommandError struct {
+	// Usage is the usage text of the subcommand group that was invoked
+	// without a subcommand, listing the commands it accepts. It is empty
+	// for the root router.
+	Usage string
+}
 
-// Error returns "no command specified".
+// Error returns "no command specified", followed by Usage when it is set.
 func (e *NoCommandError) Error() string {
+	if e.Usage != "" {
+		return "no command specified\n\n" + e.Usage
+	}
 	return "no command specified"
 }
 
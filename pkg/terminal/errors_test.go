@@ -120,6 +120,14 @@ func TestNoCommandError(t *testing.T) {
 	}
 }
 
+func TestNoCommandError_Usage(t *testing.T) {
+	err := &NoCommandError{Usage: "Usage: trace <command>"}
+	want := "no command specified\n\nUsage: trace <command>"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
 func TestLevenshtein(t *testing.T) {
 	tests := []struct {
 		a, b string
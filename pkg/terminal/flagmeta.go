@@ -0,0 +1,176 @@
+package terminal
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// FlagMeta describes a flag beyond what [flag.Flag] records. Attach it with
+// [DescribeFlag]; [HelpCommand] and shell-completion generators read it with
+// [FlagMetaFor].
+type FlagMeta struct {
+	// Required marks a flag the command cannot run without. Help shows
+	// "(required)" after its usage. It is not enforced: the command still
+	// validates its own flags in Run.
+	Required bool
+
+	// Enum lists the values the flag accepts. Help lists them after the
+	// usage and completion offers them. It is not enforced either.
+	Enum []string
+
+	// Hidden leaves the flag out of help and completion. It still parses.
+	Hidden bool
+}
+
+// describedValue wraps a flag's Value to carry the metadata attached by
+// DescribeFlag, so the metadata lives and dies with the FlagSet.
+type describedValue struct {
+	flag.Value
+	meta FlagMeta
+}
+
+// String forwards to the wrapped Value. It is safe on the zero
+// describedValue, which the flag package builds to find a flag's zero value.
+func (v *describedValue) String() string {
+	if v.Value == nil {
+		return ""
+	}
+	return v.Value.String()
+}
+
+// Get forwards to the wrapped Value if it is a [flag.Getter].
+func (v *describedValue) Get() any {
+	if g, ok := v.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return v.String()
+}
+
+// IsBoolFlag reports whether the wrapped Value is a boolean flag, so a
+// described bool flag still parses without a value.
+func (v *describedValue) IsBoolFlag() bool {
+	bf, ok := v.Value.(interface{ IsBoolFlag() bool })
+	return ok && bf.IsBoolFlag()
+}
+
+// DescribeFlag attaches meta to the flag called name in fs, replacing any
+// metadata attached before. Call it in a command's Flags method right after
+// defining the flag:
+//
+//	fs.StringVar(&c.format, "format", "json", "Output format (json, html)")
+//	terminal.DescribeFlag(fs, "format", terminal.FlagMeta{Enum: []string{"json", "html"}})
+//
+// The metadata is kept by wrapping the flag's Value, which parses as before.
+// It panics if fs has no such flag, like the flag package does for a
+// redefined one, since that is a programming error.
+func DescribeFlag(fs *flag.FlagSet, name string, meta FlagMeta) {
+	f := fs.Lookup(name)
+	if f == nil {
+		panic(fmt.Sprintf("terminal: DescribeFlag: flag %q is not defined", name))
+	}
+	meta.Enum = slices.Clone(meta.Enum)
+	if dv, ok := f.Value.(*describedValue); ok {
+		dv.meta = meta
+		return
+	}
+	f.Value = &describedValue{Value: f.Value, meta: meta}
+}
+
+// FlagMetaFor returns the metadata attached to f with [DescribeFlag], or the
+// zero FlagMeta if there is none.
+func FlagMetaFor(f *flag.Flag) FlagMeta {
+	if f == nil {
+		return FlagMeta{}
+	}
+	if dv, ok := f.Value.(*describedValue); ok {
+		return dv.meta
+	}
+	return FlagMeta{}
+}
+
+// FlagInfo describes a flag for help output: what [HelpCommand] shows for
+// it and what a generator of help in another format needs.
+type FlagInfo struct {
+	// Name is the flag name, without dashes.
+	Name string
+
+	// Type is the kind of value the flag takes, as [flag.UnquoteUsage]
+	// names it ("string", "int", "duration", "value" for custom types), or
+	// "bool" for a boolean flag.
+	Type string
+
+	// Default is the flag's default value, or "" when it is the zero value
+	// for its type.
+	Default string
+
+	// Usage is the flag's usage text with any back-quoted name unquoted.
+	Usage string
+
+	// Required and Enum are copied from the flag's [FlagMeta].
+	Required bool
+	Enum     []string
+}
+
+// Description returns the usage followed by the accepted values, unless the
+// usage already names them all, and the required marker, as help shows it.
+func (fi FlagInfo) Description() string {
+	desc := fi.Usage
+	unnamed := func(v string) bool { return !strings.Contains(fi.Usage, v) }
+	if slices.ContainsFunc(fi.Enum, unnamed) {
+		desc += " (one of: " + strings.Join(fi.Enum, ", ") + ")"
+	}
+	if fi.Required {
+		desc += " (required)"
+	}
+	return desc
+}
+
+// ListFlags returns a [FlagInfo] for every flag in fs in lexical order,
+// leaving out flags whose [FlagMeta] marks them hidden.
+func ListFlags(fs *flag.FlagSet) []FlagInfo {
+	var infos []FlagInfo
+	fs.VisitAll(func(f *flag.Flag) {
+		meta := FlagMetaFor(f)
+		if meta.Hidden {
+			return
+		}
+		// UnquoteUsage names the type from the Value's concrete type, so
+		// show it the Value DescribeFlag wrapped.
+		unwrapped := *f
+		if dv, ok := f.Value.(*describedValue); ok {
+			unwrapped.Value = dv.Value
+		}
+		typ, usage := flag.UnquoteUsage(&unwrapped)
+		if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && bf.IsBoolFlag() {
+			typ = "bool"
+		}
+		info := FlagInfo{
+			Name:     f.Name,
+			Type:     typ,
+			Usage:    usage,
+			Required: meta.Required,
+			Enum:     meta.Enum,
+		}
+		switch f.DefValue {
+		case "", "0", "0s", "false", "[]":
+		default:
+			info.Default = f.DefValue
+		}
+		infos = append(infos, info)
+	})
+	return infos
+}
+
+// printFlags writes fs's flags to w as a table with the columns of
+// [FlagInfo]: name, type, default, and description.
+func printFlags(w io.Writer, fs *flag.FlagSet) error {
+	table := &Table{Indent: "  "}
+	table.SetHeader("FLAG", "TYPE", "DEFAULT", "DESCRIPTION")
+	for _, fi := range ListFlags(fs) {
+		table.AddRow("--"+fi.Name, fi.Type, fi.Default, fi.Description())
+	}
+	return table.Render(w)
+}
@@ -0,0 +1,87 @@
+package terminal
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestDescribeFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("format", "json", "output format")
+	fs.String("out", "", "output file")
+
+	enum := []string{"json", "html"}
+	DescribeFlag(fs, "format", FlagMeta{Enum: enum})
+	enum[0] = "changed"
+
+	got := FlagMetaFor(fs.Lookup("format"))
+	if want := (FlagMeta{Enum: []string{"json", "html"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("FlagMetaFor(format) = %+v, want %+v", got, want)
+	}
+	if got := FlagMetaFor(fs.Lookup("out")); !reflect.DeepEqual(got, FlagMeta{}) {
+		t.Errorf("FlagMetaFor(out) = %+v, want zero FlagMeta", got)
+	}
+	if got := FlagMetaFor(nil); !reflect.DeepEqual(got, FlagMeta{}) {
+		t.Errorf("FlagMetaFor(nil) = %+v, want zero FlagMeta", got)
+	}
+
+	DescribeFlag(fs, "format", FlagMeta{Hidden: true})
+	if got := FlagMetaFor(fs.Lookup("format")); !got.Hidden || got.Enum != nil {
+		t.Errorf("FlagMetaFor(format) after redescribing = %+v, want only Hidden", got)
+	}
+}
+
+func TestDescribeFlag_SameNameOtherFlagSet(t *testing.T) {
+	a := flag.NewFlagSet("a", flag.ContinueOnError)
+	a.String("format", "json", "")
+	b := flag.NewFlagSet("b", flag.ContinueOnError)
+	b.String("format", "text", "")
+
+	DescribeFlag(a, "format", FlagMeta{Required: true})
+	if got := FlagMetaFor(b.Lookup("format")); got.Required {
+		t.Error("metadata leaked to a flag of the same name in another FlagSet")
+	}
+}
+
+func TestDescribeFlag_UndefinedPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("DescribeFlag() on an undefined flag did not panic")
+		}
+	}()
+	DescribeFlag(flag.NewFlagSet("test", flag.ContinueOnError), "missing", FlagMeta{})
+}
+
+func TestDescribeFlag_KeepsValueBehaviour(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	verbose := fs.Bool("verbose", false, "verbose output")
+	timeout := fs.Duration("timeout", 0, "timeout")
+	DescribeFlag(fs, "verbose", FlagMeta{Required: true})
+	DescribeFlag(fs, "timeout", FlagMeta{Required: true})
+
+	if err := fs.Parse([]string{"-verbose", "-timeout", "2s"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !*verbose || timeout.String() != "2s" {
+		t.Errorf("parsed verbose=%v timeout=%v, want true 2s", *verbose, *timeout)
+	}
+	if got := fs.Lookup("timeout").Value.(flag.Getter).Get(); got != *timeout {
+		t.Errorf("Get() = %v, want %v", got, *timeout)
+	}
+
+	types := map[string]string{}
+	for _, fi := range ListFlags(fs) {
+		types[fi.Name] = fi.Type
+	}
+	if types["verbose"] != "bool" || types["timeout"] != "duration" {
+		t.Errorf("ListFlags types = %v, want verbose bool and timeout duration", types)
+	}
+}
+
+func TestFlagInfo_Description_EnumInUsage(t *testing.T) {
+	fi := FlagInfo{Usage: "Output format (json, html)", Enum: []string{"json", "html"}}
+	if got, want := fi.Description(), "Output format (json, html)"; got != want {
+		t.Errorf("Description() = %q, want %q", got, want)
+	}
+}
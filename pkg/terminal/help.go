@@ -22,8 +22,13 @@ type CommandRegistry interface {
 // to generate help text dynamically.
 //
 // With no arguments, it lists all registered commands alphabetically with
-// their descriptions. With a command name argument, it shows that command's
-// description, usage, and flags.
+// their descriptions, followed by a "Topics" section when the registry is a
+// [TopicRegistry] with topics. With a command name argument, it shows that
+// command's description, usage, examples (see [ExampleProvider]), and a
+// table of its flags with their types and defaults (see [ListFlags]). Flags
+// described with [DescribeFlag] show their accepted values and whether they
+// are required; hidden flags are left out. An argument that names a
+// topic rather than a command shows the topic's title and body.
 //
 // Create with [NewHelpCommand]:
 //
@@ -46,13 +51,14 @@ func (c *HelpCommand) Name() string { return "help" }
 func (c *HelpCommand) Description() string { return "Show help for commands" }
 
 // Usage returns detailed usage information.
-func (c *HelpCommand) Usage() string { return "Usage: help [command]" }
+func (c *HelpCommand) Usage() string { return "Usage: help [command | topic]" }
 
 // Flags returns nil — the help command accepts no flags.
 func (c *HelpCommand) Flags() *flag.FlagSet { return nil }
 
 // Run executes the help command.
-// With no args, lists all commands. With one arg, shows help for that command.
+// With no args, lists all commands and topics. With one arg, shows help for
+// that command or topic.
 func (c *HelpCommand) Run(_ context.Context, tc *Context) error {
 	if len(tc.Args) == 0 {
 		return c.listCommands(tc)
@@ -63,7 +69,11 @@ func (c *HelpCommand) Run(_ context.Context, tc *Context) error {
 // listCommands writes an alphabetical listing of all registered commands.
 func (c *HelpCommand) listCommands(tc *Context) error {
 	cmds := c.registry.Commands()
-	if len(cmds) == 0 {
+	var topics []Topic
+	if tr, ok := c.registry.(TopicRegistry); ok {
+		topics = tr.Topics()
+	}
+	if len(cmds) == 0 && len(topics) == 0 {
 		fmt.Fprintln(tc.Stdout, "No commands registered.")
 		return nil
 	}
@@ -72,27 +82,43 @@ func (c *HelpCommand) listCommands(tc *Context) error {
 		return cmds[i].Name() < cmds[j].Name()
 	})
 
-	// Calculate padding for aligned output.
-	maxLen := 0
-	for _, cmd := range cmds {
-		if len(cmd.Name()) > maxLen {
-			maxLen = len(cmd.Name())
-		}
-	}
-
-	fmt.Fprintln(tc.Stdout, "Available commands:")
-	fmt.Fprintln(tc.Stdout)
+	table := &Table{Indent: "  "}
 	for _, cmd := range cmds {
-		line := fmt.Sprintf("  %-*s  %s", maxLen, cmd.Name(), cmd.Description())
+		desc := cmd.Description()
 		if ar, ok := c.registry.(AliasRegistry); ok {
 			if aliases := ar.AliasesFor(cmd.Name()); len(aliases) > 0 {
-				line += fmt.Sprintf(" (aliases: %s)", strings.Join(aliases, ", "))
+				desc += fmt.Sprintf(" (aliases: %s)", strings.Join(aliases, ", "))
 			}
 		}
-		fmt.Fprintln(tc.Stdout, line)
+		table.AddRow(cmd.Name(), desc)
+	}
+
+	if len(cmds) > 0 {
+		fmt.Fprintln(tc.Stdout, "Available commands:")
+		fmt.Fprintln(tc.Stdout)
+		if err := table.Render(tc.Stdout); err != nil {
+			return err
+		}
+		fmt.Fprintln(tc.Stdout)
+		fmt.Fprintln(tc.Stdout, "Use \"help <command>\" for more information about a command.")
+	}
+
+	if len(topics) > 0 {
+		if len(cmds) > 0 {
+			fmt.Fprintln(tc.Stdout)
+		}
+		table := &Table{Indent: "  "}
+		for _, t := range topics {
+			table.AddRow(t.Name, t.Title)
+		}
+		fmt.Fprintln(tc.Stdout, "Topics:")
+		fmt.Fprintln(tc.Stdout)
+		if err := table.Render(tc.Stdout); err != nil {
+			return err
+		}
+		fmt.Fprintln(tc.Stdout)
+		fmt.Fprintln(tc.Stdout, "Use \"help <topic>\" to read about a topic.")
 	}
-	fmt.Fprintln(tc.Stdout)
-	fmt.Fprintln(tc.Stdout, "Use \"help <command>\" for more information about a command.")
 	return nil
 }
 
@@ -100,6 +126,11 @@ func (c *HelpCommand) listCommands(tc *Context) error {
 func (c *HelpCommand) showCommand(tc *Context, name string) error {
 	cmd, found := c.registry.Lookup(name)
 	if !found {
+		if tr, ok := c.registry.(TopicRegistry); ok {
+			if t, ok := tr.LookupTopic(name); ok {
+				return showTopic(tc, t)
+			}
+		}
 		return &CommandNotFoundError{Name: name}
 	}
 
@@ -111,6 +142,11 @@ func (c *HelpCommand) showCommand(tc *Context, name string) error {
 			Stdout: tc.Stdout,
 			Stderr: tc.Stderr,
 			Logger: tc.Logger,
+			Config: tc.Config,
+			Values: tc.Values,
+
+			NonInteractive: tc.NonInteractive,
+			AssumeYes:      tc.AssumeYes,
 		}
 		return subHelp.Run(context.Background(), subCtx)
 	}
@@ -128,12 +164,33 @@ func (c *HelpCommand) showCommand(tc *Context, name string) error {
 		fmt.Fprintln(tc.Stdout, usage)
 	}
 
+	if ep, ok := cmd.(ExampleProvider); ok {
+		if examples := ep.Examples(); len(examples) > 0 {
+			fmt.Fprintln(tc.Stdout)
+			fmt.Fprintln(tc.Stdout, "Examples:")
+			for _, ex := range examples {
+				fmt.Fprintf(tc.Stdout, "  %s\n", ex)
+			}
+		}
+	}
+
 	if fs := cmd.Flags(); fs != nil {
 		fmt.Fprintln(tc.Stdout)
 		fmt.Fprintln(tc.Stdout, "Flags:")
-		fs.SetOutput(tc.Stdout)
-		fs.PrintDefaults()
+		if err := printFlags(tc.Stdout, fs); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+// showTopic writes a help topic: its title, then its body.
+func showTopic(tc *Context, t Topic) error {
+	fmt.Fprintf(tc.Stdout, "%s \u2014 %s\n", t.Name, t.Title)
+	if body := strings.TrimRight(t.Body, "\n"); body != "" {
+		fmt.Fprintln(tc.Stdout)
+		fmt.Fprintln(tc.Stdout, body)
+	}
+	return nil
+}
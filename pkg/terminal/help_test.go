@@ -236,6 +236,175 @@ func TestHelpCommand_ShowCommand_NoUsage(t *testing.T) {
 	}
 }
 
+// exampleCommand is a mockCommand that also implements ExampleProvider.
+type exampleCommand struct {
+	mockCommand
+	examples []string
+}
+
+func (c *exampleCommand) Examples() []string { return c.examples }
+
+func TestHelpCommand_ShowCommand_Examples(t *testing.T) {
+	fs := flag.NewFlagSet("deploy", flag.ContinueOnError)
+	fs.Bool("dry-run", false, "print the plan only")
+
+	registry := &mockRegistry{
+		commands: []Command{
+			&exampleCommand{
+				mockCommand: mockCommand{
+					name:  "deploy",
+					desc:  "Deploy the app",
+					usage: "Usage: cure deploy [flags] <env>",
+					flags: fs,
+				},
+				examples: []string{"cure deploy staging", "cure deploy --dry-run prod"},
+			},
+		},
+	}
+
+	cmd := NewHelpCommand(registry)
+	var buf bytes.Buffer
+	tc := &Context{Args: []string{"deploy"}, Stdout: &buf, Stderr: io.Discard}
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	output := buf.String()
+	want := "Usage: cure deploy [flags] <env>\n\nExamples:\n  cure deploy staging\n  cure deploy --dry-run prod\n\nFlags:\n"
+	if !strings.Contains(output, want) {
+		t.Errorf("examples not rendered between usage and flags, got:\n%s", output)
+	}
+}
+
+func TestHelpCommand_ShowCommand_NoExamples(t *testing.T) {
+	registry := &mockRegistry{
+		commands: []Command{
+			&exampleCommand{mockCommand: mockCommand{name: "version", desc: "Print version"}},
+		},
+	}
+
+	cmd := NewHelpCommand(registry)
+	var buf bytes.Buffer
+	tc := &Context{Args: []string{"version"}, Stdout: &buf, Stderr: io.Discard}
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "Examples:") {
+		t.Errorf("empty Examples() should not render a header, got:\n%s", buf.String())
+	}
+}
+
+func TestHelpCommand_ShowCommand_FlagMeta(t *testing.T) {
+	fs := flag.NewFlagSet("deploy", flag.ContinueOnError)
+	fs.String("env", "", "target environment")
+	fs.String("format", "text", "output format")
+	fs.Bool("internal-debug", false, "dump internal state")
+	fs.Bool("dry-run", false, "print the plan only")
+	DescribeFlag(fs, "env", FlagMeta{Required: true})
+	DescribeFlag(fs, "format", FlagMeta{Enum: []string{"text", "json"}})
+	DescribeFlag(fs, "internal-debug", FlagMeta{Hidden: true})
+
+	registry := &mockRegistry{
+		commands: []Command{
+			&mockCommand{name: "deploy", desc: "Deploy the app", flags: fs},
+		},
+	}
+
+	cmd := NewHelpCommand(registry)
+	var buf bytes.Buffer
+	tc := &Context{Args: []string{"deploy"}, Stdout: &buf, Stderr: io.Discard}
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"target environment (required)",
+		"output format (one of: text, json)",
+		"--dry-run",
+		"print the plan only",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "internal-debug") {
+		t.Errorf("hidden flag shown, got:\n%s", output)
+	}
+}
+
+func TestHelpCommand_ShowCommand_FlagTable(t *testing.T) {
+	fs := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	fs.String("format", "json", "output `format`")
+	fs.Int("retries", 3, "retry failed requests")
+	fs.Duration("timeout", 0, "give up after this long")
+	fs.Bool("v", false, "verbose output")
+
+	registry := &mockRegistry{
+		commands: []Command{
+			&mockCommand{name: "fetch", desc: "Fetch a URL", usage: "Usage: fetch [flags] <url>", flags: fs},
+		},
+	}
+
+	cmd := NewHelpCommand(registry)
+	var buf bytes.Buffer
+	tc := &Context{Args: []string{"fetch"}, Stdout: &buf, Stderr: io.Discard}
+	if err := cmd.Run(context.Background(), tc); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "Usage: fetch [flags] <url>\n\nFlags:\n") {
+		t.Fatalf("usage not shown above the flags, got:\n%s", output)
+	}
+	_, table, _ := strings.Cut(output, "Flags:\n")
+	lines := strings.Split(strings.TrimRight(table, "\n"), "\n")
+	header := lines[0]
+	col := func(name string) int { return strings.Index(header, name) }
+	for _, name := range []string{"FLAG", "TYPE", "DEFAULT", "DESCRIPTION"} {
+		if col(name) < 0 {
+			t.Fatalf("header %q missing column %s", header, name)
+		}
+	}
+
+	want := map[string][3]string{ // flag -> type, default, description
+		"--format":  {"format", "json", "output format"},
+		"--retries": {"int", "3", "retry failed requests"},
+		"--timeout": {"duration", "", "give up after this long"},
+		"--v":       {"bool", "", "verbose output"},
+	}
+	for _, line := range lines[2:] {
+		name := strings.Fields(line)[0]
+		w, ok := want[name]
+		if !ok {
+			t.Errorf("unexpected flag row %q", line)
+			continue
+		}
+		delete(want, name)
+		cell := func(at int) string {
+			if at >= len(line) {
+				return ""
+			}
+			return strings.Fields(line[at:] + " ")[0]
+		}
+		if got := cell(col("TYPE")); got != w[0] {
+			t.Errorf("%s type = %q, want %q in row %q", name, got, w[0], line)
+		}
+		if w[1] != "" && cell(col("DEFAULT")) != w[1] {
+			t.Errorf("%s default not in the DEFAULT column: %q", name, line)
+		}
+		if w[1] == "" && line[col("DEFAULT"):col("DESCRIPTION")] != strings.Repeat(" ", col("DESCRIPTION")-col("DEFAULT")) {
+			t.Errorf("%s shows a zero default: %q", name, line)
+		}
+		if got := line[col("DESCRIPTION"):]; got != w[2] {
+			t.Errorf("%s description = %q, want %q", name, got, w[2])
+		}
+	}
+	for name := range want {
+		t.Errorf("flag %s not listed", name)
+	}
+}
+
 func TestHelpCommand_UnknownCommand(t *testing.T) {
 	registry := &mockRegistry{
 		commands: []Command{
@@ -289,6 +458,66 @@ func TestHelpCommand_Integration(t *testing.T) {
 	}
 }
 
+func TestHelpCommand_Topics(t *testing.T) {
+	router := New(WithStdout(io.Discard), WithStderr(io.Discard))
+	router.Register(&mockCommand{name: "version", desc: "Print version"})
+	router.Register(NewHelpCommand(router))
+	router.RegisterTopic("formats", "Output formats", "NDJSON is the default.\nHTML renders a report.\n")
+	router.RegisterTopic("config", "Configuration files", "Settings are merged in layers.")
+
+	var buf bytes.Buffer
+	router.stdout = &buf
+	if err := router.RunArgs([]string{"help"}); err != nil {
+		t.Fatalf("RunArgs(help) error = %v", err)
+	}
+	out := buf.String()
+	commands, topics := strings.Index(out, "Available commands:"), strings.Index(out, "Topics:")
+	if commands < 0 || topics < commands {
+		t.Fatalf("help output lacks a Topics section after the commands:\n%s", out)
+	}
+	section := out[topics:]
+	for _, want := range []string{"config", "Configuration files", "formats", "Output formats"} {
+		if !strings.Contains(section, want) {
+			t.Errorf("Topics section missing %q:\n%s", want, section)
+		}
+	}
+	if strings.Index(section, "config") > strings.Index(section, "formats") {
+		t.Errorf("topics not sorted by name:\n%s", section)
+	}
+
+	buf.Reset()
+	if err := router.RunArgs([]string{"help", "formats"}); err != nil {
+		t.Fatalf("RunArgs(help formats) error = %v", err)
+	}
+	want := "formats \u2014 Output formats\n\nNDJSON is the default.\nHTML renders a report.\n"
+	if buf.String() != want {
+		t.Errorf("help formats output = %q, want %q", buf.String(), want)
+	}
+
+	// A command of the same name wins over a topic.
+	router.RegisterTopic("version", "Versioning", "Topic body")
+	buf.Reset()
+	if err := router.RunArgs([]string{"help", "version"}); err != nil {
+		t.Fatalf("RunArgs(help version) error = %v", err)
+	}
+	if strings.Contains(buf.String(), "Topic body") {
+		t.Errorf("help version showed the topic instead of the command:\n%s", buf.String())
+	}
+
+	if err := router.RunArgs([]string{"help", "nonexistent"}); err == nil {
+		t.Error("RunArgs(help nonexistent) error = nil, want unknown command")
+	}
+}
+
+func TestRouter_RegisterTopic_EmptyName(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterTopic(\"\") did not panic")
+		}
+	}()
+	New().RegisterTopic("", "Title", "Body")
+}
+
 func TestRouter_Lookup(t *testing.T) {
 	router := New(WithStdout(io.Discard))
 	router.Register(&mockCommand{name: "version", desc: "Print version"})
@@ -317,8 +546,11 @@ func TestRouter_Lookup(t *testing.T) {
 	}
 }
 
-// Verify Router implements CommandRegistry.
-var _ CommandRegistry = (*Router)(nil)
+// Verify Router implements CommandRegistry and TopicRegistry.
+var (
+	_ CommandRegistry = (*Router)(nil)
+	_ TopicRegistry   = (*Router)(nil)
+)
 
 func TestHelpCommand_SubRouterHelp(t *testing.T) {
 	config := New(
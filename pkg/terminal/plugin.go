@@ -0,0 +1,95 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// WithPluginPrefix enables git-style external plugins. When a command name
+// is not registered, the router looks for an executable named prefix+name
+// on PATH, such as "cure-deploy" for "cure deploy", and runs it instead of
+// returning a [CommandNotFoundError].
+//
+// The plugin receives the remaining arguments, the process environment,
+// and the router's stdin, stdout, and stderr; when those are the process's
+// own streams, as by default, the plugin inherits them directly. The router
+// writes nothing of its own. A plugin that exits non-zero yields a
+// [*PluginExitError] carrying its exit code.
+//
+// Names containing a path separator are never looked up. An empty prefix
+// disables plugins, which is the default.
+//
+// Example:
+//
+//	router := terminal.New(terminal.WithPluginPrefix("cure-"))
+func WithPluginPrefix(prefix string) Option {
+	return func(r *Router) {
+		r.pluginPrefix = prefix
+	}
+}
+
+// PluginExitError is returned when a plugin found through
+// [WithPluginPrefix] exits with a non-zero status. The plugin has already
+// reported its failure on the shared stderr, so callers usually exit with
+// [PluginExitError.ExitCode] without printing the error.
+type PluginExitError struct {
+	// Path is the plugin executable that was run.
+	Path string
+
+	// Code is the plugin's exit code, or -1 if it was killed by a signal.
+	Code int
+}
+
+// Error returns a message naming the plugin and its exit code.
+func (e *PluginExitError) Error() string {
+	return fmt.Sprintf("plugin %s exited with status %d", e.Path, e.Code)
+}
+
+// ExitCode returns the plugin's exit code, implementing [ExitCoder].
+func (e *PluginExitError) ExitCode() int { return e.Code }
+
+// lookupPlugin returns the path of the plugin executable for name, if
+// plugins are enabled and one is on PATH.
+func (r *Router) lookupPlugin(name string) (string, bool) {
+	if r.pluginPrefix == "" || name == "" || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	path, err := exec.LookPath(r.pluginPrefix + name)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// runPlugin executes the plugin at path with args and the given streams.
+// Cancelling ctx kills the plugin.
+func (r *Router) runPlugin(ctx context.Context, name, path string, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if r.logger != nil {
+		r.logger.DebugContext(ctx, "running plugin",
+			slog.String("command", name),
+			slog.String("path", path),
+		)
+	}
+
+	start := time.Now()
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		err = &PluginExitError{Path: path, Code: exitErr.ExitCode()}
+	} else if err != nil {
+		err = &CommandError{Command: name, Err: fmt.Errorf("failed to run plugin %s: %w", path, err)}
+	}
+	r.recordAudit(name, nil, args, start, err)
+	return err
+}
@@ -0,0 +1,106 @@
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writePlugin writes an executable shell script named name to a new
+// directory and puts that directory first on PATH.
+func writePlugin(t *testing.T, name, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin stubs are shell scripts")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestWithPluginPrefix(t *testing.T) {
+	writePlugin(t, "cure-hello", `echo "hello $*"; echo "env=$CURE_PLUGIN_TEST" >&2; cat`)
+	t.Setenv("CURE_PLUGIN_TEST", "yes")
+
+	var stdout, stderr bytes.Buffer
+	router := New(
+		WithStdin(strings.NewReader("from stdin\n")),
+		WithStdout(&stdout),
+		WithStderr(&stderr),
+		WithPluginPrefix("cure-"),
+	)
+	router.Register(&mockCommand{name: "version"})
+
+	if err := router.RunArgs([]string{"hello", "a", "--b"}); err != nil {
+		t.Fatalf("RunArgs() error = %v", err)
+	}
+	if got, want := stdout.String(), "hello a --b\nfrom stdin\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if got, want := stderr.String(), "env=yes\n"; got != want {
+		t.Errorf("stderr = %q, want %q", got, want)
+	}
+}
+
+func TestWithPluginPrefix_ExitCode(t *testing.T) {
+	writePlugin(t, "cure-fail", "exit 3\n")
+
+	router := New(WithStdout(&bytes.Buffer{}), WithStderr(&bytes.Buffer{}), WithPluginPrefix("cure-"))
+	err := router.RunArgs([]string{"fail"})
+
+	var exitErr ExitCoder
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("RunArgs() error = %v, want an ExitCoder", err)
+	}
+	if exitErr.ExitCode() != 3 {
+		t.Errorf("ExitCode() = %d, want 3", exitErr.ExitCode())
+	}
+	var pluginErr *PluginExitError
+	if !errors.As(err, &pluginErr) || filepath.Base(pluginErr.Path) != "cure-fail" {
+		t.Errorf("RunArgs() error = %#v, want *PluginExitError for cure-fail", err)
+	}
+}
+
+func TestWithPluginPrefix_NotFound(t *testing.T) {
+	writePlugin(t, "cure-hello", "exit 0\n")
+
+	tests := []struct {
+		name   string
+		prefix string
+		cmd    string
+	}{
+		{"plugins disabled", "", "hello"},
+		{"no such plugin", "cure-", "missing"},
+		{"path separator", "cure-", "../cure-hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := New(WithStdout(&bytes.Buffer{}), WithStderr(&bytes.Buffer{}), WithPluginPrefix(tt.prefix))
+			err := router.RunArgs([]string{tt.cmd})
+			var notFound *CommandNotFoundError
+			if !errors.As(err, &notFound) {
+				t.Errorf("RunArgs(%q) error = %v, want CommandNotFoundError", tt.cmd, err)
+			}
+		})
+	}
+}
+
+func TestWithPluginPrefix_RegisteredWins(t *testing.T) {
+	writePlugin(t, "cure-version", "echo plugin\n")
+
+	var stdout bytes.Buffer
+	router := New(WithStdout(&stdout), WithStderr(&bytes.Buffer{}), WithPluginPrefix("cure-"))
+	router.Register(&mockCommand{name: "version"})
+	if err := router.RunArgs([]string{"version"}); err != nil {
+		t.Fatalf("RunArgs() error = %v", err)
+	}
+	if strings.Contains(stdout.String(), "plugin") {
+		t.Errorf("plugin ran instead of the registered command: %q", stdout.String())
+	}
+}
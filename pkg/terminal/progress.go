@@ -0,0 +1,156 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// progressFrames are the spinner frames drawn by [Progress].
+var progressFrames = []string{"|", "/", "-", `\`}
+
+// progressInterval is how often a running [Progress] redraws its line.
+const progressInterval = 100 * time.Millisecond
+
+// Progress draws a single-line spinner with an optional "done/total" counter
+// on a terminal. It is meant for stderr while a long-running command streams
+// its results to stdout: the line is redrawn in place with a carriage return
+// and erased by [Progress.Stop], so nothing is left behind in scrollback.
+//
+// When the writer is not a terminal (a pipe, a file, a test buffer, or
+// [io.Discard]) every method is a no-op, so callers never need to check.
+// A Progress is safe for concurrent use.
+//
+// Example:
+//
+//	p := terminal.NewProgress(tc.Stderr, "tracing")
+//	p.Start()
+//	defer p.Stop()
+//	for i, job := range jobs {
+//	    run(job)
+//	    p.Update(i+1, len(jobs))
+//	}
+type Progress struct {
+	w       io.Writer
+	label   string
+	enabled bool
+
+	mu      sync.Mutex
+	done    int
+	total   int
+	frame   int
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewProgress returns a Progress that draws to w, prefixed with label. It is
+// inactive unless w is an *os.File attached to a terminal.
+func NewProgress(w io.Writer, label string) *Progress {
+	return newProgress(w, label, isTerminal(w))
+}
+
+// newProgress returns a Progress whose terminal detection is decided by the
+// caller. Tests use it to exercise rendering on a buffer.
+func newProgress(w io.Writer, label string, enabled bool) *Progress {
+	return &Progress{w: w, label: label, enabled: enabled}
+}
+
+// Start begins redrawing the progress line in the background. Calling Start
+// on a Progress that is already running, or on an inactive one, does nothing.
+func (p *Progress) Start() {
+	if !p.enabled {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		return
+	}
+	p.stop = make(chan struct{})
+	p.stopped = make(chan struct{})
+	p.draw()
+	go p.loop(p.stop, p.stopped)
+}
+
+// Update records that done of total units are complete. A total of 0 or less
+// means the total is unknown and only done is shown. The line is redrawn on
+// the next tick.
+func (p *Progress) Update(done, total int) {
+	if !p.enabled {
+		return
+	}
+	p.mu.Lock()
+	p.done, p.total = done, total
+	p.mu.Unlock()
+}
+
+// Stop halts the background redraw and erases the progress line. It is safe
+// to call more than once and on a Progress that was never started.
+func (p *Progress) Stop() {
+	if !p.enabled {
+		return
+	}
+	p.mu.Lock()
+	stop, stopped := p.stop, p.stopped
+	p.stop = nil
+	p.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-stopped
+
+	p.mu.Lock()
+	fmt.Fprint(p.w, "\r\x1b[K")
+	p.mu.Unlock()
+}
+
+// loop redraws the line every progressInterval until stop is closed.
+func (p *Progress) loop(stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			p.frame = (p.frame + 1) % len(progressFrames)
+			p.draw()
+			p.mu.Unlock()
+		}
+	}
+}
+
+// draw writes the current line. The caller must hold p.mu.
+func (p *Progress) draw() {
+	line := "\r\x1b[K" + progressFrames[p.frame]
+	if p.label != "" {
+		line += " " + p.label
+	}
+	switch {
+	case p.total > 0:
+		line += fmt.Sprintf(" %d/%d", p.done, p.total)
+	case p.done > 0:
+		line += fmt.Sprintf(" %d", p.done)
+	}
+	fmt.Fprint(p.w, line)
+}
+
+// isTerminal reports whether w is an *os.File attached to a character
+// device. Like prompt.IsInteractive it relies on ModeCharDevice so no
+// platform-specific syscalls are needed.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
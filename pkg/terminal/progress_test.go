@@ -0,0 +1,85 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress_NonTTY(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgress(&buf, "tracing")
+	p.Start()
+	p.Update(1, 3)
+	time.Sleep(2 * progressInterval)
+	p.Update(3, 3)
+	p.Stop()
+	p.Stop()
+
+	if buf.Len() != 0 {
+		t.Errorf("NewProgress(buffer) wrote %q, want no output", buf.String())
+	}
+}
+
+func TestProgress_RegularFile(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatalf("os.Create() error = %v", err)
+	}
+	defer f.Close()
+
+	p := NewProgress(f, "tracing")
+	p.Start()
+	p.Update(1, 1)
+	p.Stop()
+
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("NewProgress(file) wrote %d bytes, want none", info.Size())
+	}
+}
+
+func TestProgress_Render(t *testing.T) {
+	// Progress serialises its writes, and buf is only read after Stop.
+	var buf bytes.Buffer
+	p := newProgress(&buf, "batch", true)
+	p.Update(2, 5)
+	p.Start()
+	p.Start() // no-op while running
+	p.Stop()
+
+	out := buf.String()
+	if !strings.Contains(out, "batch 2/5") {
+		t.Errorf("output = %q, want it to contain %q", out, "batch 2/5")
+	}
+	if !strings.HasSuffix(out, "\r\x1b[K") {
+		t.Errorf("output = %q, want it to end by erasing the line", out)
+	}
+	if strings.Contains(out, "\n") {
+		t.Errorf("output = %q, want no newlines", out)
+	}
+}
+
+func TestProgress_UnknownTotal(t *testing.T) {
+	var buf bytes.Buffer
+	p := newProgress(&buf, "", true)
+	p.Update(7, 0)
+	p.Start()
+	p.Stop()
+
+	if got := buf.String(); !strings.HasPrefix(got, "\r\x1b[K| 7") {
+		t.Errorf("output = %q, want spinner and bare count", got)
+	}
+}
+
+func TestProgress_StopWithoutStart(t *testing.T) {
+	p := newProgress(io.Discard, "x", true)
+	p.Stop()
+}
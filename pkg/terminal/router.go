@@ -8,8 +8,10 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mrlm-net/cure/pkg/config"
@@ -21,7 +23,9 @@ import (
 // subcommand group name and remaining args are dispatched to child commands.
 //
 // Commands are registered via [Router.Register] and executed via [Router.Run]
-// or [Router.RunContext].
+// or [Router.RunContext]. Registration is safe to call from several
+// goroutines, and alongside lookups and runs, so commands can be added while
+// plugins load.
 //
 // Configure output streams and execution strategy with functional options:
 //
@@ -36,7 +40,10 @@ import (
 //		os.Exit(1)
 //	}
 type Router struct {
+	// mu guards root, aliases, and topics.
+	mu      sync.RWMutex
 	root    *node
+	stdin   io.Reader
 	stdout  io.Writer
 	stderr  io.Writer
 	runner  Runner
@@ -44,6 +51,27 @@ type Router struct {
 	aliases map[string][]string // primary name -> []alias names
 	Config  *config.Config      // Configuration object passed to commands
 
+	// runnerErr is the lookup error of an unknown WithRunnerName; every run
+	// returns it.
+	runnerErr error
+
+	// Audit log (see WithAuditLog)
+	audit     *AuditLogger
+	auditArgs bool
+
+	// External plugins (see WithPluginPrefix)
+	pluginPrefix string
+
+	// nonInteractive is copied to Context.NonInteractive (see
+	// WithNonInteractive).
+	nonInteractive bool
+
+	// assumeYes is copied to Context.AssumeYes (see WithAssumeYes).
+	assumeYes bool
+
+	// Help topics (see RegisterTopic)
+	topics map[string]Topic
+
 	// Subcommand identity (only set when Router is used as a Command)
 	name string
 	desc string
@@ -60,6 +88,16 @@ var _ Command = (*Router)(nil)
 // Option is a functional option for configuring a [Router].
 type Option func(*Router)
 
+// WithStdin sets the standard input stream for command execution.
+// Commands receive this reader via [Context].Stdin.
+//
+// Default: os.Stdin
+func WithStdin(r io.Reader) Option {
+	return func(rt *Router) {
+		rt.stdin = r
+	}
+}
+
 // WithStdout sets the standard output stream for command execution.
 // Commands receive this writer via [Context].Stdout.
 //
@@ -85,7 +123,7 @@ func WithStderr(w io.Writer) Option {
 // Default: &[SerialRunner]{}
 func WithRunner(runner Runner) Option {
 	return func(r *Router) {
-		r.runner = runner
+		r.runner, r.runnerErr = runner, nil
 	}
 }
 
@@ -119,11 +157,39 @@ func WithConfig(cfg *config.Config) Option {
 	}
 }
 
+// WithNonInteractive marks every command run by the router as
+// non-interactive: [Context.Interactive] reports false even when stdin is a
+// terminal, so commands require flags instead of prompting. Wire it to a
+// global flag such as "--non-interactive" for scripts and CI. Sub-routers
+// inherit it from the parent's Context.
+//
+// Default: false
+func WithNonInteractive(enabled bool) Option {
+	return func(r *Router) {
+		r.nonInteractive = enabled
+	}
+}
+
+// WithAssumeYes makes [Context.Confirm] answer yes without prompting for
+// every command run by the router, so destructive steps such as
+// overwriting files proceed in scripts. Wire it to a global flag such as
+// "--yes". It also applies when prompting is disabled by
+// [WithNonInteractive]. Sub-routers inherit it from the parent's Context.
+//
+// Default: false
+func WithAssumeYes(enabled bool) Option {
+	return func(r *Router) {
+		r.assumeYes = enabled
+	}
+}
+
 // New creates a new Router with the provided options.
-// Defaults: stdout=os.Stdout, stderr=os.Stderr, runner=&SerialRunner{}.
+// Defaults: stdin=os.Stdin, stdout=os.Stdout, stderr=os.Stderr,
+// runner=&SerialRunner{}.
 func New(opts ...Option) *Router {
 	r := &Router{
 		root:        &node{children: make(map[byte]*node)},
+		stdin:       os.Stdin,
 		stdout:      os.Stdout,
 		stderr:      os.Stderr,
 		runner:      &SerialRunner{},
@@ -165,22 +231,29 @@ func (r *Router) Flags() *flag.FlagSet { return nil }
 
 // Run dispatches to child commands when the Router is used as a Command
 // in a parent Router. It creates a child router context that inherits the
-// parent's output streams without mutating the Router's fields, making it
-// safe for concurrent use.
+// parent's streams, Values, and settings without mutating the Router's
+// fields, making it safe for concurrent use.
 func (r *Router) Run(ctx context.Context, tc *Context) error {
 	if tc == nil || len(tc.Args) == 0 {
-		return &NoCommandError{}
+		return &NoCommandError{Usage: r.Usage()}
 	}
-	return r.runContextWith(ctx, tc.Args, tc.Stdout, tc.Stderr)
+	return r.runContextWith(ctx, tc.Args, tc)
 }
 
 // Register adds a command to the router's radix tree.
 // Panics if cmd.Name() is empty or if a command with the same name is
-// already registered.
+// already registered. It is safe for concurrent use.
 //
 // If the command implements [AliasProvider], its aliases are automatically
 // registered.
 func (r *Router) Register(cmd Command) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.register(cmd)
+}
+
+// register is Register with r.mu held.
+func (r *Router) register(cmd Command) {
 	name := cmd.Name()
 	if name == "" {
 		panic("terminal: command name cannot be empty")
@@ -200,13 +273,15 @@ func (r *Router) Register(cmd Command) {
 
 // RegisterWithAliases adds a command and its aliases to the router.
 // Each alias routes to the same command. Panics if any alias conflicts
-// with an existing command or alias name.
+// with an existing command or alias name. It is safe for concurrent use.
 //
 // Example:
 //
 //	router.RegisterWithAliases(&VersionCommand{}, "v", "ver")
 func (r *Router) RegisterWithAliases(cmd Command, aliases ...string) {
-	r.Register(cmd)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.register(cmd)
 	for _, alias := range aliases {
 		if alias == "" {
 			panic("terminal: alias cannot be empty")
@@ -219,7 +294,7 @@ func (r *Router) RegisterWithAliases(cmd Command, aliases ...string) {
 	}
 }
 
-// addAlias records an alias mapping for help display.
+// addAlias records an alias mapping for help display. r.mu must be held.
 func (r *Router) addAlias(primary, alias string) {
 	r.aliases[primary] = append(r.aliases[primary], alias)
 }
@@ -227,7 +302,9 @@ func (r *Router) addAlias(primary, alias string) {
 // AliasesFor returns the registered aliases for a command name.
 // Returns nil if the command has no aliases.
 func (r *Router) AliasesFor(name string) []string {
-	return r.aliases[name]
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.aliases[name])
 }
 
 // RunArgs executes the command identified by the first element of args.
@@ -247,15 +324,21 @@ func (r *Router) RunArgs(args []string) error {
 // Returns an error if no args are provided, the command is not found,
 // flag parsing fails, or the command itself returns an error.
 func (r *Router) RunContext(ctx context.Context, args []string) error {
-	return r.runContextWith(ctx, args, r.stdout, r.stderr)
+	return r.runContextWith(ctx, args, &Context{Stdin: r.stdin, Stdout: r.stdout, Stderr: r.stderr})
 }
 
-// runContextWith is the shared dispatch implementation that takes explicit
-// output streams. This avoids mutating Router fields when sub-routers
-// inherit streams from a parent context.
-func (r *Router) runContextWith(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+// runContextWith is the shared dispatch implementation. The command's
+// Context inherits the streams, Values, and settings of parent, the Context
+// of the parent router's command, which avoids mutating Router fields when
+// sub-routers run. The router's own logger and config win over the
+// parent's, and its NonInteractive and AssumeYes settings are combined with
+// the parent's.
+func (r *Router) runContextWith(ctx context.Context, args []string, parent *Context) error {
+	if r.runnerErr != nil {
+		return r.runnerErr
+	}
 	if len(args) == 0 {
-		return &NoCommandError{}
+		return &NoCommandError{Usage: r.Usage()}
 	}
 
 	cmdName := args[0]
@@ -268,8 +351,11 @@ func (r *Router) runContextWith(ctx context.Context, args []string, stdout, stde
 		)
 	}
 
-	cmd, found := r.root.search(cmdName)
+	cmd, found := r.Lookup(cmdName)
 	if !found {
+		if path, ok := r.lookupPlugin(cmdName); ok {
+			return r.runPlugin(ctx, cmdName, path, cmdArgs, parent.Stdin, parent.Stdout, parent.Stderr)
+		}
 		if r.logger != nil {
 			r.logger.InfoContext(ctx, "command not found",
 				slog.String("command", cmdName),
@@ -279,15 +365,28 @@ func (r *Router) runContextWith(ctx context.Context, args []string, stdout, stde
 	}
 
 	execCtx := &Context{
-		Stdout: stdout,
-		Stderr: stderr,
-		Logger: r.logger,
-		Config: r.Config,
+		Stdin:          parent.Stdin,
+		Stdout:         parent.Stdout,
+		Stderr:         parent.Stderr,
+		Logger:         parent.Logger,
+		Config:         parent.Config,
+		Values:         parent.Values,
+		NonInteractive: parent.NonInteractive || r.nonInteractive,
+		AssumeYes:      parent.AssumeYes || r.assumeYes,
+	}
+	if r.logger != nil {
+		execCtx.Logger = r.logger
+	}
+	if r.Config != nil {
+		execCtx.Config = r.Config
 	}
 
+	start := time.Now()
 	if fs := cmd.Flags(); fs != nil {
 		if err := fs.Parse(cmdArgs); err != nil {
-			return &FlagParseError{Command: cmdName, Err: err}
+			err = &FlagParseError{Command: cmdName, Err: err}
+			r.recordAudit(cmdName, cmd, cmdArgs, start, err)
+			return err
 		}
 		execCtx.Flags = fs
 		execCtx.Args = fs.Args()
@@ -309,8 +408,8 @@ func (r *Router) runContextWith(ctx context.Context, args []string, stdout, stde
 		defer cancel()
 	}
 
-	start := time.Now()
 	err := r.runner.Execute(ctx, []Command{cmd}, execCtx)
+	r.recordAudit(cmdName, cmd, cmdArgs, start, err)
 	if r.logger != nil {
 		duration := time.Since(start)
 		if err != nil {
@@ -339,7 +438,9 @@ func (r *Router) runContextWith(ctx context.Context, args []string, stdout, stde
 
 // suggestNames returns similar command names for "did you mean?" suggestions.
 func (r *Router) suggestNames(name string) []string {
+	r.mu.RLock()
 	similar := r.root.findSimilar(name, 3)
+	r.mu.RUnlock()
 	if len(similar) == 0 {
 		return nil
 	}
@@ -353,13 +454,17 @@ func (r *Router) suggestNames(name string) []string {
 // Lookup finds a registered command by exact name match.
 // Returns the command and true if found, nil and false otherwise.
 func (r *Router) Lookup(name string) (Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	return r.root.search(name)
 }
 
-// Commands returns all registered commands, deduplicated by primary name.
-// Use this to build help text or command listings.
+// Commands returns all registered commands, deduplicated by primary name and
+// sorted by name. Use this to build help text or command listings.
 func (r *Router) Commands() []Command {
+	r.mu.RLock()
 	all := r.root.collectCommands()
+	r.mu.RUnlock()
 	seen := make(map[string]bool, len(all))
 	unique := make([]Command, 0, len(all))
 	for _, cmd := range all {
@@ -368,5 +473,8 @@ func (r *Router) Commands() []Command {
 			unique = append(unique, cmd)
 		}
 	}
+	sort.Slice(unique, func(i, j int) bool {
+		return unique[i].Name() < unique[j].Name()
+	})
 	return unique
 }
@@ -7,6 +7,8 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -159,6 +161,74 @@ func TestRouter_Commands(t *testing.T) {
 	}
 }
 
+func TestRouter_Commands_Sorted(t *testing.T) {
+	router := New(WithStdout(io.Discard))
+	for _, name := range []string{"version", "trace", "help", "generate", "completion", "config"} {
+		router.Register(&mockCommand{name: name})
+	}
+	router.RegisterWithAliases(&mockCommand{name: "doctor"}, "dr")
+
+	// Repeat to catch map-iteration order leaking through.
+	for i := 0; i < 10; i++ {
+		cmds := router.Commands()
+		got := make([]string, len(cmds))
+		for j, cmd := range cmds {
+			got[j] = cmd.Name()
+		}
+		want := []string{"completion", "config", "doctor", "generate", "help", "trace", "version"}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("Commands() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestRouter_Register_Concurrent(t *testing.T) {
+	router := New(WithStdout(io.Discard), WithStderr(io.Discard))
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			name := fmt.Sprintf("cmd%d", i)
+			if i%2 == 0 {
+				router.RegisterWithAliases(&mockCommand{name: name}, name+"-alias")
+			} else {
+				router.Register(&aliasCommand{mockCommand: mockCommand{name: name}, aliases: []string{name + "-alias"}})
+			}
+			router.RegisterTopic(name, "Topic "+name, "")
+		}()
+		// Readers run alongside registration.
+		go func() {
+			defer wg.Done()
+			name := fmt.Sprintf("cmd%d", i)
+			router.Lookup(name)
+			router.AliasesFor(name)
+			router.Commands()
+			router.Topics()
+			_ = router.RunArgs([]string{name})
+		}()
+	}
+	wg.Wait()
+
+	if got := len(router.Commands()); got != n {
+		t.Errorf("Commands() len = %d, want %d", got, n)
+	}
+	if got := len(router.Topics()); got != n {
+		t.Errorf("Topics() len = %d, want %d", got, n)
+	}
+	for i := range n {
+		name := fmt.Sprintf("cmd%d", i)
+		if _, ok := router.Lookup(name + "-alias"); !ok {
+			t.Errorf("Lookup(%q) not found", name+"-alias")
+		}
+		if got := router.AliasesFor(name); len(got) != 1 || got[0] != name+"-alias" {
+			t.Errorf("AliasesFor(%q) = %q, want [%s-alias]", name, got, name)
+		}
+	}
+}
+
 func TestRouter_WithOptions(t *testing.T) {
 	stdout := &bytes.Buffer{}
 	stderr := &bytes.Buffer{}
@@ -283,6 +353,51 @@ func TestRouter_Subcommand_InheritsStreams(t *testing.T) {
 	}
 }
 
+func TestRouter_Subcommand_InheritsStdin(t *testing.T) {
+	var buf bytes.Buffer
+	echo := &stdinMockCommand{mockCommand: mockCommand{name: "echo"}}
+
+	group := New(WithName("group"), WithStdin(strings.NewReader("ignored")))
+	group.Register(echo)
+
+	root := New(WithStdin(strings.NewReader("piped input")), WithStdout(&buf), WithStderr(io.Discard))
+	root.Register(group)
+
+	if err := root.RunArgs([]string{"group", "echo"}); err != nil {
+		t.Fatalf("RunArgs() error = %v", err)
+	}
+	if buf.String() != "piped input" {
+		t.Errorf("output = %q, want %q", buf.String(), "piped input")
+	}
+}
+
+func TestRouter_Subcommand_InheritsValues(t *testing.T) {
+	leaf := &valueReaderCommand{mockCommand: mockCommand{name: "whoami"}, key: "user"}
+
+	level2 := New(WithName("get"))
+	level2.Register(leaf)
+
+	level1 := New(WithName("account"))
+	level1.Register(level2)
+
+	root := New(
+		WithStdout(io.Discard),
+		WithStderr(io.Discard),
+		WithRunner(&valueMiddleware{next: &SerialRunner{}, key: "user", value: "alice"}),
+	)
+	root.Register(level1)
+
+	if err := root.RunArgs([]string{"account", "get", "whoami"}); err != nil {
+		t.Fatalf("RunArgs() error = %v", err)
+	}
+	if !leaf.called {
+		t.Fatal("nested command was not called")
+	}
+	if leaf.got != "alice" {
+		t.Errorf("nested command read %v, want %q", leaf.got, "alice")
+	}
+}
+
 func TestRouter_Subcommand_EmptyArgs(t *testing.T) {
 	config := New(
 		WithName("config"),
@@ -291,6 +406,7 @@ func TestRouter_Subcommand_EmptyArgs(t *testing.T) {
 		WithStderr(io.Discard),
 	)
 	config.Register(&mockCommand{name: "set"})
+	config.Register(&mockCommand{name: "get"})
 
 	root := New(WithStdout(io.Discard), WithStderr(io.Discard))
 	root.Register(config)
@@ -301,7 +417,28 @@ func TestRouter_Subcommand_EmptyArgs(t *testing.T) {
 	}
 	var noCmd *NoCommandError
 	if !errors.As(err, &noCmd) {
-		t.Errorf("error should contain NoCommandError, got %T: %v", err, err)
+		t.Fatalf("error should contain NoCommandError, got %T: %v", err, err)
+	}
+	want := "Available commands: get, set"
+	if !strings.Contains(noCmd.Usage, want) {
+		t.Errorf("Usage = %q, want it to contain %q", noCmd.Usage, want)
+	}
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("Error() = %q, want it to contain %q", err.Error(), want)
+	}
+}
+
+func TestRouter_EmptyArgs_NoUsage(t *testing.T) {
+	root := New(WithStdout(io.Discard), WithStderr(io.Discard))
+	root.Register(&mockCommand{name: "version"})
+
+	err := root.RunArgs(nil)
+	var noCmd *NoCommandError
+	if !errors.As(err, &noCmd) {
+		t.Fatalf("error = %T: %v, want NoCommandError", err, err)
+	}
+	if noCmd.Usage != "" {
+		t.Errorf("Usage = %q, want empty for the root router", noCmd.Usage)
 	}
 }
 
@@ -446,6 +583,17 @@ func (c *writerMockCommand) Run(_ context.Context, tc *Context) error {
 	return c.err
 }
 
+// stdinMockCommand copies stdin to stdout.
+type stdinMockCommand struct {
+	mockCommand
+}
+
+func (c *stdinMockCommand) Run(_ context.Context, tc *Context) error {
+	c.called = true
+	_, err := io.Copy(tc.Stdout, tc.Stdin)
+	return err
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
 }
@@ -1,6 +1,7 @@
 package terminal
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"runtime"
@@ -15,10 +16,19 @@ import (
 //
 // Errors from all commands are aggregated using [errors.Join].
 // Respects context cancellation -- no new commands start after ctx.Done().
+//
+// By default commands share Stdout, so their output interleaves as it is
+// written. Set OrderedOutput to keep each command's output together.
 type ConcurrentRunner struct {
 	// MaxWorkers limits the number of concurrent goroutines.
 	// Zero or negative means runtime.NumCPU().
 	MaxWorkers int
+
+	// OrderedOutput gives each command a private Stdout buffer and writes
+	// the buffers to Stdout in command order once all commands finish, so
+	// the output is deterministic. Nothing is shown until then. Stderr is
+	// still shared.
+	OrderedOutput bool
 }
 
 // WithMaxWorkers returns a ConcurrentRunner with the specified worker limit.
@@ -48,7 +58,12 @@ func (r *ConcurrentRunner) Execute(ctx context.Context, commands []Command, exec
 		wg   sync.WaitGroup
 	)
 
-	for _, cmd := range commands {
+	var outputs []bytes.Buffer
+	if r.OrderedOutput {
+		outputs = make([]bytes.Buffer, len(commands))
+	}
+
+	for i, cmd := range commands {
 		// Check context before starting new work
 		select {
 		case <-ctx.Done():
@@ -60,7 +75,7 @@ func (r *ConcurrentRunner) Execute(ctx context.Context, commands []Command, exec
 		}
 
 		wg.Add(1)
-		go func(c Command) {
+		go func(idx int, c Command) {
 			defer wg.Done()
 
 			// Acquire semaphore
@@ -82,6 +97,14 @@ func (r *ConcurrentRunner) Execute(ctx context.Context, commands []Command, exec
 				Stdout: execCtx.Stdout,
 				Stderr: execCtx.Stderr,
 				Logger: execCtx.Logger,
+				Config: execCtx.Config,
+				Values: execCtx.Values,
+
+				NonInteractive: execCtx.NonInteractive,
+				AssumeYes:      execCtx.AssumeYes,
+			}
+			if outputs != nil {
+				cmdCtx.Stdout = &outputs[idx]
 			}
 
 			if err := c.Run(ctx, cmdCtx); err != nil {
@@ -89,10 +112,18 @@ func (r *ConcurrentRunner) Execute(ctx context.Context, commands []Command, exec
 				errs = append(errs, err)
 				mu.Unlock()
 			}
-		}(cmd)
+		}(i, cmd)
 	}
 
 done:
 	wg.Wait()
+	if execCtx.Stdout != nil {
+		for i := range outputs {
+			if _, err := outputs[i].WriteTo(execCtx.Stdout); err != nil {
+				errs = append(errs, err)
+				break
+			}
+		}
+	}
 	return errors.Join(errs...)
 }
@@ -1,9 +1,13 @@
 package terminal
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -180,3 +184,72 @@ func BenchmarkConcurrentRunner_10Commands(b *testing.B) {
 		_ = runner.Execute(ctx, cmds, execCtx)
 	}
 }
+
+// delayedWriterCommand writes its name twice to Stdout, sleeping before each write.
+type delayedWriterCommand struct {
+	mockCommand
+	delay time.Duration
+}
+
+func (c *delayedWriterCommand) Run(_ context.Context, tc *Context) error {
+	c.called = true
+	for i := 1; i <= 2; i++ {
+		time.Sleep(c.delay)
+		fmt.Fprintf(tc.Stdout, "%s%d;", c.name, i)
+	}
+	return nil
+}
+
+func TestConcurrentRunner_OrderedOutput(t *testing.T) {
+	// The first command finishes last, so unordered output would start with c.
+	commands := []Command{
+		&delayedWriterCommand{mockCommand: mockCommand{name: "a"}, delay: 30 * time.Millisecond},
+		&delayedWriterCommand{mockCommand: mockCommand{name: "b"}, delay: 15 * time.Millisecond},
+		&delayedWriterCommand{mockCommand: mockCommand{name: "c"}},
+	}
+
+	var stdout syncBuffer
+	execCtx := &Context{Stdout: &stdout, Stderr: io.Discard}
+	runner := &ConcurrentRunner{MaxWorkers: 3, OrderedOutput: true}
+	if err := runner.Execute(context.Background(), commands, execCtx); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got, want := stdout.String(), "a1;a2;b1;b2;c1;c2;"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+}
+
+func TestConcurrentRunner_InterleavedByDefault(t *testing.T) {
+	commands := []Command{
+		&delayedWriterCommand{mockCommand: mockCommand{name: "a"}, delay: 30 * time.Millisecond},
+		&delayedWriterCommand{mockCommand: mockCommand{name: "c"}},
+	}
+
+	var stdout syncBuffer
+	execCtx := &Context{Stdout: &stdout, Stderr: io.Discard}
+	runner := &ConcurrentRunner{MaxWorkers: 2}
+	if err := runner.Execute(context.Background(), commands, execCtx); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := stdout.String(); !strings.HasPrefix(got, "c1;") {
+		t.Errorf("stdout = %q, want the faster command's output first", got)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
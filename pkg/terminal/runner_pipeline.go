@@ -62,6 +62,11 @@ func (r *PipelineRunner) Execute(ctx context.Context, commands []Command, execCt
 				Flags:  execCtx.Flags,
 				Stderr: execCtx.Stderr,
 				Logger: execCtx.Logger,
+				Config: execCtx.Config,
+				Values: execCtx.Values,
+
+				NonInteractive: execCtx.NonInteractive,
+				AssumeYes:      execCtx.AssumeYes,
 			}
 
 			// First command: stdin from execCtx
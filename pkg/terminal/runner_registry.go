@@ -0,0 +1,97 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Verify the built-in runners satisfy Runner at compile time.
+var (
+	_ Runner = (*SerialRunner)(nil)
+	_ Runner = (*ConcurrentRunner)(nil)
+	_ Runner = (*PipelineRunner)(nil)
+)
+
+// ErrRunnerNotFound is returned (wrapped) by [RunnerByName] for a name that
+// has not been registered.
+var ErrRunnerNotFound = errors.New("terminal: runner not found")
+
+// RunnerFactory creates a [Runner] for [RunnerByName]. It is called once per
+// lookup, so each caller gets its own Runner.
+type RunnerFactory func() Runner
+
+var (
+	runnersMu sync.RWMutex
+	runners   = map[string]RunnerFactory{
+		"serial":     func() Runner { return &SerialRunner{} },
+		"concurrent": func() Runner { return &ConcurrentRunner{} },
+		"pipeline":   func() Runner { return &PipelineRunner{} },
+	}
+)
+
+// RegisterRunner makes a custom runner available to [RunnerByName] and
+// [WithRunnerName] under name. It panics if name is empty or already
+// registered, including the built-in "serial", "concurrent", and
+// "pipeline". Call it from an init function.
+func RegisterRunner(name string, factory RunnerFactory) {
+	if name == "" {
+		panic("terminal: RegisterRunner called with empty runner name")
+	}
+	runnersMu.Lock()
+	defer runnersMu.Unlock()
+	if _, dup := runners[name]; dup {
+		panic(fmt.Sprintf("terminal: RegisterRunner called twice for runner %q", name))
+	}
+	runners[name] = factory
+}
+
+// RunnerByName returns a new Runner for name, such as a "runner" config
+// value. The built-in names are "serial" ([SerialRunner]), "concurrent"
+// ([ConcurrentRunner] with one worker per CPU), and "pipeline"
+// ([PipelineRunner]). Returns [ErrRunnerNotFound] (wrapped) for any other
+// name not added with [RegisterRunner].
+func RunnerByName(name string) (Runner, error) {
+	runnersMu.RLock()
+	factory, ok := runners[name]
+	runnersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q (available: %s)", ErrRunnerNotFound, name, strings.Join(RunnerNames(), ", "))
+	}
+	return factory(), nil
+}
+
+// RunnerNames returns a sorted list of the names [RunnerByName] accepts.
+func RunnerNames() []string {
+	runnersMu.RLock()
+	defer runnersMu.RUnlock()
+	names := make([]string, 0, len(runners))
+	for name := range runners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithRunnerName sets the execution strategy by name, resolved with
+// [RunnerByName], so it can come from configuration without importing the
+// concrete types:
+//
+//	name, _ := cfg.Get("runner", "serial").(string)
+//	router := terminal.New(terminal.WithRunnerName(name))
+//
+// An unknown name does not fail New; instead every Run or RunContext call
+// on the router returns the [ErrRunnerNotFound] error without running a
+// command. A later [WithRunner] or WithRunnerName replaces it.
+func WithRunnerName(name string) Option {
+	return func(r *Router) {
+		runner, err := RunnerByName(name)
+		if err != nil {
+			r.runner, r.runnerErr = nil, err
+			return
+		}
+		r.runner, r.runnerErr = runner, nil
+	}
+}
@@ -0,0 +1,115 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunnerByName(t *testing.T) {
+	tests := []struct {
+		name string
+		want Runner
+	}{
+		{"serial", &SerialRunner{}},
+		{"concurrent", &ConcurrentRunner{}},
+		{"pipeline", &PipelineRunner{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RunnerByName(tt.name)
+			if err != nil {
+				t.Fatalf("RunnerByName(%q) error = %v", tt.name, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RunnerByName(%q) = %#v, want %#v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunnerByName_Unknown(t *testing.T) {
+	_, err := RunnerByName("parallel")
+	if !errors.Is(err, ErrRunnerNotFound) {
+		t.Fatalf("RunnerByName(parallel) error = %v, want ErrRunnerNotFound", err)
+	}
+	if !strings.Contains(err.Error(), `"parallel"`) || !strings.Contains(err.Error(), "concurrent, pipeline, serial") {
+		t.Errorf("error %q should name the runner and list the available ones", err)
+	}
+}
+
+// countingRunner is a custom Runner that counts Execute calls.
+type countingRunner struct{ calls int }
+
+func (r *countingRunner) Execute(ctx context.Context, commands []Command, execCtx *Context) error {
+	r.calls++
+	return (&SerialRunner{}).Execute(ctx, commands, execCtx)
+}
+
+func TestRegisterRunner(t *testing.T) {
+	custom := &countingRunner{}
+	RegisterRunner("counting", func() Runner { return custom })
+	t.Cleanup(func() {
+		runnersMu.Lock()
+		delete(runners, "counting")
+		runnersMu.Unlock()
+	})
+
+	got, err := RunnerByName("counting")
+	if err != nil || got != custom {
+		t.Fatalf("RunnerByName(counting) = %v, %v; want the registered runner", got, err)
+	}
+
+	for _, name := range []string{"", "counting", "serial"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterRunner(%q) did not panic", name)
+				}
+			}()
+			RegisterRunner(name, func() Runner { return &SerialRunner{} })
+		}()
+	}
+}
+
+func TestWithRunnerName(t *testing.T) {
+	custom := &countingRunner{}
+	RegisterRunner("counting", func() Runner { return custom })
+	t.Cleanup(func() {
+		runnersMu.Lock()
+		delete(runners, "counting")
+		runnersMu.Unlock()
+	})
+
+	cmd := &mockCommand{name: "version"}
+	router := New(WithStdout(io.Discard), WithStderr(io.Discard), WithRunnerName("counting"))
+	router.Register(cmd)
+	if err := router.RunArgs([]string{"version"}); err != nil {
+		t.Fatalf("RunArgs() error = %v", err)
+	}
+	if !cmd.called || custom.calls != 1 {
+		t.Errorf("called = %v, runner calls = %d; want the command run through the named runner", cmd.called, custom.calls)
+	}
+}
+
+func TestWithRunnerName_Unknown(t *testing.T) {
+	cmd := &mockCommand{name: "version"}
+	router := New(WithStdout(io.Discard), WithStderr(io.Discard), WithRunnerName("nope"))
+	router.Register(cmd)
+	if err := router.RunArgs([]string{"version"}); !errors.Is(err, ErrRunnerNotFound) {
+		t.Errorf("RunArgs() error = %v, want ErrRunnerNotFound", err)
+	}
+	if cmd.called {
+		t.Error("command ran despite the unknown runner")
+	}
+
+	// A later WithRunner replaces the failed lookup.
+	router = New(WithRunnerName("nope"), WithRunner(&SerialRunner{}))
+	router.Register(cmd)
+	if err := router.RunArgs([]string{"version"}); err != nil {
+		t.Errorf("RunArgs() after WithRunner error = %v", err)
+	}
+}
@@ -124,6 +124,48 @@ func TestErrNotImplemented_StillExists(t *testing.T) {
 	}
 }
 
+// confirmCommand records the answer of tc.Confirm.
+type confirmCommand struct {
+	mockCommand
+	confirmed bool
+	err       error
+}
+
+func (c *confirmCommand) Run(_ context.Context, tc *Context) error {
+	c.confirmed, c.err = tc.Confirm("Overwrite?")
+	return nil
+}
+
+func TestRunners_AssumeYes(t *testing.T) {
+	runners := []struct {
+		name   string
+		runner Runner
+	}{
+		{"serial", &SerialRunner{}},
+		{"concurrent", &ConcurrentRunner{MaxWorkers: 2}},
+		{"concurrent ordered", &ConcurrentRunner{MaxWorkers: 2, OrderedOutput: true}},
+		{"pipeline", &PipelineRunner{}},
+	}
+
+	for _, tt := range runners {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds := []*confirmCommand{{}, {}}
+			execCtx := newExecCtx()
+			execCtx.NonInteractive = true
+			execCtx.AssumeYes = true
+
+			if err := tt.runner.Execute(context.Background(), []Command{cmds[0], cmds[1]}, execCtx); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			for i, cmd := range cmds {
+				if !cmd.confirmed || cmd.err != nil {
+					t.Errorf("command %d: Confirm() = %v, %v; want true, nil", i, cmd.confirmed, cmd.err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkSerialRunner_Execute(b *testing.B) {
 	runner := &SerialRunner{}
 	cmd := &mockCommand{name: "bench"}
@@ -0,0 +1,156 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mrlm-net/cure/pkg/style"
+)
+
+// Table writes rows of text in aligned columns. Column widths are measured
+// in terminal cells: ANSI styling from [style] takes no space, East Asian wide
+// characters take two cells, and combining marks take none.
+//
+// The zero value is ready to use and writes rows with no indent, separated by
+// two spaces. The last column is never padded, so rows carry no trailing
+// whitespace.
+//
+// Example:
+//
+//	t := &terminal.Table{Indent: "  "}
+//	t.SetHeader("NAME", "DESCRIPTION")
+//	t.AddRow("trace", "Trace network connections")
+//	t.AddRow("version", "Print version information")
+//	t.Render(os.Stdout)
+//
+// Output:
+//
+//	NAME     DESCRIPTION
+//	-------  -------------------------
+//	trace    Trace network connections
+//	version  Print version information
+type Table struct {
+	// Indent is written at the start of every line.
+	Indent string
+
+	// Gap is the number of spaces between columns. Zero means 2.
+	Gap int
+
+	header []string
+	rows   [][]string
+}
+
+// SetHeader sets the column titles. A header is written above the rows,
+// followed by a separator line of dashes as wide as each column.
+func (t *Table) SetHeader(cols ...string) {
+	t.header = cols
+}
+
+// AddRow appends a row. Rows may have different numbers of columns; missing
+// cells are treated as empty.
+func (t *Table) AddRow(cols ...string) {
+	t.rows = append(t.rows, cols)
+}
+
+// Render writes the table to w. It writes nothing when the table has neither
+// a header nor rows.
+func (t *Table) Render(w io.Writer) error {
+	lines := t.rows
+	if t.header != nil {
+		lines = append([][]string{t.header}, t.rows...)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	widths := t.widths(lines)
+	if t.header != nil {
+		sep := make([]string, len(widths))
+		for i, n := range widths {
+			sep[i] = strings.Repeat("-", n)
+		}
+		lines = append([][]string{t.header, sep}, t.rows...)
+	}
+
+	gap := t.Gap
+	if gap <= 0 {
+		gap = 2
+	}
+	for _, row := range lines {
+		var b strings.Builder
+		b.WriteString(t.Indent)
+		last := len(row) - 1
+		for last >= 0 && row[last] == "" {
+			last--
+		}
+		for i := 0; i <= last; i++ {
+			b.WriteString(row[i])
+			if i < last {
+				b.WriteString(strings.Repeat(" ", widths[i]-displayWidth(row[i])+gap))
+			}
+		}
+		if _, err := fmt.Fprintln(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// widths returns the display width of the widest cell in each column.
+func (t *Table) widths(lines [][]string) []int {
+	var widths []int
+	for _, row := range lines {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+	return widths
+}
+
+// displayWidth returns the number of terminal cells s occupies.
+func displayWidth(s string) int {
+	s = style.Reset(s)
+	n := 0
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		switch {
+		case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r), r == '\u200b':
+			// combining marks and zero-width space take no cell
+		case isWide(r):
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// isWide reports whether r is rendered two cells wide: the East Asian Wide
+// and Fullwidth blocks and the common emoji planes.
+func isWide(r rune) bool {
+	switch {
+	case r >= 0x1100 && r <= 0x115F, // Hangul Jamo
+		r >= 0x2E80 && r <= 0x303E,   // CJK radicals, punctuation
+		r >= 0x3041 && r <= 0x33FF,   // Hiragana, Katakana, CJK symbols
+		r >= 0x3400 && r <= 0x4DBF,   // CJK extension A
+		r >= 0x4E00 && r <= 0x9FFF,   // CJK unified ideographs
+		r >= 0xA000 && r <= 0xA4CF,   // Yi
+		r >= 0xAC00 && r <= 0xD7A3,   // Hangul syllables
+		r >= 0xF900 && r <= 0xFAFF,   // CJK compatibility ideographs
+		r >= 0xFE30 && r <= 0xFE4F,   // CJK compatibility forms
+		r >= 0xFF00 && r <= 0xFF60,   // Fullwidth forms
+		r >= 0xFFE0 && r <= 0xFFE6,   // Fullwidth signs
+		r >= 0x1F300 && r <= 0x1F64F, // emoji
+		r >= 0x1F900 && r <= 0x1F9FF, // supplemental symbols
+		r >= 0x20000 && r <= 0x3FFFD: // CJK extensions B and later
+		return true
+	}
+	return false
+}
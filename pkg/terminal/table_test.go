@@ -0,0 +1,131 @@
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/mrlm-net/cure/pkg/style"
+)
+
+func TestTable_Render(t *testing.T) {
+	tests := []struct {
+		name  string
+		table func() *Table
+		want  string
+	}{
+		{
+			name:  "empty",
+			table: func() *Table { return &Table{} },
+			want:  "",
+		},
+		{
+			name: "aligned columns",
+			table: func() *Table {
+				tbl := &Table{Indent: "  "}
+				tbl.AddRow("trace", "Trace network connections")
+				tbl.AddRow("v", "Print version")
+				return tbl
+			},
+			want: "  trace  Trace network connections\n" +
+				"  v      Print version\n",
+		},
+		{
+			name: "header and separator",
+			table: func() *Table {
+				tbl := &Table{}
+				tbl.SetHeader("NAME", "STATUS")
+				tbl.AddRow("example.com", "200")
+				return tbl
+			},
+			want: "NAME         STATUS\n" +
+				"-----------  ------\n" +
+				"example.com  200\n",
+		},
+		{
+			name: "ragged rows and custom gap",
+			table: func() *Table {
+				tbl := &Table{Gap: 1}
+				tbl.AddRow("a", "bb", "ccc")
+				tbl.AddRow("dddd")
+				tbl.AddRow("e", "", "f")
+				return tbl
+			},
+			want: "a    bb ccc\n" +
+				"dddd\n" +
+				"e       f\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.table().Render(&buf); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Render() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTable_Render_MultiWidth(t *testing.T) {
+	if !style.Enabled() {
+		style.Enable()
+		t.Cleanup(style.Disable)
+	}
+
+	tbl := &Table{}
+	tbl.AddRow("héllo", "accented")        // 5 cells, 6 bytes
+	tbl.AddRow("日本", "wide")               // 4 cells, 6 bytes
+	tbl.AddRow(style.Red("red"), "styled") // 3 cells plus escape codes
+	tbl.AddRow("e\u0301", "combining")     // 1 cell
+	tbl.AddRow("ab", "plain")
+
+	var buf bytes.Buffer
+	if err := tbl.Render(&buf); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := "héllo  accented\n" +
+		"日本   wide\n" +
+		style.Red("red") + "    styled\n" +
+		"e\u0301      combining\n" +
+		"ab     plain\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Render() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"héllo", 5},
+		{"日本語", 6},
+		{"ｆｕｌｌ", 8},
+		{"한국", 4},
+		{"e\u0301", 1},
+		{"a\u200bb", 2},
+		{"\x1b[1;31mbold\x1b[0m", 4},
+	}
+	for _, tt := range tests {
+		if got := displayWidth(tt.in); got != tt.want {
+			t.Errorf("displayWidth(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTable_Render_WriteError(t *testing.T) {
+	tbl := &Table{}
+	tbl.AddRow("a", "b")
+	if err := tbl.Render(errWriter{}); err == nil {
+		t.Error("Render() error = nil, want write error")
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
@@ -0,0 +1,73 @@
+package terminal
+
+import "sort"
+
+// Topic is a help topic: documentation for a concept, such as output
+// formats or configuration, that is not a runnable command. Register one
+// with [Router.RegisterTopic]; "help <name>" shows it.
+type Topic struct {
+	// Name is the argument that selects the topic, as in "help formats".
+	Name string
+
+	// Title is the one-line summary shown in the help listing and as the
+	// heading of the topic.
+	Title string
+
+	// Body is the documentation, written as is.
+	Body string
+}
+
+// TopicRegistry extends CommandRegistry with help topics.
+// [Router] implements this interface.
+type TopicRegistry interface {
+	CommandRegistry
+
+	// Topics returns all registered topics, sorted by name.
+	Topics() []Topic
+
+	// LookupTopic finds a topic by exact name match.
+	LookupTopic(name string) (Topic, bool)
+}
+
+// RegisterTopic adds a help topic to the router, replacing any topic
+// registered under the same name. [HelpCommand] lists topics in a "Topics"
+// section and shows one when "help <name>" does not name a command, so a
+// command of the same name takes precedence. Panics if name is empty. It is
+// safe for concurrent use.
+//
+// Example:
+//
+//	router.RegisterTopic("formats", "Output formats", "cure writes NDJSON by default...")
+func (r *Router) RegisterTopic(name, title, body string) {
+	if name == "" {
+		panic("terminal: topic name cannot be empty")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.topics == nil {
+		r.topics = make(map[string]Topic)
+	}
+	r.topics[name] = Topic{Name: name, Title: title, Body: body}
+}
+
+// Topics returns all registered help topics, sorted by name.
+func (r *Router) Topics() []Topic {
+	r.mu.RLock()
+	topics := make([]Topic, 0, len(r.topics))
+	for _, t := range r.topics {
+		topics = append(topics, t)
+	}
+	r.mu.RUnlock()
+	sort.Slice(topics, func(i, j int) bool {
+		return topics[i].Name < topics[j].Name
+	})
+	return topics
+}
+
+// LookupTopic finds a registered help topic by exact name match.
+func (r *Router) LookupTopic(name string) (Topic, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.topics[name]
+	return t, ok
+}
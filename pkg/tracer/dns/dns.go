@@ -2,14 +2,16 @@ package dns
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"fmt"
+	"math/rand/v2"
 	"net"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/mrlm-net/cure/pkg/tracer/event"
+	"github.com/mrlm-net/cure/pkg/tracer/ratelimit"
+	"github.com/mrlm-net/cure/pkg/tracer/resolvconf"
 )
 
 var (
@@ -56,15 +58,6 @@ func ipFamily(ip net.IP) string {
 	return "ipv6"
 }
 
-func generateTraceID() string {
-	b := make([]byte, 8)
-	if _, err := rand.Read(b); err != nil {
-		// Fallback to timestamp-based ID if crypto/rand fails.
-		return hex.EncodeToString(fmt.Appendf(nil, "%08x", time.Now().UnixNano()))
-	}
-	return hex.EncodeToString(b)
-}
-
 // Option is a functional option for TraceDNS.
 type Option func(*traceConfig)
 
@@ -75,6 +68,19 @@ type traceConfig struct {
 	server   string        // empty = system default resolver; otherwise "IP:port"
 	count    int           // default 1
 	interval time.Duration // default 0
+	jitter   float64       // default 0; fraction of interval
+	limiter  *ratelimit.Limiter
+
+	recordType RecordType // 0 = A and AAAA via the system resolver
+	edns       bool
+	freshDNS   bool
+
+	traceID    string
+	traceIDSet bool
+
+	labels map[string]string
+
+	clock event.Clock // set by WithClock; nil means the system clock
 }
 
 // WithEmitter sets the event emitter.
@@ -84,6 +90,36 @@ func WithEmitter(em event.Emitter) Option {
 	}
 }
 
+// WithTraceID sets the trace ID carried by every event, for example to
+// correlate a trace with an existing request log. TraceDNS returns
+// [event.ErrEmptyTraceID] if id is empty. Default: a random ID from
+// [event.NewTraceID].
+func WithTraceID(id string) Option {
+	return func(cfg *traceConfig) {
+		cfg.traceID = id
+		cfg.traceIDSet = true
+	}
+}
+
+// WithClock sets the clock that times each attempt's duration_ms, so tests
+// can assert exact values. The query timeout and WithInterval waits still
+// use the system clock. nil means [event.SystemClock], which is the default.
+func WithClock(c event.Clock) Option {
+	return func(cfg *traceConfig) {
+		cfg.clock = c
+	}
+}
+
+// WithLabels attaches labels, such as {"server": "resolver-a"}, to the
+// dns_query_start and dns_query_done of every attempt, so samples against
+// several resolvers can be told apart. labels is copied; see
+// [event.LabelEmitter]. Default: none.
+func WithLabels(labels map[string]string) Option {
+	return func(cfg *traceConfig) {
+		cfg.labels = labels
+	}
+}
+
 // WithDryRun enables dry-run mode, emitting synthetic events without performing real DNS queries.
 func WithDryRun(enabled bool) Option {
 	return func(cfg *traceConfig) {
@@ -125,6 +161,114 @@ func WithInterval(d time.Duration) Option {
 	}
 }
 
+// WithJitter randomises each wait set by WithInterval by up to ±fraction of
+// the interval, so repeated queries do not fall into step with a server's
+// rate-limit or cache windows. With a 10s interval and fraction 0.2, each
+// wait is between 8s and 12s. fraction is clamped to [0, 1]. The wait still
+// ends early if the context is cancelled. Default: 0 (no jitter).
+func WithJitter(fraction float64) Option {
+	return func(cfg *traceConfig) {
+		cfg.jitter = min(max(fraction, 0), 1)
+	}
+}
+
+// nextDelay returns the wait before the next repeated query: the
+// interval, moved by a random amount of up to ±jitter of itself.
+func (cfg *traceConfig) nextDelay() time.Duration {
+	if cfg.jitter <= 0 {
+		return cfg.interval
+	}
+	spread := (2*rand.Float64() - 1) * cfg.jitter * float64(cfg.interval)
+	return cfg.interval + time.Duration(spread)
+}
+
+// WithRateLimit caps repeated queries at perSecond queries per second with a
+// [ratelimit.Limiter], on top of any WithInterval delay. Waiting for the
+// limiter honours context cancellation. perSecond <= 0 disables the limit. No events are
+// emitted for the wait.
+func WithRateLimit(perSecond float64) Option {
+	return func(cfg *traceConfig) {
+		cfg.limiter = ratelimit.New(perSecond)
+	}
+}
+
+// WithRecordType queries for records of type t only, instead of the default
+// A and AAAA lookup, and reports every record in the answer section as an
+// entry of the dns_query_done "answers" array, with "name", "type", "ttl",
+// and "data" in dig's presentation form (for example "10 mail.example.com."
+// for MX). A and AAAA answers are also listed in "addrs".
+//
+// The query is sent over UDP straight to the WithServer address, or to the
+// system's first nameserver, so dns_query_done can also report the response
+// code ("rcode") and whether the reply was truncated ("truncated"). A
+// truncated reply is not retried over TCP. Default: A and AAAA through the
+// system resolver.
+func WithRecordType(t RecordType) Option {
+	return func(cfg *traceConfig) {
+		cfg.recordType = t
+	}
+}
+
+// WithEDNS adds an EDNS(0) OPT record to each query, advertising a 1232-byte
+// UDP payload and setting the DNSSEC OK bit. dns_query_done then reports
+// whether the reply carried an OPT record ("edns") and whether the resolver
+// marked the answer as DNSSEC-validated ("authenticated_data"). Like
+// WithRecordType it sends queries directly; without a record type, A is
+// queried. Default: false.
+func WithEDNS(enabled bool) Option {
+	return func(cfg *traceConfig) {
+		cfg.edns = enabled
+	}
+}
+
+// WithFreshDNS makes every attempt a new lookup through Go's own resolver,
+// which reads /etc/resolv.conf and queries the nameservers directly, instead
+// of the system resolver, which may go through the C library and a local
+// cache such as nscd. A new resolver is built for each attempt, so no state
+// carries over between samples. dns_query_start then carries "fresh": true,
+// and every dns_query_done after the first successful one reports
+// "addrs_changed", whether its addresses differ from the previous answer, so
+// intermittent resolution (such as a Private Link record that flaps between
+// private and public IPs) stands out across a WithCount series.
+//
+// Caches below Go are not bypassed: a caching stub such as systemd-resolved
+// on 127.0.0.53 still answers from its cache, and on Windows and macOS the
+// OS resolver cache may apply. Point WithServer at the upstream server to
+// skip them. Queries sent with WithRecordType or WithEDNS are always fresh.
+// Default: false.
+func WithFreshDNS(enabled bool) Option {
+	return func(cfg *traceConfig) {
+		cfg.freshDNS = enabled
+	}
+}
+
+// newResolver returns the resolver for one attempt: one that dials
+// cfg.server when set, a new pure-Go resolver with WithFreshDNS, and
+// otherwise net.DefaultResolver.
+func (cfg *traceConfig) newResolver() *net.Resolver {
+	switch {
+	case cfg.server != "":
+		return buildResolver(cfg.server)
+	case cfg.freshDNS:
+		return &net.Resolver{PreferGo: true}
+	}
+	return net.DefaultResolver
+}
+
+// direct reports whether queries are sent by the built-in wire-format client
+// rather than through a net.Resolver.
+func (cfg *traceConfig) direct() bool {
+	return cfg.recordType != 0 || cfg.edns
+}
+
+// queryType returns the record type sent by the wire-format client.
+func (cfg *traceConfig) queryType() RecordType {
+	if cfg.recordType == 0 {
+		return TypeA
+	}
+	return cfg.recordType
+}
+
 // buildResolver constructs a *net.Resolver that dials server over UDP.
 func buildResolver(server string) *net.Resolver {
 	return &net.Resolver{
@@ -138,20 +282,20 @@ func buildResolver(server string) *net.Resolver {
 // emitDryRunEvents emits synthetic dns_query_start/dns_query_done event pairs.
 // count = 0 loops until ctx is cancelled (mirrors the live-query behaviour).
 // Uses a hardcoded Azure Private Link scenario as the dry-run payload.
-func emitDryRunEvents(ctx context.Context, em event.Emitter, traceID string, count int) error {
+func emitDryRunEvents(ctx context.Context, em event.Emitter, cfg *traceConfig, traceID string) error {
 	if em == nil {
 		return nil
 	}
-	for attempt := 1; count == 0 || attempt <= count; attempt++ {
+	for attempt := 1; cfg.count == 0 || attempt <= cfg.count; attempt++ {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
-		em.Emit(event.NewEvent("dns_query_start", traceID, map[string]any{
+		startData := map[string]any{
 			"hostname": "mystorageaccount.blob.core.windows.net",
 			"attempt":  attempt,
 			"server":   "168.63.129.16:53",
-		}))
-		em.Emit(event.NewEvent("dns_query_done", traceID, map[string]any{
+		}
+		doneData := map[string]any{
 			"hostname":    "mystorageaccount.blob.core.windows.net",
 			"attempt":     attempt,
 			"server":      "168.63.129.16:53",
@@ -160,7 +304,23 @@ func emitDryRunEvents(ctx context.Context, em event.Emitter, traceID string, cou
 			"addrs": []map[string]any{
 				{"ip": "10.2.0.5", "family": "ipv4", "private": true},
 			},
-		}))
+		}
+		if cfg.direct() {
+			startData["record_type"] = cfg.queryType().String()
+			doneData["record_type"] = cfg.queryType().String()
+			doneData["rcode"] = "NOERROR"
+			doneData["truncated"] = false
+			doneData["answers"] = []map[string]any{
+				{"name": "mystorageaccount.blob.core.windows.net.", "type": "CNAME", "ttl": 60, "data": "mystorageaccount.privatelink.blob.core.windows.net."},
+				{"name": "mystorageaccount.privatelink.blob.core.windows.net.", "type": "A", "ttl": 10, "data": "10.2.0.5"},
+			}
+			if cfg.edns {
+				doneData["edns"] = true
+				doneData["authenticated_data"] = false
+			}
+		}
+		em.Emit(event.NewEvent("dns_query_start", traceID, startData))
+		em.Emit(event.NewEvent("dns_query_done", traceID, doneData))
 	}
 	return nil
 }
@@ -169,7 +329,8 @@ func emitDryRunEvents(ctx context.Context, em event.Emitter, traceID string, cou
 //
 // Events emitted per attempt:
 //   - dns_query_start
-//   - dns_query_done (with addrs on success, error on failure)
+//   - dns_query_done (with addrs on success, error on failure; with answers,
+//     rcode, and truncated when WithRecordType or WithEDNS is set)
 //
 // Example:
 //
@@ -189,28 +350,79 @@ func TraceDNS(ctx context.Context, hostname string, opts ...Option) error {
 		opt(cfg)
 	}
 
-	traceID := generateTraceID()
+	traceID := cfg.traceID
+	if cfg.traceIDSet && traceID == "" {
+		return event.ErrEmptyTraceID
+	}
+	if traceID == "" {
+		traceID = event.NewTraceID()
+	}
+
+	if len(cfg.labels) > 0 {
+		cfg.emitter = event.NewLabelEmitter(cfg.emitter, cfg.labels)
+	}
+
+	// Stop tracing as soon as the output fails; see event.Guard.
+	ctx, guard := event.Guard(ctx, cfg.emitter)
+	cfg.emitter = guard
 
 	if cfg.dryRun {
-		return emitDryRunEvents(ctx, cfg.emitter, traceID, cfg.count)
+		return guard.Check(emitDryRunEvents(ctx, cfg.emitter, cfg, traceID))
 	}
+	return guard.Check(traceQueries(ctx, cfg, traceID, hostname))
+}
 
-	var resolver *net.Resolver
-	if cfg.server != "" {
-		resolver = buildResolver(cfg.server)
-	} else {
-		resolver = net.DefaultResolver
+// Collect runs [TraceDNS] and returns the events it emitted along with its
+// error, so Go programs and tests can inspect a trace without parsing
+// NDJSON. On failure the events up to the failure are returned. An emitter
+// set with [WithEmitter] still receives every event as it happens, so a
+// trace can be streamed and inspected at once.
+//
+// Example:
+//
+//	events, err := dns.Collect(ctx, "example.com")
+//	for _, ev := range events {
+//	    if ev.Type == event.TraceSummary {
+//	        fmt.Println(ev.Data["total_ms"])
+//	    }
+//	}
+func Collect(ctx context.Context, hostname string, opts ...Option) ([]event.Event, error) {
+	var rec *event.SliceEmitter
+	opts = append(opts[:len(opts):len(opts)], func(cfg *traceConfig) {
+		rec = event.NewSliceEmitter(cfg.emitter)
+		cfg.emitter = rec
+	})
+	err := TraceDNS(ctx, hostname, opts...)
+	return rec.Events(), err
+}
+
+// traceQueries runs the configured number of lookups of hostname, emitting
+// dns_query_start and dns_query_done for each.
+func traceQueries(ctx context.Context, cfg *traceConfig, traceID, hostname string) error {
+	resolver := cfg.newResolver()
+	var prevAddrs []string // last successful answer, for addrs_changed
+	server := cfg.server
+	if cfg.direct() && server == "" {
+		if server = resolvconf.Nameserver(); server == "" {
+			return fmt.Errorf("no system DNS server found; set one with WithServer")
+		}
 	}
 
 	for attempt := 1; cfg.count == 0 || attempt <= cfg.count; attempt++ {
 		// Wait between repeated queries (skip wait before first attempt).
 		if attempt > 1 && cfg.interval > 0 {
 			select {
-			case <-time.After(cfg.interval):
+			case <-time.After(cfg.nextDelay()):
 			case <-ctx.Done():
 				return ctx.Err()
 			}
 		}
+		if err := cfg.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		iterCtx, cancel := context.WithTimeout(ctx, cfg.timeout)
 
@@ -222,16 +434,37 @@ func TraceDNS(ctx context.Context, hostname string, opts ...Option) error {
 		if cfg.server != "" {
 			startData["server"] = cfg.server
 		}
+		if cfg.direct() {
+			startData["record_type"] = cfg.queryType().String()
+		}
+		if cfg.freshDNS {
+			startData["fresh"] = true
+		}
+		resolvconf.AddResolver(startData, cfg.server)
 		if cfg.emitter != nil {
 			cfg.emitter.Emit(event.NewEvent("dns_query_start", traceID, startData))
 		}
 
-		start := time.Now()
+		if cfg.direct() {
+			start := event.Now(cfg.clock)
+			resp, err := exchange(iterCtx, server, hostname, cfg.queryType(), cfg.edns)
+			cancel()
+			doneData := queryDoneData(cfg, hostname, attempt, event.Since(cfg.clock, start).Milliseconds(), resp, err)
+			if cfg.emitter != nil {
+				cfg.emitter.Emit(event.NewEvent("dns_query_done", traceID, doneData))
+			}
+			continue
+		}
+
+		if cfg.freshDNS && attempt > 1 {
+			resolver = cfg.newResolver()
+		}
+		start := event.Now(cfg.clock)
 
 		cname, cnameErr := resolver.LookupCNAME(iterCtx, hostname)
 		ipAddrs, ipErr := resolver.LookupIPAddr(iterCtx, hostname)
 
-		duration := time.Since(start).Milliseconds()
+		duration := event.Since(cfg.clock, start).Milliseconds()
 		cancel()
 
 		// Build dns_query_done data
@@ -243,6 +476,7 @@ func TraceDNS(ctx context.Context, hostname string, opts ...Option) error {
 		if cfg.server != "" {
 			doneData["server"] = cfg.server
 		}
+		resolvconf.AddResolver(doneData, cfg.server)
 
 		if ipErr != nil {
 			doneData["error"] = ipErr.Error()
@@ -267,6 +501,13 @@ func TraceDNS(ctx context.Context, hostname string, opts ...Option) error {
 			})
 		}
 		doneData["addrs"] = addrs
+		if cfg.freshDNS {
+			ips := sortedIPs(ipAddrs)
+			if prevAddrs != nil {
+				doneData["addrs_changed"] = !slices.Equal(ips, prevAddrs)
+			}
+			prevAddrs = ips
+		}
 
 		if cfg.emitter != nil {
 			cfg.emitter.Emit(event.NewEvent("dns_query_done", traceID, doneData))
@@ -275,3 +516,68 @@ func TraceDNS(ctx context.Context, hostname string, opts ...Option) error {
 
 	return nil
 }
+
+// sortedIPs returns the addresses of ipAddrs as sorted strings, so answers
+// can be compared regardless of order.
+func sortedIPs(ipAddrs []net.IPAddr) []string {
+	ips := make([]string, len(ipAddrs))
+	for i, ia := range ipAddrs {
+		ips[i] = ia.IP.String()
+	}
+	slices.Sort(ips)
+	return ips
+}
+
+// queryDoneData builds the dns_query_done data for a query sent by the
+// wire-format client. resp is nil when err is set.
+func queryDoneData(cfg *traceConfig, hostname string, attempt int, duration int64, resp *response, err error) map[string]any {
+	data := map[string]any{
+		"hostname":    hostname,
+		"attempt":     attempt,
+		"record_type": cfg.queryType().String(),
+		"duration_ms": duration,
+	}
+	if cfg.server != "" {
+		data["server"] = cfg.server
+	}
+	resolvconf.AddResolver(data, cfg.server)
+	if err != nil {
+		data["error"] = err.Error()
+		return data
+	}
+
+	data["rcode"] = rcodeName(resp.rcode)
+	data["truncated"] = resp.truncated
+	if cfg.edns {
+		data["edns"] = resp.edns
+		data["authenticated_data"] = resp.authenticated
+	}
+	if resp.rcode != 0 {
+		data["error"] = rcodeName(resp.rcode)
+	}
+
+	answers := make([]map[string]any, 0, len(resp.answers))
+	var addrs []map[string]any
+	for _, rr := range resp.answers {
+		answers = append(answers, map[string]any{
+			"name": rr.name,
+			"type": rr.typ.String(),
+			"ttl":  rr.ttl,
+			"data": rr.data,
+		})
+		if rr.typ == TypeA || rr.typ == TypeAAAA {
+			if ip := net.ParseIP(rr.data); ip != nil {
+				addrs = append(addrs, map[string]any{
+					"ip":      rr.data,
+					"family":  ipFamily(ip),
+					"private": isPrivate(ip),
+				})
+			}
+		}
+	}
+	data["answers"] = answers
+	if addrs != nil {
+		data["addrs"] = addrs
+	}
+	return data
+}
@@ -2,11 +2,16 @@ package dns
 
 import (
 	"context"
+	"errors"
+	"maps"
 	"net"
+	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/mrlm-net/cure/pkg/tracer/event"
+	"github.com/mrlm-net/cure/pkg/tracer/resolvconf"
 )
 
 // testEmitter captures emitted events for inspection.
@@ -148,6 +153,92 @@ func TestWithCount_ZeroRunsUntilContextCancelled(t *testing.T) {
 	}
 }
 
+func TestWithJitter_DelayRange(t *testing.T) {
+	const interval = time.Second
+	tests := []struct {
+		name     string
+		fraction float64
+		lo, hi   time.Duration
+	}{
+		{"default has no jitter", 0, interval, interval},
+		{"fraction 0.2", 0.2, 800 * time.Millisecond, 1200 * time.Millisecond},
+		{"fraction clamped to 1", 3, 0, 2 * interval},
+		{"negative fraction is no jitter", -0.5, interval, interval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &traceConfig{}
+			WithInterval(interval)(cfg)
+			WithJitter(tt.fraction)(cfg)
+
+			var below, above bool
+			for range 10000 {
+				d := cfg.nextDelay()
+				if d < tt.lo || d > tt.hi {
+					t.Fatalf("nextDelay() = %v, want within [%v, %v]", d, tt.lo, tt.hi)
+				}
+				below = below || d < interval
+				above = above || d > interval
+			}
+			if tt.lo != tt.hi && !(below && above) {
+				t.Errorf("delays never spread both sides of %v (below=%v above=%v)", interval, below, above)
+			}
+		})
+	}
+}
+
+func TestWithJitter_CancelDuringWait(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	// Nothing listens on port 1, so the first query fails fast and the
+	// trace moves on to the wait.
+	err := TraceDNS(ctx, "example.com",
+		WithEmitter(&testEmitter{}),
+		WithServer("127.0.0.1:1"),
+		WithTimeout(20*time.Millisecond),
+		WithCount(2),
+		WithInterval(time.Hour),
+		WithJitter(0.5),
+	)
+	if err == nil {
+		t.Fatal("error = nil, want context error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("returned after %v, want the jittered wait cut short by cancellation", elapsed)
+	}
+}
+
+func TestWithRateLimit_PacesQueries(t *testing.T) {
+	const (
+		count     = 3
+		perSecond = 10.0
+	)
+	em := &testEmitter{}
+	start := time.Now()
+	// Nothing listens on port 1, so each query fails fast; the limiter alone
+	// determines the pacing.
+	err := TraceDNS(context.Background(), "example.com",
+		WithEmitter(em),
+		WithServer("127.0.0.1:1"),
+		WithTimeout(100*time.Millisecond),
+		WithCount(count),
+		WithRateLimit(perSecond),
+	)
+	if err != nil {
+		t.Fatalf("TraceDNS() error = %v", err)
+	}
+	elapsed := time.Since(start)
+
+	want := time.Duration(count-1) * time.Duration(float64(time.Second)/perSecond)
+	if elapsed < want {
+		t.Errorf("%d queries took %v, want at least %v", count, elapsed, want)
+	}
+	if len(em.events) != 2*count {
+		t.Errorf("got %d events, want %d (rate limiting must not emit events)", len(em.events), 2*count)
+	}
+}
+
 func BenchmarkTraceDNS_DryRun(b *testing.B) {
 	em := &testEmitter{}
 	for b.Loop() {
@@ -159,3 +250,166 @@ func BenchmarkTraceDNS_DryRun(b *testing.B) {
 		)
 	}
 }
+
+func TestTraceDNS_WithTraceID(t *testing.T) {
+	em := &testEmitter{}
+	err := TraceDNS(context.Background(), "example.com",
+		WithEmitter(em),
+		WithDryRun(true),
+		WithTraceID("req-1234"),
+	)
+	if err != nil {
+		t.Fatalf("TraceDNS() error = %v", err)
+	}
+	if len(em.events) == 0 {
+		t.Fatal("no events emitted")
+	}
+	for _, ev := range em.events {
+		if ev.TraceID != "req-1234" {
+			t.Errorf("%s TraceID = %q, want %q", ev.Type, ev.TraceID, "req-1234")
+		}
+	}
+
+	err = TraceDNS(context.Background(), "example.com", WithDryRun(true), WithTraceID(""))
+	if !errors.Is(err, event.ErrEmptyTraceID) {
+		t.Errorf("TraceDNS() with empty trace ID error = %v, want %v", err, event.ErrEmptyTraceID)
+	}
+}
+
+func TestTraceDNS_Resolver(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		host string
+		want string
+	}{
+		{"custom server", []Option{WithServer("127.0.0.1:1"), WithTimeout(100 * time.Millisecond)}, "example.com", "127.0.0.1:1"},
+		{"system resolver", nil, "localhost", resolvconf.Nameserver()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := &testEmitter{}
+			if err := TraceDNS(context.Background(), tt.host, append(tt.opts, WithEmitter(em))...); err != nil {
+				t.Fatalf("TraceDNS() error = %v", err)
+			}
+			for _, ev := range em.events {
+				if ev.Type != "dns_query_start" && ev.Type != "dns_query_done" {
+					continue
+				}
+				got, ok := ev.Data["resolver"]
+				if tt.want == "" && ok {
+					t.Errorf("%s resolver = %v, want none when it is unknown", ev.Type, got)
+				} else if tt.want != "" && got != tt.want {
+					t.Errorf("%s resolver = %v, want %q", ev.Type, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	em := &testEmitter{}
+	events, err := Collect(context.Background(), "example.com", WithDryRun(true), WithEmitter(em))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(events) == 0 {
+		t.Fatal("Collect() returned no events")
+	}
+	if len(em.events) != len(events) {
+		t.Errorf("WithEmitter emitter got %d events, Collect returned %d", len(em.events), len(events))
+	}
+	if last := events[len(events)-1].Type; last != "dns_query_done" {
+		t.Errorf("last event = %q, want %q", last, "dns_query_done")
+	}
+}
+
+// flipEmitter records events and calls flip after every dns_query_done.
+type flipEmitter struct {
+	testEmitter
+	flip func()
+}
+
+func (e *flipEmitter) Emit(ev event.Event) error {
+	if ev.Type == "dns_query_done" {
+		e.flip()
+	}
+	return e.testEmitter.Emit(ev)
+}
+
+func TestWithFreshDNS_AddrsChanged(t *testing.T) {
+	// The answer flips between a private and a public IP after the second
+	// query, like a flapping Private Link record.
+	var queries atomic.Int32
+	private := fakeReply{answers: []fakeRecord{{typ: TypeA, ttl: 30, data: []byte{10, 0, 0, 7}}}}
+	public := fakeReply{answers: []fakeRecord{{typ: TypeA, ttl: 30, data: []byte{20, 60, 1, 2}}}}
+	server, _ := startFakeServerFunc(t, func() fakeReply {
+		if queries.Load() >= 2 {
+			return public
+		}
+		return private
+	})
+
+	em := &flipEmitter{flip: func() { queries.Add(1) }}
+	err := TraceDNS(context.Background(), "myservice.privatelink.example.com",
+		WithEmitter(em),
+		WithServer(server),
+		WithFreshDNS(true),
+		WithCount(3),
+		WithTimeout(2*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("TraceDNS() error = %v", err)
+	}
+
+	var changed []interface{}
+	for _, ev := range em.events {
+		switch ev.Type {
+		case "dns_query_start":
+			if ev.Data["fresh"] != true {
+				t.Errorf("dns_query_start fresh = %v, want true", ev.Data["fresh"])
+			}
+		case "dns_query_done":
+			if errMsg, ok := ev.Data["error"]; ok {
+				t.Fatalf("dns_query_done error = %v", errMsg)
+			}
+			changed = append(changed, ev.Data["addrs_changed"])
+		}
+	}
+	// The first answer has nothing to compare with; the second repeats it;
+	// the third differs.
+	want := []interface{}{nil, false, true}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("addrs_changed per attempt = %v, want %v", changed, want)
+	}
+}
+
+func TestWithFreshDNS_Off(t *testing.T) {
+	em := &testEmitter{}
+	TraceDNS(context.Background(), "example.com",
+		WithEmitter(em),
+		WithServer("127.0.0.1:1"),
+		WithTimeout(20*time.Millisecond),
+	)
+	for _, ev := range em.events {
+		if _, ok := ev.Data["fresh"]; ok {
+			t.Errorf("%s has fresh without WithFreshDNS", ev.Type)
+		}
+	}
+}
+
+func TestWithLabels(t *testing.T) {
+	labels := map[string]string{"env": "prod", "region": "eu"}
+	events, err := Collect(context.Background(), "example.com", WithDryRun(true), WithLabels(labels))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(events) == 0 {
+		t.Fatal("Collect() returned no events")
+	}
+	for _, ev := range events {
+		if !maps.Equal(ev.Labels, labels) {
+			t.Errorf("%s labels = %v, want %v", ev.Type, ev.Labels, labels)
+		}
+	}
+}
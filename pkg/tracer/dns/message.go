@@ -0,0 +1,382 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecordType is a DNS resource record type, as carried in the TYPE field of
+// a question or answer.
+type RecordType uint16
+
+// Record types understood by WithRecordType. Answers of other types are
+// still reported, with their type number and no data.
+const (
+	TypeA     RecordType = 1
+	TypeNS    RecordType = 2
+	TypeCNAME RecordType = 5
+	TypeMX    RecordType = 15
+	TypeTXT   RecordType = 16
+	TypeAAAA  RecordType = 28
+
+	typeOPT RecordType = 41
+)
+
+var recordTypeNames = map[RecordType]string{
+	TypeA:     "A",
+	TypeNS:    "NS",
+	TypeCNAME: "CNAME",
+	TypeMX:    "MX",
+	TypeTXT:   "TXT",
+	TypeAAAA:  "AAAA",
+	typeOPT:   "OPT",
+}
+
+// String returns the mnemonic for t, such as "AAAA", or "TYPE<n>" for types
+// without one.
+func (t RecordType) String() string {
+	if name, ok := recordTypeNames[t]; ok {
+		return name
+	}
+	return "TYPE" + strconv.Itoa(int(t))
+}
+
+// ParseRecordType parses a record type mnemonic, case-insensitively. Only the
+// types usable with WithRecordType are accepted.
+func ParseRecordType(s string) (RecordType, error) {
+	for t, name := range recordTypeNames {
+		if t != typeOPT && strings.EqualFold(s, name) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported record type %q (want A, AAAA, CNAME, MX, NS, or TXT)", s)
+}
+
+// Header flag bits and limits used by the wire-format client.
+const (
+	flagResponse  = 1 << 15
+	flagTruncated = 1 << 9
+	flagRecursion = 1 << 8
+	flagAuthentic = 1 << 5
+
+	// ednsPayloadSize is the UDP payload size advertised in the OPT record,
+	// the value recommended by DNS Flag Day 2020.
+	ednsPayloadSize = 1232
+	// ednsDNSSECOK is the DO bit in the OPT record's TTL field.
+	ednsDNSSECOK = 1 << 15
+
+	headerLen = 12
+)
+
+var rcodeNames = map[int]string{
+	0: "NOERROR",
+	1: "FORMERR",
+	2: "SERVFAIL",
+	3: "NXDOMAIN",
+	4: "NOTIMP",
+	5: "REFUSED",
+}
+
+// rcodeName returns the mnemonic for a response code, or "RCODE<n>".
+func rcodeName(rcode int) string {
+	if name, ok := rcodeNames[rcode]; ok {
+		return name
+	}
+	return "RCODE" + strconv.Itoa(rcode)
+}
+
+var errMalformed = errors.New("malformed DNS message")
+
+// answer is one resource record from the answer section.
+type answer struct {
+	name string
+	typ  RecordType
+	ttl  uint32
+	data string // presentation form; empty for unsupported types
+}
+
+// response is the part of a DNS reply reported in dns_query_done.
+type response struct {
+	rcode         int
+	truncated     bool
+	authenticated bool
+	edns          bool // the reply carried an OPT record
+	answers       []answer
+}
+
+// buildQuery encodes a recursive query for name and qtype. With edns set an
+// OPT record advertising ednsPayloadSize and the DO bit is added.
+func buildQuery(id uint16, name string, qtype RecordType, edns bool) ([]byte, error) {
+	msg := make([]byte, headerLen, 512)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], flagRecursion)
+	binary.BigEndian.PutUint16(msg[4:], 1) // QDCOUNT
+	if edns {
+		binary.BigEndian.PutUint16(msg[10:], 1) // ARCOUNT
+	}
+
+	name = strings.TrimSuffix(name, ".")
+	if len(name) > 253 {
+		return nil, fmt.Errorf("name %q is too long", name)
+	}
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if label == "" || len(label) > 63 {
+				return nil, fmt.Errorf("invalid name %q", name)
+			}
+			msg = append(msg, byte(len(label)))
+			msg = append(msg, label...)
+		}
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(qtype))
+	msg = binary.BigEndian.AppendUint16(msg, 1) // class IN
+
+	if edns {
+		msg = append(msg, 0) // root name
+		msg = binary.BigEndian.AppendUint16(msg, uint16(typeOPT))
+		msg = binary.BigEndian.AppendUint16(msg, ednsPayloadSize)
+		msg = binary.BigEndian.AppendUint32(msg, ednsDNSSECOK)
+		msg = binary.BigEndian.AppendUint16(msg, 0) // RDLENGTH
+	}
+	return msg, nil
+}
+
+// parseResponse decodes a reply to the query with the given id.
+func parseResponse(msg []byte, id uint16) (*response, error) {
+	if len(msg) < headerLen {
+		return nil, errMalformed
+	}
+	if binary.BigEndian.Uint16(msg[0:]) != id {
+		return nil, fmt.Errorf("response ID mismatch")
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&flagResponse == 0 {
+		return nil, fmt.Errorf("message is not a response")
+	}
+	resp := &response{
+		rcode:         int(flags & 0xf),
+		truncated:     flags&flagTruncated != 0,
+		authenticated: flags&flagAuthentic != 0,
+	}
+	qd := int(binary.BigEndian.Uint16(msg[4:]))
+	an := int(binary.BigEndian.Uint16(msg[6:]))
+	ns := int(binary.BigEndian.Uint16(msg[8:]))
+	ar := int(binary.BigEndian.Uint16(msg[10:]))
+
+	off := headerLen
+	for range qd {
+		_, n, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		off = n + 4 // QTYPE, QCLASS
+	}
+
+	for i := range an + ns + ar {
+		rr, next, extRcode, err := readRecord(msg, off)
+		if err != nil {
+			// A truncated reply may end mid-record; keep what was read.
+			if resp.truncated {
+				break
+			}
+			return nil, err
+		}
+		off = next
+		switch {
+		case i < an:
+			resp.answers = append(resp.answers, rr)
+		case i >= an+ns && rr.typ == typeOPT:
+			resp.edns = true
+			resp.rcode |= extRcode << 4
+		}
+	}
+	return resp, nil
+}
+
+// readRecord decodes the resource record at off. It returns the record, the
+// offset just past it, and for OPT records the upper bits of the extended
+// response code.
+func readRecord(msg []byte, off int) (answer, int, int, error) {
+	name, off, err := readName(msg, off)
+	if err != nil {
+		return answer{}, 0, 0, err
+	}
+	if off+10 > len(msg) {
+		return answer{}, 0, 0, errMalformed
+	}
+	rr := answer{
+		name: name,
+		typ:  RecordType(binary.BigEndian.Uint16(msg[off:])),
+		ttl:  binary.BigEndian.Uint32(msg[off+4:]),
+	}
+	rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+	start := off + 10
+	end := start + rdlen
+	if end > len(msg) {
+		return answer{}, 0, 0, errMalformed
+	}
+	rdata := msg[start:end]
+
+	extRcode := 0
+	switch rr.typ {
+	case TypeA, TypeAAAA:
+		if (rr.typ == TypeA && rdlen != 4) || (rr.typ == TypeAAAA && rdlen != 16) {
+			return answer{}, 0, 0, errMalformed
+		}
+		rr.data = net.IP(rdata).String()
+	case TypeNS, TypeCNAME:
+		if rr.data, _, err = readName(msg, start); err != nil {
+			return answer{}, 0, 0, err
+		}
+	case TypeMX:
+		if rdlen < 3 {
+			return answer{}, 0, 0, errMalformed
+		}
+		host, _, err := readName(msg, start+2)
+		if err != nil {
+			return answer{}, 0, 0, err
+		}
+		rr.data = fmt.Sprintf("%d %s", binary.BigEndian.Uint16(rdata), host)
+	case TypeTXT:
+		var parts []string
+		for i := 0; i < len(rdata); {
+			n := int(rdata[i])
+			if i+1+n > len(rdata) {
+				return answer{}, 0, 0, errMalformed
+			}
+			parts = append(parts, strconv.Quote(string(rdata[i+1:i+1+n])))
+			i += 1 + n
+		}
+		rr.data = strings.Join(parts, " ")
+	case typeOPT:
+		extRcode = int(rr.ttl >> 24)
+	}
+	return rr, end, extRcode, nil
+}
+
+// readName decodes the possibly compressed domain name at off and returns it
+// in absolute form with a trailing dot, plus the offset just past it in the
+// original position.
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errMalformed
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case n&0xc0 == 0xc0:
+			if off+1 >= len(msg) || jumps > 10 {
+				return "", 0, errMalformed
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			jumps++
+		case n&0xc0 != 0:
+			return "", 0, errMalformed
+		default:
+			if off+1+n > len(msg) {
+				return "", 0, errMalformed
+			}
+			labels = append(labels, string(msg[off+1:off+1+n]))
+			off += 1 + n
+		}
+	}
+}
+
+// exchange sends one query for name and qtype to server over UDP and waits
+// for the matching reply. Replies with another ID are ignored, as stray
+// datagrams from earlier queries may still arrive.
+func exchange(ctx context.Context, server, name string, qtype RecordType, edns bool) (*response, error) {
+	id := uint16(rand.UintN(1 << 16))
+	query, err := buildQuery(id, name, qtype, edns)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if d, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(d)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			return nil, err
+		}
+		if n < 2 || binary.BigEndian.Uint16(buf) != id {
+			continue
+		}
+		return parseResponse(buf[:n], id)
+	}
+}
+
+// Answer is one resource record from the answer section of a reply, as
+// returned by [ParseReply].
+type Answer struct {
+	Name string // absolute, with a trailing dot
+	Type RecordType
+	TTL  uint32
+	Data string // presentation form; empty for types WithRecordType does not accept
+}
+
+// Reply is a DNS reply decoded by [ParseReply].
+type Reply struct {
+	Rcode     string // response code mnemonic, such as "NOERROR" or "NXDOMAIN"
+	Truncated bool
+	Answers   []Answer
+}
+
+// NewQuery encodes a recursive query for name and qtype with a random ID,
+// for callers that send it over a transport of their own, such as the UDP
+// tracer. Decode the reply with [ParseReply].
+func NewQuery(name string, qtype RecordType) ([]byte, error) {
+	return buildQuery(uint16(rand.UintN(1<<16)), name, qtype, false)
+}
+
+// ParseReply decodes msg as the reply to query, a message built by
+// [NewQuery]. It fails if msg is malformed, is not a response, or answers
+// a query with another ID.
+func ParseReply(query, msg []byte) (*Reply, error) {
+	if len(query) < 2 {
+		return nil, errMalformed
+	}
+	resp, err := parseResponse(msg, binary.BigEndian.Uint16(query))
+	if err != nil {
+		return nil, err
+	}
+	reply := &Reply{Rcode: rcodeName(resp.rcode), Truncated: resp.truncated}
+	for _, rr := range resp.answers {
+		reply.Answers = append(reply.Answers, Answer{Name: rr.name, Type: rr.typ, TTL: rr.ttl, Data: rr.data})
+	}
+	return reply, nil
+}
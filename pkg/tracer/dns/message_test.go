@@ -0,0 +1,275 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeRecord is an answer record served by startFakeServer. data is the raw
+// RDATA; a name pointer to the question (0xc00c) is used as the owner name.
+type fakeRecord struct {
+	typ  RecordType
+	ttl  uint32
+	data []byte
+}
+
+// fakeReply describes how startFakeServer answers every query.
+type fakeReply struct {
+	flags   uint16 // extra header flags, e.g. flagTruncated
+	rcode   int
+	answers []fakeRecord
+}
+
+// startFakeServer serves reply on a local UDP socket and returns its address.
+// The server echoes the question and, when the query carries an OPT record,
+// adds one to the reply. Each received query is sent on the returned channel.
+func startFakeServer(t *testing.T, reply fakeReply) (string, <-chan []byte) {
+	t.Helper()
+	return startFakeServerFunc(t, func() fakeReply { return reply })
+}
+
+// startFakeServerFunc is startFakeServer with the reply chosen by calling
+// replyFor for each query, for answers that change during a test.
+func startFakeServerFunc(t *testing.T, replyFor func() fakeReply) (string, <-chan []byte) {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	queries := make(chan []byte, 8)
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			q := append([]byte(nil), buf[:n]...)
+			select {
+			case queries <- q:
+			default:
+			}
+
+			// The question ends at the first zero byte after the header,
+			// followed by QTYPE and QCLASS.
+			qend := headerLen
+			for q[qend] != 0 {
+				qend += int(q[qend]) + 1
+			}
+			qend += 5
+			edns := binary.BigEndian.Uint16(q[10:]) > 0
+			reply := replyFor()
+
+			msg := append([]byte(nil), q[:qend]...)
+			binary.BigEndian.PutUint16(msg[2:], flagResponse|flagRecursion|reply.flags|uint16(reply.rcode))
+			binary.BigEndian.PutUint16(msg[6:], uint16(len(reply.answers)))
+			binary.BigEndian.PutUint16(msg[10:], 0)
+			for _, rr := range reply.answers {
+				msg = append(msg, 0xc0, 0x0c)
+				msg = binary.BigEndian.AppendUint16(msg, uint16(rr.typ))
+				msg = binary.BigEndian.AppendUint16(msg, 1)
+				msg = binary.BigEndian.AppendUint32(msg, rr.ttl)
+				msg = binary.BigEndian.AppendUint16(msg, uint16(len(rr.data)))
+				msg = append(msg, rr.data...)
+			}
+			if edns {
+				binary.BigEndian.PutUint16(msg[10:], 1)
+				msg = append(msg, 0, 0, byte(typeOPT), 0x04, 0xd0, 0, 0, 0, 0, 0, 0)
+			}
+			pc.WriteTo(msg, addr)
+		}
+	}()
+	return pc.LocalAddr().String(), queries
+}
+
+func TestParseRecordType(t *testing.T) {
+	for in, want := range map[string]RecordType{
+		"A": TypeA, "aaaa": TypeAAAA, "Cname": TypeCNAME, "MX": TypeMX, "ns": TypeNS, "TXT": TypeTXT,
+	} {
+		if got, err := ParseRecordType(in); err != nil || got != want {
+			t.Errorf("ParseRecordType(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "SRV", "OPT", "TYPE1"} {
+		if _, err := ParseRecordType(s); err == nil {
+			t.Errorf("ParseRecordType(%q) error = nil, want error", s)
+		}
+	}
+	if got := RecordType(99).String(); got != "TYPE99" {
+		t.Errorf("RecordType(99).String() = %q, want %q", got, "TYPE99")
+	}
+}
+
+func TestBuildQuery(t *testing.T) {
+	msg, err := buildQuery(0xbeef, "example.com.", TypeMX, true)
+	if err != nil {
+		t.Fatalf("buildQuery() error = %v", err)
+	}
+	want := []byte{
+		0xbe, 0xef, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 1,
+		7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0, 0, 15, 0, 1,
+		0, 0, 41, 0x04, 0xd0, 0, 0, 0x80, 0, 0, 0,
+	}
+	if !reflect.DeepEqual(msg, want) {
+		t.Errorf("buildQuery() =\n%v\nwant\n%v", msg, want)
+	}
+
+	for _, name := range []string{"a..b", string(make([]byte, 64)) + ".com"} {
+		if _, err := buildQuery(1, name, TypeA, false); err == nil {
+			t.Errorf("buildQuery(%q) error = nil, want error", name)
+		}
+	}
+}
+
+func TestReadName_Loop(t *testing.T) {
+	// A pointer to itself must not hang.
+	msg := append(make([]byte, headerLen), 0xc0, headerLen)
+	if _, _, err := readName(msg, headerLen); err == nil {
+		t.Error("readName(pointer loop) error = nil, want error")
+	}
+}
+
+func TestTraceDNS_RecordType(t *testing.T) {
+	server, queries := startFakeServer(t, fakeReply{answers: []fakeRecord{
+		{typ: TypeMX, ttl: 300, data: []byte{0, 10, 4, 'm', 'a', 'i', 'l', 0xc0, 0x0c}},
+		{typ: TypeTXT, ttl: 60, data: []byte{5, 'h', 'e', 'l', 'l', 'o', 2, 'h', 'i'}},
+		{typ: TypeA, ttl: 30, data: []byte{10, 0, 0, 7}},
+	}})
+
+	em := &testEmitter{}
+	err := TraceDNS(context.Background(), "example.com",
+		WithEmitter(em),
+		WithServer(server),
+		WithRecordType(TypeMX),
+		WithTimeout(2*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("TraceDNS() error = %v", err)
+	}
+
+	q := <-queries
+	if qtype := binary.BigEndian.Uint16(q[len(q)-4:]); qtype != uint16(TypeMX) {
+		t.Errorf("query type = %d, want %d", qtype, TypeMX)
+	}
+	if binary.BigEndian.Uint16(q[10:]) != 0 {
+		t.Error("query has an additional record without WithEDNS")
+	}
+
+	if len(em.events) != 2 {
+		t.Fatalf("got %d events, want 2", len(em.events))
+	}
+	if em.events[0].Data["record_type"] != "MX" {
+		t.Errorf("dns_query_start record_type = %v, want MX", em.events[0].Data["record_type"])
+	}
+	done := em.events[1].Data
+	if done["rcode"] != "NOERROR" || done["truncated"] != false {
+		t.Errorf("dns_query_done rcode = %v, truncated = %v", done["rcode"], done["truncated"])
+	}
+	if _, ok := done["edns"]; ok {
+		t.Error("dns_query_done has edns without WithEDNS")
+	}
+	wantAnswers := []map[string]any{
+		{"name": "example.com.", "type": "MX", "ttl": uint32(300), "data": "10 mail.example.com."},
+		{"name": "example.com.", "type": "TXT", "ttl": uint32(60), "data": `"hello" "hi"`},
+		{"name": "example.com.", "type": "A", "ttl": uint32(30), "data": "10.0.0.7"},
+	}
+	if !reflect.DeepEqual(done["answers"], wantAnswers) {
+		t.Errorf("answers = %v, want %v", done["answers"], wantAnswers)
+	}
+	wantAddrs := []map[string]any{{"ip": "10.0.0.7", "family": "ipv4", "private": true}}
+	if !reflect.DeepEqual(done["addrs"], wantAddrs) {
+		t.Errorf("addrs = %v, want %v", done["addrs"], wantAddrs)
+	}
+}
+
+func TestTraceDNS_EDNSTruncated(t *testing.T) {
+	server, queries := startFakeServer(t, fakeReply{flags: flagTruncated | flagAuthentic})
+
+	em := &testEmitter{}
+	err := TraceDNS(context.Background(), "big.example.com",
+		WithEmitter(em),
+		WithServer(server),
+		WithEDNS(true),
+		WithTimeout(2*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("TraceDNS() error = %v", err)
+	}
+
+	q := <-queries
+	if binary.BigEndian.Uint16(q[10:]) != 1 {
+		t.Error("query has no OPT record with WithEDNS")
+	}
+
+	done := em.events[len(em.events)-1].Data
+	for key, want := range map[string]any{
+		"record_type":        "A",
+		"truncated":          true,
+		"edns":               true,
+		"authenticated_data": true,
+	} {
+		if done[key] != want {
+			t.Errorf("dns_query_done %s = %v, want %v", key, done[key], want)
+		}
+	}
+}
+
+func TestTraceDNS_RcodeError(t *testing.T) {
+	server, _ := startFakeServer(t, fakeReply{rcode: 3})
+
+	em := &testEmitter{}
+	if err := TraceDNS(context.Background(), "missing.example.com", WithEmitter(em), WithServer(server), WithRecordType(TypeAAAA)); err != nil {
+		t.Fatalf("TraceDNS() error = %v", err)
+	}
+	done := em.events[len(em.events)-1].Data
+	if done["rcode"] != "NXDOMAIN" || done["error"] != "NXDOMAIN" {
+		t.Errorf("dns_query_done rcode = %v, error = %v, want NXDOMAIN", done["rcode"], done["error"])
+	}
+}
+
+func TestParseReply(t *testing.T) {
+	query, err := NewQuery("example.com", TypeA)
+	if err != nil {
+		t.Fatalf("NewQuery() error = %v", err)
+	}
+	if got := binary.BigEndian.Uint16(query[4:]); got != 1 {
+		t.Fatalf("QDCOUNT = %d, want 1", got)
+	}
+
+	msg := append([]byte(nil), query...)
+	binary.BigEndian.PutUint16(msg[2:], flagResponse|flagRecursion|3)
+	binary.BigEndian.PutUint16(msg[6:], 1)
+	msg = append(msg, 0xc0, 0x0c)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(TypeA))
+	msg = binary.BigEndian.AppendUint16(msg, 1)
+	msg = binary.BigEndian.AppendUint32(msg, 300)
+	msg = binary.BigEndian.AppendUint16(msg, 4)
+	msg = append(msg, 192, 0, 2, 1)
+
+	reply, err := ParseReply(query, msg)
+	if err != nil {
+		t.Fatalf("ParseReply() error = %v", err)
+	}
+	want := &Reply{
+		Rcode:   "NXDOMAIN",
+		Answers: []Answer{{Name: "example.com.", Type: TypeA, TTL: 300, Data: "192.0.2.1"}},
+	}
+	if !reflect.DeepEqual(reply, want) {
+		t.Errorf("ParseReply() = %+v, want %+v", reply, want)
+	}
+
+	other := append([]byte(nil), msg...)
+	other[0] ^= 0xff
+	if _, err := ParseReply(query, other); err == nil {
+		t.Error("ParseReply() with another ID error = nil, want mismatch")
+	}
+	if _, err := ParseReply(query, query); err == nil {
+		t.Error("ParseReply() of the query itself error = nil, want not a response")
+	}
+}
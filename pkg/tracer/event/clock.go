@@ -0,0 +1,33 @@
+package event
+
+import "time"
+
+// Clock tells the time a tracer uses to measure the durations it reports,
+// such as duration_ms and the trace_summary phases. Tracers read the system
+// clock unless a test passes its own with the tracer's WithClock option, so
+// that durations can be asserted exactly. Timeouts and deadlines always use
+// the system clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the [Clock] that reads [time.Now].
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Now reads c, or the system clock when c is nil, so a tracer can keep the
+// Clock its WithClock option was given as is.
+func Now(c Clock) time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
+
+// Since returns the time elapsed since t as read by [Now] on c.
+func Since(c Clock, t time.Time) time.Duration {
+	return Now(c).Sub(t)
+}
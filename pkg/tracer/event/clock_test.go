@@ -0,0 +1,24 @@
+package event
+
+import (
+	"testing"
+	"time"
+)
+
+type fixedClock struct{ t time.Time }
+
+func (c fixedClock) Now() time.Time { return c.t }
+
+func TestNowAndSince(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := fixedClock{t: at}
+	if got := Now(c); !got.Equal(at) {
+		t.Errorf("Now(clock) = %v, want %v", got, at)
+	}
+	if got := Since(c, at.Add(-time.Second)); got != time.Second {
+		t.Errorf("Since(clock) = %v, want 1s", got)
+	}
+	if got := Now(nil); time.Since(got) > time.Minute {
+		t.Errorf("Now(nil) = %v, want the system time", got)
+	}
+}
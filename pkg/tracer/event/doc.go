@@ -1,2 +1,11 @@
 // Package event defines the core event types and Emitter interface for network tracing.
+//
+// [Event] and [Emitter] are the contract between the tracers and whatever
+// consumes their output. The common events, such as DNS lookups and TCP
+// connects, have constructors ([DNSStart], [DNSDone], [TCPConnectDone], ...)
+// so that tracers and external producers agree on their types and data keys:
+//
+//	ev := event.DNSDone(traceID, "93.184.216.34", 12*time.Millisecond)
+//	ev.Data["resolver"] = "1.1.1.1:53" // tracer-specific extras
+//	em.Emit(ev)
 package event
@@ -3,32 +3,89 @@ package event
 import "time"
 
 // Event represents a single trace event in the lifecycle of a network operation.
+// Its JSON form is one NDJSON line of trace output; the field names and
+// their meaning are stable, and new data keys may be added over time.
 type Event struct {
-	// Type identifies the event category (e.g., "dns_start", "tcp_connect", "http_request").
+	// Type identifies the event category (e.g., "dns_start",
+	// "tcp_connect_done", "http_response_done"). The types shared by several
+	// tracers are the Type* constants.
 	Type string `json:"type"`
 
 	// Timestamp is the Unix timestamp (nanoseconds) when the event occurred.
 	Timestamp int64 `json:"timestamp"`
 
+	// EmittedAt is Timestamp as an RFC 3339 string in UTC, for readers such as
+	// jq pipelines that would otherwise convert nanoseconds by hand.
+	EmittedAt string `json:"emitted_at,omitempty"`
+
 	// TraceID correlates events from the same trace session.
 	TraceID string `json:"trace_id"`
 
 	// Data contains event-specific fields (e.g., resolved IP, status code, latency).
 	Data map[string]interface{} `json:"data"`
+
+	// Error describes why the step the event reports failed, such as a
+	// refused connect or a failed TLS handshake, and Failed is set with it,
+	// so consumers can detect failures without probing Data. Both are
+	// omitted for events that did not fail. The same message is also in
+	// Data["error"], which is kept for compatibility and will be removed in
+	// a later release.
+	Error  string `json:"error,omitempty"`
+	Failed bool   `json:"failed,omitempty"`
+
+	// Labels are static key/value pairs, such as "env": "prod", that the
+	// caller attached to every event of a trace with a tracer's WithLabels
+	// option, for grouping and filtering. Omitted when empty.
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
-// NewEvent creates an Event with the current timestamp.
+// NewEvent creates an Event with the current timestamp, set both as Unix
+// nanoseconds and as an RFC 3339 string. If data has a non-empty "error"
+// string the event is marked failed with it, as by [Event.Fail].
 func NewEvent(typ, traceID string, data map[string]interface{}) Event {
-	return Event{
+	now := time.Now()
+	ev := Event{
 		Type:      typ,
-		Timestamp: time.Now().UnixNano(),
+		Timestamp: now.UnixNano(),
+		EmittedAt: now.UTC().Format(time.RFC3339Nano),
 		TraceID:   traceID,
 		Data:      data,
 	}
+	if msg, ok := data["error"].(string); ok && msg != "" {
+		ev.Error, ev.Failed = msg, true
+	}
+	return ev
+}
+
+// Fail marks e as failed with msg, setting Error and Failed and, for
+// compatibility, Data["error"]. Use it for an event built before the
+// failure was known; NewEvent already marks events whose data carries an
+// error.
+func (e *Event) Fail(msg string) {
+	e.Error, e.Failed = msg, true
+	if e.Data == nil {
+		e.Data = make(map[string]interface{})
+	}
+	e.Data["error"] = msg
+}
+
+// Time returns when the event occurred. It uses Timestamp when set and falls
+// back to parsing EmittedAt, so events from producers that only fill one of
+// the two still have a time. It returns the zero time when neither is usable.
+func (e Event) Time() time.Time {
+	if e.Timestamp != 0 {
+		return time.Unix(0, e.Timestamp)
+	}
+	if t, err := time.Parse(time.RFC3339Nano, e.EmittedAt); err == nil {
+		return t
+	}
+	return time.Time{}
 }
 
 // Emitter consumes trace events. Implementations may write to stdout,
-// buffer for HTML generation, or integrate with external systems.
+// buffer for HTML generation, or integrate with external systems. Every
+// tracer accepts an Emitter through its WithEmitter option, so custom
+// implementations plug in anywhere the built-in formatters do.
 type Emitter interface {
 	// Emit processes a single event. Returns an error if emission fails.
 	Emit(event Event) error
@@ -36,3 +93,31 @@ type Emitter interface {
 	// Close finalizes any buffered output. Not all emitters require cleanup.
 	Close() error
 }
+
+// TraceSummary is the type of the event each tracer emits last, describing
+// the whole trace in a protocol-independent shape. Its data holds "phases"
+// (phase name → duration_ms), "total_ms", and "ok".
+const TraceSummary = "trace_summary"
+
+// Phases accumulates per-phase durations in milliseconds for a
+// TraceSummary event. Phases that occur more than once, such as DNS lookups
+// across redirects, are summed.
+type Phases map[string]int64
+
+// Add adds ms to the named phase.
+func (p Phases) Add(name string, ms int64) {
+	p[name] += ms
+}
+
+// SummaryData builds the data of a TraceSummary event.
+func SummaryData(phases Phases, total time.Duration, ok bool) map[string]interface{} {
+	ph := make(map[string]interface{}, len(phases))
+	for name, ms := range phases {
+		ph[name] = ms
+	}
+	return map[string]interface{}{
+		"phases":   ph,
+		"total_ms": total.Milliseconds(),
+		"ok":       ok,
+	}
+}
@@ -3,6 +3,7 @@ package event
 import (
 	"encoding/json"
 	"testing"
+	"time"
 )
 
 func TestNewEvent(t *testing.T) {
@@ -26,6 +27,49 @@ func TestNewEvent(t *testing.T) {
 	}
 }
 
+func TestNewEvent_Error(t *testing.T) {
+	ok := NewEvent("tcp_connect_done", "t1", map[string]interface{}{"duration_ms": int64(1)})
+	if ok.Failed || ok.Error != "" {
+		t.Errorf("event without error: Failed = %v, Error = %q, want unset", ok.Failed, ok.Error)
+	}
+	b, err := json.Marshal(ok)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if _, found := raw["error"]; found {
+		t.Errorf("JSON of an event without error has an error field: %s", b)
+	}
+	if _, found := raw["failed"]; found {
+		t.Errorf("JSON of an event without error has a failed field: %s", b)
+	}
+
+	failed := NewEvent("tcp_connect_done", "t1", map[string]interface{}{"error": "connection refused"})
+	if !failed.Failed || failed.Error != "connection refused" {
+		t.Errorf("event with data error: Failed = %v, Error = %q, want true and the message", failed.Failed, failed.Error)
+	}
+	b, err = json.Marshal(failed)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded Event
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !decoded.Failed || decoded.Error != "connection refused" {
+		t.Errorf("decoded Failed = %v, Error = %q, want true and the message", decoded.Failed, decoded.Error)
+	}
+
+	late := NewEvent("tls_handshake_done", "t1", nil)
+	late.Fail("handshake failure")
+	if !late.Failed || late.Error != "handshake failure" || late.Data["error"] != "handshake failure" {
+		t.Errorf("after Fail: Failed = %v, Error = %q, Data = %v", late.Failed, late.Error, late.Data)
+	}
+}
+
 func TestEvent_JSON(t *testing.T) {
 	data := map[string]interface{}{
 		"host":   "example.com",
@@ -72,3 +116,69 @@ func TestEvent_JSON(t *testing.T) {
 		t.Errorf("decoded Data[secure] = %v, want true", decoded.Data["secure"])
 	}
 }
+
+func TestSummaryData(t *testing.T) {
+	phases := Phases{}
+	phases.Add("dns", 5)
+	phases.Add("dns", 3)
+	phases.Add("connect", 10)
+
+	data := SummaryData(phases, 42*time.Millisecond, true)
+	if data["total_ms"] != int64(42) {
+		t.Errorf("total_ms = %v, want 42", data["total_ms"])
+	}
+	if data["ok"] != true {
+		t.Errorf("ok = %v, want true", data["ok"])
+	}
+	got, ok := data["phases"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("phases = %T, want map[string]interface{}", data["phases"])
+	}
+	if got["dns"] != int64(8) || got["connect"] != int64(10) {
+		t.Errorf("phases = %v, want dns=8 connect=10", got)
+	}
+}
+
+func TestNewEvent_EmittedAt(t *testing.T) {
+	ev := NewEvent("test_type", "trace123", nil)
+
+	emitted, err := time.Parse(time.RFC3339Nano, ev.EmittedAt)
+	if err != nil {
+		t.Fatalf("EmittedAt = %q, want RFC 3339: %v", ev.EmittedAt, err)
+	}
+	if emitted.UnixNano() != ev.Timestamp {
+		t.Errorf("EmittedAt = %s (%d), want it to equal Timestamp %d", ev.EmittedAt, emitted.UnixNano(), ev.Timestamp)
+	}
+
+	out, err := json.Marshal(ev)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var fields map[string]interface{}
+	json.Unmarshal(out, &fields)
+	for _, key := range []string{"timestamp", "emitted_at"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("JSON missing %q: %s", key, out)
+		}
+	}
+}
+
+func TestEvent_Time(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+	tests := []struct {
+		name string
+		ev   Event
+		want time.Time
+	}{
+		{"timestamp", Event{Timestamp: ts.UnixNano()}, ts},
+		{"emitted_at only", Event{EmittedAt: ts.Format(time.RFC3339Nano)}, ts},
+		{"neither", Event{EmittedAt: "yesterday"}, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ev.Time(); !got.Equal(tt.want) {
+				t.Errorf("Time() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
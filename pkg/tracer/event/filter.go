@@ -0,0 +1,43 @@
+package event
+
+import "sync"
+
+// FilterEmitter forwards to another Emitter only the events a predicate
+// keeps, such as the events of one type or time window. Create one with
+// [NewFilterEmitter]. It is safe for concurrent use if the predicate is.
+type FilterEmitter struct {
+	next Emitter
+	keep func(Event) bool
+
+	mu      sync.Mutex
+	dropped int
+}
+
+// NewFilterEmitter returns a [FilterEmitter] that forwards each event for
+// which keep returns true to next and drops the rest. Closing the
+// FilterEmitter does not close next; its owner does.
+func NewFilterEmitter(next Emitter, keep func(Event) bool) *FilterEmitter {
+	return &FilterEmitter{next: next, keep: keep}
+}
+
+// Emit forwards ev to the next emitter and returns its error if keep
+// returns true for it. Otherwise ev is dropped and Emit returns nil.
+func (f *FilterEmitter) Emit(ev Event) error {
+	if !f.keep(ev) {
+		f.mu.Lock()
+		f.dropped++
+		f.mu.Unlock()
+		return nil
+	}
+	return f.next.Emit(ev)
+}
+
+// Close is a no-op; the next emitter is owned by the caller.
+func (f *FilterEmitter) Close() error { return nil }
+
+// Dropped returns the number of events dropped so far.
+func (f *FilterEmitter) Dropped() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dropped
+}
@@ -0,0 +1,32 @@
+package event
+
+import "testing"
+
+func TestFilterEmitter(t *testing.T) {
+	next := NewSliceEmitter(nil)
+	f := NewFilterEmitter(next, func(ev Event) bool { return ev.Type != "noise" })
+
+	for _, typ := range []string{"a", "noise", "b", "noise"} {
+		if err := f.Emit(NewEvent(typ, "t1", nil)); err != nil {
+			t.Fatalf("Emit(%s) error = %v", typ, err)
+		}
+	}
+	events := next.Events()
+	if len(events) != 2 || events[0].Type != "a" || events[1].Type != "b" {
+		t.Errorf("forwarded %v, want a, b", events)
+	}
+	if f.Dropped() != 2 {
+		t.Errorf("Dropped() = %d, want 2", f.Dropped())
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestFilterEmitter_ForwardsError(t *testing.T) {
+	next := &failingEmitter{}
+	f := NewFilterEmitter(next, func(Event) bool { return true })
+	if err := f.Emit(NewEvent("a", "t1", nil)); err == nil {
+		t.Error("Emit() error = nil, want the next emitter's error")
+	}
+}
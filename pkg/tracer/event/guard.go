@@ -0,0 +1,81 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// GuardedEmitter forwards events to another Emitter until an Emit fails,
+// then drops every later event and cancels the trace's context. It lets a
+// tracer stop promptly when its output is gone (a closed file, a broken pipe)
+// instead of tracing on into a dead writer.
+//
+// Create one with [Guard]. It is safe for concurrent use.
+type GuardedEmitter struct {
+	em     Emitter
+	cancel context.CancelCauseFunc
+
+	mu  sync.Mutex
+	err error
+}
+
+// Guard wraps em in a [GuardedEmitter] and returns a context derived from ctx
+// that is cancelled when the first Emit fails. A nil em is allowed; events are
+// then discarded.
+//
+// Example:
+//
+//	ctx, guard := event.Guard(ctx, cfg.emitter)
+//	cfg.emitter = guard
+//	return guard.Check(trace(ctx, cfg))
+func Guard(ctx context.Context, em Emitter) (context.Context, *GuardedEmitter) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return ctx, &GuardedEmitter{em: em, cancel: cancel}
+}
+
+// Emit forwards ev to the wrapped emitter. The first error is recorded,
+// cancels the guarded context, and is returned wrapped as "emit failed: ...";
+// later calls return that same error without forwarding.
+func (g *GuardedEmitter) Emit(ev Event) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.err != nil {
+		return g.err
+	}
+	if g.em == nil {
+		return nil
+	}
+	if err := g.em.Emit(ev); err != nil {
+		g.err = fmt.Errorf("emit failed: %w", err)
+		g.cancel(g.err)
+		return g.err
+	}
+	return nil
+}
+
+// Close releases the guarded context. It does not close the wrapped
+// emitter, which stays owned by the caller.
+func (g *GuardedEmitter) Close() error {
+	g.cancel(nil)
+	return nil
+}
+
+// Err returns the recorded emit failure, or nil if every Emit succeeded.
+func (g *GuardedEmitter) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
+
+// Check returns the recorded emit failure if there is one, otherwise err.
+// Tracers pass their own result through Check so that an emit failure, which
+// usually surfaces as a cancelled context, is what the caller sees. Check
+// also releases the guarded context.
+func (g *GuardedEmitter) Check(err error) error {
+	g.Close()
+	if emitErr := g.Err(); emitErr != nil {
+		return emitErr
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// failingEmitter fails every Emit after the first ok calls.
+type failingEmitter struct {
+	ok    int
+	calls int
+}
+
+func (e *failingEmitter) Emit(Event) error {
+	e.calls++
+	if e.calls > e.ok {
+		return errors.New("broken pipe")
+	}
+	return nil
+}
+
+func (e *failingEmitter) Close() error { return nil }
+
+func TestGuard(t *testing.T) {
+	inner := &failingEmitter{ok: 1}
+	ctx, guard := Guard(context.Background(), inner)
+
+	if err := guard.Emit(NewEvent("a", "t", nil)); err != nil {
+		t.Fatalf("first Emit() error = %v", err)
+	}
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("ctx.Err() = %v after successful emit", err)
+	}
+
+	err := guard.Emit(NewEvent("b", "t", nil))
+	if err == nil || err.Error() != "emit failed: broken pipe" {
+		t.Fatalf("second Emit() error = %v, want emit failed: broken pipe", err)
+	}
+	if !errors.Is(context.Cause(ctx), err) {
+		t.Errorf("context.Cause() = %v, want %v", context.Cause(ctx), err)
+	}
+
+	guard.Emit(NewEvent("c", "t", nil))
+	if inner.calls != 2 {
+		t.Errorf("inner emitter called %d times, want 2 (events after a failure are dropped)", inner.calls)
+	}
+
+	if got := guard.Check(context.Canceled); got != err {
+		t.Errorf("Check() = %v, want the emit error %v", got, err)
+	}
+}
+
+func TestGuard_NoFailure(t *testing.T) {
+	_, guard := Guard(context.Background(), nil)
+	if err := guard.Emit(NewEvent("a", "t", nil)); err != nil {
+		t.Fatalf("Emit() with nil emitter error = %v", err)
+	}
+	want := errors.New("trace error")
+	if got := guard.Check(want); got != want {
+		t.Errorf("Check() = %v, want %v", got, want)
+	}
+	if got := guard.Check(nil); got != nil {
+		t.Errorf("Check(nil) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,30 @@
+package event
+
+import "encoding/hex"
+
+// DefaultHexdumpBytes is how many bytes of a payload [AddHexdump] dumps
+// when given a limit of zero or less.
+const DefaultHexdumpBytes = 256
+
+// AddHexdump sets data["hexdump"] to a hex and ASCII dump of the first
+// limit bytes of b, in the format of [hex.Dump], and data["hexdump_truncated"]
+// to true when b was longer. limit <= 0 means [DefaultHexdumpBytes]. Nothing
+// is added for an empty b. The TCP and UDP tracers' WithHexdump options use
+// it on their send and receive events.
+//
+// Example value for "hi\n":
+//
+//	00000000  68 69 0a                                          |hi.|
+func AddHexdump(data map[string]interface{}, b []byte, limit int) {
+	if len(b) == 0 {
+		return
+	}
+	if limit <= 0 {
+		limit = DefaultHexdumpBytes
+	}
+	if len(b) > limit {
+		b = b[:limit]
+		data["hexdump_truncated"] = true
+	}
+	data["hexdump"] = hex.Dump(b)
+}
@@ -0,0 +1,46 @@
+package event
+
+import "testing"
+
+func TestAddHexdump(t *testing.T) {
+	tests := []struct {
+		name          string
+		b             []byte
+		limit         int
+		wantDump      string
+		wantTruncated bool
+	}{
+		{
+			name:     "short payload",
+			b:        []byte("hi\n"),
+			wantDump: "00000000  68 69 0a                                          |hi.|\n",
+		},
+		{
+			name:          "truncated at limit",
+			b:             []byte{0x00, 0x01, 0xff, 0x41},
+			limit:         3,
+			wantDump:      "00000000  00 01 ff                                          |...|\n",
+			wantTruncated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string]interface{}{}
+			AddHexdump(data, tt.b, tt.limit)
+			if got := data["hexdump"]; got != tt.wantDump {
+				t.Errorf("hexdump = %q, want %q", got, tt.wantDump)
+			}
+			if _, got := data["hexdump_truncated"]; got != tt.wantTruncated {
+				t.Errorf("hexdump_truncated set = %v, want %v", got, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestAddHexdump_Empty(t *testing.T) {
+	data := map[string]interface{}{}
+	AddHexdump(data, nil, 0)
+	if len(data) != 0 {
+		t.Errorf("data = %v, want nothing added for an empty payload", data)
+	}
+}
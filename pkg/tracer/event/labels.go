@@ -0,0 +1,40 @@
+package event
+
+import "maps"
+
+// LabelEmitter sets the same labels, such as {"env": "prod", "region": "eu"},
+// on every event before forwarding it to another Emitter, so monitoring
+// pipelines can group and filter traces without parsing the target. The
+// tracers' WithLabels options use it.
+//
+// Create one with [NewLabelEmitter]. It is safe for concurrent use if the
+// next emitter is.
+type LabelEmitter struct {
+	next   Emitter
+	labels map[string]string
+}
+
+// NewLabelEmitter returns a [LabelEmitter] that adds labels to each event's
+// Labels and forwards it to next. labels is copied. Labels an event already
+// carries win over labels of the same key. next may be nil to discard events.
+// Closing the LabelEmitter does not close next; its owner does.
+func NewLabelEmitter(next Emitter, labels map[string]string) *LabelEmitter {
+	return &LabelEmitter{next: next, labels: maps.Clone(labels)}
+}
+
+// Emit adds the labels to ev and forwards it to the next emitter, returning
+// its error. The event's own Labels map is not modified.
+func (l *LabelEmitter) Emit(ev Event) error {
+	if l.next == nil {
+		return nil
+	}
+	if len(l.labels) > 0 {
+		merged := maps.Clone(l.labels)
+		maps.Copy(merged, ev.Labels)
+		ev.Labels = merged
+	}
+	return l.next.Emit(ev)
+}
+
+// Close is a no-op; the next emitter is owned by the caller.
+func (l *LabelEmitter) Close() error { return nil }
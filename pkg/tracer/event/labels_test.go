@@ -0,0 +1,47 @@
+package event
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestLabelEmitter(t *testing.T) {
+	labels := map[string]string{"env": "prod", "region": "eu"}
+	rec := NewSliceEmitter(nil)
+	em := NewLabelEmitter(rec, labels)
+	labels["env"] = "changed" // the emitter keeps its own copy
+
+	own := map[string]string{"region": "us"}
+	for _, ev := range []Event{
+		NewEvent("dns_start", "t1", nil),
+		{Type: "dns_done", TraceID: "t1", Labels: own},
+	} {
+		if err := em.Emit(ev); err != nil {
+			t.Fatalf("Emit() error = %v", err)
+		}
+	}
+
+	got := rec.Events()
+	if len(got) != 2 {
+		t.Fatalf("forwarded %d events, want 2", len(got))
+	}
+	if want := map[string]string{"env": "prod", "region": "eu"}; !maps.Equal(got[0].Labels, want) {
+		t.Errorf("labels = %v, want %v", got[0].Labels, want)
+	}
+	if want := map[string]string{"env": "prod", "region": "us"}; !maps.Equal(got[1].Labels, want) {
+		t.Errorf("labels = %v, want %v with the event's own label winning", got[1].Labels, want)
+	}
+	if len(own) != 1 {
+		t.Errorf("event's own labels modified: %v", own)
+	}
+}
+
+func TestLabelEmitter_NilNext(t *testing.T) {
+	em := NewLabelEmitter(nil, map[string]string{"env": "prod"})
+	if err := em.Emit(NewEvent("dns_start", "t1", nil)); err != nil {
+		t.Errorf("Emit() error = %v, want nil", err)
+	}
+	if err := em.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
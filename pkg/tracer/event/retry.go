@@ -0,0 +1,109 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+// TraceRetry is the type of the event a tracer emits between attempts of a
+// trace retried by its WithTraceRetry option. Its data holds "attempt" (the
+// attempt that failed, from 1), "max_attempts", "reason" (see
+// [TransientReason]), "error", and "wait_ms".
+const TraceRetry = "trace_retry"
+
+const (
+	// retryBaseDelay is the wait after the first failed attempt; it doubles
+	// after every further one.
+	retryBaseDelay = 250 * time.Millisecond
+
+	// retryMaxDelay caps the wait between attempts.
+	retryMaxDelay = 5 * time.Second
+)
+
+// TransientReason classifies err as a network failure that may succeed when
+// tried again, returning a short reason such as "connection_refused", or ""
+// when err is permanent:
+//
+//   - "connection_refused", "connection_reset": the peer refused or reset
+//     the connection
+//   - "dns_temporary": a lookup failed with a temporary error or timed out;
+//     a name that does not exist is permanent
+//   - "timeout": an i/o or dial timeout ([net.Error] Timeout)
+//   - "temporary": any other error reporting Temporary() true
+//
+// Everything else, such as an invalid address or URL, is permanent.
+func TransientReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return "connection_refused"
+	}
+	if errors.Is(err, syscall.ECONNRESET) {
+		return "connection_reset"
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsNotFound || !(dnsErr.IsTemporary || dnsErr.IsTimeout) {
+			return ""
+		}
+		return "dns_temporary"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+	// net.Error.Temporary is deprecated, but some errors still report it.
+	var tempErr interface{ Temporary() bool }
+	if errors.As(err, &tempErr) && tempErr.Temporary() {
+		return "temporary"
+	}
+	return ""
+}
+
+// RetryTransient runs trace up to attempts times, stopping at the first
+// success, a permanent error (see [TransientReason]), or when ctx is done.
+// Before each retry it emits a [TraceRetry] event to em and waits, starting
+// at 250ms and doubling up to 5s. It returns the last attempt's error, or
+// ctx.Err() if ctx ends during a wait. Attempts below 2 run trace once.
+//
+// Tracers use it to implement their WithTraceRetry option:
+//
+//	err := event.RetryTransient(ctx, cfg.emitter, traceID, cfg.traceRetry, func() error {
+//	    return traceAddr(ctx, cfg, traceID, addr)
+//	})
+func RetryTransient(ctx context.Context, em Emitter, traceID string, attempts int, trace func() error) error {
+	wait := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := trace()
+		if err == nil || attempt >= attempts || ctx.Err() != nil {
+			return err
+		}
+		reason := TransientReason(err)
+		if reason == "" {
+			return err
+		}
+
+		if em != nil {
+			em.Emit(NewEvent(TraceRetry, traceID, map[string]interface{}{
+				"attempt":      attempt,
+				"max_attempts": attempts,
+				"reason":       reason,
+				"error":        err.Error(),
+				"wait_ms":      wait.Milliseconds(),
+			}))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		wait = min(wait*2, retryMaxDelay)
+	}
+}
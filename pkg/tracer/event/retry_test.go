@@ -0,0 +1,113 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTransientReason(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, "connection_refused"},
+		{"reset", fmt.Errorf("read: %w", syscall.ECONNRESET), "connection_reset"},
+		{"dns temporary", &net.DNSError{Err: "server misbehaving", IsTemporary: true}, "dns_temporary"},
+		{"dns timeout", &net.DNSError{Err: "i/o timeout", IsTimeout: true}, "dns_temporary"},
+		{"dns not found", &net.DNSError{Err: "no such host", IsNotFound: true}, ""},
+		{"i/o timeout", &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, "timeout"},
+		{"cancelled", context.Canceled, ""},
+		{"invalid address", errors.New(`invalid address "nope"`), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TransientReason(tt.err); got != tt.want {
+				t.Errorf("TransientReason(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryTransient(t *testing.T) {
+	refused := fmt.Errorf("dial: %w", syscall.ECONNREFUSED)
+
+	t.Run("retries until success", func(t *testing.T) {
+		sink := NewSliceEmitter(nil)
+		calls := 0
+		err := RetryTransient(context.Background(), sink, "t", 3, func() error {
+			calls++
+			if calls < 2 {
+				return refused
+			}
+			return nil
+		})
+		if err != nil || calls != 2 {
+			t.Fatalf("RetryTransient() = %v after %d calls, want nil after 2", err, calls)
+		}
+		events := sink.Events()
+		if len(events) != 1 || events[0].Type != TraceRetry {
+			t.Fatalf("events = %v, want one %s", events, TraceRetry)
+		}
+		data := events[0].Data
+		if data["attempt"] != 1 || data["max_attempts"] != 3 || data["reason"] != "connection_refused" {
+			t.Errorf("trace_retry data = %v", data)
+		}
+	})
+
+	t.Run("stops after attempts", func(t *testing.T) {
+		calls := 0
+		err := RetryTransient(context.Background(), nil, "t", 2, func() error {
+			calls++
+			return refused
+		})
+		if !errors.Is(err, syscall.ECONNREFUSED) || calls != 2 {
+			t.Errorf("RetryTransient() = %v after %d calls, want refused after 2", err, calls)
+		}
+	})
+
+	t.Run("permanent error fails at once", func(t *testing.T) {
+		calls := 0
+		permanent := errors.New("invalid URL")
+		err := RetryTransient(context.Background(), nil, "t", 5, func() error {
+			calls++
+			return permanent
+		})
+		if err != permanent || calls != 1 {
+			t.Errorf("RetryTransient() = %v after %d calls, want the permanent error after 1", err, calls)
+		}
+	})
+
+	t.Run("cancelled context stops retries", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := RetryTransient(ctx, nil, "t", 5, func() error {
+			calls++
+			cancel()
+			return refused
+		})
+		if !errors.Is(err, syscall.ECONNREFUSED) || calls != 1 {
+			t.Errorf("RetryTransient() = %v after %d calls, want refused after 1", err, calls)
+		}
+	})
+
+	t.Run("cancelled during wait", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		calls := 0
+		err := RetryTransient(ctx, nil, "t", 5, func() error {
+			calls++
+			return refused
+		})
+		if !errors.Is(err, context.DeadlineExceeded) || calls != 1 {
+			t.Errorf("RetryTransient() = %v after %d calls, want context.DeadlineExceeded after 1", err, calls)
+		}
+	})
+}
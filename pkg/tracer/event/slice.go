@@ -0,0 +1,48 @@
+package event
+
+import "sync"
+
+// SliceEmitter records every event it receives in memory and optionally
+// forwards it to another Emitter, so a Go program can stream a trace and
+// inspect its events afterwards without parsing NDJSON. The tracers'
+// Collect functions use it.
+//
+// Create one with [NewSliceEmitter]. It is safe for concurrent use.
+type SliceEmitter struct {
+	next Emitter
+
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewSliceEmitter returns a [SliceEmitter] that forwards each event to next
+// after recording it. next may be nil to only record. Closing the
+// SliceEmitter does not close next; its owner does.
+func NewSliceEmitter(next Emitter) *SliceEmitter {
+	return &SliceEmitter{next: next}
+}
+
+// Emit records ev, then forwards it to the next emitter, if any, and returns
+// its error. The event is recorded even if forwarding fails.
+func (s *SliceEmitter) Emit(ev Event) error {
+	s.mu.Lock()
+	s.events = append(s.events, ev)
+	s.mu.Unlock()
+	if s.next == nil {
+		return nil
+	}
+	return s.next.Emit(ev)
+}
+
+// Close is a no-op; the next emitter is owned by the caller.
+func (s *SliceEmitter) Close() error { return nil }
+
+// Events returns a copy of the events recorded so far, in the order they
+// were emitted.
+func (s *SliceEmitter) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Event, len(s.events))
+	copy(out, s.events)
+	return out
+}
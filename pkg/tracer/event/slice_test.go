@@ -0,0 +1,54 @@
+package event
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSliceEmitter_Records(t *testing.T) {
+	s := NewSliceEmitter(nil)
+	s.Emit(NewEvent("a", "t1", nil))
+	s.Emit(NewEvent("b", "t1", nil))
+
+	events := s.Events()
+	if len(events) != 2 || events[0].Type != "a" || events[1].Type != "b" {
+		t.Fatalf("Events() = %v, want a, b", events)
+	}
+	events[0].Type = "changed"
+	if s.Events()[0].Type != "a" {
+		t.Error("Events() returned the internal slice")
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestSliceEmitter_Forwards(t *testing.T) {
+	next := &failingEmitter{}
+	s := NewSliceEmitter(next)
+	if err := s.Emit(NewEvent("a", "t1", nil)); err == nil {
+		t.Error("Emit() error = nil, want the next emitter's error")
+	}
+	if next.calls != 1 {
+		t.Errorf("next emitter got %d events, want 1", next.calls)
+	}
+	if len(s.Events()) != 1 {
+		t.Errorf("Events() has %d events, want 1 even though forwarding failed", len(s.Events()))
+	}
+}
+
+func TestSliceEmitter_Concurrent(t *testing.T) {
+	s := NewSliceEmitter(nil)
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Emit(NewEvent("a", "t1", nil))
+		}()
+	}
+	wg.Wait()
+	if n := len(s.Events()); n != 50 {
+		t.Errorf("Events() has %d events, want 50", n)
+	}
+}
@@ -0,0 +1,34 @@
+package event
+
+// TapEmitter calls a function with every event before forwarding it to
+// another Emitter, so a caller can watch a trace, such as counting events or
+// keeping the last one of a type, without writing an Emitter of its own.
+//
+// Create one with [NewTapEmitter]. It is safe for concurrent use if the tap
+// function and the next emitter are.
+type TapEmitter struct {
+	next Emitter
+	tap  func(*Event)
+}
+
+// NewTapEmitter returns a [TapEmitter] that passes each event to tap and
+// then forwards it to next. tap receives a pointer, so it may also annotate
+// the event, such as by setting a Data field, before next sees it. next may
+// be nil to discard events after tap has seen them. Closing the TapEmitter
+// does not close next; its owner does.
+func NewTapEmitter(next Emitter, tap func(*Event)) *TapEmitter {
+	return &TapEmitter{next: next, tap: tap}
+}
+
+// Emit passes ev to the tap function, then forwards it to the next emitter
+// and returns its error.
+func (t *TapEmitter) Emit(ev Event) error {
+	t.tap(&ev)
+	if t.next == nil {
+		return nil
+	}
+	return t.next.Emit(ev)
+}
+
+// Close is a no-op; the next emitter is owned by the caller.
+func (t *TapEmitter) Close() error { return nil }
@@ -0,0 +1,52 @@
+package event
+
+import "testing"
+
+func TestTapEmitter(t *testing.T) {
+	next := NewSliceEmitter(nil)
+	var seen []string
+	em := NewTapEmitter(next, func(ev *Event) {
+		seen = append(seen, ev.Type)
+		if ev.Data == nil {
+			ev.Data = make(map[string]interface{})
+		}
+		ev.Data["tapped"] = true
+	})
+
+	for _, typ := range []string{"a", "b"} {
+		if err := em.Emit(NewEvent(typ, "t1", nil)); err != nil {
+			t.Fatalf("Emit(%s) error = %v", typ, err)
+		}
+	}
+	if len(seen) != 2 || seen[0] != "a" || seen[1] != "b" {
+		t.Errorf("tap saw %v, want a, b", seen)
+	}
+	events := next.Events()
+	if len(events) != 2 {
+		t.Fatalf("forwarded %d events, want 2", len(events))
+	}
+	if events[0].Data["tapped"] != true {
+		t.Errorf("forwarded event data = %v, want the tap's annotation", events[0].Data)
+	}
+	if err := em.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestTapEmitter_NilNext(t *testing.T) {
+	called := false
+	em := NewTapEmitter(nil, func(*Event) { called = true })
+	if err := em.Emit(NewEvent("a", "t1", nil)); err != nil {
+		t.Errorf("Emit() error = %v, want nil", err)
+	}
+	if !called {
+		t.Error("tap not called with a nil next emitter")
+	}
+}
+
+func TestTapEmitter_ForwardsError(t *testing.T) {
+	em := NewTapEmitter(&failingEmitter{}, func(*Event) {})
+	if err := em.Emit(NewEvent("a", "t1", nil)); err == nil {
+		t.Error("Emit() error = nil, want the next emitter's error")
+	}
+}
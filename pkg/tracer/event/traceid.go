@@ -0,0 +1,24 @@
+package event
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrEmptyTraceID is returned by the tracers when a caller-supplied trace ID
+// is empty.
+var ErrEmptyTraceID = errors.New("trace ID must not be empty")
+
+// NewTraceID returns a random 16-character hex trace ID. If crypto/rand fails
+// it falls back to an ID derived from the current time.
+func NewTraceID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		// Fallback to timestamp-based ID if crypto/rand fails.
+		return hex.EncodeToString(fmt.Appendf(nil, "%08x", time.Now().UnixNano()))
+	}
+	return hex.EncodeToString(b)
+}
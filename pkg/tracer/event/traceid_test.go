@@ -0,0 +1,19 @@
+package event
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestNewTraceID(t *testing.T) {
+	a, b := NewTraceID(), NewTraceID()
+	if len(a) != 16 {
+		t.Errorf("NewTraceID() length = %d, want 16", len(a))
+	}
+	if _, err := hex.DecodeString(a); err != nil {
+		t.Errorf("NewTraceID() = %q, want hex: %v", a, err)
+	}
+	if a == b {
+		t.Errorf("NewTraceID() returned %q twice", a)
+	}
+}
@@ -0,0 +1,107 @@
+package event
+
+import "time"
+
+// Event types shared by the tracers. Every tracer that resolves a name,
+// opens a TCP connection, or performs a TLS handshake reports it with these
+// types, so consumers can match on them without caring which tracer ran.
+const (
+	TypeDNSStart          = "dns_start"
+	TypeDNSDone           = "dns_done"
+	TypeDNSSkipped        = "dns_skipped"
+	TypeTCPConnectStart   = "tcp_connect_start"
+	TypeTCPConnectDone    = "tcp_connect_done"
+	TypeTLSHandshakeStart = "tls_handshake_start"
+	TypeTLSHandshakeDone  = "tls_handshake_done"
+)
+
+// The constructors below build the common events with their documented
+// data keys. Durations are reported as "duration_ms", whole milliseconds.
+// Tracers add tracer-specific keys, such as "resolver" or "proxy", to the
+// returned event's Data before emitting it.
+
+// DNSStart returns a [TypeDNSStart] event for a lookup of host.
+// Data: "host".
+func DNSStart(traceID, host string) Event {
+	return NewEvent(TypeDNSStart, traceID, map[string]interface{}{
+		"host": host,
+	})
+}
+
+// DNSDone returns a [TypeDNSDone] event for a lookup that resolved to ip,
+// the first address returned, after d.
+// Data: "ip", "duration_ms".
+func DNSDone(traceID, ip string, d time.Duration) Event {
+	return NewEvent(TypeDNSDone, traceID, map[string]interface{}{
+		"ip":          ip,
+		"duration_ms": d.Milliseconds(),
+	})
+}
+
+// DNSFailed returns a [TypeDNSDone] event for a lookup that failed with err
+// after d.
+// Data: "error", "duration_ms".
+func DNSFailed(traceID string, err error, d time.Duration) Event {
+	return NewEvent(TypeDNSDone, traceID, map[string]interface{}{
+		"error":       err.Error(),
+		"duration_ms": d.Milliseconds(),
+	})
+}
+
+// DNSSkipped returns a [TypeDNSSkipped] event for a host that needed no
+// lookup, emitted in place of dns_start and dns_done. reason says why, such
+// as "ip_literal" for an address like "192.0.2.1" or "2001:db8::1".
+// Data: "host", "reason".
+func DNSSkipped(traceID, host, reason string) Event {
+	return NewEvent(TypeDNSSkipped, traceID, map[string]interface{}{
+		"host":   host,
+		"reason": reason,
+	})
+}
+
+// TCPConnectStart returns a [TypeTCPConnectStart] event for a connection
+// to addr.
+// Data: "addr".
+func TCPConnectStart(traceID, addr string) Event {
+	return NewEvent(TypeTCPConnectStart, traceID, map[string]interface{}{
+		"addr": addr,
+	})
+}
+
+// TCPConnectDone returns a [TypeTCPConnectDone] event for a connection
+// established from localAddr to remoteAddr after d.
+// Data: "local_addr", "remote_addr", "duration_ms".
+func TCPConnectDone(traceID, localAddr, remoteAddr string, d time.Duration) Event {
+	return NewEvent(TypeTCPConnectDone, traceID, map[string]interface{}{
+		"local_addr":  localAddr,
+		"remote_addr": remoteAddr,
+		"duration_ms": d.Milliseconds(),
+	})
+}
+
+// TCPConnectFailed returns a [TypeTCPConnectDone] event for a connection
+// attempt that failed with err after d.
+// Data: "error", "duration_ms".
+func TCPConnectFailed(traceID string, err error, d time.Duration) Event {
+	return NewEvent(TypeTCPConnectDone, traceID, map[string]interface{}{
+		"error":       err.Error(),
+		"duration_ms": d.Milliseconds(),
+	})
+}
+
+// TLSHandshakeStart returns a [TypeTLSHandshakeStart] event. It carries no
+// data.
+func TLSHandshakeStart(traceID string) Event {
+	return NewEvent(TypeTLSHandshakeStart, traceID, map[string]interface{}{})
+}
+
+// TLSHandshakeDone returns a [TypeTLSHandshakeDone] event for a handshake
+// that negotiated version, such as "TLS 1.3", after d. A failed handshake
+// adds "error" to the event's Data.
+// Data: "duration_ms", "version".
+func TLSHandshakeDone(traceID, version string, d time.Duration) Event {
+	return NewEvent(TypeTLSHandshakeDone, traceID, map[string]interface{}{
+		"duration_ms": d.Milliseconds(),
+		"version":     version,
+	})
+}
@@ -0,0 +1,84 @@
+package event
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConstructors(t *testing.T) {
+	errRefused := errors.New("connection refused")
+	tests := []struct {
+		name     string
+		ev       Event
+		wantType string
+		wantData map[string]interface{}
+	}{
+		{
+			name:     "DNSStart",
+			ev:       DNSStart("t1", "example.com"),
+			wantType: "dns_start",
+			wantData: map[string]interface{}{"host": "example.com"},
+		},
+		{
+			name:     "DNSDone",
+			ev:       DNSDone("t1", "93.184.216.34", 12*time.Millisecond),
+			wantType: "dns_done",
+			wantData: map[string]interface{}{"ip": "93.184.216.34", "duration_ms": int64(12)},
+		},
+		{
+			name:     "DNSFailed",
+			ev:       DNSFailed("t1", errors.New("no such host"), 3*time.Millisecond),
+			wantType: "dns_done",
+			wantData: map[string]interface{}{"error": "no such host", "duration_ms": int64(3)},
+		},
+		{
+			name:     "TCPConnectStart",
+			ev:       TCPConnectStart("t1", "example.com:443"),
+			wantType: "tcp_connect_start",
+			wantData: map[string]interface{}{"addr": "example.com:443"},
+		},
+		{
+			name:     "TCPConnectDone",
+			ev:       TCPConnectDone("t1", "10.0.0.2:50000", "93.184.216.34:443", 1500*time.Microsecond),
+			wantType: "tcp_connect_done",
+			wantData: map[string]interface{}{"local_addr": "10.0.0.2:50000", "remote_addr": "93.184.216.34:443", "duration_ms": int64(1)},
+		},
+		{
+			name:     "TCPConnectFailed",
+			ev:       TCPConnectFailed("t1", errRefused, 0),
+			wantType: "tcp_connect_done",
+			wantData: map[string]interface{}{"error": "connection refused", "duration_ms": int64(0)},
+		},
+		{
+			name:     "TLSHandshakeStart",
+			ev:       TLSHandshakeStart("t1"),
+			wantType: "tls_handshake_start",
+			wantData: map[string]interface{}{},
+		},
+		{
+			name:     "TLSHandshakeDone",
+			ev:       TLSHandshakeDone("t1", "TLS 1.3", 40*time.Millisecond),
+			wantType: "tls_handshake_done",
+			wantData: map[string]interface{}{"duration_ms": int64(40), "version": "TLS 1.3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.ev.Type != tt.wantType {
+				t.Errorf("Type = %q, want %q", tt.ev.Type, tt.wantType)
+			}
+			if tt.ev.TraceID != "t1" {
+				t.Errorf("TraceID = %q, want %q", tt.ev.TraceID, "t1")
+			}
+			if tt.ev.Time().IsZero() {
+				t.Error("event has no time")
+			}
+			if !reflect.DeepEqual(tt.ev.Data, tt.wantData) {
+				t.Errorf("Data = %#v, want %#v", tt.ev.Data, tt.wantData)
+			}
+		})
+	}
+}
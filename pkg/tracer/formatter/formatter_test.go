@@ -3,8 +3,16 @@ package formatter
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/mrlm-net/cure/pkg/tracer/event"
 )
@@ -51,6 +59,95 @@ func TestNDJSONEmitter(t *testing.T) {
 	}
 }
 
+// flushRecorder is a writer with a Flush method that records when it was
+// flushed. Writes are held until the next flush, like a bufio.Writer.
+type flushRecorder struct {
+	mu      sync.Mutex
+	pending bytes.Buffer
+	out     bytes.Buffer
+	flushes []time.Time
+}
+
+func (w *flushRecorder) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.pending.Write(p)
+}
+
+func (w *flushRecorder) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending.WriteTo(&w.out)
+	w.flushes = append(w.flushes, time.Now())
+	return nil
+}
+
+// state returns the flushed output and the flush times so far.
+func (w *flushRecorder) state() (string, []time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.out.String(), slices.Clone(w.flushes)
+}
+
+func TestNDJSONEmitter_FlushPerEvent(t *testing.T) {
+	w := &flushRecorder{}
+	em := NewNDJSONEmitter(w)
+	for range 2 {
+		if err := em.Emit(event.NewEvent("dns_start", "trace1", nil)); err != nil {
+			t.Fatalf("Emit() error = %v", err)
+		}
+	}
+	out, flushes := w.state()
+	if len(flushes) != 2 || strings.Count(out, "\n") != 2 {
+		t.Errorf("after 2 events: %d flushes, output %q; want 2 flushes and both lines", len(flushes), out)
+	}
+	if err := em.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+func TestNDJSONEmitter_FlushInterval(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	w := &flushRecorder{}
+	em := NewNDJSONEmitter(w, WithFlushInterval(interval))
+
+	start := time.Now()
+	if err := em.Emit(event.NewEvent("dns_start", "trace1", nil)); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if out, _ := w.state(); out != "" {
+		t.Errorf("output %q visible before the first flush interval", out)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		out, flushes := w.state()
+		if out != "" {
+			if elapsed := flushes[0].Sub(start); elapsed < interval/2 {
+				t.Errorf("first flush after %s, want about %s", elapsed, interval)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("event not flushed within 2s")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := em.Emit(event.NewEvent("dns_done", "trace1", nil)); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if err := em.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if out, _ := w.state(); strings.Count(out, "\n") != 2 {
+		t.Errorf("output after Close = %q, want both events", out)
+	}
+	if err := em.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+}
+
 func TestHTMLEmitter_Buffer(t *testing.T) {
 	var buf bytes.Buffer
 	em := NewHTMLEmitter(&buf)
@@ -113,3 +210,429 @@ func TestHTMLEmitter_Output(t *testing.T) {
 		t.Error("HTML output missing ip data")
 	}
 }
+
+func TestHTMLEmitter_RawJSON(t *testing.T) {
+	var buf bytes.Buffer
+	em := NewHTMLEmitter(&buf)
+
+	ev := event.NewEvent("tls_handshake_done", "trace1", map[string]interface{}{
+		"subject": "<script>alert(1)</script>",
+		"chain":   []interface{}{map[string]interface{}{"issuer": "R3"}},
+	})
+	if err := em.Emit(ev); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if err := em.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	html := buf.String()
+
+	if !strings.Contains(html, "<details class=\"event-raw\">") {
+		t.Error("HTML output missing raw JSON <details> element")
+	}
+	if !strings.Contains(html, "data-item") {
+		t.Error("HTML output missing formatted data summary")
+	}
+	if !strings.Contains(html, "&#34;issuer&#34;: &#34;R3&#34;") {
+		t.Error("HTML output missing pretty-printed nested data")
+	}
+	if strings.Contains(html, "<script>") {
+		t.Error("HTML output contains unescaped event data")
+	}
+}
+
+// failingEmitter returns err from every call and counts invocations.
+type failingEmitter struct {
+	err    error
+	emits  int
+	closes int
+}
+
+func (f *failingEmitter) Emit(event.Event) error { f.emits++; return f.err }
+func (f *failingEmitter) Close() error           { f.closes++; return f.err }
+
+func TestMultiEmitter(t *testing.T) {
+	var jsonBuf, htmlBuf bytes.Buffer
+	em := MultiEmitter(NewNDJSONEmitter(&jsonBuf), NewHTMLEmitter(&htmlBuf))
+
+	ev := event.NewEvent("dns_start", "trace1", map[string]interface{}{"host": "example.com"})
+	if err := em.Emit(ev); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if err := em.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if !strings.Contains(jsonBuf.String(), `"type":"dns_start"`) {
+		t.Errorf("NDJSON output missing event, got %q", jsonBuf.String())
+	}
+	if !strings.Contains(htmlBuf.String(), "Network Trace Report") {
+		t.Error("HTML output missing report")
+	}
+}
+
+func TestMultiEmitter_Errors(t *testing.T) {
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	a := &failingEmitter{err: errA}
+	b := &failingEmitter{err: errB}
+	em := MultiEmitter(a, b)
+
+	err := em.Emit(event.NewEvent("dns_start", "trace1", nil))
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Emit() error = %v, want both wrapped errors", err)
+	}
+	err = em.Close()
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Close() error = %v, want both wrapped errors", err)
+	}
+	if a.emits != 1 || b.emits != 1 || a.closes != 1 || b.closes != 1 {
+		t.Errorf("calls = a(%d,%d) b(%d,%d), want every emitter called once", a.emits, a.closes, b.emits, b.closes)
+	}
+}
+
+func TestSummaryEmitter(t *testing.T) {
+	var out, summary bytes.Buffer
+	em := NewSummaryEmitter(NewNDJSONEmitter(&out), &summary)
+
+	types := []string{"dns_start", "dns_done", "tcp_connect_start", "tcp_connect_done", "tcp_connect_start", "tcp_connect_done", event.TraceSummary}
+	start := time.Unix(0, 0)
+	for i, typ := range types {
+		ev := event.NewEvent(typ, "trace1", nil)
+		ev.Timestamp = start.Add(time.Duration(i) * 50 * time.Millisecond).UnixNano()
+		if err := em.Emit(ev); err != nil {
+			t.Fatalf("Emit() error = %v", err)
+		}
+	}
+
+	want := map[string]int{"dns_start": 1, "dns_done": 1, "tcp_connect_start": 2, "tcp_connect_done": 2, event.TraceSummary: 1}
+	if got := em.Counts(); !maps.Equal(got, want) {
+		t.Errorf("Counts() = %v, want %v", got, want)
+	}
+	if summary.Len() != 0 {
+		t.Errorf("summary written before Close: %q", summary.String())
+	}
+	if err := em.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := em.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+
+	if n := strings.Count(out.String(), "\n"); n != len(types) {
+		t.Errorf("forwarded %d events, want %d", n, len(types))
+	}
+	wantLine := "7 events in 300ms: dns_done=1 dns_start=1 tcp_connect_done=2 tcp_connect_start=2 trace_summary=1\n"
+	if summary.String() != wantLine {
+		t.Errorf("summary = %q, want %q", summary.String(), wantLine)
+	}
+}
+
+func TestSummaryEmitter_Errors(t *testing.T) {
+	errClose := errors.New("close failed")
+	inner := &failingEmitter{err: errClose}
+	var summary bytes.Buffer
+	em := NewSummaryEmitter(inner, &summary)
+
+	if err := em.Emit(event.NewEvent("dns_start", "trace1", nil)); !errors.Is(err, errClose) {
+		t.Errorf("Emit() error = %v, want the wrapped emitter's error", err)
+	}
+	if err := em.Close(); !errors.Is(err, errClose) {
+		t.Errorf("Close() error = %v, want the wrapped emitter's error", err)
+	}
+	if inner.closes != 1 {
+		t.Errorf("wrapped emitter closed %d times, want 1", inner.closes)
+	}
+	if want := "1 event in 0s: dns_start=1\n"; summary.String() != want {
+		t.Errorf("summary = %q, want %q", summary.String(), want)
+	}
+}
+
+func TestHTMLEmitter_EmittedAtOnly(t *testing.T) {
+	var buf bytes.Buffer
+	em := NewHTMLEmitter(&buf)
+
+	// Events from other producers may carry only the RFC 3339 time.
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	em.Emit(event.Event{Type: "dns_start", EmittedAt: ts.Format(time.RFC3339Nano)})
+	if err := em.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if want := ts.Format("15:04:05.000"); !strings.Contains(buf.String(), want) {
+		t.Errorf("HTML output missing event time %q", want)
+	}
+}
+
+func TestHTMLEmitter_Thresholds(t *testing.T) {
+	events := []event.Event{
+		event.NewEvent("dns_done", "t", map[string]interface{}{"duration_ms": int64(50)}),
+		event.NewEvent("tcp_connect_done", "t", map[string]interface{}{"duration_ms": int64(150)}),
+		event.NewEvent("ttfb", "t", map[string]interface{}{"duration_ms": float64(900)}), // as decoded by replay
+		event.NewEvent("udp_send", "t", map[string]interface{}{"duration_ms": int64(5000)}),
+	}
+	thresholds := map[string]time.Duration{
+		"dns_done":         100 * time.Millisecond,
+		"tcp_connect_done": 100 * time.Millisecond,
+		"ttfb":             100 * time.Millisecond,
+	}
+	edited := DefaultThresholds()
+	edited["dns_done"] = 20 * time.Millisecond
+
+	tests := []struct {
+		name string
+		opts []HTMLOption
+		want []string
+		not  []string
+	}{
+		{
+			name: "custom thresholds",
+			opts: []HTMLOption{WithThresholds(thresholds)},
+			want: []string{
+				`class="event tcp_connect_done phase-warn"`,
+				`class="event ttfb phase-slow"`,
+				`<span class="phase-badge" title="warn">150 ms</span>`,
+				`<span class="phase-badge" title="slow">900 ms</span>`,
+				`class="event dns_done"`,
+				`class="event udp_send"`,
+			},
+		},
+		{
+			name: "no thresholds",
+			opts: []HTMLOption{WithThresholds(nil)},
+			want: []string{`class="event tcp_connect_done"`, `class="event ttfb"`},
+			not:  []string{`phase-warn"`, `phase-slow"`, `<span class="phase-badge"`},
+		},
+		{
+			name: "edited defaults",
+			opts: []HTMLOption{WithThresholds(edited)},
+			want: []string{`class="event dns_done phase-warn"`, `class="event tcp_connect_done phase-warn"`},
+		},
+		{
+			// Runs after "edited defaults" to show the edit did not leak.
+			name: "defaults",
+			want: []string{`class="event tcp_connect_done phase-warn"`, `class="event ttfb phase-warn"`, `class="event dns_done"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			em := NewHTMLEmitter(&buf, tt.opts...)
+			for _, ev := range events {
+				em.Emit(ev)
+			}
+			if err := em.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			html := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("HTML output missing %q", want)
+				}
+			}
+			for _, not := range tt.not {
+				if strings.Contains(html, not) {
+					t.Errorf("HTML output contains %q", not)
+				}
+			}
+		})
+	}
+}
+
+func TestHTMLEmitter_MaxEvents(t *testing.T) {
+	var buf bytes.Buffer
+	em := NewHTMLEmitter(&buf, WithMaxEvents(3))
+	for i := 1; i <= 8; i++ {
+		em.Emit(event.NewEvent("tcp_probe", "t", map[string]interface{}{"seq": i}))
+	}
+	if err := em.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	html := buf.String()
+	if !strings.Contains(html, "5 older events omitted") {
+		t.Error("HTML output missing omission notice")
+	}
+	if n := strings.Count(html, `class="event tcp_probe"`); n != 3 {
+		t.Errorf("HTML output has %d events, want 3", n)
+	}
+	// The newest events survive, oldest first.
+	i6, i7, i8 := strings.Index(html, "seq:</strong> 6<"), strings.Index(html, "seq:</strong> 7<"), strings.Index(html, "seq:</strong> 8<")
+	if i6 < 0 || !(i6 < i7 && i7 < i8) {
+		t.Errorf("want seq 6, 7, 8 in order; indexes %d, %d, %d", i6, i7, i8)
+	}
+	if strings.Contains(html, "seq:</strong> 5<") {
+		t.Error("HTML output contains dropped event seq 5")
+	}
+}
+
+func TestHTMLEmitter_MaxEventsUnbounded(t *testing.T) {
+	for _, n := range []int{0, -1, 10} {
+		var buf bytes.Buffer
+		em := NewHTMLEmitter(&buf, WithMaxEvents(n))
+		for i := 0; i < 5; i++ {
+			em.Emit(event.NewEvent("dns_start", "t", nil))
+		}
+		em.Close()
+		if strings.Contains(buf.String(), "older events omitted") {
+			t.Errorf("WithMaxEvents(%d): unexpected omission notice", n)
+		}
+		if got := strings.Count(buf.String(), `class="event dns_start"`); got != 5 {
+			t.Errorf("WithMaxEvents(%d): %d events, want 5", n, got)
+		}
+	}
+}
+
+func TestHTMLEmitter_LargeReportOrder(t *testing.T) {
+	// Force several prepareViews workers even on a single-CPU machine.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	const n = 4*viewChunk + 7
+	var buf bytes.Buffer
+	em := NewHTMLEmitter(&buf)
+	for i := 0; i < n; i++ {
+		em.Emit(event.NewEvent("tcp_probe", "t", map[string]interface{}{"seq": i}))
+	}
+	if err := em.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	html := buf.String()
+	last := -1
+	for i := 0; i < n; i++ {
+		idx := strings.Index(html, fmt.Sprintf("seq:</strong> %d<", i))
+		if idx <= last {
+			t.Fatalf("event seq %d at index %d, want after %d", i, idx, last)
+		}
+		last = idx
+	}
+}
+
+func BenchmarkHTMLEmitter_Close(b *testing.B) {
+	events := make([]event.Event, 10000)
+	for i := range events {
+		events[i] = event.NewEvent("http_response_done", "bench", map[string]interface{}{
+			"status_code":    200,
+			"duration_ms":    int64(i % 1500),
+			"content_length": 1024,
+			"proto":          "HTTP/2.0",
+			"url":            "https://example.com/api/items",
+			"headers":        map[string]string{"Content-Type": "application/json"},
+			"remote_addr":    "93.184.216.34:443",
+			"tls_version":    "TLS 1.3",
+		})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		em := NewHTMLEmitter(io.Discard)
+		for _, ev := range events {
+			em.Emit(ev)
+		}
+		if err := em.Close(); err != nil {
+			b.Fatalf("Close() error = %v", err)
+		}
+	}
+}
+
+func TestJSONArrayEmitter(t *testing.T) {
+	var buf bytes.Buffer
+	em := NewJSONArrayEmitter(&buf)
+
+	for _, ev := range []event.Event{
+		event.NewEvent("dns_start", "trace1", map[string]interface{}{"host": "example.com"}),
+		event.NewEvent("dns_done", "trace1", map[string]interface{}{"ip": "93.184.216.34"}),
+		event.NewEvent("trace_summary", "trace1", map[string]interface{}{"ok": true}),
+	} {
+		if err := em.Emit(ev); err != nil {
+			t.Fatalf("Emit(%s) error = %v", ev.Type, err)
+		}
+	}
+	if err := em.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := em.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+
+	var events []event.Event
+	if err := json.Unmarshal(buf.Bytes(), &events); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v\n%s", err, buf.String())
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	for i, want := range []string{"dns_start", "dns_done", "trace_summary"} {
+		if events[i].Type != want || events[i].TraceID != "trace1" {
+			t.Errorf("events[%d] = %s/%s, want %s/trace1", i, events[i].Type, events[i].TraceID, want)
+		}
+	}
+	if err := em.Emit(event.NewEvent("late", "trace1", nil)); err == nil {
+		t.Error("Emit() after Close error = nil, want error")
+	}
+}
+
+func TestJSONArrayEmitter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	em := NewJSONArrayEmitter(&buf)
+	if err := em.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	var events []event.Event
+	if err := json.Unmarshal(buf.Bytes(), &events); err != nil {
+		t.Fatalf("json.Unmarshal(%q) error = %v", buf.String(), err)
+	}
+	if events == nil || len(events) != 0 {
+		t.Errorf("events = %#v, want empty non-nil slice", events)
+	}
+}
+
+func TestTemplateEmitter(t *testing.T) {
+	var out, errOut bytes.Buffer
+	em, err := NewTemplateEmitter(&out, `{{.Type}} {{.Data.duration_ms}}ms`, &errOut)
+	if err != nil {
+		t.Fatalf("NewTemplateEmitter() error = %v", err)
+	}
+	events := []event.Event{
+		event.NewEvent("dns_done", "t1", map[string]interface{}{"duration_ms": 12}),
+		event.NewEvent("tcp_connect_start", "t1", nil),
+		event.NewEvent("tcp_connect_done", "t1", map[string]interface{}{"duration_ms": 30}),
+		event.NewEvent("tls_handshake_start", "t1", map[string]interface{}{}),
+	}
+	for _, ev := range events {
+		if err := em.Emit(ev); err != nil {
+			t.Fatalf("Emit(%s) error = %v", ev.Type, err)
+		}
+	}
+	if err := em.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if want := "dns_done 12ms\ntcp_connect_done 30ms\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if n := strings.Count(errOut.String(), "\n"); n != 1 {
+		t.Errorf("reported %d errors, want 1: %q", n, errOut.String())
+	}
+	if !strings.Contains(errOut.String(), "tcp_connect_start") {
+		t.Errorf("error report = %q, want the first failing event type", errOut.String())
+	}
+}
+
+func TestTemplateEmitter_Index(t *testing.T) {
+	var out bytes.Buffer
+	em, err := NewTemplateEmitter(&out, "{{.TraceID}}\t{{.Type}}\t{{index .Data \"status\"}}\n", io.Discard)
+	if err != nil {
+		t.Fatalf("NewTemplateEmitter() error = %v", err)
+	}
+	em.Emit(event.NewEvent("http_response_done", "t1", map[string]interface{}{"status": 200}))
+	em.Emit(event.NewEvent("dns_start", "t1", nil))
+	if want := "t1\thttp_response_done\t200\nt1\tdns_start\t<no value>\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestNewTemplateEmitter_Invalid(t *testing.T) {
+	if _, err := NewTemplateEmitter(io.Discard, "{{.Type", io.Discard); err == nil {
+		t.Error("NewTemplateEmitter() with an unclosed action error = nil, want error")
+	}
+}
@@ -1,10 +1,15 @@
 package formatter
 
 import (
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"io"
-	"sort"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/mrlm-net/cure/pkg/tracer/event"
@@ -12,56 +17,238 @@ import (
 
 // HTMLEmitter buffers events and generates an HTML report on Close.
 type HTMLEmitter struct {
-	events []event.Event
-	w      io.Writer
+	events     []event.Event
+	w          io.Writer
+	thresholds map[string]time.Duration
+
+	// maxEvents caps events; once full, events is a ring whose oldest entry
+	// is at head. omitted counts the events dropped to make room.
+	maxEvents int
+	head      int
+	omitted   int
+}
+
+// HTMLOption is a functional option for NewHTMLEmitter.
+type HTMLOption func(*HTMLEmitter)
+
+// DefaultThresholds returns the per-event-type durations above which the
+// HTML report highlights an event's duration_ms. They suit a typical
+// internet round trip; pass [WithThresholds] to tune them for a LAN or a
+// slow link. Each call returns a new map, so a caller may edit it, such as
+// to change one threshold, without affecting other reports.
+func DefaultThresholds() map[string]time.Duration {
+	return map[string]time.Duration{
+		"dns_done":           100 * time.Millisecond,
+		"dns_query_done":     100 * time.Millisecond,
+		"tcp_connect_done":   100 * time.Millisecond,
+		"proxy_connect":      100 * time.Millisecond,
+		"tls_handshake_done": 200 * time.Millisecond,
+		"ttfb":               500 * time.Millisecond,
+		"http_response_done": 1 * time.Second,
+	}
 }
 
+// slowFactor is the multiple of a threshold at which a highlighted duration
+// turns from "warn" (yellow) to "slow" (red).
+const slowFactor = 5
+
 // NewHTMLEmitter creates an emitter that buffers events for HTML output.
-func NewHTMLEmitter(w io.Writer) *HTMLEmitter {
-	return &HTMLEmitter{
-		events: make([]event.Event, 0),
-		w:      w,
+// Durations are highlighted using [DefaultThresholds] unless
+// [WithThresholds] is given.
+func NewHTMLEmitter(w io.Writer, opts ...HTMLOption) *HTMLEmitter {
+	e := &HTMLEmitter{
+		events:     make([]event.Event, 0),
+		w:          w,
+		thresholds: DefaultThresholds(),
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
+	return e
 }
 
-// Emit buffers an event.
+// WithThresholds sets, per event type, the duration above which the event's
+// duration_ms is highlighted: yellow past the threshold and red past five
+// times it. Event types missing from m are never highlighted, and a nil or
+// empty map turns highlighting off, giving the plain report.
+//
+// Example:
+//
+//	formatter.NewHTMLEmitter(f, formatter.WithThresholds(map[string]time.Duration{
+//	    "dns_done": 20 * time.Millisecond, // yellow > 20ms, red > 100ms
+//	}))
+func WithThresholds(m map[string]time.Duration) HTMLOption {
+	return func(e *HTMLEmitter) {
+		e.thresholds = m
+	}
+}
+
+// WithMaxEvents caps the report at the n most recent events, so a long
+// repeat or batch trace cannot grow the buffer without bound. Older events
+// are dropped as new ones arrive, and the report opens with a
+// "N older events omitted" notice. n <= 0 means no limit, which is the
+// default.
+func WithMaxEvents(n int) HTMLOption {
+	return func(e *HTMLEmitter) {
+		e.maxEvents = max(n, 0)
+	}
+}
+
+// phaseLevel returns "warn" or "slow" when ev's duration_ms exceeds its
+// threshold, and "" otherwise.
+func (e *HTMLEmitter) phaseLevel(ev event.Event) string {
+	limit, ok := e.thresholds[ev.Type]
+	if !ok || limit <= 0 {
+		return ""
+	}
+	ms, ok := durationMS(ev.Data["duration_ms"])
+	if !ok {
+		return ""
+	}
+	d := time.Duration(ms * float64(time.Millisecond))
+	switch {
+	case d > slowFactor*limit:
+		return "slow"
+	case d > limit:
+		return "warn"
+	}
+	return ""
+}
+
+// durationMS converts a duration_ms value to float64. Live events carry an
+// integer; events decoded from NDJSON, as in replay, carry a float64.
+func durationMS(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Emit buffers an event. With [WithMaxEvents], the oldest buffered event is
+// dropped once the cap is reached.
 func (e *HTMLEmitter) Emit(ev event.Event) error {
+	if e.maxEvents > 0 && len(e.events) == e.maxEvents {
+		e.events[e.head] = ev
+		e.head = (e.head + 1) % e.maxEvents
+		e.omitted++
+		return nil
+	}
 	e.events = append(e.events, ev)
 	return nil
 }
 
+// buffered returns the buffered events, oldest first.
+func (e *HTMLEmitter) buffered() []event.Event {
+	if e.head == 0 {
+		return e.events
+	}
+	return append(append(make([]event.Event, 0, len(e.events)), e.events[e.head:]...), e.events[:e.head]...)
+}
+
+// formatData renders data as one "key: value" line per entry, sorted by key.
+// It runs once per event, so it writes into a single builder rather than
+// concatenating strings.
+func formatData(data map[string]interface{}) template.HTML {
+	if len(data) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, k := range slices.Sorted(maps.Keys(data)) {
+		b.WriteString(`<div class="data-item"><strong>`)
+		template.HTMLEscape(&b, []byte(k))
+		b.WriteString(":</strong> ")
+		template.HTMLEscape(&b, []byte(fmt.Sprint(data[k])))
+		b.WriteString("</div>")
+	}
+	return template.HTML(b.String())
+}
+
+// formatJSON returns data as indented JSON. The result is a plain string so
+// html/template escapes it like any other text.
+func formatJSON(data map[string]interface{}) string {
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("error encoding data: %v", err)
+	}
+	return string(b)
+}
+
+// eventView is an event together with the report fields derived from it,
+// computed before the template runs.
+type eventView struct {
+	event.Event
+	Level    string        // "", "warn", or "slow"; see phaseLevel
+	Clock    string        // wall-clock time as 15:04:05.000, or ""
+	DataHTML template.HTML // formatData output
+	JSON     string        // formatJSON output
+}
+
+// viewChunk is the minimum number of events each prepareViews worker
+// handles; smaller reports are prepared on the calling goroutine.
+const viewChunk = 512
+
+// view derives the report fields for ev.
+func (e *HTMLEmitter) view(ev event.Event) eventView {
+	v := eventView{Event: ev, Level: e.phaseLevel(ev)}
+	if t := ev.Time(); !t.IsZero() {
+		v.Clock = t.Format("15:04:05.000")
+	}
+	if len(ev.Data) > 0 {
+		v.DataHTML = formatData(ev.Data)
+		v.JSON = formatJSON(ev.Data)
+	}
+	return v
+}
+
+// prepareViews derives the report fields for every event. Each event is
+// independent, so large captures are split across up to GOMAXPROCS workers.
+func (e *HTMLEmitter) prepareViews(events []event.Event) []eventView {
+	views := make([]eventView, len(events))
+	workers := min(runtime.GOMAXPROCS(0), len(events)/viewChunk)
+	if workers <= 1 {
+		for i, ev := range events {
+			views[i] = e.view(ev)
+		}
+		return views
+	}
+
+	chunk := (len(events) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(events); start += chunk {
+		end := min(start+chunk, len(events))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				views[i] = e.view(events[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return views
+}
+
 // Close generates the HTML report and writes it to the configured writer.
 func (e *HTMLEmitter) Close() error {
-	tmpl, err := template.New("report").Funcs(template.FuncMap{
-		"formatTime": func(ts int64) string {
-			return time.Unix(0, ts).Format("15:04:05.000")
-		},
-		"formatData": func(data map[string]interface{}) template.HTML {
-			if len(data) == 0 {
-				return ""
-			}
-			result := ""
-			keys := make([]string, 0, len(data))
-			for k := range data {
-				keys = append(keys, k)
-			}
-			sort.Strings(keys)
-			for _, k := range keys {
-				result += fmt.Sprintf("<div class=\"data-item\"><strong>%s:</strong> %s</div>",
-					template.HTMLEscapeString(k),
-					template.HTMLEscapeString(fmt.Sprintf("%v", data[k])))
-			}
-			return template.HTML(result)
-		},
-	}).Parse(htmlTemplate)
+	tmpl, err := template.New("report").Parse(htmlTemplate)
 	if err != nil {
 		return err
 	}
 
 	data := struct {
-		Events []event.Event
+		Events  []eventView
+		Omitted int
 	}{
-		Events: e.events,
+		Events:  e.prepareViews(e.buffered()),
+		Omitted: e.omitted,
 	}
 
 	return tmpl.Execute(e.w, data)
@@ -116,26 +303,67 @@ const htmlTemplate = `<!DOCTYPE html>
             font-family: monospace;
             font-size: 0.9em;
         }
+        .event-raw {
+            margin-top: 8px;
+        }
+        .event-raw summary {
+            cursor: pointer;
+            color: #666;
+            font-size: 0.85em;
+        }
+        .event-raw pre {
+            margin: 5px 0 0;
+            padding: 10px;
+            background: #f9f9f9;
+            border-radius: 3px;
+            font-size: 0.85em;
+            overflow-x: auto;
+        }
         .dns_start, .dns_done { border-left-color: #28a745; }
         .tcp_connect_start, .tcp_connect_done { border-left-color: #17a2b8; }
         .tls_handshake_start, .tls_handshake_done { border-left-color: #ffc107; }
         .http_request_start, .http_response_done { border-left-color: #dc3545; }
         .udp_send, .udp_receive { border-left-color: #6f42c1; }
+        .phase-badge {
+            margin-left: 10px;
+            padding: 1px 6px;
+            border-radius: 3px;
+            font-family: monospace;
+            font-size: 0.85em;
+        }
+        .event.phase-warn { background: #fff8e1; }
+        .event.phase-warn .phase-badge { background: #ffc107; color: #333; }
+        .event.phase-slow { background: #fdecea; }
+        .event.phase-slow .phase-badge { background: #dc3545; color: white; }
+        .omitted {
+            padding: 10px 15px;
+            color: #666;
+            font-style: italic;
+        }
     </style>
 </head>
 <body>
     <h1>Network Trace Report</h1>
+    {{if .Omitted}}
+    <div class="omitted">{{.Omitted}} older events omitted</div>
+    {{end}}
     {{range .Events}}
-    <div class="event {{.Type}}">
+    {{$level := .Level}}
+    <div class="event {{.Type}}{{with $level}} phase-{{.}}{{end}}">
         <div>
-            <span class="event-type">{{.Type}}</span>
-            <span class="event-time">{{formatTime .Timestamp}}</span>
+            <span class="event-type">{{.Type}}</span>{{if $level}}
+            <span class="phase-badge" title="{{$level}}">{{.Data.duration_ms}} ms</span>{{end}}
+            <span class="event-time" title="{{.EmittedAt}}">{{.Clock}}</span>
             <span class="event-trace-id">trace: {{.TraceID}}</span>
         </div>
         {{if .Data}}
         <div class="event-data">
-            {{formatData .Data}}
+            {{.DataHTML}}
         </div>
+        <details class="event-raw">
+            <summary>Raw JSON</summary>
+            <pre>{{.JSON}}</pre>
+        </details>
         {{end}}
     </div>
     {{end}}
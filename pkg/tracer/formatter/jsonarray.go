@@ -0,0 +1,72 @@
+package formatter
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+)
+
+// errClosed is returned by JSONArrayEmitter.Emit after Close.
+var errClosed = errors.New("formatter: emit after close")
+
+// JSONArrayEmitter writes events as a single JSON array, one event per line,
+// for consumers that decode the whole output at once. Unlike HTMLEmitter it
+// does not buffer: "[" is written with the first event, each further event
+// is preceded by a comma, and Close writes the closing "]". Close writes
+// "[]" if no event was emitted, so the output is a valid JSON document
+// either way.
+type JSONArrayEmitter struct {
+	w io.Writer
+
+	mu     sync.Mutex
+	n      int // events written
+	closed bool
+}
+
+// NewJSONArrayEmitter creates an emitter that writes a JSON array to w.
+func NewJSONArrayEmitter(w io.Writer) *JSONArrayEmitter {
+	return &JSONArrayEmitter{w: w}
+}
+
+// Emit writes ev as the next element of the array. It returns an error
+// after Close.
+func (e *JSONArrayEmitter) Emit(ev event.Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return errClosed
+	}
+	sep := ",\n"
+	if e.n == 0 {
+		sep = "[\n"
+	}
+	if _, err := io.WriteString(e.w, sep); err != nil {
+		return err
+	}
+	e.n++
+	_, err = e.w.Write(data)
+	return err
+}
+
+// Close ends the array. Calling it again does nothing.
+func (e *JSONArrayEmitter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	end := "\n]\n"
+	if e.n == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
@@ -0,0 +1,42 @@
+package formatter
+
+import (
+	"errors"
+
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+)
+
+// multiEmitter fans events out to several emitters.
+type multiEmitter struct {
+	emitters []event.Emitter
+}
+
+// MultiEmitter returns an emitter that forwards every event to each of
+// emitters in order, so one trace can produce several output formats.
+// Emit and Close are called on every wrapped emitter even if an earlier one
+// fails; the errors are combined with errors.Join.
+func MultiEmitter(emitters ...event.Emitter) event.Emitter {
+	return &multiEmitter{emitters: emitters}
+}
+
+// Emit forwards ev to every wrapped emitter.
+func (m *multiEmitter) Emit(ev event.Event) error {
+	var errs []error
+	for _, em := range m.emitters {
+		if err := em.Emit(ev); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every wrapped emitter.
+func (m *multiEmitter) Close() error {
+	var errs []error
+	for _, em := range m.emitters {
+		if err := em.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -3,18 +3,52 @@ package formatter
 import (
 	"encoding/json"
 	"io"
+	"sync"
+	"time"
 
 	"github.com/mrlm-net/cure/pkg/tracer/event"
 )
 
-// NDJSONEmitter writes events as newline-delimited JSON.
+// NDJSONEmitter writes events as newline-delimited JSON. If the writer can be
+// flushed, as a [bufio.Writer] or a gzip stream can, it is flushed after
+// every event, or every [WithFlushInterval] if one is set.
 type NDJSONEmitter struct {
-	w io.Writer
+	w        io.Writer
+	interval time.Duration
+
+	mu       sync.Mutex
+	flushErr error         // first error of a periodic flush, returned by Emit
+	stop     chan struct{} // closed by Close to stop the periodic flush
+	done     chan struct{} // closed when the periodic flush has stopped
+	closed   bool
+}
+
+// NDJSONOption is a functional option for NewNDJSONEmitter.
+type NDJSONOption func(*NDJSONEmitter)
+
+// WithFlushInterval flushes the writer every d instead of after every event,
+// so a buffered writer batches events into fewer writes while a downstream
+// consumer still sees them within d. Close flushes what is left. It has no
+// effect unless the writer has a Flush method. d <= 0 flushes after every
+// event, which is the default.
+func WithFlushInterval(d time.Duration) NDJSONOption {
+	return func(e *NDJSONEmitter) {
+		e.interval = d
+	}
 }
 
 // NewNDJSONEmitter creates an emitter that writes NDJSON to w.
-func NewNDJSONEmitter(w io.Writer) *NDJSONEmitter {
-	return &NDJSONEmitter{w: w}
+func NewNDJSONEmitter(w io.Writer, opts ...NDJSONOption) *NDJSONEmitter {
+	e := &NDJSONEmitter{w: w}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.interval > 0 && flusherOf(w) != nil {
+		e.stop = make(chan struct{})
+		e.done = make(chan struct{})
+		go e.flushEvery(e.interval)
+	}
+	return e
 }
 
 // Emit writes a single event as a JSON line.
@@ -23,11 +57,78 @@ func (e *NDJSONEmitter) Emit(ev event.Event) error {
 	if err != nil {
 		return err
 	}
-	_, err = e.w.Write(append(data, '\n'))
-	return err
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.flushErr != nil {
+		return e.flushErr
+	}
+	if _, err := e.w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if e.stop != nil && !e.closed {
+		return nil
+	}
+	return flush(e.w)
 }
 
-// Close is a no-op for NDJSON (implements event.Emitter).
+// Close stops the periodic flush, if any, and flushes the writer. It does
+// not close the writer.
 func (e *NDJSONEmitter) Close() error {
+	e.mu.Lock()
+	if e.closed || e.stop == nil {
+		e.closed = true
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	close(e.stop)
+	e.mu.Unlock()
+
+	<-e.done
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := flush(e.w); err != nil {
+		return err
+	}
+	return e.flushErr
+}
+
+// flushEvery flushes the writer every d until Close.
+func (e *NDJSONEmitter) flushEvery(d time.Duration) {
+	defer close(e.done)
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			e.mu.Lock()
+			if err := flush(e.w); err != nil && e.flushErr == nil {
+				e.flushErr = err
+			}
+			e.mu.Unlock()
+		}
+	}
+}
+
+// flusherOf returns a function that flushes w, or nil if w has no Flush
+// method. Both Flush() error, as on bufio.Writer and gzip.Writer, and
+// Flush(), as on http.Flusher, are recognised.
+func flusherOf(w io.Writer) func() error {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush
+	case interface{ Flush() }:
+		return func() error { f.Flush(); return nil }
+	}
+	return nil
+}
+
+// flush flushes w if it can be flushed.
+func flush(w io.Writer) error {
+	if f := flusherOf(w); f != nil {
+		return f()
+	}
 	return nil
 }
@@ -0,0 +1,106 @@
+package formatter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+)
+
+// SummaryEmitter forwards events to another emitter and counts them by
+// type. Close closes the wrapped emitter, then writes a one-line tally to
+// its writer, such as
+//
+//	9 events in 312ms: dns_done=1 dns_start=1 http_request_start=1 ...
+//
+// The duration runs from the first event's timestamp to the last one's.
+// Types are listed in name order.
+type SummaryEmitter struct {
+	em event.Emitter
+	w  io.Writer
+
+	mu          sync.Mutex
+	counts      map[string]int
+	total       int
+	first, last int64 // Timestamp of the first and last event
+	closed      bool
+}
+
+// NewSummaryEmitter returns an emitter that forwards every event to em and
+// writes a tally of the events to w when closed. w is typically stderr, so
+// the summary stays out of the trace output.
+func NewSummaryEmitter(em event.Emitter, w io.Writer) *SummaryEmitter {
+	return &SummaryEmitter{em: em, w: w, counts: make(map[string]int)}
+}
+
+// Emit counts ev and forwards it to the wrapped emitter.
+func (s *SummaryEmitter) Emit(ev event.Event) error {
+	s.mu.Lock()
+	s.counts[ev.Type]++
+	s.total++
+	if s.total == 1 {
+		s.first = ev.Timestamp
+	}
+	s.last = ev.Timestamp
+	s.mu.Unlock()
+	return s.em.Emit(ev)
+}
+
+// Counts returns the number of events emitted so far, by type.
+func (s *SummaryEmitter) Counts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int, len(s.counts))
+	for typ, n := range s.counts {
+		counts[typ] = n
+	}
+	return counts
+}
+
+// Close closes the wrapped emitter and writes the summary line. Calling it
+// again does nothing.
+func (s *SummaryEmitter) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	err := s.em.Close()
+	_, werr := fmt.Fprintln(s.w, s.line())
+	return errors.Join(err, werr)
+}
+
+// line formats the summary.
+func (s *SummaryEmitter) line() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	types := make([]string, 0, len(s.counts))
+	for typ := range s.counts {
+		types = append(types, typ)
+	}
+	slices.Sort(types)
+
+	var b strings.Builder
+	noun := "events"
+	if s.total == 1 {
+		noun = "event"
+	}
+	d := time.Duration(s.last - s.first).Round(time.Millisecond)
+	fmt.Fprintf(&b, "%d %s in %s", s.total, noun, d)
+	for i, typ := range types {
+		sep := " "
+		if i == 0 {
+			sep = ": "
+		}
+		fmt.Fprintf(&b, "%s%s=%d", sep, typ, s.counts[typ])
+	}
+	return b.String()
+}
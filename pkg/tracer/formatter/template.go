@@ -0,0 +1,68 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"text/template"
+
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+)
+
+// TemplateEmitter writes each event as the output of a Go text/template
+// executed with the event, one line per event, such as
+//
+//	{{.Type}} {{.Data.duration_ms}}ms
+//
+// The template sees the [event.Event] fields (.Type, .TraceID, .Data,
+// .Labels, ...). A missing Data key is an error, so a template written for
+// one event type fails on others; {{index .Data "key"}} prints "<no value>"
+// instead. An event the template fails on is skipped: the first failure is
+// reported to the error writer and later ones are not, so one bad field
+// does not flood the output, and the trace still succeeds.
+type TemplateEmitter struct {
+	w    io.Writer
+	errW io.Writer
+	tmpl *template.Template
+
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	reported bool
+}
+
+// NewTemplateEmitter returns an emitter that renders every event with the
+// template text and writes it to w, reporting the first rendering error to
+// errW (typically stderr). text is parsed once, here; it returns an error
+// if text does not parse.
+func NewTemplateEmitter(w io.Writer, text string, errW io.Writer) (*TemplateEmitter, error) {
+	tmpl, err := template.New("event").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("formatter: invalid template: %w", err)
+	}
+	return &TemplateEmitter{w: w, errW: errW, tmpl: tmpl}, nil
+}
+
+// Emit renders ev and writes it, adding a newline unless the template
+// ends with one. A rendering error is not returned: ev is skipped and the
+// error reported once.
+func (e *TemplateEmitter) Emit(ev event.Event) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.buf.Reset()
+	if err := e.tmpl.Execute(&e.buf, ev); err != nil {
+		if !e.reported {
+			e.reported = true
+			fmt.Fprintf(e.errW, "template: %s event: %v (further errors are not reported)\n", ev.Type, err)
+		}
+		return nil
+	}
+	if !bytes.HasSuffix(e.buf.Bytes(), []byte("\n")) {
+		e.buf.WriteByte('\n')
+	}
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
+
+// Close does nothing; every event is written by Emit.
+func (e *TemplateEmitter) Close() error { return nil }
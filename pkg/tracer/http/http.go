@@ -1,18 +1,28 @@
 package http
 
 import (
+	"bufio"
 	"context"
-	"crypto/rand"
 	"crypto/tls"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
+	"net"
 	nethttp "net/http"
 	"net/http/httptrace"
+	"net/textproto"
+	neturl "net/url"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mrlm-net/cure/pkg/tracer/event"
+	"github.com/mrlm-net/cure/pkg/tracer/resolvconf"
 )
 
 // TraceURL performs an HTTP request to the specified URL and emits lifecycle events.
@@ -22,11 +32,19 @@ import (
 //   - conn_reused (if connection was reused from pool)
 //   - dns_start, dns_done
 //   - tcp_connect_start, tcp_connect_done
-//   - tls_handshake_start, tls_handshake_done (if HTTPS)
+//   - tls_handshake_start, tls_handshake_done (if HTTPS; "resumed" says
+//     whether a session from an earlier request of the trace was reused)
 //   - request_written (when request is fully sent to wire)
 //   - http_redirect (once per redirect hop, with from/to/status_code)
+//   - redirect_loop (if a redirect revisits a URL or exceeds the limit)
 //   - ttfb (time to first response byte)
 //   - http_response_done
+//   - http_retry (before each retry of a status set with [WithRetryOn])
+//   - trace_retry (before each retry of the whole trace, see [WithTraceRetry])
+//   - phase_timeout (if a phase outlasts [WithMaxTimePerPhase])
+//   - trace_error (if the request fails, with the timeout that fired)
+//   - trace_summary (always last for each request, with phase durations)
+//   - pool_stats (once at the end, with [WithSharedTransport])
 //
 // Example:
 //
@@ -36,25 +54,486 @@ import (
 //	    http.WithMethod("POST"),
 //	    http.WithBodyString(`{"key":"value"}`),
 //	)
+//
+// When keep-alives are disabled via [WithDisableKeepAlives], http_request_start
+// carries "keepalive_disabled": true so reports note that every request paid
+// the full DNS/connect/TLS cost.
+//
+// With [WithCount] or [WithRepeatUntil] the request is repeated; see
+// [WithRepeatUntil] for the stop rules and the repeat_stopped event.
 func TraceURL(ctx context.Context, url string, opts ...Option) error {
 	cfg := &traceConfig{
-		emitter: nil,
-		dryRun:  false,
-		method:  "GET",
-		body:    "",
-		headers: make(map[string]string),
-		redact:  true,
+		emitter:      nil,
+		dryRun:       false,
+		method:       "GET",
+		body:         "",
+		headers:      make(map[string]string),
+		redact:       true,
+		count:        1,
+		maxRedirects: DefaultMaxRedirects,
+		maxRetries:   DefaultMaxRetries,
+		userAgent:    DefaultUserAgent,
 	}
 	for _, opt := range opts {
 		opt(cfg)
 	}
 
 	// Generate trace ID
-	traceID := generateTraceID()
+	traceID := cfg.traceID
+	if cfg.traceIDSet && traceID == "" {
+		return event.ErrEmptyTraceID
+	}
+	if traceID == "" {
+		traceID = event.NewTraceID()
+	}
+
+	if cfg.form != nil {
+		if cfg.body != "" {
+			return ErrBodyConflict
+		}
+		cfg.body = encodeForm(cfg.form)
+		cfg.contentType = formContentType
+	}
+
+	for host, ip := range cfg.resolve {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("resolve override for %s: %q is not an IP address", host, ip)
+		}
+	}
+
+	if cfg.headersFile != "" {
+		fileHeaders, err := readHeadersFile(cfg.headersFile)
+		if err != nil {
+			return err
+		}
+		cfg.headers = mergeHeaders(fileHeaders, cfg.headers)
+	}
+
+	// Repeated requests of one trace may resume each other's TLS sessions,
+	// as a real client would; tls_handshake_done reports "resumed".
+	cfg.sessionCache = tls.NewLRUClientSessionCache(0)
+
+	if len(cfg.labels) > 0 {
+		cfg.emitter = event.NewLabelEmitter(cfg.emitter, cfg.labels)
+	}
+
+	// Stop tracing as soon as the output fails; see event.Guard.
+	ctx, guard := event.Guard(ctx, cfg.emitter)
+	cfg.emitter = guard
 
 	if cfg.dryRun {
-		return emitDryRunEvents(cfg.emitter, traceID, url)
+		return guard.Check(emitDryRunEvents(cfg.emitter, cfg, traceID, url))
+	}
+
+	if cfg.totalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, cfg.totalTimeout, errTotalTimeout)
+		defer cancel()
+	}
+
+	var pool *poolStats
+	if cfg.sharedTransport {
+		if cfg.transport == nil {
+			transport := newTransport(cfg, traceID).(*nethttp.Transport)
+			defer transport.CloseIdleConnections()
+			cfg.transport = transport
+		}
+		pool = &poolStats{}
+		cfg.emitter = event.NewTapEmitter(cfg.emitter, pool.observe)
+	}
+
+	err := event.RetryTransient(ctx, cfg.emitter, traceID, cfg.traceRetry, func() error {
+		if cfg.count == 1 && cfg.repeatUntil == nil {
+			return traceWithRetry(ctx, cfg, traceID, url, 0)
+		}
+		return traceRepeated(ctx, cfg, traceID, url)
+	})
+	if pool != nil {
+		emit(guard, "pool_stats", traceID, pool.stats())
+	}
+	return guard.Check(err)
+}
+
+// Collect runs [TraceURL] and returns the events it emitted along with its
+// error, so Go programs and tests can inspect a trace without parsing
+// NDJSON. On failure the events up to the failure are returned. An emitter
+// set with [WithEmitter] still receives every event as it happens, so a
+// trace can be streamed and inspected at once.
+//
+// Example:
+//
+//	events, err := http.Collect(ctx, "https://example.com")
+//	for _, ev := range events {
+//	    if ev.Type == event.TraceSummary {
+//	        fmt.Println(ev.Data["total_ms"])
+//	    }
+//	}
+func Collect(ctx context.Context, url string, opts ...Option) ([]event.Event, error) {
+	var rec *event.SliceEmitter
+	opts = append(opts[:len(opts):len(opts)], func(cfg *traceConfig) {
+		rec = event.NewSliceEmitter(cfg.emitter)
+		cfg.emitter = rec
+	})
+	err := TraceURL(ctx, url, opts...)
+	return rec.Events(), err
+}
+
+// traceRepeated runs traceRequest until cfg.repeatUntil matches an event,
+// cfg.count attempts have been made, or ctx is cancelled, then emits
+// repeat_stopped with the reason. Failed attempts do not stop the loop; the
+// last failure is returned when the attempts run out.
+func traceRepeated(ctx context.Context, cfg *traceConfig, traceID, url string) error {
+	watch := &conditionWatch{cond: cfg.repeatUntil}
+	attemptCfg := *cfg
+	attemptCfg.emitter = event.NewTapEmitter(cfg.emitter, watch.observe)
+
+	attempt := 0
+	var lastErr error
+	stop := func(reason string) {
+		data := map[string]interface{}{
+			"reason":   reason,
+			"attempts": attempt,
+		}
+		if lastErr != nil {
+			data["last_error"] = lastErr.Error()
+		}
+		emit(cfg.emitter, "repeat_stopped", traceID, data)
+	}
+
+	for cfg.count == 0 || attempt < cfg.count {
+		// Wait between attempts (skip wait before the first one).
+		if attempt > 0 && cfg.interval > 0 {
+			select {
+			case <-time.After(cfg.nextDelay()):
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			stop("cancelled")
+			return ctx.Err()
+		}
+
+		attempt++
+		lastErr = traceWithRetry(ctx, &attemptCfg, traceID, url, attempt)
+		if watch.met.Load() {
+			stop("condition_met")
+			return nil
+		}
+	}
+
+	stop("max_reached")
+	return lastErr
+}
+
+// traceWithRetry runs traceRequest, repeating it while the response status
+// is one of cfg.retryOn, up to cfg.maxRetries times. Before each retry it
+// emits http_retry and waits as long as the response's Retry-After asks,
+// returning ctx.Err() if ctx is cancelled during the wait.
+func traceWithRetry(ctx context.Context, cfg *traceConfig, traceID, url string, attempt int) error {
+	if len(cfg.retryOn) == 0 {
+		return traceRequest(ctx, cfg, traceID, url, attempt)
 	}
+	rec := &retryStatus{}
+	tryCfg := *cfg
+	tryCfg.emitter = event.NewTapEmitter(cfg.emitter, rec.observe)
+
+	for retry := 1; ; retry++ {
+		rec.status, rec.retryAfter = 0, ""
+		err := traceRequest(ctx, &tryCfg, traceID, url, attempt)
+		if err != nil || retry > cfg.maxRetries || !slices.Contains(cfg.retryOn, rec.status) {
+			return err
+		}
+
+		wait := retryDelay(rec.retryAfter, time.Now())
+		data := map[string]interface{}{
+			"retry":       retry,
+			"max_retries": cfg.maxRetries,
+			"status":      rec.status,
+			"reason":      fmt.Sprintf("status %d", rec.status),
+			"wait_ms":     wait.Milliseconds(),
+		}
+		if rec.retryAfter != "" {
+			data["retry_after"] = rec.retryAfter
+		}
+		emit(cfg.emitter, "http_retry", traceID, data)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// DefaultMaxRetries is the number of retries [WithRetryOn] allows unless
+// changed with [WithMaxRetries].
+const DefaultMaxRetries = 3
+
+const (
+	// defaultRetryDelay is the wait before a retry whose response has no
+	// usable Retry-After header.
+	defaultRetryDelay = time.Second
+
+	// maxRetryDelay caps the wait a Retry-After header can ask for.
+	maxRetryDelay = time.Minute
+)
+
+// retryDelay returns how long to wait before retrying a response whose
+// Retry-After header is value: a number of seconds or an HTTP-date, relative
+// to now. A missing or malformed value gives defaultRetryDelay; a date in
+// the past gives 0. The result is capped at maxRetryDelay.
+func retryDelay(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultRetryDelay
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return defaultRetryDelay
+		}
+		d = time.Duration(secs) * time.Second
+	} else if at, err := nethttp.ParseTime(value); err == nil {
+		d = max(at.Sub(now), 0)
+	} else {
+		return defaultRetryDelay
+	}
+	return min(d, maxRetryDelay)
+}
+
+// retryStatus records the status and Retry-After header of the last
+// http_response_done it observes.
+type retryStatus struct {
+	status     int
+	retryAfter string
+}
+
+func (r *retryStatus) observe(ev *event.Event) {
+	if status, ok := ResponseStatus(*ev); ok {
+		r.status = status
+		headers, _ := ev.Data["headers"].(map[string]interface{})
+		switch v := headers["Retry-After"].(type) {
+		case string:
+			r.retryAfter = v
+		case []string:
+			if len(v) > 0 {
+				r.retryAfter = v[0]
+			}
+		}
+	}
+}
+
+// errTotalTimeout is the context cause set when the WithTotalTimeout deadline
+// expires.
+var errTotalTimeout = errors.New("total timeout exceeded")
+
+// PhaseTimeoutError is the error of a request cut short by
+// [WithMaxTimePerPhase]: Phase ("dns", "connect", "tls", or "ttfb") took
+// longer than Limit.
+type PhaseTimeoutError struct {
+	Phase string
+	Limit time.Duration
+}
+
+func (e *PhaseTimeoutError) Error() string {
+	return fmt.Sprintf("%s phase exceeded %s", e.Phase, e.Limit)
+}
+
+// phaseWatchdog arms a timer when a phase starts and disarms it when the
+// phase ends. A timer that fires emits phase_timeout and cancels the
+// request's context with a [PhaseTimeoutError]. Its methods are no-ops on a
+// nil watchdog, so trace hooks call them unconditionally.
+type phaseWatchdog struct {
+	limit  time.Duration
+	cancel context.CancelCauseFunc
+	fire   func(phase string)
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer // by phase and, for connect, address
+	fired  bool
+}
+
+// newPhaseWatchdog returns a context derived from ctx that the watchdog
+// cancels. fire is called once, with the phase, when a timer fires.
+func newPhaseWatchdog(ctx context.Context, limit time.Duration, fire func(phase string)) (context.Context, *phaseWatchdog) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return ctx, &phaseWatchdog{limit: limit, cancel: cancel, fire: fire, timers: make(map[string]*time.Timer)}
+}
+
+// start arms the timer for phase under key; parallel dials use one key each.
+func (w *phaseWatchdog) start(key, phase string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if t := w.timers[key]; t != nil {
+		t.Stop()
+	}
+	w.timers[key] = time.AfterFunc(w.limit, func() {
+		w.mu.Lock()
+		first := !w.fired
+		w.fired = true
+		w.mu.Unlock()
+		if first {
+			w.fire(phase)
+			w.cancel(&PhaseTimeoutError{Phase: phase, Limit: w.limit})
+		}
+	})
+}
+
+// done disarms the timer under key.
+func (w *phaseWatchdog) done(key string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if t := w.timers[key]; t != nil {
+		t.Stop()
+		delete(w.timers, key)
+	}
+}
+
+// stop disarms every timer and releases the context.
+func (w *phaseWatchdog) stop() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	for _, t := range w.timers {
+		t.Stop()
+	}
+	w.mu.Unlock()
+	w.cancel(nil)
+}
+
+// requestError emits trace_error for a failed request and returns err wrapped
+// with msg. When a timeout caused the failure, the event's "timeout" field
+// names it ("connect", "total", or the phase of a [PhaseTimeoutError]) and
+// the returned error says so.
+func requestError(ctx context.Context, cfg *traceConfig, traceID, msg string, err error) error {
+	var phaseErr *PhaseTimeoutError
+	if errors.As(context.Cause(ctx), &phaseErr) {
+		err = phaseErr
+	}
+	data := map[string]interface{}{"error": err.Error()}
+	kind := timeoutKind(ctx, err)
+	if kind != "" {
+		data["timeout"] = kind
+		msg = fmt.Sprintf("%s (%s timeout)", msg, kind)
+	}
+	emit(cfg.emitter, "trace_error", traceID, data)
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// timeoutKind reports which timeout caused err: "total" when the
+// WithTotalTimeout deadline expired, the phase when WithMaxTimePerPhase cut
+// it short, "connect" when dialing timed out, or "" for any other failure.
+func timeoutKind(ctx context.Context, err error) string {
+	cause := context.Cause(ctx)
+	if errors.Is(cause, errTotalTimeout) {
+		return "total"
+	}
+	var phaseErr *PhaseTimeoutError
+	if errors.As(cause, &phaseErr) {
+		return phaseErr.Phase
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout() {
+		return "connect"
+	}
+	return ""
+}
+
+// conditionWatch records whether any event it observes satisfies cond.
+// met is atomic because httptrace hooks emit from the transport's
+// goroutines while traceRepeated reads it between attempts.
+type conditionWatch struct {
+	cond func(event.Event) bool
+	met  atomic.Bool
+}
+
+func (c *conditionWatch) observe(ev *event.Event) {
+	if c.cond != nil && !c.met.Load() && c.cond(*ev) {
+		c.met.Store(true)
+	}
+}
+
+// poolStats counts the connections the requests of a trace got, from the
+// conn_reused events it observes, for pool_stats.
+type poolStats struct {
+	mu                    sync.Mutex
+	created, reused, idle int
+}
+
+func (p *poolStats) observe(ev *event.Event) {
+	if ev.Type == "conn_reused" {
+		p.mu.Lock()
+		if reused, _ := ev.Data["reused"].(bool); reused {
+			p.reused++
+		} else {
+			p.created++
+		}
+		if idle, _ := ev.Data["was_idle"].(bool); idle {
+			p.idle++
+		}
+		p.mu.Unlock()
+	}
+}
+
+// stats returns the pool_stats event data.
+func (p *poolStats) stats() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return map[string]interface{}{
+		"requests": p.created + p.reused,
+		"created":  p.created,
+		"reused":   p.reused,
+		"idle":     p.idle,
+	}
+}
+
+// traceRequest performs a single traced request. attempt numbers repeated
+// requests from 1 and is added to http_request_start; 0 means not repeating.
+//
+// A trace_summary event with the dns, connect, tls, ttfb, and transfer phases
+// is emitted last, whether or not the request succeeded. When redirects were
+// followed, it and http_response_done carry the redirect chain; see
+// addRedirectChain.
+func traceRequest(ctx context.Context, cfg *traceConfig, traceID, url string, attempt int) (err error) {
+	// Phase timings for trace_summary. Transport hooks may run concurrently
+	// (e.g. parallel dials), so updates are serialised.
+	begin := event.Now(cfg.clock)
+	phases := event.Phases{}
+	var phasesMu sync.Mutex
+	addPhase := func(name string, ms int64) {
+		phasesMu.Lock()
+		defer phasesMu.Unlock()
+		phases.Add(name, ms)
+	}
+	// The transport can finish a dial after the request has returned, such
+	// as one a phase timeout abandoned, so hooks emit through hookEmit,
+	// which drops their events once trace_summary is out.
+	finished := false
+	hookEmit := func(ev event.Event) {
+		phasesMu.Lock()
+		defer phasesMu.Unlock()
+		if !finished {
+			emitEvent(cfg.emitter, ev)
+		}
+	}
+	// URLs requested so far, updated by CheckRedirect on the client's
+	// goroutine. client.Do has returned by the time it is read.
+	chain := []string{url}
+	defer func() {
+		phasesMu.Lock()
+		defer phasesMu.Unlock()
+		data := event.SummaryData(phases, event.Since(cfg.clock, begin), err == nil)
+		addRedirectChain(data, chain)
+		emit(cfg.emitter, event.TraceSummary, traceID, data)
+		finished = true
+	}()
 
 	// Create HTTP request
 	var bodyReader io.Reader
@@ -67,54 +546,110 @@ func TraceURL(ctx context.Context, url string, opts ...Option) error {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Add custom headers
+	// Custom headers are applied after the User-Agent and Content-Type so -H
+	// wins for both.
+	if cfg.userAgent != "" {
+		req.Header.Set("User-Agent", cfg.userAgent)
+	}
+	if cfg.contentType != "" {
+		req.Header.Set("Content-Type", cfg.contentType)
+	}
 	for k, v := range cfg.headers {
 		req.Header.Set(k, v)
 	}
+	if cfg.hostHeader != "" {
+		req.Host = cfg.hostHeader
+	}
+	// An explicit Accept-Encoding stops the transport from decoding the body.
+	if cfg.acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", cfg.acceptEncoding)
+	}
 
 	// Emit request start event (before trace hooks so it appears first)
-	reqStart := time.Now()
-	emit(cfg.emitter, "http_request_start", traceID, map[string]interface{}{
+	reqStart := event.Now(cfg.clock)
+	startData := map[string]interface{}{
 		"method":  cfg.method,
 		"url":     url,
+		"host":    req.Host,
 		"headers": redactHeaders(req.Header, cfg.redact),
-	})
+	}
+	if ua := req.Header.Get("User-Agent"); ua != "" {
+		startData["user_agent"] = ua
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		startData["content_type"] = ct
+	}
+	if cfg.body != "" {
+		startData["body_size"] = len(cfg.body)
+	}
+	if cfg.disableKeepAlives {
+		startData["keepalive_disabled"] = true
+	}
+	if attempt > 0 {
+		startData["attempt"] = attempt
+	}
+	if cfg.acceptEncoding != "" {
+		startData["accept_encoding"] = cfg.acceptEncoding
+	}
+	emit(cfg.emitter, "http_request_start", traceID, startData)
+
+	var wd *phaseWatchdog
+	if cfg.phaseTimeout > 0 {
+		ctx, wd = newPhaseWatchdog(ctx, cfg.phaseTimeout, func(phase string) {
+			emit(cfg.emitter, "phase_timeout", traceID, map[string]interface{}{
+				"phase":      phase,
+				"timeout_ms": cfg.phaseTimeout.Milliseconds(),
+			})
+		})
+		defer wd.stop()
+	}
 
 	// Set up HTTP trace hooks
-	var dnsStart, tcpStart, tlsStart, writeStart time.Time
+	var dnsStart, tcpStart, tlsStart, writeStart, firstByte time.Time
 	trace := &httptrace.ClientTrace{
 		GotConn: func(info httptrace.GotConnInfo) {
-			emit(cfg.emitter, "conn_reused", traceID, map[string]interface{}{
+			hookEmit(event.NewEvent("conn_reused", traceID, map[string]interface{}{
 				"reused":   info.Reused,
 				"was_idle": info.WasIdle,
-			})
+			}))
 		},
 		DNSStart: func(info httptrace.DNSStartInfo) {
-			dnsStart = time.Now()
-			emit(cfg.emitter, "dns_start", traceID, map[string]interface{}{
-				"host": info.Host,
-			})
+			wd.start("dns", "dns")
+			dnsStart = event.Now(cfg.clock)
+			ev := event.DNSStart(traceID, info.Host)
+			resolvconf.AddResolver(ev.Data, "")
+			hookEmit(ev)
 		},
 		DNSDone: func(info httptrace.DNSDoneInfo) {
-			duration := time.Since(dnsStart).Milliseconds()
+			wd.done("dns")
+			duration := event.Since(cfg.clock, dnsStart)
+			addPhase("dns", duration.Milliseconds())
 			var ip string
 			if len(info.Addrs) > 0 {
 				ip = info.Addrs[0].IP.String()
 			}
-			emit(cfg.emitter, "dns_done", traceID, map[string]interface{}{
-				"ip":          ip,
-				"duration_ms": duration,
-			})
+			// Unlike event.DNSFailed, a failed lookup keeps the ip key,
+			// empty unless the transport returned addresses.
+			ev := event.DNSDone(traceID, ip, duration)
+			if info.Err != nil {
+				ev.Fail(info.Err.Error())
+			}
+			resolvconf.AddResolver(ev.Data, "")
+			hookEmit(ev)
 		},
 		ConnectStart: func(network, addr string) {
-			tcpStart = time.Now()
-			emit(cfg.emitter, "tcp_connect_start", traceID, map[string]interface{}{
-				"network": network,
-				"addr":    addr,
-			})
+			wd.start("connect "+addr, "connect")
+			tcpStart = event.Now(cfg.clock)
+			ev := event.TCPConnectStart(traceID, addr)
+			ev.Data["network"] = network
+			hookEmit(ev)
 		},
 		ConnectDone: func(network, addr string, err error) {
-			duration := time.Since(tcpStart).Milliseconds()
+			wd.done("connect " + addr)
+			duration := event.Since(cfg.clock, tcpStart).Milliseconds()
+			addPhase("connect", duration)
+			// The transport reports the dialled address, not the two ends
+			// of the connection, so this is not event.TCPConnectDone.
 			data := map[string]interface{}{
 				"network":     network,
 				"addr":        addr,
@@ -123,47 +658,64 @@ func TraceURL(ctx context.Context, url string, opts ...Option) error {
 			if err != nil {
 				data["error"] = err.Error()
 			}
-			emit(cfg.emitter, "tcp_connect_done", traceID, data)
+			hookEmit(event.NewEvent(event.TypeTCPConnectDone, traceID, data))
 		},
 		TLSHandshakeStart: func() {
-			tlsStart = time.Now()
-			emit(cfg.emitter, "tls_handshake_start", traceID, map[string]interface{}{})
+			wd.start("tls", "tls")
+			tlsStart = event.Now(cfg.clock)
+			hookEmit(event.TLSHandshakeStart(traceID))
 		},
 		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
-			duration := time.Since(tlsStart).Milliseconds()
-			data := map[string]interface{}{
-				"duration_ms": duration,
-				"version":     tlsVersionString(state.Version),
-			}
+			wd.done("tls")
+			duration := event.Since(cfg.clock, tlsStart)
+			addPhase("tls", duration.Milliseconds())
+			ev := event.TLSHandshakeDone(traceID, tlsVersionString(state.Version), duration)
+			ev.Data["resumed"] = state.DidResume
 			if err != nil {
-				data["error"] = err.Error()
+				ev.Fail(err.Error())
 			}
-			emit(cfg.emitter, "tls_handshake_done", traceID, data)
+			hookEmit(ev)
 		},
 		WroteRequest: func(info httptrace.WroteRequestInfo) {
-			duration := time.Since(writeStart).Milliseconds()
+			duration := event.Since(cfg.clock, writeStart).Milliseconds()
 			data := map[string]interface{}{
 				"duration_ms": duration,
 			}
 			if info.Err != nil {
 				data["error"] = info.Err.Error()
 			}
-			emit(cfg.emitter, "request_written", traceID, data)
+			hookEmit(event.NewEvent("request_written", traceID, data))
+			wd.start("ttfb", "ttfb")
 		},
 		GotFirstResponseByte: func() {
-			duration := time.Since(reqStart).Milliseconds()
-			emit(cfg.emitter, "ttfb", traceID, map[string]interface{}{
+			wd.done("ttfb")
+			firstByte = event.Now(cfg.clock)
+			duration := firstByte.Sub(reqStart).Milliseconds()
+			addPhase("ttfb", duration)
+			hookEmit(event.NewEvent("ttfb", traceID, map[string]interface{}{
 				"duration_ms": duration,
-			})
+			}))
 		},
 	}
 
-	writeStart = time.Now()
+	writeStart = event.Now(cfg.clock)
 	req = req.WithContext(httptrace.WithClientTrace(ctx, trace))
 
+	// A transport made for this request alone is closed with it, so its
+	// idle keep-alive connection does not outlive the trace. One set with
+	// WithTransport or WithSharedTransport belongs to the caller.
+	transport := newTransport(cfg, traceID)
+	if cfg.transport == nil {
+		defer transport.(*nethttp.Transport).CloseIdleConnections()
+	}
+
 	// Execute request — CheckRedirect emits http_redirect for every hop
 	client := &nethttp.Client{
+		Transport: transport,
 		CheckRedirect: func(req *nethttp.Request, via []*nethttp.Request) error {
+			if cfg.maxRedirects == 0 {
+				return nethttp.ErrUseLastResponse
+			}
 			if len(via) > 0 {
 				prev := via[len(via)-1]
 				statusCode := 0
@@ -177,46 +729,231 @@ func TraceURL(ctx context.Context, url string, opts ...Option) error {
 					"status_code": statusCode,
 				})
 			}
-			if len(via) >= 10 {
-				return fmt.Errorf("stopped after 10 redirects")
+			if err := checkRedirectLoop(cfg, traceID, req, via); err != nil {
+				return err
 			}
+			chain = append(chain, req.URL.String())
 			return nil
 		},
 	}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return requestError(ctx, cfg, traceID, "request failed", err)
 	}
 	defer resp.Body.Close()
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+	// Read response body. With WithHeadOnly it is left unread; the deferred
+	// Close then makes the transport drop the connection instead of
+	// returning it to the pool with a half-read body.
+	var body []byte
+	if !cfg.headOnly {
+		body, err = io.ReadAll(resp.Body)
+		if !firstByte.IsZero() {
+			addPhase("transfer", event.Since(cfg.clock, firstByte).Milliseconds())
+		}
+		if err != nil {
+			return requestError(ctx, cfg, traceID, "failed to read response", err)
+		}
 	}
 
 	// Emit response done event
-	duration := time.Since(reqStart).Milliseconds()
-	emit(cfg.emitter, "http_response_done", traceID, map[string]interface{}{
+	duration := event.Since(cfg.clock, reqStart).Milliseconds()
+	doneData := map[string]interface{}{
 		"status":      resp.StatusCode,
 		"headers":     redactHeaders(resp.Header, cfg.redact),
 		"body_size":   len(body),
 		"duration_ms": duration,
-	})
+		"decoded":     resp.Uncompressed,
+	}
+	if cfg.headOnly {
+		doneData["body_skipped"] = true
+	}
+	// The transport strips Content-Encoding from bodies it decoded itself.
+	if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+		doneData["content_encoding"] = enc
+	} else if resp.Uncompressed {
+		doneData["content_encoding"] = "gzip"
+	}
+	addRedirectChain(doneData, chain)
+	emit(cfg.emitter, "http_response_done", traceID, doneData)
 
 	return nil
 }
 
+// ErrBodyConflict is returned by TraceURL when both [WithFormData] and
+// [WithBodyString] supply a request body.
+var ErrBodyConflict = errors.New("form data and request body are mutually exclusive")
+
+// formContentType is the Content-Type sent with WithFormData bodies.
+const formContentType = "application/x-www-form-urlencoded"
+
+// encodeForm URL-encodes form with its keys sorted.
+func encodeForm(form map[string]string) string {
+	values := make(neturl.Values, len(form))
+	for k, v := range form {
+		values.Set(k, v)
+	}
+	return values.Encode()
+}
+
+// DefaultMaxRedirects is the number of redirects TraceURL follows before
+// giving up, unless changed with [WithMaxRedirects].
+const DefaultMaxRedirects = 10
+
+// ErrRedirectLoop is returned, wrapped with the offending URL, when a redirect
+// chain revisits a URL or grows longer than the redirect limit.
+var ErrRedirectLoop = errors.New("redirect loop")
+
+// addRedirectChain adds "redirect_chain", the URLs requested in order from
+// the original to the last, and "redirect_hops", the number of redirects
+// followed, to data. Nothing is added when no redirect was followed.
+func addRedirectChain(data map[string]interface{}, chain []string) {
+	if len(chain) < 2 {
+		return
+	}
+	data["redirect_chain"] = chain
+	data["redirect_hops"] = len(chain) - 1
+}
+
+// checkRedirectLoop stops a redirect chain that returns to a URL it has
+// already visited or that exceeds cfg.maxRedirects, emitting a redirect_loop
+// event with the hop count, the offending URL, and the URLs in the cycle
+// (for an exceeded limit, the whole chain). via holds the requests made so
+// far, oldest first; req is the next one.
+func checkRedirectLoop(cfg *traceConfig, traceID string, req *nethttp.Request, via []*nethttp.Request) error {
+	next := req.URL.String()
+	hops := len(via)
+
+	var cycle []string
+	var reason string
+	for i, prev := range via {
+		if prev.URL.String() == next {
+			reason = "repeated_url"
+			for _, r := range via[i:] {
+				cycle = append(cycle, r.URL.String())
+			}
+			break
+		}
+	}
+	if reason == "" && hops > cfg.maxRedirects {
+		reason = "max_exceeded"
+		for _, r := range via {
+			cycle = append(cycle, r.URL.String())
+		}
+	}
+	if reason == "" {
+		return nil
+	}
+
+	cycle = append(cycle, next)
+	emit(cfg.emitter, "redirect_loop", traceID, map[string]interface{}{
+		"reason": reason,
+		"url":    next,
+		"hops":   hops,
+		"max":    cfg.maxRedirects,
+		"cycle":  cycle,
+	})
+	if reason == "repeated_url" {
+		return fmt.Errorf("%w: %s revisited after %d redirects", ErrRedirectLoop, next, hops)
+	}
+	return fmt.Errorf("%w: more than %d redirects, last to %s", ErrRedirectLoop, cfg.maxRedirects, next)
+}
+
+// newTransport returns the RoundTripper for one request: cfg.transport when
+// set with WithTransport, otherwise a clone of the default transport
+// configured from the keep-alive, compression, TLS, connect-timeout, and
+// resolve-override options.
+func newTransport(cfg *traceConfig, traceID string) nethttp.RoundTripper {
+	if cfg.transport != nil {
+		return cfg.transport
+	}
+	transport := nethttp.DefaultTransport.(*nethttp.Transport).Clone()
+	transport.DisableKeepAlives = cfg.disableKeepAlives
+	transport.DisableCompression = cfg.acceptEncoding == "identity"
+	tlsConfig := &tls.Config{}
+	if cfg.tlsConfig != nil {
+		tlsConfig = cfg.tlsConfig.Clone()
+	}
+	if tlsConfig.ClientSessionCache == nil {
+		tlsConfig.ClientSessionCache = cfg.sessionCache
+	}
+	transport.TLSClientConfig = tlsConfig
+	if cfg.connectTimeout > 0 {
+		dialer := &net.Dialer{Timeout: cfg.connectTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+	}
+	if len(cfg.resolve) > 0 {
+		transport.DialContext = resolveDialer(cfg, traceID, transport.DialContext)
+	}
+	return transport
+}
+
+// resolveDialer wraps dial so connections to a host pinned with
+// WithResolveOverride go to its IP instead. Only the dialled address
+// changes; the request URL, and with it the Host header and TLS server
+// name, keep the original host. A dns_override event reports each rewrite.
+func resolveDialer(cfg *traceConfig, traceID string, dial func(context.Context, string, string) (net.Conn, error)) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err == nil {
+			if ip, ok := cfg.resolve[strings.ToLower(host)]; ok {
+				addr = net.JoinHostPort(ip, port)
+				emit(cfg.emitter, "dns_override", traceID, map[string]interface{}{
+					"host": host,
+					"ip":   ip,
+					"addr": addr,
+				})
+			}
+		}
+		return dial(ctx, network, addr)
+	}
+}
+
 // Option is a functional option for TraceURL.
 type Option func(*traceConfig)
 
 type traceConfig struct {
-	emitter event.Emitter
-	dryRun  bool
-	method  string
-	body    string
-	headers map[string]string
-	redact  bool
+	emitter     event.Emitter
+	dryRun      bool
+	method      string
+	body        string
+	form        map[string]string
+	contentType string // default Content-Type, set by WithFormData
+	headers     map[string]string
+	headersFile string
+	redact      bool
+
+	disableKeepAlives bool
+	acceptEncoding    string
+	headOnly          bool
+	userAgent         string
+	hostHeader        string // req.Host; default "" = the URL's host
+	maxRedirects      int    // default DefaultMaxRedirects
+
+	tlsConfig       *tls.Config            // default nil = system roots
+	sessionCache    tls.ClientSessionCache // shared by the requests of one trace
+	transport       nethttp.RoundTripper
+	sharedTransport bool              // one transport for every request; reports pool_stats
+	connectTimeout  time.Duration     // default 0 = transport default
+	resolve         map[string]string // lower-cased host -> IP
+	totalTimeout    time.Duration     // default 0 = no limit
+	phaseTimeout    time.Duration     // default 0 = no per-phase limit
+
+	count       int           // default 1; 0 = until repeatUntil matches
+	interval    time.Duration // default 0
+	jitter      float64       // default 0; fraction of interval
+	repeatUntil func(event.Event) bool
+
+	retryOn    []int // statuses to retry; nil = no retries
+	maxRetries int   // default DefaultMaxRetries
+	traceRetry int   // attempts of the whole trace; default 0 = one
+
+	traceID    string
+	traceIDSet bool
+
+	labels map[string]string
+
+	clock event.Clock // set by WithClock; nil means the system clock
 }
 
 // WithEmitter sets the event emitter. Default: NDJSON to stdout.
@@ -226,6 +963,36 @@ func WithEmitter(em event.Emitter) Option {
 	}
 }
 
+// WithTraceID sets the trace ID carried by every event, for example to
+// correlate a trace with an existing request log. TraceURL returns
+// [event.ErrEmptyTraceID] if id is empty. Default: a random ID from
+// [event.NewTraceID].
+func WithTraceID(id string) Option {
+	return func(cfg *traceConfig) {
+		cfg.traceID = id
+		cfg.traceIDSet = true
+	}
+}
+
+// WithClock sets the clock that times the request phases reported in
+// duration_ms fields and trace_summary, so tests can assert exact values.
+// Request, connect, and per-phase timeouts still use the system clock. nil
+// means [event.SystemClock], which is the default.
+func WithClock(c event.Clock) Option {
+	return func(cfg *traceConfig) {
+		cfg.clock = c
+	}
+}
+
+// WithLabels attaches labels, such as {"env": "prod"}, to every event of
+// the request, including those of redirects, retries, and each
+// [WithCount] repeat. labels is copied; see [event.LabelEmitter]. Default: none.
+func WithLabels(labels map[string]string) Option {
+	return func(cfg *traceConfig) {
+		cfg.labels = labels
+	}
+}
+
 // WithDryRun enables dry-run mode (emit events without actual I/O).
 func WithDryRun(enabled bool) Option {
 	return func(cfg *traceConfig) {
@@ -255,6 +1022,28 @@ func WithHeaders(headers map[string]string) Option {
 	}
 }
 
+// WithFormData sends form as an application/x-www-form-urlencoded body and
+// sets the matching Content-Type, unless one is given with [WithHeaders].
+// Keys are encoded in sorted order. Combine it with [WithMethod]("POST") for
+// a typical form submission. TraceURL returns [ErrBodyConflict] if
+// [WithBodyString] is also set.
+func WithFormData(form map[string]string) Option {
+	return func(cfg *traceConfig) {
+		cfg.form = form
+	}
+}
+
+// WithHeadersFromFile adds headers read from the file at path, one
+// "Name: Value" per line. Blank lines and lines starting with # are skipped.
+// Headers set with [WithHeaders] take precedence over the file, compared
+// case-insensitively. TraceURL returns an error if the file cannot be read
+// or a line is malformed.
+func WithHeadersFromFile(path string) Option {
+	return func(cfg *traceConfig) {
+		cfg.headersFile = path
+	}
+}
+
 // WithRedact enables/disables header redaction. Default: true.
 func WithRedact(enabled bool) Option {
 	return func(cfg *traceConfig) {
@@ -262,21 +1051,374 @@ func WithRedact(enabled bool) Option {
 	}
 }
 
-// generateTraceID creates a simple trace ID using crypto/rand.
-func generateTraceID() string {
-	b := make([]byte, 8)
-	if _, err := rand.Read(b); err != nil {
-		// Fallback to timestamp-based ID if crypto/rand fails.
-		return hex.EncodeToString([]byte(fmt.Sprintf("%08x", time.Now().UnixNano())))
+// WithConnectTimeout limits how long establishing the TCP connection may take
+// (net.Dialer.Timeout). A failed request reports "timeout": "connect" in its
+// trace_error event when this limit fires. Default: the standard transport's
+// 30s.
+func WithConnectTimeout(d time.Duration) Option {
+	return func(cfg *traceConfig) {
+		cfg.connectTimeout = d
+	}
+}
+
+// WithMaxTimePerPhase fails a request when any one of its DNS, connect,
+// TLS, or ttfb phases takes longer than d, which is stricter than a total
+// timeout and names the phase that blew its budget. The ttfb phase runs from
+// the request being written to the first response byte. The request is
+// aborted, a phase_timeout event ("phase", "timeout_ms") is emitted, the
+// trace_error event reports the phase as its "timeout", and the returned
+// error wraps a [*PhaseTimeoutError]. d <= 0 disables the check, which is
+// the default.
+func WithMaxTimePerPhase(d time.Duration) Option {
+	return func(cfg *traceConfig) {
+		cfg.phaseTimeout = d
+	}
+}
+
+// WithTotalTimeout bounds the whole trace, including redirects, the response
+// body, and repeated requests, with a context deadline. A request cut short by
+// it reports "timeout": "total" in its trace_error event. Default: no limit.
+func WithTotalTimeout(d time.Duration) Option {
+	return func(cfg *traceConfig) {
+		cfg.totalTimeout = d
+	}
+}
+
+// DefaultUserAgent is the User-Agent sent when [WithUserAgent] is not used, so
+// synthetic traffic from cure can be told apart in server logs.
+const DefaultUserAgent = "cure"
+
+// WithUserAgent sets the User-Agent request header. A User-Agent passed to
+// [WithHeaders] still takes precedence. The effective value is reported as
+// "user_agent" in http_request_start; an empty ua leaves Go's default in
+// place and omits the field. Default: [DefaultUserAgent].
+func WithUserAgent(ua string) Option {
+	return func(cfg *traceConfig) {
+		cfg.userAgent = ua
+	}
+}
+
+// WithHostHeader sends host as the request's Host, in place of the URL's
+// authority, to reach a virtual host on a server addressed by IP or by
+// another name. The connection, and the TLS server name (SNI), still go to
+// the URL's host; pair it with [WithTLSConfig] to change SNI too, or use
+// [WithResolveOverride] to keep the name and dial another address instead.
+// A Host given to [WithHeaders] is ignored by net/http, so use this option.
+// net/http keeps the Host across redirects to a relative location only.
+// http_request_start reports the effective value as "host".
+// Default: "", the URL's host.
+func WithHostHeader(host string) Option {
+	return func(cfg *traceConfig) {
+		cfg.hostHeader = host
+	}
+}
+
+// WithDisableKeepAlives disables HTTP keep-alives so every request opens a
+// fresh connection and performs a full DNS/connect/TLS cycle. Useful for
+// latency comparisons where connection reuse would skew per-phase timings.
+// The TLS handshakes after the first may still be abbreviated by session
+// resumption; tls_handshake_done reports "resumed".
+// Default: false.
+func WithDisableKeepAlives(disabled bool) Option {
+	return func(cfg *traceConfig) {
+		cfg.disableKeepAlives = disabled
+	}
+}
+
+// WithAcceptEncoding sends enc (e.g. "gzip", "br", "identity") as the
+// Accept-Encoding header, overriding any set via WithHeaders. The body is then
+// left as sent on the wire, so body_size is the encoded size. "identity" also
+// disables the transport's automatic gzip.
+//
+// http_request_start carries the "accept_encoding" sent, and
+// http_response_done always reports "decoded" (whether the transport
+// decompressed the body) and the "content_encoding" the server used, if any.
+// Without this option the transport requests gzip itself and decodes the
+// response transparently. Default: "".
+func WithAcceptEncoding(enc string) Option {
+	return func(cfg *traceConfig) {
+		cfg.acceptEncoding = enc
+	}
+}
+
+// WithHeadOnly stops each request once the status line and headers have
+// arrived, without downloading the body, whatever the method. This keeps
+// latency checks against large responses fast when only the status and
+// headers matter. http_response_done then reports "body_size" 0 and
+// "body_skipped" true, and the trace summary has no transfer phase. The
+// connection is closed rather than reused, since its unread body cannot be
+// skipped safely. Default: false.
+func WithHeadOnly(enabled bool) Option {
+	return func(cfg *traceConfig) {
+		cfg.headOnly = enabled
+	}
+}
+
+// WithMaxRedirects sets how many redirects TraceURL follows before aborting
+// with a redirect_loop event and [ErrRedirectLoop]. A redirect back to a URL
+// already visited aborts regardless of the limit. With n == 0 redirects are
+// not followed: the 3xx response is traced as the final one. If n < 0 it is
+// ignored. Default: [DefaultMaxRedirects].
+func WithMaxRedirects(n int) Option {
+	return func(cfg *traceConfig) {
+		if n >= 0 {
+			cfg.maxRedirects = n
+		}
+	}
+}
+
+// WithTLSConfig sets the TLS client configuration, for example to trust a
+// private CA through RootCAs. Each request uses a clone of c. Unless c sets
+// ClientSessionCache, the requests of one trace share a session cache, so
+// repeated requests on fresh connections can resume TLS sessions. Default:
+// nil, which verifies against the system roots.
+func WithTLSConfig(c *tls.Config) Option {
+	return func(cfg *traceConfig) {
+		cfg.tlsConfig = c
+	}
+}
+
+// WithResolveOverride pins host to ip, like curl's --resolve: connections
+// to host are dialled to ip without a DNS lookup, while the Host header and
+// TLS server name (SNI) stay host. This targets one backend behind a load
+// balancer or a new origin before DNS is switched. Each rewritten dial emits
+// a dns_override event with "host", "ip", and the dialled "addr", in place
+// of dns_start and dns_done, so the trace summary has no dns phase.
+//
+// Hosts match case-insensitively, including hosts reached by redirects.
+// Repeat the option to pin several hosts; a later override of the same host
+// wins. TraceURL returns an error if ip is not an IP address. The override
+// is ignored when [WithTransport] is set. Default: none.
+func WithResolveOverride(host, ip string) Option {
+	return func(cfg *traceConfig) {
+		if cfg.resolve == nil {
+			cfg.resolve = make(map[string]string)
+		}
+		cfg.resolve[strings.ToLower(host)] = strings.Trim(ip, "[]")
 	}
-	return hex.EncodeToString(b)
+}
+
+// WithTransport sets the RoundTripper used for requests instead of the
+// default transport, for custom dialers, certificate pinning, or an
+// in-memory test double. The request context still carries the httptrace
+// hooks, but most lifecycle events (dns_*, tcp_connect_*, tls_handshake_*,
+// conn_reused, request_written, ttfb) fire only if rt honours httptrace the
+// way net/http.Transport does; http_request_start, http_redirect,
+// http_response_done, and trace_summary are always emitted.
+//
+// The options that configure the default transport are ignored when rt is
+// set: [WithDisableKeepAlives], [WithConnectTimeout], [WithTLSConfig], and
+// [WithResolveOverride].
+// Default: nil, a fresh clone of net/http.DefaultTransport per request, or
+// one for the whole trace with [WithSharedTransport].
+func WithTransport(rt nethttp.RoundTripper) Option {
+	return func(cfg *traceConfig) {
+		cfg.transport = rt
+	}
+}
+
+// WithSharedTransport makes every request of a trace, including repeats
+// from [WithCount] and retries, use one transport, so later requests can
+// reuse the keep-alive connections of earlier ones as a long-lived client
+// would. Without it each request gets a fresh transport and a new
+// connection. The trace then ends with a pool_stats event counting, from
+// the conn_reused events, the "requests" made, the connections "created"
+// and "reused", and how many reused connections were "idle" in the pool,
+// which shows how well keep-alive works for the target. With
+// [WithTransport] that transport is shared already; this option only adds
+// pool_stats. Default: false.
+func WithSharedTransport(enabled bool) Option {
+	return func(cfg *traceConfig) {
+		cfg.sharedTransport = enabled
+	}
+}
+
+// WithCount sets the maximum number of requests to make. n = 0 repeats until
+// the WithRepeatUntil condition is met or the context is cancelled; without a
+// condition, n = 0 repeats until cancellation. If n < 0 it is set to 1.
+// Default: 1.
+func WithCount(n int) Option {
+	return func(cfg *traceConfig) {
+		if n < 0 {
+			n = 1
+		}
+		cfg.count = n
+	}
+}
+
+// WithInterval sets the wait duration between repeated requests.
+func WithInterval(d time.Duration) Option {
+	return func(cfg *traceConfig) {
+		cfg.interval = d
+	}
+}
+
+// nextDelay returns the wait before the next repeated request: the
+// interval, moved by a random amount of up to ±jitter of itself.
+func (cfg *traceConfig) nextDelay() time.Duration {
+	if cfg.jitter <= 0 {
+		return cfg.interval
+	}
+	spread := (2*rand.Float64() - 1) * cfg.jitter * float64(cfg.interval)
+	return cfg.interval + time.Duration(spread)
+}
+
+// WithJitter randomises each wait set by WithInterval by up to ±fraction of
+// the interval, so repeated requests do not fall into step with a server's
+// rate-limit or cache windows. With a 10s interval and fraction 0.2, each
+// wait is between 8s and 12s. fraction is clamped to [0, 1]. The wait still
+// ends early if the context is cancelled. Default: 0 (no jitter).
+func WithJitter(fraction float64) Option {
+	return func(cfg *traceConfig) {
+		cfg.jitter = min(max(fraction, 0), 1)
+	}
+}
+
+// WithRepeatUntil repeats the request until cond returns true for any event
+// of an attempt, such as the http_response_done of a healthy response. The
+// attempt in which the condition is met always runs to completion. The number
+// of attempts is bounded by WithCount; when the condition is nil only WithCount
+// applies.
+//
+// Repeated traces end with a repeat_stopped event whose "reason" is
+// "condition_met", "max_reached", or "cancelled", with the number of
+// "attempts" made and the "last_error" of the final attempt, if any.
+func WithRepeatUntil(cond func(event.Event) bool) Option {
+	return func(cfg *traceConfig) {
+		cfg.repeatUntil = cond
+	}
+}
+
+// WithRetryOn retries a request whose response status is one of statuses,
+// such as 429 Too Many Requests or 503 Service Unavailable, up to
+// [WithMaxRetries] times. Before each retry an http_retry event reports the
+// "retry" number, "max_retries", the "status" and "reason", and the
+// "wait_ms" before the next request. The wait honours the response's
+// Retry-After header, in seconds or as an HTTP-date (reported as
+// "retry_after"), up to one minute; without one it is one second.
+// Cancelling the context ends the wait.
+//
+// Every try is a full request with its own http_response_done and
+// trace_summary, but a request and its retries count as one attempt for
+// [WithCount]. Requests that fail without a response are not retried.
+// Default: no retries.
+func WithRetryOn(statuses ...int) Option {
+	return func(cfg *traceConfig) {
+		cfg.retryOn = append([]int(nil), statuses...)
+	}
+}
+
+// WithMaxRetries sets how many times [WithRetryOn] retries one request.
+// Values below 1 leave the default, [DefaultMaxRetries].
+func WithMaxRetries(n int) Option {
+	return func(cfg *traceConfig) {
+		if n > 0 {
+			cfg.maxRetries = n
+		}
+	}
+}
+
+// WithTraceRetry runs the whole trace up to attempts times while it fails
+// with a transient network error: a refused or reset connection, a
+// temporary DNS failure, or a timeout (see [event.TransientReason]).
+// Permanent errors, such as an invalid URL or an unknown host, fail at once.
+// Before each retry a trace_retry event reports the "attempt" that failed,
+// "max_attempts", the "reason" and "error", and the "wait_ms" before the
+// next attempt, which starts at 250ms and doubles up to 5s. Cancelling the
+// context ends the wait.
+//
+// Unlike [WithRetryOn], which retries responses by status, this retries
+// requests that got no response at all. Each attempt emits its own events
+// and trace_summary. Values below 2 disable retries, which is the default.
+func WithTraceRetry(attempts int) Option {
+	return func(cfg *traceConfig) {
+		cfg.traceRetry = attempts
+	}
+}
+
+// UntilStatus returns a WithRepeatUntil condition that matches a final
+// response with the given status code.
+func UntilStatus(code int) func(event.Event) bool {
+	return func(ev event.Event) bool {
+		status, ok := ResponseStatus(ev)
+		return ok && status == code
+	}
+}
+
+// UntilSuccess returns a WithRepeatUntil condition that matches a final
+// response with a 2xx status code.
+func UntilSuccess() func(event.Event) bool {
+	return func(ev event.Event) bool {
+		status, ok := ResponseStatus(ev)
+		return ok && status >= 200 && status < 300
+	}
+}
+
+// ResponseStatus extracts the status code from an http_response_done event.
+// It reports false for any other event. Events decoded from NDJSON carry the
+// status as a float64 and are handled too.
+func ResponseStatus(ev event.Event) (int, bool) {
+	if ev.Type != "http_response_done" {
+		return 0, false
+	}
+	switch v := ev.Data["status"].(type) {
+	case int:
+		return v, true
+	case float64: // events decoded from JSON
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
+
+// readHeadersFile parses a headers file for WithHeadersFromFile.
+func readHeadersFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open headers file: %w", err)
+	}
+	defer f.Close()
+
+	headers := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("headers file %s line %d: want \"Name: Value\", got %q", path, lineNo, line)
+		}
+		headers[textproto.CanonicalMIMEHeaderKey(name)] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read headers file: %w", err)
+	}
+	return headers, nil
+}
+
+// mergeHeaders returns base overlaid with override. Names are canonicalised
+// so "authorization" in override replaces "Authorization" in base.
+func mergeHeaders(base, override map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+	for k, v := range override {
+		merged[textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+	return merged
 }
 
 // redactHeaders redacts sensitive headers if redaction is enabled.
 func redactHeaders(headers nethttp.Header, redact bool) map[string]interface{} {
 	result := make(map[string]interface{})
 	for k, v := range headers {
-		if redact && isSensitiveHeader(k) {
+		if redact && IsSensitiveHeader(k) {
 			result[k] = "[REDACTED]"
 		} else {
 			if len(v) == 1 {
@@ -289,8 +1431,9 @@ func redactHeaders(headers nethttp.Header, redact bool) map[string]interface{} {
 	return result
 }
 
-// isSensitiveHeader checks if a header is sensitive and should be redacted.
-func isSensitiveHeader(name string) bool {
+// IsSensitiveHeader reports whether the header called name is redacted in
+// events by default: Authorization, Cookie, or Set-Cookie, in any case.
+func IsSensitiveHeader(name string) bool {
 	lower := strings.ToLower(name)
 	return lower == "authorization" || lower == "cookie" || lower == "set-cookie"
 }
@@ -317,43 +1460,78 @@ func tlsVersionString(version uint16) string {
 // Centralising the nil-check removes one branch per call site and lowers
 // the cyclomatic complexity of TraceURL.
 func emit(em event.Emitter, name, traceID string, data map[string]interface{}) {
+	emitEvent(em, event.NewEvent(name, traceID, data))
+}
+
+// emitEvent emits ev when em is set.
+func emitEvent(em event.Emitter, ev event.Event) {
 	if em != nil {
-		em.Emit(event.NewEvent(name, traceID, data))
+		em.Emit(ev)
 	}
 }
 
 // emitDryRunEvents emits synthetic events without making an actual HTTP request.
-func emitDryRunEvents(em event.Emitter, traceID, url string) error {
+func emitDryRunEvents(em event.Emitter, cfg *traceConfig, traceID, url string) error {
 	if em == nil {
 		return nil
 	}
 
 	// HTTP request start
-	em.Emit(event.NewEvent("http_request_start", traceID, map[string]interface{}{"method": "GET", "url": url}))
+	host := cfg.hostHeader
+	if host == "" {
+		if u, err := neturl.Parse(url); err == nil {
+			host = u.Host
+		}
+	}
+	startData := map[string]interface{}{"method": "GET", "url": url, "host": host, "user_agent": cfg.userAgent}
+	if cfg.disableKeepAlives {
+		startData["keepalive_disabled"] = true
+	}
+	em.Emit(event.NewEvent("http_request_start", traceID, startData))
 
 	// Connection info
 	em.Emit(event.NewEvent("conn_reused", traceID, map[string]interface{}{"reused": false, "was_idle": false}))
 
-	// DNS events
-	em.Emit(event.NewEvent("dns_start", traceID, map[string]interface{}{"host": "example.com"}))
-	em.Emit(event.NewEvent("dns_done", traceID, map[string]interface{}{"ip": "93.184.216.34", "duration_ms": 10}))
+	// DNS events, or the pinned address when the URL's host is overridden.
+	// Either way the connect events use the port the URL would dial.
+	u, _ := neturl.Parse(url)
+	port := "443"
+	if u != nil && u.Port() != "" {
+		port = u.Port()
+	} else if strings.HasPrefix(url, "http://") {
+		port = "80"
+	}
+	addr := net.JoinHostPort("93.184.216.34", port)
+	if u != nil && cfg.resolve[strings.ToLower(u.Hostname())] != "" {
+		ip := cfg.resolve[strings.ToLower(u.Hostname())]
+		addr = net.JoinHostPort(ip, port)
+		em.Emit(event.NewEvent("dns_override", traceID, map[string]interface{}{"host": u.Hostname(), "ip": ip, "addr": addr}))
+	} else {
+		em.Emit(event.DNSStart(traceID, "example.com"))
+		em.Emit(event.DNSDone(traceID, "93.184.216.34", 10*time.Millisecond))
+	}
 
 	// TCP events
-	em.Emit(event.NewEvent("tcp_connect_start", traceID, map[string]interface{}{"network": "tcp", "addr": "93.184.216.34:443"}))
-	em.Emit(event.NewEvent("tcp_connect_done", traceID, map[string]interface{}{"network": "tcp", "addr": "93.184.216.34:443", "duration_ms": 50}))
+	em.Emit(event.NewEvent(event.TypeTCPConnectStart, traceID, map[string]interface{}{"network": "tcp", "addr": addr}))
+	em.Emit(event.NewEvent(event.TypeTCPConnectDone, traceID, map[string]interface{}{"network": "tcp", "addr": addr, "duration_ms": 50}))
 
 	// TLS events (if HTTPS)
 	if strings.HasPrefix(url, "https://") {
-		em.Emit(event.NewEvent("tls_handshake_start", traceID, map[string]interface{}{}))
-		em.Emit(event.NewEvent("tls_handshake_done", traceID, map[string]interface{}{"duration_ms": 100, "version": "TLS 1.3"}))
+		em.Emit(event.TLSHandshakeStart(traceID))
+		tlsDone := event.TLSHandshakeDone(traceID, "TLS 1.3", 100*time.Millisecond)
+		tlsDone.Data["resumed"] = false
+		em.Emit(tlsDone)
 	}
 
 	// Request written to wire
 	em.Emit(event.NewEvent("request_written", traceID, map[string]interface{}{"duration_ms": 1}))
 
-	// Redirect (synthetic example for http:// URLs redirecting to https://)
-	if strings.HasPrefix(url, "http://") {
+	// Redirect (synthetic example for http:// URLs redirecting to https://,
+	// unless redirects are disabled)
+	chain := []string{url}
+	if strings.HasPrefix(url, "http://") && cfg.maxRedirects > 0 {
 		httpsURL := "https://" + strings.TrimPrefix(url, "http://")
+		chain = append(chain, httpsURL)
 		em.Emit(event.NewEvent("http_redirect", traceID, map[string]interface{}{
 			"from":        url,
 			"to":          httpsURL,
@@ -366,7 +1544,25 @@ func emitDryRunEvents(em event.Emitter, traceID, url string) error {
 	em.Emit(event.NewEvent("ttfb", traceID, map[string]interface{}{"duration_ms": 120}))
 
 	// HTTP response done
-	em.Emit(event.NewEvent("http_response_done", traceID, map[string]interface{}{"status": 200, "body_size": 1256, "duration_ms": 300}))
+	doneData := map[string]interface{}{"status": 200, "body_size": 1256, "duration_ms": 300}
+	if cfg.headOnly {
+		doneData["body_size"] = 0
+		doneData["body_skipped"] = true
+	}
+	addRedirectChain(doneData, chain)
+	em.Emit(event.NewEvent("http_response_done", traceID, doneData))
+
+	// Trace summary
+	phases := event.Phases{"dns": 10, "connect": 50, "ttfb": 120, "transfer": 180}
+	if strings.HasPrefix(url, "https://") {
+		phases["tls"] = 100
+	}
+	if cfg.headOnly {
+		delete(phases, "transfer")
+	}
+	summary := event.SummaryData(phases, 300*time.Millisecond, true)
+	addRedirectChain(summary, chain)
+	em.Emit(event.NewEvent(event.TraceSummary, traceID, summary))
 
 	return nil
 }
@@ -2,11 +2,25 @@ package http
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
 	"net/http/httptest"
+	"net/http/httptrace"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	nethttp "net/http"
 
@@ -137,6 +151,73 @@ func TestTraceURL_Redact(t *testing.T) {
 	}
 }
 
+func TestTraceURL_HeadersFromFile(t *testing.T) {
+	var got nethttp.Header
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		got = r.Header.Clone()
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "headers.txt")
+	content := "# shared headers\n" +
+		"\n" +
+		"Authorization: Bearer file-secret\n" +
+		"x-team: platform\n" +
+		"X-Env:   staging  \n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var buf bytes.Buffer
+	em := formatter.NewNDJSONEmitter(&buf)
+	err := TraceURL(context.Background(), ts.URL,
+		WithEmitter(em),
+		WithHeadersFromFile(path),
+		WithHeaders(map[string]string{"x-env": "prod"}),
+	)
+	if err != nil {
+		t.Fatalf("TraceURL() error = %v", err)
+	}
+	em.Close()
+
+	if got.Get("Authorization") != "Bearer file-secret" || got.Get("X-Team") != "platform" {
+		t.Errorf("server got headers %v, want the file headers", got)
+	}
+	if v := got.Values("X-Env"); len(v) != 1 || v[0] != "prod" {
+		t.Errorf("X-Env = %v, want [prod] from WithHeaders", v)
+	}
+	output := buf.String()
+	if strings.Contains(output, "file-secret") {
+		t.Error("Authorization header from file not redacted")
+	}
+	if !strings.Contains(output, "[REDACTED]") || !strings.Contains(output, "platform") {
+		t.Errorf("output = %s, want redacted Authorization and visible X-Team", output)
+	}
+}
+
+func TestTraceURL_HeadersFromFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.txt")
+	if err := os.WriteFile(bad, []byte("X-Ok: 1\nnot a header\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	for path, want := range map[string]string{
+		bad:                           "line 2",
+		filepath.Join(dir, "missing"): "failed to open headers file",
+	} {
+		em := &testEmitter{}
+		err := TraceURL(context.Background(), "http://127.0.0.1:1", WithEmitter(em), WithHeadersFromFile(path))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("TraceURL(%s) error = %v, want it to contain %q", path, err, want)
+		}
+		if len(em.events) != 0 {
+			t.Errorf("TraceURL(%s) emitted %v before failing", path, eventTypes(em.events))
+		}
+	}
+}
+
 func TestTraceURL_Redirect(t *testing.T) {
 	// Set up a redirect chain: /redirect → /final
 	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
@@ -198,6 +279,198 @@ func TestTraceURL_Redirect(t *testing.T) {
 	}
 }
 
+func TestTraceURL_RedirectLoop(t *testing.T) {
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/self":
+			nethttp.Redirect(w, r, "/self", nethttp.StatusFound)
+		case "/a":
+			nethttp.Redirect(w, r, "/b", nethttp.StatusFound)
+		case "/b":
+			nethttp.Redirect(w, r, "/a", nethttp.StatusMovedPermanently)
+		default: // /n/<i> redirects to /n/<i+1> forever
+			var i int
+			fmt.Sscanf(r.URL.Path, "/n/%d", &i)
+			nethttp.Redirect(w, r, fmt.Sprintf("/n/%d", i+1), nethttp.StatusFound)
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name      string
+		path      string
+		opts      []Option
+		reason    string
+		url       string
+		hops      int
+		cycle     []string
+		wantInErr string
+	}{
+		{
+			name:      "self redirect",
+			path:      "/self",
+			reason:    "repeated_url",
+			url:       ts.URL + "/self",
+			hops:      1,
+			cycle:     []string{ts.URL + "/self", ts.URL + "/self"},
+			wantInErr: "/self revisited after 1 redirects",
+		},
+		{
+			name:      "two-hop cycle",
+			path:      "/a",
+			reason:    "repeated_url",
+			url:       ts.URL + "/a",
+			hops:      2,
+			cycle:     []string{ts.URL + "/a", ts.URL + "/b", ts.URL + "/a"},
+			wantInErr: "/a revisited after 2 redirects",
+		},
+		{
+			name:      "max exceeded",
+			path:      "/n/0",
+			opts:      []Option{WithMaxRedirects(3)},
+			reason:    "max_exceeded",
+			url:       ts.URL + "/n/4",
+			hops:      4,
+			cycle:     []string{ts.URL + "/n/0", ts.URL + "/n/1", ts.URL + "/n/2", ts.URL + "/n/3", ts.URL + "/n/4"},
+			wantInErr: "more than 3 redirects",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := &testEmitter{}
+			err := TraceURL(context.Background(), ts.URL+tt.path, append(tt.opts, WithEmitter(em))...)
+			if !errors.Is(err, ErrRedirectLoop) {
+				t.Fatalf("TraceURL() error = %v, want ErrRedirectLoop", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantInErr) {
+				t.Errorf("TraceURL() error = %q, want it to contain %q", err, tt.wantInErr)
+			}
+
+			var loop *event.Event
+			for i := range em.events {
+				if em.events[i].Type == "redirect_loop" {
+					loop = &em.events[i]
+				}
+			}
+			if loop == nil {
+				t.Fatalf("no redirect_loop event; got %v", eventTypes(em.events))
+			}
+			if loop.Data["reason"] != tt.reason || loop.Data["url"] != tt.url || loop.Data["hops"] != tt.hops {
+				t.Errorf("redirect_loop = %v, want reason %q url %q hops %d", loop.Data, tt.reason, tt.url, tt.hops)
+			}
+			if got := fmt.Sprint(loop.Data["cycle"]); got != fmt.Sprint(tt.cycle) {
+				t.Errorf("redirect_loop cycle = %s, want %s", got, fmt.Sprint(tt.cycle))
+			}
+			if last := em.events[len(em.events)-1]; last.Type != event.TraceSummary || last.Data["ok"] != false {
+				t.Errorf("last event = %s %v, want failed trace_summary", last.Type, last.Data)
+			}
+		})
+	}
+}
+
+func TestTraceURL_MaxRedirectsZero(t *testing.T) {
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.URL.Path == "/old" {
+			nethttp.Redirect(w, r, "/new", nethttp.StatusMovedPermanently)
+			return
+		}
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	events, err := Collect(context.Background(), ts.URL+"/old", WithMaxRedirects(0))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	var done *event.Event
+	for i, ev := range events {
+		switch ev.Type {
+		case "http_redirect", "redirect_loop":
+			t.Errorf("%s emitted with redirects disabled", ev.Type)
+		case "http_response_done":
+			done = &events[i]
+		}
+	}
+	if done == nil {
+		t.Fatal("no http_response_done event")
+	}
+	if status, _ := ResponseStatus(*done); status != nethttp.StatusMovedPermanently {
+		t.Errorf("status = %d, want %d", status, nethttp.StatusMovedPermanently)
+	}
+}
+
+func TestTraceURL_RedirectChain(t *testing.T) {
+	// /r/<n> redirects to /r/<n-1> until /r/0, which answers 200.
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		var n int
+		fmt.Sscanf(r.URL.Path, "/r/%d", &n)
+		if n > 0 {
+			nethttp.Redirect(w, r, fmt.Sprintf("/r/%d", n-1), nethttp.StatusFound)
+			return
+		}
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+	u := func(n int) string { return fmt.Sprintf("%s/r/%d", ts.URL, n) }
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantErr   bool
+		wantChain []string
+	}{
+		{"three hops", nil, false, []string{u(3), u(2), u(1), u(0)}},
+		{"over limit", []Option{WithMaxRedirects(2)}, true, []string{u(3), u(2), u(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := &testEmitter{}
+			err := TraceURL(context.Background(), u(3), append(tt.opts, WithEmitter(em))...)
+			if tt.wantErr {
+				if !errors.Is(err, ErrRedirectLoop) || !strings.Contains(err.Error(), "more than 2 redirects") {
+					t.Fatalf("TraceURL() error = %v, want more than 2 redirects", err)
+				}
+			} else if err != nil {
+				t.Fatalf("TraceURL() error = %v", err)
+			}
+
+			checked := map[string]bool{}
+			for _, ev := range em.events {
+				if ev.Type != "http_response_done" && ev.Type != event.TraceSummary {
+					continue
+				}
+				checked[ev.Type] = true
+				if got := fmt.Sprint(ev.Data["redirect_chain"]); got != fmt.Sprint(tt.wantChain) {
+					t.Errorf("%s redirect_chain = %s, want %v", ev.Type, got, tt.wantChain)
+				}
+				if got := ev.Data["redirect_hops"]; got != len(tt.wantChain)-1 {
+					t.Errorf("%s redirect_hops = %v, want %d", ev.Type, got, len(tt.wantChain)-1)
+				}
+			}
+			if !checked[event.TraceSummary] || checked["http_response_done"] == tt.wantErr {
+				t.Errorf("checked %v, want trace_summary and, on success only, http_response_done", checked)
+			}
+		})
+	}
+}
+
+func TestTraceURL_NoRedirectChain(t *testing.T) {
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	em := &testEmitter{}
+	if err := TraceURL(context.Background(), ts.URL, WithEmitter(em)); err != nil {
+		t.Fatalf("TraceURL() error = %v", err)
+	}
+	for _, ev := range em.events {
+		if _, ok := ev.Data["redirect_chain"]; ok {
+			t.Errorf("%s carries redirect_chain without redirects: %v", ev.Type, ev.Data)
+		}
+	}
+}
+
 func TestTraceURL_NewEvents(t *testing.T) {
 	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
 		w.WriteHeader(200)
@@ -344,3 +617,1258 @@ func TestTraceURL_CustomHeaders(t *testing.T) {
 		t.Errorf("User-Agent = %q, want %q", receivedHeaders.Get("User-Agent"), "cure-tracer")
 	}
 }
+
+func TestTraceURL_DisableKeepAlives(t *testing.T) {
+	var connHeader string
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		connHeader = r.Header.Get("Connection")
+		if r.Close {
+			connHeader = "close"
+		}
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	em := formatter.NewNDJSONEmitter(&buf)
+
+	err := TraceURL(context.Background(), ts.URL, WithEmitter(em), WithDisableKeepAlives(true))
+	if err != nil {
+		t.Fatalf("TraceURL() error = %v", err)
+	}
+	em.Close()
+
+	if connHeader != "close" {
+		t.Errorf("Connection header = %q, want %q", connHeader, "close")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var first event.Event
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if first.Type != "http_request_start" {
+		t.Fatalf("first event = %q, want http_request_start", first.Type)
+	}
+	if disabled, _ := first.Data["keepalive_disabled"].(bool); !disabled {
+		t.Errorf("http_request_start keepalive_disabled = %v, want true", first.Data["keepalive_disabled"])
+	}
+}
+
+func TestTraceURL_DisableKeepAlives_DryRun(t *testing.T) {
+	events, err := Collect(context.Background(), "https://example.com", WithDryRun(true), WithDisableKeepAlives(true))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if disabled, _ := events[0].Data["keepalive_disabled"].(bool); events[0].Type != "http_request_start" || !disabled {
+		t.Errorf("first event = %s %v, want http_request_start with keepalive_disabled", events[0].Type, events[0].Data)
+	}
+}
+
+func TestTraceURL_ClosesIdleConnections(t *testing.T) {
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	before := runtime.NumGoroutine()
+	if err := TraceURL(context.Background(), ts.URL, WithEmitter(&testEmitter{}), WithCount(20)); err != nil {
+		t.Fatalf("TraceURL() error = %v", err)
+	}
+
+	// Each per-request transport owns a keep-alive connection with its own
+	// read and write goroutines; they must go once its request is done.
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before+4 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before+4 {
+		t.Errorf("goroutines = %d after 20 requests, want about %d: idle connections leaked", after, before)
+	}
+}
+
+func TestTraceURL_SharedTransport(t *testing.T) {
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		wantCreated   int
+		wantReused    int
+		wantPoolStats bool
+	}{
+		{"shared", []Option{WithSharedTransport(true)}, 1, 3, true},
+		{"shared without keep-alives", []Option{WithSharedTransport(true), WithDisableKeepAlives(true)}, 4, 0, true},
+		{"per-request transport", nil, 4, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := Collect(context.Background(), ts.URL, append(tt.opts, WithCount(4))...)
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+
+			created, reused := 0, 0
+			var stats *event.Event
+			for i, ev := range events {
+				switch ev.Type {
+				case "conn_reused":
+					if ev.Data["reused"].(bool) {
+						reused++
+					} else {
+						created++
+					}
+				case "pool_stats":
+					stats = &events[i]
+					if i != len(events)-1 {
+						t.Errorf("pool_stats is event %d of %d, want last", i+1, len(events))
+					}
+				}
+			}
+			if created != tt.wantCreated || reused != tt.wantReused {
+				t.Errorf("conn_reused created/reused = %d/%d, want %d/%d", created, reused, tt.wantCreated, tt.wantReused)
+			}
+			if (stats != nil) != tt.wantPoolStats {
+				t.Fatalf("pool_stats emitted = %v, want %v", stats != nil, tt.wantPoolStats)
+			}
+			if stats == nil {
+				return
+			}
+			want := map[string]interface{}{"requests": 4, "created": tt.wantCreated, "reused": tt.wantReused, "idle": tt.wantReused}
+			if !reflect.DeepEqual(stats.Data, want) {
+				t.Errorf("pool_stats data = %v, want %v", stats.Data, want)
+			}
+		})
+	}
+}
+
+func TestTraceURL_ResolveOverride(t *testing.T) {
+	var gotHost, gotSNI string
+	ts := httptest.NewUnstartedServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		gotHost = r.Host
+		gotSNI = r.TLS.ServerName
+		w.WriteHeader(200)
+	}))
+	ts.StartTLS()
+	defer ts.Close()
+
+	// The test certificate is valid for example.com, which must not resolve
+	// for the request to reach the server.
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+	target := "https://example.com:" + port + "/"
+	roots := x509.NewCertPool()
+	roots.AddCert(ts.Certificate())
+
+	var buf bytes.Buffer
+	em := formatter.NewNDJSONEmitter(&buf)
+	err := TraceURL(context.Background(), target,
+		WithEmitter(em),
+		WithTLSConfig(&tls.Config{RootCAs: roots}),
+		WithResolveOverride("Example.com", "127.0.0.1"),
+	)
+	if err != nil {
+		t.Fatalf("TraceURL() error = %v", err)
+	}
+	em.Close()
+
+	if gotHost != "example.com:"+port {
+		t.Errorf("Host = %q, want %q", gotHost, "example.com:"+port)
+	}
+	if gotSNI != "example.com" {
+		t.Errorf("SNI = %q, want %q", gotSNI, "example.com")
+	}
+
+	var override *event.Event
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var ev event.Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		switch ev.Type {
+		case "dns_override":
+			override = &ev
+		case event.TypeDNSStart:
+			t.Error("dns_start emitted for an overridden host")
+		}
+	}
+	if override == nil {
+		t.Fatal("no dns_override event")
+	}
+	if override.Data["host"] != "example.com" || override.Data["ip"] != "127.0.0.1" || override.Data["addr"] != "127.0.0.1:"+port {
+		t.Errorf("dns_override data = %v", override.Data)
+	}
+}
+
+func TestTraceURL_ResolveOverride_DryRun(t *testing.T) {
+	events, err := Collect(context.Background(), "http://example.com:8080/", WithDryRun(true), WithResolveOverride("example.com", "127.0.0.1"))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	var sawOverride bool
+	for _, ev := range events {
+		switch ev.Type {
+		case "dns_override":
+			sawOverride = true
+			if ev.Data["addr"] != "127.0.0.1:8080" {
+				t.Errorf("dns_override addr = %v, want 127.0.0.1:8080", ev.Data["addr"])
+			}
+		case event.TypeTCPConnectStart, event.TypeTCPConnectDone:
+			if ev.Data["addr"] != "127.0.0.1:8080" {
+				t.Errorf("%s addr = %v, want 127.0.0.1:8080", ev.Type, ev.Data["addr"])
+			}
+		}
+	}
+	if !sawOverride {
+		t.Error("no dns_override event")
+	}
+}
+
+func TestTraceURL_ResolveOverride_InvalidIP(t *testing.T) {
+	err := TraceURL(context.Background(), "http://example.com", WithDryRun(true), WithResolveOverride("example.com", "not-an-ip"))
+	if err == nil || !strings.Contains(err.Error(), "not an IP address") {
+		t.Errorf("TraceURL() error = %v, want invalid IP error", err)
+	}
+}
+
+func TestTraceURL_RepeatUntil(t *testing.T) {
+	tests := []struct {
+		name         string
+		readyAfter   int // requests before the server returns 200
+		count        int
+		wantReason   string
+		wantAttempts float64
+		wantErr      bool
+	}{
+		{"condition met", 2, 5, "condition_met", 3, false},
+		{"max reached", 10, 3, "max_reached", 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var served int
+			ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+				served++
+				if served <= tt.readyAfter {
+					w.WriteHeader(nethttp.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(nethttp.StatusOK)
+			}))
+			defer ts.Close()
+
+			var buf bytes.Buffer
+			err := TraceURL(context.Background(), ts.URL,
+				WithEmitter(formatter.NewNDJSONEmitter(&buf)),
+				WithRepeatUntil(UntilStatus(nethttp.StatusOK)),
+				WithCount(tt.count),
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TraceURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var starts int
+			var stopped *event.Event
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				var ev event.Event
+				if err := json.Unmarshal([]byte(line), &ev); err != nil {
+					t.Fatalf("json.Unmarshal() error = %v", err)
+				}
+				switch ev.Type {
+				case "http_request_start":
+					starts++
+					if got := ev.Data["attempt"]; got != float64(starts) {
+						t.Errorf("http_request_start attempt = %v, want %d", got, starts)
+					}
+				case "repeat_stopped":
+					stopped = &ev
+				}
+			}
+
+			if float64(starts) != tt.wantAttempts {
+				t.Errorf("got %d requests, want %v", starts, tt.wantAttempts)
+			}
+			if stopped == nil {
+				t.Fatal("missing repeat_stopped event")
+			}
+			if stopped.Data["reason"] != tt.wantReason {
+				t.Errorf("repeat_stopped reason = %v, want %q", stopped.Data["reason"], tt.wantReason)
+			}
+			if stopped.Data["attempts"] != tt.wantAttempts {
+				t.Errorf("repeat_stopped attempts = %v, want %v", stopped.Data["attempts"], tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestTraceURL_RepeatUntil_Cancelled(t *testing.T) {
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(nethttp.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var buf bytes.Buffer
+	err := TraceURL(ctx, ts.URL,
+		WithEmitter(formatter.NewNDJSONEmitter(&buf)),
+		WithRepeatUntil(UntilSuccess()),
+		WithCount(0),
+		WithInterval(10*time.Millisecond),
+	)
+	if err == nil {
+		t.Fatal("TraceURL() error = nil, want context error")
+	}
+	if !strings.Contains(buf.String(), `"reason":"cancelled"`) {
+		t.Errorf("output missing cancelled repeat_stopped event:\n%s", buf.String())
+	}
+}
+
+func TestWithRetryOn(t *testing.T) {
+	var served int
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		served++
+		if served == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(nethttp.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(nethttp.StatusOK)
+	}))
+	defer ts.Close()
+
+	events, err := Collect(context.Background(), ts.URL, WithRetryOn(429, 503))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if served != 2 {
+		t.Fatalf("server got %d requests, want 2", served)
+	}
+
+	var statuses []int
+	var retries []event.Event
+	for _, ev := range events {
+		if status, ok := ResponseStatus(ev); ok {
+			statuses = append(statuses, status)
+		}
+		if ev.Type == "http_retry" {
+			retries = append(retries, ev)
+		}
+	}
+	if !reflect.DeepEqual(statuses, []int{429, 200}) {
+		t.Errorf("response statuses = %v, want [429 200]", statuses)
+	}
+	if len(retries) != 1 {
+		t.Fatalf("got %d http_retry events, want 1", len(retries))
+	}
+	want := map[string]interface{}{
+		"retry":       1,
+		"max_retries": DefaultMaxRetries,
+		"status":      429,
+		"reason":      "status 429",
+		"wait_ms":     int64(0),
+		"retry_after": "0",
+	}
+	if !reflect.DeepEqual(retries[0].Data, want) {
+		t.Errorf("http_retry data = %#v, want %#v", retries[0].Data, want)
+	}
+}
+
+func TestWithRetryOn_MaxRetries(t *testing.T) {
+	var served int
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		served++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(nethttp.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	events, err := Collect(context.Background(), ts.URL, WithRetryOn(503), WithMaxRetries(2))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if served != 3 {
+		t.Errorf("server got %d requests, want 3", served)
+	}
+	var retries int
+	for _, ev := range events {
+		if ev.Type == "http_retry" {
+			retries++
+		}
+	}
+	if retries != 2 {
+		t.Errorf("got %d http_retry events, want 2", retries)
+	}
+}
+
+func TestWithRetryOn_CancelledWait(t *testing.T) {
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(nethttp.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := Collect(ctx, ts.URL, WithRetryOn(429))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Collect() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Collect() took %v, want the wait cut short", elapsed)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", defaultRetryDelay},
+		{"2", 2 * time.Second},
+		{" 0 ", 0},
+		{"-1", defaultRetryDelay},
+		{"soon", defaultRetryDelay},
+		{"3600", maxRetryDelay},
+		{now.Add(10 * time.Second).Format(nethttp.TimeFormat), 10 * time.Second},
+		{now.Add(-time.Hour).Format(nethttp.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.value, now); got != tt.want {
+			t.Errorf("retryDelay(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWithJitter_DelayRange(t *testing.T) {
+	const interval = time.Second
+	tests := []struct {
+		name     string
+		fraction float64
+		lo, hi   time.Duration
+	}{
+		{"default has no jitter", 0, interval, interval},
+		{"fraction 0.2", 0.2, 800 * time.Millisecond, 1200 * time.Millisecond},
+		{"fraction clamped to 1", 3, 0, 2 * interval},
+		{"negative fraction is no jitter", -0.5, interval, interval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &traceConfig{}
+			WithInterval(interval)(cfg)
+			WithJitter(tt.fraction)(cfg)
+
+			var below, above bool
+			for range 10000 {
+				d := cfg.nextDelay()
+				if d < tt.lo || d > tt.hi {
+					t.Fatalf("nextDelay() = %v, want within [%v, %v]", d, tt.lo, tt.hi)
+				}
+				below = below || d < interval
+				above = above || d > interval
+			}
+			if tt.lo != tt.hi && !(below && above) {
+				t.Errorf("delays never spread both sides of %v (below=%v above=%v)", interval, below, above)
+			}
+		})
+	}
+}
+
+func TestWithJitter_CancelDuringWait(t *testing.T) {
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := TraceURL(ctx, ts.URL,
+		WithEmitter(&testEmitter{}),
+		WithCount(2),
+		WithInterval(time.Hour),
+		WithJitter(0.5),
+	)
+	if err == nil {
+		t.Fatal("error = nil, want context error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("returned after %v, want the jittered wait cut short by cancellation", elapsed)
+	}
+}
+
+func TestTraceURL_WithTraceID(t *testing.T) {
+	em := &testEmitter{}
+	err := TraceURL(context.Background(), "https://example.com",
+		WithEmitter(em),
+		WithDryRun(true),
+		WithTraceID("req-1234"),
+	)
+	if err != nil {
+		t.Fatalf("TraceURL() error = %v", err)
+	}
+	if len(em.events) == 0 {
+		t.Fatal("no events emitted")
+	}
+	for _, ev := range em.events {
+		if ev.TraceID != "req-1234" {
+			t.Errorf("%s TraceID = %q, want %q", ev.Type, ev.TraceID, "req-1234")
+		}
+	}
+
+	err = TraceURL(context.Background(), "https://example.com", WithDryRun(true), WithTraceID(""))
+	if !errors.Is(err, event.ErrEmptyTraceID) {
+		t.Errorf("TraceURL() with empty trace ID error = %v, want %v", err, event.ErrEmptyTraceID)
+	}
+}
+
+// testEmitter captures emitted events for inspection.
+type testEmitter struct{ events []event.Event }
+
+func (e *testEmitter) Emit(ev event.Event) error { e.events = append(e.events, ev); return nil }
+func (e *testEmitter) Close() error              { return nil }
+
+func TestTraceURL_HeadOnly(t *testing.T) {
+	const bodySize = 256 << 20 // far more than socket buffers can absorb
+	written := make(chan int64, 1)
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Header().Set("Content-Length", fmt.Sprint(bodySize))
+		w.Header().Set("X-Check", "ok")
+		w.WriteHeader(nethttp.StatusOK)
+		chunk := make([]byte, 64<<10)
+		var n int64
+		for n < bodySize {
+			m, err := w.Write(chunk)
+			n += int64(m)
+			if err != nil {
+				break
+			}
+		}
+		written <- n
+	}))
+	defer ts.Close()
+
+	em := &testEmitter{}
+	if err := TraceURL(context.Background(), ts.URL, WithEmitter(em), WithHeadOnly(true)); err != nil {
+		t.Fatalf("TraceURL() error = %v", err)
+	}
+
+	var done *event.Event
+	for i := range em.events {
+		if em.events[i].Type == "http_response_done" {
+			done = &em.events[i]
+		}
+	}
+	if done == nil {
+		t.Fatal("missing http_response_done event")
+	}
+	if done.Data["status"] != 200 {
+		t.Errorf("status = %v, want 200", done.Data["status"])
+	}
+	if done.Data["body_size"] != 0 || done.Data["body_skipped"] != true {
+		t.Errorf("body_size = %v, body_skipped = %v, want 0 and true", done.Data["body_size"], done.Data["body_skipped"])
+	}
+	if h, _ := done.Data["headers"].(map[string]interface{}); h["X-Check"] != "ok" {
+		t.Errorf("headers = %v, want X-Check: ok", done.Data["headers"])
+	}
+
+	select {
+	case n := <-written:
+		if n >= bodySize {
+			t.Errorf("server wrote the whole %d-byte body; want the client to stop reading early", n)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("server still writing the body; connection was not closed")
+	}
+}
+
+func TestTraceURL_AcceptEncoding(t *testing.T) {
+	payload := strings.Repeat("compress me ", 100)
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	zw.Write([]byte(payload))
+	zw.Close()
+
+	var gotAccept string
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		gotAccept = r.Header.Get("Accept-Encoding")
+		if strings.Contains(gotAccept, "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(gzipped.Bytes())
+			return
+		}
+		w.Write([]byte(payload))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name         string
+		enc          string
+		wantAccept   string
+		wantEncoding interface{} // nil = field absent
+		wantDecoded  bool
+		wantBodySize int
+	}{
+		{"transport default", "", "gzip", "gzip", true, len(payload)},
+		{"explicit gzip", "gzip", "gzip", "gzip", false, gzipped.Len()},
+		{"identity", "identity", "identity", nil, false, len(payload)},
+		{"unsupported br", "br", "br", nil, false, len(payload)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := &testEmitter{}
+			opts := []Option{WithEmitter(em)}
+			if tt.enc != "" {
+				opts = append(opts, WithAcceptEncoding(tt.enc))
+			}
+			if err := TraceURL(context.Background(), ts.URL, opts...); err != nil {
+				t.Fatalf("TraceURL() error = %v", err)
+			}
+			if gotAccept != tt.wantAccept {
+				t.Errorf("server got Accept-Encoding %q, want %q", gotAccept, tt.wantAccept)
+			}
+
+			for _, ev := range em.events {
+				switch ev.Type {
+				case "http_request_start":
+					if tt.enc != "" && ev.Data["accept_encoding"] != tt.enc {
+						t.Errorf("http_request_start accept_encoding = %v, want %q", ev.Data["accept_encoding"], tt.enc)
+					}
+				case "http_response_done":
+					if got := ev.Data["content_encoding"]; got != tt.wantEncoding {
+						t.Errorf("content_encoding = %v, want %v", got, tt.wantEncoding)
+					}
+					if got := ev.Data["decoded"]; got != tt.wantDecoded {
+						t.Errorf("decoded = %v, want %v", got, tt.wantDecoded)
+					}
+					if got := ev.Data["body_size"]; got != tt.wantBodySize {
+						t.Errorf("body_size = %v, want %d", got, tt.wantBodySize)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTraceURL_TraceSummary(t *testing.T) {
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Write([]byte("ok"))
+	}))
+	closed := httptest.NewServer(nethttp.NotFoundHandler())
+	closedURL := closed.URL
+	closed.Close()
+	defer ts.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		wantOK     bool
+		wantPhases []string
+	}{
+		{"success", ts.URL, true, []string{"connect", "ttfb", "transfer"}},
+		{"connection refused", closedURL, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := &testEmitter{}
+			err := TraceURL(context.Background(), tt.url, WithEmitter(em))
+			if (err == nil) != tt.wantOK {
+				t.Fatalf("TraceURL() error = %v, want ok = %v", err, tt.wantOK)
+			}
+
+			last := em.events[len(em.events)-1]
+			if last.Type != event.TraceSummary {
+				t.Fatalf("last event = %q, want %s", last.Type, event.TraceSummary)
+			}
+			if last.Data["ok"] != tt.wantOK {
+				t.Errorf("trace_summary ok = %v, want %v", last.Data["ok"], tt.wantOK)
+			}
+			if _, ok := last.Data["total_ms"].(int64); !ok {
+				t.Errorf("trace_summary total_ms = %T, want int64", last.Data["total_ms"])
+			}
+			phases, _ := last.Data["phases"].(map[string]interface{})
+			for _, name := range tt.wantPhases {
+				if _, ok := phases[name]; !ok {
+					t.Errorf("trace_summary phases missing %q: %v", name, phases)
+				}
+			}
+		})
+	}
+}
+
+func TestTraceURL_Timeouts(t *testing.T) {
+	slow := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	tests := []struct {
+		name        string
+		url         string
+		opts        []Option
+		wantTimeout string
+	}{
+		{
+			// 10.255.255.1 is unroutable, so the dial hangs until the timeout.
+			name:        "connect",
+			url:         "http://10.255.255.1/",
+			opts:        []Option{WithConnectTimeout(50 * time.Millisecond), WithTotalTimeout(5 * time.Second)},
+			wantTimeout: "connect",
+		},
+		{
+			name:        "total",
+			url:         slow.URL,
+			opts:        []Option{WithConnectTimeout(time.Second), WithTotalTimeout(100 * time.Millisecond)},
+			wantTimeout: "total",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := &testEmitter{}
+			err := TraceURL(context.Background(), tt.url, append(tt.opts, WithEmitter(em))...)
+			if err == nil {
+				t.Fatal("TraceURL() error = nil, want timeout")
+			}
+
+			var traceErr *event.Event
+			for i, ev := range em.events {
+				if ev.Type == "trace_error" {
+					traceErr = &em.events[i]
+				}
+			}
+			if traceErr == nil {
+				t.Fatal("missing trace_error event")
+			}
+			if tt.wantTimeout == "connect" && traceErr.Data["timeout"] == nil {
+				t.Skipf("dial to unroutable address failed without timing out: %v", err)
+			}
+			if got := traceErr.Data["timeout"]; got != tt.wantTimeout {
+				t.Errorf("trace_error timeout = %v, want %q", got, tt.wantTimeout)
+			}
+			if !strings.Contains(err.Error(), tt.wantTimeout+" timeout") {
+				t.Errorf("TraceURL() error = %q, want it to name the %s timeout", err, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestTimeoutKind(t *testing.T) {
+	dialTimeout := &net.OpError{Op: "dial", Net: "tcp", Err: &timeoutError{}}
+	readTimeout := &net.OpError{Op: "read", Net: "tcp", Err: &timeoutError{}}
+
+	expired, cancel := context.WithTimeoutCause(context.Background(), 0, errTotalTimeout)
+	defer cancel()
+	<-expired.Done()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want string
+	}{
+		{"dial timeout", context.Background(), fmt.Errorf("wrapped: %w", dialTimeout), "connect"},
+		{"read timeout", context.Background(), readTimeout, ""},
+		{"total deadline", expired, context.DeadlineExceeded, "total"},
+		{"other error", context.Background(), errors.New("boom"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timeoutKind(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("timeoutKind() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// timeoutError is a net.Error that reports a timeout.
+type timeoutError struct{}
+
+func (*timeoutError) Error() string   { return "i/o timeout" }
+func (*timeoutError) Timeout() bool   { return true }
+func (*timeoutError) Temporary() bool { return true }
+
+// failAfterWriter accepts the first n writes, then fails every write.
+type failAfterWriter struct{ n int }
+
+func (w *failAfterWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("broken pipe")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestTraceURL_EmitError(t *testing.T) {
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	for _, dryRun := range []bool{false, true} {
+		em := formatter.NewNDJSONEmitter(&failAfterWriter{n: 1})
+		err := TraceURL(context.Background(), ts.URL, WithEmitter(em), WithDryRun(dryRun))
+		if err == nil || !strings.Contains(err.Error(), "emit failed: broken pipe") {
+			t.Errorf("TraceURL(dryRun=%v) error = %v, want emit failed: broken pipe", dryRun, err)
+		}
+	}
+}
+
+func TestTraceURL_UserAgent(t *testing.T) {
+	got := make(chan string, 1)
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		got <- r.UserAgent()
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, DefaultUserAgent},
+		{"option", []Option{WithUserAgent("cure/v1.2.3")}, "cure/v1.2.3"},
+		{"header wins", []Option{WithUserAgent("cure/v1.2.3"), WithHeaders(map[string]string{"User-Agent": "probe/2"})}, "probe/2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := &testEmitter{}
+			if err := TraceURL(context.Background(), ts.URL, append(tt.opts, WithEmitter(em))...); err != nil {
+				t.Fatalf("TraceURL() error = %v", err)
+			}
+			if ua := <-got; ua != tt.want {
+				t.Errorf("server saw User-Agent %q, want %q", ua, tt.want)
+			}
+			if ua := em.events[0].Data["user_agent"]; ua != tt.want {
+				t.Errorf("http_request_start user_agent = %v, want %q", ua, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithHostHeader(t *testing.T) {
+	got := make(chan string, 1)
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		got <- r.Host
+	}))
+	defer ts.Close()
+	authority := strings.TrimPrefix(ts.URL, "http://")
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, authority},
+		{"override", []Option{WithHostHeader("vhost.example.com")}, "vhost.example.com"},
+		{"override with port", []Option{WithHostHeader("vhost.example.com:8443")}, "vhost.example.com:8443"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := Collect(context.Background(), ts.URL, tt.opts...)
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if host := <-got; host != tt.want {
+				t.Errorf("server saw Host %q, want %q", host, tt.want)
+			}
+			if host := events[0].Data["host"]; host != tt.want {
+				t.Errorf("http_request_start host = %v, want %q", host, tt.want)
+			}
+		})
+	}
+
+	events, err := Collect(context.Background(), "https://203.0.113.10/", WithDryRun(true), WithHostHeader("vhost.example.com"))
+	if err != nil {
+		t.Fatalf("Collect(dry run) error = %v", err)
+	}
+	if host := events[0].Data["host"]; host != "vhost.example.com" {
+		t.Errorf("dry-run http_request_start host = %v, want vhost.example.com", host)
+	}
+}
+
+// stubRoundTripper answers every request in memory. It fires the
+// GotFirstResponseByte hook, as a transport honouring httptrace would.
+type stubRoundTripper struct {
+	status int
+	err    error
+	reqs   []*nethttp.Request
+}
+
+func (s *stubRoundTripper) RoundTrip(req *nethttp.Request) (*nethttp.Response, error) {
+	s.reqs = append(s.reqs, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	if trace := httptrace.ContextClientTrace(req.Context()); trace != nil && trace.GotFirstResponseByte != nil {
+		trace.GotFirstResponseByte()
+	}
+	return &nethttp.Response{
+		StatusCode: s.status,
+		Header:     nethttp.Header{"X-Stub": {"1"}},
+		Body:       io.NopCloser(strings.NewReader("stub body")),
+		Request:    req,
+	}, nil
+}
+
+func TestTraceURL_Transport(t *testing.T) {
+	rt := &stubRoundTripper{status: nethttp.StatusTeapot}
+	em := &testEmitter{}
+	err := TraceURL(context.Background(), "http://stub.invalid/brew",
+		WithEmitter(em),
+		WithTransport(rt),
+		WithHeaders(map[string]string{"X-Test": "yes"}),
+	)
+	if err != nil {
+		t.Fatalf("TraceURL() error = %v", err)
+	}
+
+	if len(rt.reqs) != 1 || rt.reqs[0].Header.Get("X-Test") != "yes" {
+		t.Fatalf("stub got %d requests (headers %v), want 1 with X-Test", len(rt.reqs), rt.reqs)
+	}
+	want := []string{"http_request_start", "ttfb", "http_response_done", event.TraceSummary}
+	if got := eventTypes(em.events); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	for _, ev := range em.events {
+		if ev.Type != "http_response_done" {
+			continue
+		}
+		if ev.Data["status"] != nethttp.StatusTeapot || ev.Data["body_size"] != len("stub body") {
+			t.Errorf("http_response_done = %v, want status 418 and the stub body size", ev.Data)
+		}
+	}
+}
+
+func TestTraceURL_Transport_Error(t *testing.T) {
+	rt := &stubRoundTripper{err: errors.New("stub refused")}
+	em := &testEmitter{}
+	err := TraceURL(context.Background(), "http://stub.invalid/", WithEmitter(em), WithTransport(rt))
+	if err == nil || !strings.Contains(err.Error(), "stub refused") {
+		t.Fatalf("TraceURL() error = %v, want the transport error", err)
+	}
+	var sawError bool
+	for _, ev := range em.events {
+		if ev.Type == "trace_error" {
+			sawError = true
+		}
+	}
+	if !sawError {
+		t.Errorf("events = %v, want a trace_error", eventTypes(em.events))
+	}
+}
+
+func TestTraceURL_FormData(t *testing.T) {
+	var gotType string
+	var gotForm map[string][]string
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		gotType = r.Header.Get("Content-Type")
+		r.ParseForm()
+		gotForm = r.PostForm
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	em := &testEmitter{}
+	err := TraceURL(context.Background(), ts.URL,
+		WithEmitter(em),
+		WithMethod("POST"),
+		WithFormData(map[string]string{"user": "alice", "note": "a&b c"}),
+	)
+	if err != nil {
+		t.Fatalf("TraceURL() error = %v", err)
+	}
+
+	if gotType != "application/x-www-form-urlencoded" {
+		t.Errorf("server Content-Type = %q", gotType)
+	}
+	if fmt.Sprint(gotForm) != "map[note:[a&b c] user:[alice]]" {
+		t.Errorf("server form = %v", gotForm)
+	}
+	start := em.events[0]
+	wantBody := "note=a%26b+c&user=alice"
+	if start.Data["content_type"] != "application/x-www-form-urlencoded" || start.Data["body_size"] != len(wantBody) {
+		t.Errorf("http_request_start = %v, want content_type and body_size %d", start.Data, len(wantBody))
+	}
+}
+
+func TestTraceURL_FormData_ContentTypeOverride(t *testing.T) {
+	var gotType string
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		gotType = r.Header.Get("Content-Type")
+	}))
+	defer ts.Close()
+
+	err := TraceURL(context.Background(), ts.URL,
+		WithEmitter(&testEmitter{}),
+		WithMethod("POST"),
+		WithFormData(map[string]string{"a": "1"}),
+		WithHeaders(map[string]string{"content-type": "application/x-www-form-urlencoded; charset=utf-8"}),
+	)
+	if err != nil {
+		t.Fatalf("TraceURL() error = %v", err)
+	}
+	if gotType != "application/x-www-form-urlencoded; charset=utf-8" {
+		t.Errorf("server Content-Type = %q, want the WithHeaders value", gotType)
+	}
+}
+
+func TestTraceURL_FormData_Conflict(t *testing.T) {
+	em := &testEmitter{}
+	err := TraceURL(context.Background(), "http://127.0.0.1:1",
+		WithEmitter(em),
+		WithBodyString("raw"),
+		WithFormData(map[string]string{"a": "1"}),
+	)
+	if !errors.Is(err, ErrBodyConflict) {
+		t.Fatalf("TraceURL() error = %v, want ErrBodyConflict", err)
+	}
+	if len(em.events) != 0 {
+		t.Errorf("emitted %v before failing", eventTypes(em.events))
+	}
+}
+
+func TestCollect(t *testing.T) {
+	ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(204)
+	}))
+	defer ts.Close()
+
+	em := &testEmitter{}
+	events, err := Collect(context.Background(), ts.URL, WithEmitter(em))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(em.events) != len(events) {
+		t.Errorf("WithEmitter emitter got %d events, Collect returned %d", len(em.events), len(events))
+	}
+	var status int
+	for _, ev := range events {
+		if code, ok := ResponseStatus(ev); ok {
+			status = code
+		}
+	}
+	if status != 204 {
+		t.Errorf("response status = %d, want 204", status)
+	}
+	if last := events[len(events)-1].Type; last != event.TraceSummary {
+		t.Errorf("last event = %q, want %q", last, event.TraceSummary)
+	}
+}
+
+func TestCollect_PartialOnError(t *testing.T) {
+	events, err := Collect(context.Background(), "http://127.0.0.1:1/", WithConnectTimeout(time.Second))
+	if err == nil {
+		t.Fatal("Collect() error = nil, want connection error")
+	}
+	if len(events) == 0 || events[0].Type != "http_request_start" {
+		t.Fatalf("Collect() events = %v, want the events before the failure", events)
+	}
+}
+
+func TestTraceURL_TLSResumption(t *testing.T) {
+	ts := httptest.NewTLSServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ts.Certificate())
+	em := &testEmitter{}
+	err := TraceURL(context.Background(), ts.URL,
+		WithEmitter(em),
+		WithTLSConfig(&tls.Config{RootCAs: roots}),
+		WithDisableKeepAlives(true),
+		WithCount(2),
+	)
+	if err != nil {
+		t.Fatalf("TraceURL() error = %v", err)
+	}
+
+	var resumed []interface{}
+	for _, ev := range em.events {
+		if ev.Type == event.TypeTLSHandshakeDone {
+			resumed = append(resumed, ev.Data["resumed"])
+		}
+	}
+	// The first handshake is full; the second, on a fresh connection,
+	// resumes the session ticket from the first.
+	if want := []interface{}{false, true}; !reflect.DeepEqual(resumed, want) {
+		t.Errorf("tls_handshake_done resumed = %v, want %v", resumed, want)
+	}
+}
+
+func TestWithLabels(t *testing.T) {
+	labels := map[string]string{"env": "prod", "region": "eu"}
+	events, err := Collect(context.Background(), "https://example.com", WithDryRun(true), WithLabels(labels))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(events) == 0 {
+		t.Fatal("Collect() returned no events")
+	}
+	for _, ev := range events {
+		if !maps.Equal(ev.Labels, labels) {
+			t.Errorf("%s labels = %v, want %v", ev.Type, ev.Labels, labels)
+		}
+	}
+}
+
+// slowListener delays every accepted connection, stalling the TLS handshake
+// of a server started on it.
+type slowListener struct {
+	net.Listener
+	delay time.Duration
+}
+
+func (l *slowListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		time.Sleep(l.delay)
+	}
+	return conn, err
+}
+
+func TestWithMaxTimePerPhase(t *testing.T) {
+	t.Run("slow TLS handshake", func(t *testing.T) {
+		ts := httptest.NewUnstartedServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {}))
+		ts.Listener = &slowListener{Listener: ts.Listener, delay: 500 * time.Millisecond}
+		ts.StartTLS()
+		defer ts.Close()
+		roots := x509.NewCertPool()
+		roots.AddCert(ts.Certificate())
+
+		em := &testEmitter{}
+		start := time.Now()
+		err := TraceURL(context.Background(), ts.URL,
+			WithEmitter(em),
+			WithTLSConfig(&tls.Config{RootCAs: roots}),
+			WithMaxTimePerPhase(50*time.Millisecond),
+		)
+		var phaseErr *PhaseTimeoutError
+		if !errors.As(err, &phaseErr) || phaseErr.Phase != "tls" {
+			t.Fatalf("TraceURL() error = %v, want a tls PhaseTimeoutError", err)
+		}
+		if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+			t.Errorf("trace took %s, want it aborted well before the handshake completes", elapsed)
+		}
+
+		var timeout, traceErr *event.Event
+		for i, ev := range em.events {
+			switch ev.Type {
+			case "phase_timeout":
+				timeout = &em.events[i]
+			case "trace_error":
+				traceErr = &em.events[i]
+			}
+		}
+		if timeout == nil || timeout.Data["phase"] != "tls" || timeout.Data["timeout_ms"] != int64(50) {
+			t.Errorf("phase_timeout = %+v, want phase tls and timeout_ms 50", timeout)
+		}
+		if traceErr == nil || traceErr.Data["timeout"] != "tls" {
+			t.Errorf("trace_error = %+v, want timeout tls", traceErr)
+		}
+	})
+
+	t.Run("slow first byte", func(t *testing.T) {
+		ts := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			select {
+			case <-time.After(500 * time.Millisecond):
+			case <-r.Context().Done():
+			}
+		}))
+		defer ts.Close()
+
+		err := TraceURL(context.Background(), ts.URL, WithMaxTimePerPhase(50*time.Millisecond))
+		var phaseErr *PhaseTimeoutError
+		if !errors.As(err, &phaseErr) || phaseErr.Phase != "ttfb" {
+			t.Fatalf("TraceURL() error = %v, want a ttfb PhaseTimeoutError", err)
+		}
+	})
+
+	t.Run("within budget", func(t *testing.T) {
+		ts := httptest.NewTLSServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {}))
+		defer ts.Close()
+		roots := x509.NewCertPool()
+		roots.AddCert(ts.Certificate())
+
+		em := &testEmitter{}
+		err := TraceURL(context.Background(), ts.URL,
+			WithEmitter(em),
+			WithTLSConfig(&tls.Config{RootCAs: roots}),
+			WithMaxTimePerPhase(5*time.Second),
+		)
+		if err != nil {
+			t.Fatalf("TraceURL() error = %v", err)
+		}
+		for _, ev := range em.events {
+			if ev.Type == "phase_timeout" {
+				t.Errorf("unexpected phase_timeout %v", ev.Data)
+			}
+		}
+	})
+}
+
+func TestWithTraceRetry(t *testing.T) {
+	// Reserve a port, then free it so the first attempts are refused.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	srv := &nethttp.Server{Handler: nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(204)
+	})}
+	defer srv.Close()
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		if ln, err := net.Listen("tcp", addr); err == nil {
+			srv.Serve(ln)
+		}
+	}()
+
+	events, err := Collect(context.Background(), "http://"+addr+"/", WithTraceRetry(5))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	var retries, status int
+	for _, ev := range events {
+		if ev.Type == event.TraceRetry {
+			retries++
+			if ev.Data["reason"] != "connection_refused" {
+				t.Errorf("trace_retry reason = %v, want connection_refused", ev.Data["reason"])
+			}
+		}
+		if code, ok := ResponseStatus(ev); ok {
+			status = code
+		}
+	}
+	if retries == 0 {
+		t.Error("no trace_retry event before the successful attempt")
+	}
+	if status != 204 {
+		t.Errorf("response status = %d, want 204", status)
+	}
+}
+
+func TestWithTraceRetry_Permanent(t *testing.T) {
+	events, err := Collect(context.Background(), "http://[::1", WithTraceRetry(5))
+	if err == nil {
+		t.Fatal("Collect() error = nil, want invalid URL")
+	}
+	for _, ev := range events {
+		if ev.Type == event.TraceRetry {
+			t.Errorf("permanent error was retried: %v", ev)
+		}
+	}
+}
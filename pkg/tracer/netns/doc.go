@@ -0,0 +1,5 @@
+// Package netns runs network operations inside a Linux network namespace,
+// such as a Kubernetes pod's, so a trace sees the namespace's interfaces,
+// routes, and DNS configuration instead of the host's. Other platforms
+// return [ErrUnsupported].
+package netns
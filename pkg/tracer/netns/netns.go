@@ -0,0 +1,80 @@
+package netns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mrlm-net/cure/pkg/tracer/resolvconf"
+)
+
+// ErrUnsupported is returned on platforms without network namespaces. It
+// wraps [errors.ErrUnsupported].
+var ErrUnsupported = fmt.Errorf("netns: network namespaces are only supported on Linux: %w", errors.ErrUnsupported)
+
+// Do runs fn on an OS thread that has entered the network namespace at path,
+// such as "/proc/1234/ns/net" or "/run/netns/blue", and returns its error.
+// Sockets fn creates belong to the namespace and keep working after Do
+// returns. Goroutines fn starts run on other threads, outside it.
+//
+// Entering a namespace needs CAP_SYS_ADMIN; without it Do fails with a
+// permission error before fn runs. The thread is discarded afterwards rather
+// than reused, so the rest of the program never runs in the namespace.
+func Do(path string, fn func() error) error {
+	return do(path, fn)
+}
+
+// DialContext dials address with d from inside the network namespace at
+// path. address should be an IP and port: a hostname would be resolved
+// outside the namespace, so resolve it first with [Resolver].
+func DialContext(ctx context.Context, path string, d *net.Dialer, network, address string) (net.Conn, error) {
+	var conn net.Conn
+	err := Do(path, func() error {
+		var err error
+		conn, err = d.DialContext(ctx, network, address)
+		return err
+	})
+	return conn, err
+}
+
+// Resolver returns a Go resolver whose queries leave from the network
+// namespace at path. They go to the namespace's nameserver when
+// [Nameserver] finds one, and to the host's otherwise. Search domains
+// and other resolv.conf options are still the host's.
+func Resolver(path string) *net.Resolver {
+	server := Nameserver(path)
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			if server != "" {
+				address = server
+			}
+			return DialContext(ctx, path, &net.Dialer{}, network, address)
+		},
+	}
+}
+
+// procNetns matches the network namespace of a process.
+var procNetns = regexp.MustCompile(`^/proc/(\d+|self)/ns/net$`)
+
+// Nameserver returns the first nameserver, as "IP:53", of the resolv.conf
+// used in the network namespace at path, or "" if it is not known. For
+// "/proc/<pid>/ns/net" that is the process's own /etc/resolv.conf, as seen
+// through /proc/<pid>/root; for a namespace in /run/netns created by
+// "ip netns add <name>" it is /etc/netns/<name>/resolv.conf.
+func Nameserver(path string) string {
+	path = filepath.Clean(path)
+	if m := procNetns.FindStringSubmatch(path); m != nil {
+		return resolvconf.NameserverFile(filepath.Join("/proc", m[1], "root/etc/resolv.conf"))
+	}
+	for _, dir := range []string{"/run/netns/", "/var/run/netns/"} {
+		if name, ok := strings.CutPrefix(path, dir); ok && !strings.Contains(name, "/") {
+			return resolvconf.NameserverFile(filepath.Join("/etc/netns", name, "resolv.conf"))
+		}
+	}
+	return ""
+}
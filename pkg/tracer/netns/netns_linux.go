@@ -0,0 +1,41 @@
+//go:build linux
+
+package netns
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+func do(path string, fn func() error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("netns: %w", err)
+	}
+	defer f.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		// Never unlocked: when the goroutine exits, the runtime ends the
+		// thread instead of handing it, still in the namespace, to other
+		// goroutines.
+		runtime.LockOSThread()
+		if err := setns(int(f.Fd())); err != nil {
+			errc <- fmt.Errorf("netns: enter %s: %w", path, err)
+			return
+		}
+		errc <- fn()
+	}()
+	return <-errc
+}
+
+// setns moves the calling thread into the network namespace open as fd.
+func setns(fd int) error {
+	_, _, errno := syscall.RawSyscall(sysSetns, uintptr(fd), syscall.CLONE_NEWNET, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
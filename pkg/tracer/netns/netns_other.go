@@ -0,0 +1,7 @@
+//go:build !linux
+
+package netns
+
+func do(string, func() error) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,100 @@
+package netns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/mrlm-net/cure/pkg/tracer/resolvconf"
+)
+
+// ownNetns is the network namespace the test runs in. Entering it changes
+// nothing, so Do can be exercised without creating a namespace.
+const ownNetns = "/proc/self/ns/net"
+
+// skipUnlessEnterable skips the test when the platform or the test's
+// privileges do not allow entering a network namespace.
+func skipUnlessEnterable(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("network namespaces are Linux only")
+	}
+	if err := Do(ownNetns, func() error { return nil }); errors.Is(err, syscall.EPERM) {
+		t.Skipf("cannot enter a network namespace: %v", err)
+	}
+}
+
+func TestDo(t *testing.T) {
+	skipUnlessEnterable(t)
+
+	called := false
+	if err := Do(ownNetns, func() error { called = true; return nil }); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if !called {
+		t.Error("Do() did not call fn")
+	}
+
+	want := errors.New("boom")
+	if err := Do(ownNetns, func() error { return want }); !errors.Is(err, want) {
+		t.Errorf("Do() error = %v, want fn's error", err)
+	}
+}
+
+func TestDo_MissingPath(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("network namespaces are Linux only")
+	}
+	err := Do(filepath.Join(t.TempDir(), "missing"), func() error {
+		t.Error("fn called for a missing namespace")
+		return nil
+	})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Do() error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestDo_Unsupported(t *testing.T) {
+	if runtime.GOOS == "linux" {
+		t.Skip("network namespaces are supported on Linux")
+	}
+	if err := Do(ownNetns, func() error { return nil }); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Do() error = %v, want errors.ErrUnsupported", err)
+	}
+}
+
+func TestDialContext(t *testing.T) {
+	skipUnlessEnterable(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+
+	conn, err := DialContext(context.Background(), ownNetns, &net.Dialer{}, "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("DialContext() error = %v", err)
+	}
+	conn.Close()
+}
+
+func TestNameserver(t *testing.T) {
+	if got := Nameserver("/tmp/not-a-namespace"); got != "" {
+		t.Errorf("Nameserver(unknown path) = %q, want empty", got)
+	}
+	// The test's own namespace uses the resolv.conf of the test's root.
+	if got, want := Nameserver(ownNetns), resolvconf.NameserverFile("/proc/self/root/etc/resolv.conf"); got != want {
+		t.Errorf("Nameserver(%q) = %q, want %q", ownNetns, got, want)
+	}
+}
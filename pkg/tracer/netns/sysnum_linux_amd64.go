@@ -0,0 +1,4 @@
+package netns
+
+// sysSetns is setns(2), missing from the syscall package on amd64.
+const sysSetns = 308
@@ -0,0 +1,3 @@
+// Package ratelimit provides a ticker-based limiter for pacing outbound
+// trace requests in repeat and batch modes.
+package ratelimit
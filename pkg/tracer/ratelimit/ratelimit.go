@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter paces callers to at most one request per 1/perSecond interval
+// with a [time.Ticker] started by the first Wait, which returns at once. A
+// tick nobody waited for is kept, so after an idle spell two requests may
+// follow each other closely. It is safe for concurrent use.
+//
+// A nil *Limiter never blocks, so callers can hold an optional limiter
+// without nil checks.
+type Limiter struct {
+	interval time.Duration
+	start    sync.Once
+	ticker   *time.Ticker
+}
+
+// New returns a Limiter allowing perSecond requests per second.
+// It returns nil (no limit) when perSecond <= 0.
+func New(perSecond float64) *Limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &Limiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// Wait blocks until the next tick or ctx is done. It returns ctx.Err() if
+// the context ends first; in that case no tick is consumed.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	first := false
+	l.start.Do(func() {
+		// Unreferenced tickers are garbage collected, so it needs no Stop.
+		l.ticker = time.NewTicker(l.interval)
+		first = true
+	})
+	if first {
+		return ctx.Err()
+	}
+	select {
+	case <-l.ticker.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Wait_Paces(t *testing.T) {
+	const (
+		n         = 5
+		perSecond = 20.0
+	)
+	l := New(perSecond)
+
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	elapsed := time.Since(start)
+
+	// The first token is immediate; each following one waits one interval.
+	want := time.Duration(n-1) * time.Duration(float64(time.Second)/perSecond)
+	if elapsed < want {
+		t.Errorf("%d waits took %v, want at least %v", n, elapsed, want)
+	}
+}
+
+func TestLimiter_Wait_ContextCancelled(t *testing.T) {
+	l := New(1) // one token per second
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := l.Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Wait() returned after %v, want prompt return on cancellation", elapsed)
+	}
+}
+
+func TestLimiter_Nil(t *testing.T) {
+	tests := []struct {
+		name      string
+		perSecond float64
+	}{
+		{"zero", 0},
+		{"negative", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(tt.perSecond)
+			if l != nil {
+				t.Fatalf("New(%v) = %v, want nil", tt.perSecond, l)
+			}
+			start := time.Now()
+			for i := 0; i < 100; i++ {
+				if err := l.Wait(context.Background()); err != nil {
+					t.Fatalf("Wait() error = %v", err)
+				}
+			}
+			if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+				t.Errorf("nil limiter blocked for %v", elapsed)
+			}
+		})
+	}
+}
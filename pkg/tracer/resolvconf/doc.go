@@ -0,0 +1,3 @@
+// Package resolvconf reports the DNS nameserver the system resolver is
+// configured to use, so trace events can say which resolver answered.
+package resolvconf
@@ -0,0 +1,72 @@
+package resolvconf
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// path is the resolver configuration read by Nameserver.
+const path = "/etc/resolv.conf"
+
+// Nameserver returns the first nameserver listed in /etc/resolv.conf as
+// "IP:53", or "" when it cannot be determined (no file, as on Windows, or
+// no usable entry). The file is read once and the result cached for the
+// life of the process.
+//
+// The Go resolver may fall through to later nameservers, so this is the
+// resolver that is asked first rather than proof of which one answered.
+func Nameserver() string {
+	return nameserver()
+}
+
+var nameserver = sync.OnceValue(func() string {
+	return NameserverFile(path)
+})
+
+// NameserverFile is like [Nameserver] for the resolv.conf at name, such as
+// the one a container or network namespace uses. The file is read on every
+// call.
+func NameserverFile(name string) string {
+	f, err := os.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	return parse(f)
+}
+
+// parse returns the first valid "nameserver <IP>" entry of a resolv.conf,
+// joined with port 53. Comments, other directives, and entries that are not
+// IP addresses are skipped. IPv6 zones are kept ("fe80::1%eth0").
+func parse(r io.Reader) string {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		addr := fields[1]
+		host, _, _ := strings.Cut(addr, "%")
+		if net.ParseIP(host) == nil {
+			continue
+		}
+		return net.JoinHostPort(addr, "53")
+	}
+	return ""
+}
+
+// AddResolver sets data["resolver"] to server, or to [Nameserver] when server
+// is empty. Tracers call it for their DNS events; nothing is added when the
+// resolver cannot be determined.
+func AddResolver(data map[string]interface{}, server string) {
+	if server == "" {
+		server = Nameserver()
+	}
+	if server != "" {
+		data["resolver"] = server
+	}
+}
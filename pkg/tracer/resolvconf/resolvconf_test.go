@@ -0,0 +1,63 @@
+package resolvconf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		conf string
+		want string
+	}{
+		{"first nameserver", "search corp.example\nnameserver 10.0.0.2\nnameserver 1.1.1.1\n", "10.0.0.2:53"},
+		{"systemd stub", "# managed by systemd-resolved\nnameserver 127.0.0.53\noptions edns0 trust-ad\n", "127.0.0.53:53"},
+		{"ipv6", "nameserver 2001:4860:4860::8888\n", "[2001:4860:4860::8888]:53"},
+		{"ipv6 zone", "nameserver fe80::1%eth0\n", "[fe80::1%eth0]:53"},
+		{"skips invalid entry", "nameserver\nnameserver resolver.local\n  nameserver  8.8.8.8  \n", "8.8.8.8:53"},
+		{"commented out", "#nameserver 10.0.0.2\n; nameserver 10.0.0.3\n", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parse(strings.NewReader(tt.conf)); got != tt.want {
+				t.Errorf("parse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddResolver(t *testing.T) {
+	data := map[string]interface{}{}
+	AddResolver(data, "10.0.0.2:53")
+	if data["resolver"] != "10.0.0.2:53" {
+		t.Errorf("resolver = %v, want the explicit server", data["resolver"])
+	}
+
+	data = map[string]interface{}{}
+	AddResolver(data, "")
+	got, ok := data["resolver"]
+	if ns := Nameserver(); ns == "" {
+		if ok {
+			t.Errorf("resolver = %v, want none when the system nameserver is unknown", got)
+		}
+	} else if got != ns {
+		t.Errorf("resolver = %v, want system nameserver %q", got, ns)
+	}
+}
+
+func TestNameserverFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "resolv.conf")
+	if err := os.WriteFile(name, []byte("search cluster.local\nnameserver 10.96.0.10\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if got := NameserverFile(name); got != "10.96.0.10:53" {
+		t.Errorf("NameserverFile() = %q, want %q", got, "10.96.0.10:53")
+	}
+	if got := NameserverFile(filepath.Join(t.TempDir(), "missing")); got != "" {
+		t.Errorf("NameserverFile(missing) = %q, want empty", got)
+	}
+}
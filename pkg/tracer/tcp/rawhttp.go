@@ -0,0 +1,96 @@
+package tcp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// maxRawHTTPHead caps how much of the response readHTTPHead buffers while
+// looking for the end of the header block.
+const maxRawHTTPHead = 64 << 10
+
+// readHTTPHead reads from conn until it has seen the end of an HTTP header
+// block, the peer closes the connection, or more than limit bytes have
+// arrived, and returns what was read; it never reads more than limit+1
+// bytes. The error is nil in all three cases and is only set when a read
+// fails, such as on a deadline, alongside the bytes read before it.
+func readHTTPHead(conn net.Conn, limit int) ([]byte, error) {
+	var head []byte
+	buf := make([]byte, 4096)
+	for len(head) <= limit {
+		n, err := conn.Read(buf[:min(len(buf), limit+1-len(head))])
+		head = append(head, buf[:n]...)
+		if headerEnd(head) > 0 {
+			return head, nil
+		}
+		if errors.Is(err, io.EOF) {
+			return head, nil
+		}
+		if err != nil {
+			return head, err
+		}
+	}
+	return head, nil
+}
+
+// headerEnd returns the length of the header block in b, including the
+// blank line that ends it, or 0 if the block is incomplete. Bare "\n" line
+// endings are accepted, as net/http does.
+func headerEnd(b []byte) int {
+	crlf := bytes.Index(b, []byte("\r\n\r\n"))
+	lf := bytes.Index(b, []byte("\n\n"))
+	switch {
+	case crlf >= 0 && (lf < 0 || crlf < lf):
+		return crlf + 4
+	case lf >= 0:
+		return lf + 2
+	}
+	return 0
+}
+
+// parseRawHTTP inspects the start of a raw HTTP/1.x response and returns
+// the data for an http_raw_response event: "proto", "status_code", "reason",
+// "header_bytes" (status line and headers, including the terminating blank
+// line), and "header_count". "truncated" is set when the header block did not
+// end within b. A response that does not start with an HTTP status line
+// yields only "error".
+func parseRawHTTP(b []byte) map[string]interface{} {
+	if len(b) == 0 {
+		return map[string]interface{}{"error": "no response received"}
+	}
+
+	size := headerEnd(b)
+	truncated := size == 0
+	if truncated {
+		size = len(b)
+	}
+	lines := strings.Split(strings.TrimRight(string(b[:size]), "\r\n"), "\n")
+
+	statusLine := strings.TrimSuffix(lines[0], "\r")
+	proto, rest, _ := strings.Cut(statusLine, " ")
+	code, reason, _ := strings.Cut(rest, " ")
+	status, err := strconv.Atoi(code)
+	if !strings.HasPrefix(proto, "HTTP/") || len(code) != 3 || err != nil {
+		if len(statusLine) > 64 {
+			statusLine = statusLine[:64]
+		}
+		return map[string]interface{}{"error": fmt.Sprintf("response is not HTTP: status line %q", statusLine)}
+	}
+
+	data := map[string]interface{}{
+		"proto":        proto,
+		"status_code":  status,
+		"reason":       reason,
+		"header_bytes": size,
+		"header_count": len(lines) - 1,
+	}
+	if truncated {
+		data["truncated"] = true
+	}
+	return data
+}
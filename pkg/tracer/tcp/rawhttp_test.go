@@ -0,0 +1,130 @@
+package tcp
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRawHTTP(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want map[string]interface{}
+	}{
+		{
+			name: "complete",
+			in:   "HTTP/1.1 301 Moved Permanently\r\nLocation: /new\r\nContent-Length: 0\r\n\r\nbody",
+			want: map[string]interface{}{
+				"proto": "HTTP/1.1", "status_code": 301, "reason": "Moved Permanently",
+				"header_bytes": 69, "header_count": 2,
+			},
+		},
+		{
+			name: "bare newlines",
+			in:   "HTTP/1.0 200 OK\nServer: x\n\n",
+			want: map[string]interface{}{
+				"proto": "HTTP/1.0", "status_code": 200, "reason": "OK",
+				"header_bytes": 27, "header_count": 1,
+			},
+		},
+		{
+			name: "truncated",
+			in:   "HTTP/1.1 204 No Content\r\nDate: today\r\n",
+			want: map[string]interface{}{
+				"proto": "HTTP/1.1", "status_code": 204, "reason": "No Content",
+				"header_bytes": 38, "header_count": 1, "truncated": true,
+			},
+		},
+		{
+			name: "not http",
+			in:   "SSH-2.0-OpenSSH_9.6\r\n",
+			want: map[string]interface{}{"error": `response is not HTTP: status line "SSH-2.0-OpenSSH_9.6"`},
+		},
+		{
+			name: "bad status code",
+			in:   "HTTP/1.1 20 OK\r\n\r\n",
+			want: map[string]interface{}{"error": `response is not HTTP: status line "HTTP/1.1 20 OK"`},
+		},
+		{
+			name: "empty",
+			in:   "",
+			want: map[string]interface{}{"error": "no response received"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRawHTTP([]byte(tt.in)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRawHTTP(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraceAddr_ParseHTTP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Read(make([]byte, 1024))
+		// Split the header block across writes and keep the connection
+		// open, so the tracer has to read until the blank line.
+		conn.Write([]byte("HTTP/1.1 404 Not Found\r\nContent-"))
+		time.Sleep(20 * time.Millisecond)
+		conn.Write([]byte("Length: 0\r\n\r\n"))
+		<-done
+	}()
+
+	em := &testEmitter{}
+	err = TraceAddr(context.Background(), listener.Addr().String(),
+		WithEmitter(em),
+		WithDataString("GET /missing HTTP/1.1\r\nHost: localhost\r\n\r\n"),
+		WithParseHTTP(true),
+	)
+	if err != nil {
+		t.Fatalf("TraceAddr() error = %v", err)
+	}
+
+	var found bool
+	for _, ev := range em.events {
+		switch ev.Type {
+		case "tcp_receive":
+			if ev.Data["bytes"] != 45 {
+				t.Errorf("tcp_receive bytes = %v, want 45", ev.Data["bytes"])
+			}
+		case "http_raw_response":
+			found = true
+			if ev.Data["status_code"] != 404 || ev.Data["header_bytes"] != 45 || ev.Data["header_count"] != 1 {
+				t.Errorf("http_raw_response = %v", ev.Data)
+			}
+		}
+	}
+	if !found {
+		t.Error("missing http_raw_response event")
+	}
+}
+
+func TestTraceAddr_ParseHTTP_DryRun(t *testing.T) {
+	em := &testEmitter{}
+	if err := TraceAddr(context.Background(), "example.com:80", WithEmitter(em), WithDryRun(true), WithParseHTTP(true)); err != nil {
+		t.Fatalf("TraceAddr() error = %v", err)
+	}
+	for _, ev := range em.events {
+		if ev.Type == "http_raw_response" {
+			return
+		}
+	}
+	t.Error("dry run with WithParseHTTP missing http_raw_response event")
+}
@@ -0,0 +1,201 @@
+package tcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+)
+
+// ProxyAuth holds SOCKS5 username/password credentials (RFC 1929).
+type ProxyAuth struct {
+	User     string
+	Password string
+}
+
+// The SOCKS5 client below is hand-written rather than built on
+// golang.org/x/net/proxy: that package only returns a connected net.Conn, so
+// it hides the negotiated auth method and the proxy's bound address, and
+// runs the greeting and CONNECT as one step. The proxy_connect event and
+// tcp_connect_done's proxy_bind_addr need all three.
+
+// SOCKS5 protocol constants (RFC 1928, RFC 1929).
+const (
+	socks5Version = 0x05
+
+	socks5MethodNone     = 0x00
+	socks5MethodPassword = 0x02
+	socks5MethodNoAccept = 0xff
+
+	socks5CmdConnect = 0x01
+
+	socks5AtypIPv4   = 0x01
+	socks5AtypDomain = 0x03
+	socks5AtypIPv6   = 0x04
+
+	socks5AuthVersion = 0x01
+)
+
+// socks5Replies maps CONNECT reply codes to human-readable messages.
+var socks5Replies = map[byte]string{
+	0x01: "general SOCKS server failure",
+	0x02: "connection not allowed by ruleset",
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "TTL expired",
+	0x07: "command not supported",
+	0x08: "address type not supported",
+}
+
+// socks5Handshake negotiates an authentication method with the proxy on conn
+// and authenticates when the proxy asks for username/password.
+// It returns the method used: "none" or "password".
+func socks5Handshake(conn net.Conn, auth *ProxyAuth) (string, error) {
+	methods := []byte{socks5MethodNone}
+	if auth != nil {
+		methods = []byte{socks5MethodNone, socks5MethodPassword}
+	}
+	greeting := append([]byte{socks5Version, byte(len(methods))}, methods...)
+	if _, err := conn.Write(greeting); err != nil {
+		return "", fmt.Errorf("send greeting: %w", err)
+	}
+
+	var resp [2]byte
+	if _, err := io.ReadFull(conn, resp[:]); err != nil {
+		return "", fmt.Errorf("read method selection: %w", err)
+	}
+	if resp[0] != socks5Version {
+		return "", fmt.Errorf("unexpected SOCKS version %d", resp[0])
+	}
+
+	switch resp[1] {
+	case socks5MethodNone:
+		return "none", nil
+	case socks5MethodPassword:
+		if auth == nil {
+			return "", errors.New("proxy requires username/password authentication")
+		}
+		if err := socks5Authenticate(conn, auth); err != nil {
+			return "", err
+		}
+		return "password", nil
+	case socks5MethodNoAccept:
+		return "", errors.New("proxy accepted none of the offered authentication methods")
+	default:
+		return "", fmt.Errorf("proxy selected unsupported authentication method %#x", resp[1])
+	}
+}
+
+// socks5Authenticate performs RFC 1929 username/password authentication.
+func socks5Authenticate(conn net.Conn, auth *ProxyAuth) error {
+	if len(auth.User) > 255 || len(auth.Password) > 255 {
+		return errors.New("SOCKS5 username and password must be at most 255 bytes")
+	}
+	req := []byte{socks5AuthVersion, byte(len(auth.User))}
+	req = append(req, auth.User...)
+	req = append(req, byte(len(auth.Password)))
+	req = append(req, auth.Password...)
+	if _, err := conn.Write(req); err != nil {
+		return fmt.Errorf("send credentials: %w", err)
+	}
+
+	var resp [2]byte
+	if _, err := io.ReadFull(conn, resp[:]); err != nil {
+		return fmt.Errorf("read authentication status: %w", err)
+	}
+	if resp[1] != 0x00 {
+		return errors.New("proxy rejected username/password")
+	}
+	return nil
+}
+
+// socks5Connect asks the proxy on conn to connect to target (host:port).
+// IP hosts are sent as addresses; anything else is sent as a domain name for
+// the proxy to resolve. It returns the address the proxy bound for the
+// connection.
+func socks5Connect(conn net.Conn, target string) (string, error) {
+	host, portStr, err := net.SplitHostPort(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid target %q: %w", target, err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return "", fmt.Errorf("invalid port in %q: %w", target, err)
+	}
+
+	// A zone names an interface of this host, so it means nothing to the
+	// proxy, and sent as a domain name it would fail on the proxy's side.
+	if ip, err := netip.ParseAddr(host); err == nil && ip.Zone() != "" {
+		return "", fmt.Errorf("zoned address %q cannot be reached through a SOCKS5 proxy", host)
+	}
+
+	req := []byte{socks5Version, socks5CmdConnect, 0x00}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(req, socks5AtypIPv4)
+			req = append(req, ip4...)
+		} else {
+			req = append(req, socks5AtypIPv6)
+			req = append(req, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return "", fmt.Errorf("hostname %q too long for SOCKS5", host)
+		}
+		req = append(req, socks5AtypDomain, byte(len(host)))
+		req = append(req, host...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+	if _, err := conn.Write(req); err != nil {
+		return "", fmt.Errorf("send connect request: %w", err)
+	}
+
+	// Reply: VER REP RSV ATYP BND.ADDR BND.PORT
+	var hdr [4]byte
+	if _, err := io.ReadFull(conn, hdr[:]); err != nil {
+		return "", fmt.Errorf("read connect reply: %w", err)
+	}
+	if hdr[1] != 0x00 {
+		msg, ok := socks5Replies[hdr[1]]
+		if !ok {
+			msg = fmt.Sprintf("reply code %#x", hdr[1])
+		}
+		return "", fmt.Errorf("proxy could not connect to %s: %s", target, msg)
+	}
+
+	var bindHost string
+	switch hdr[3] {
+	case socks5AtypIPv4, socks5AtypIPv6:
+		size := net.IPv4len
+		if hdr[3] == socks5AtypIPv6 {
+			size = net.IPv6len
+		}
+		ip := make(net.IP, size)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", fmt.Errorf("read bound address: %w", err)
+		}
+		bindHost = ip.String()
+	case socks5AtypDomain:
+		var n [1]byte
+		if _, err := io.ReadFull(conn, n[:]); err != nil {
+			return "", fmt.Errorf("read bound address: %w", err)
+		}
+		name := make([]byte, n[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return "", fmt.Errorf("read bound address: %w", err)
+		}
+		bindHost = string(name)
+	default:
+		return "", fmt.Errorf("unexpected address type %#x in connect reply", hdr[3])
+	}
+
+	var bindPort [2]byte
+	if _, err := io.ReadFull(conn, bindPort[:]); err != nil {
+		return "", fmt.Errorf("read bound port: %w", err)
+	}
+	return net.JoinHostPort(bindHost, strconv.Itoa(int(binary.BigEndian.Uint16(bindPort[:])))), nil
+}
@@ -0,0 +1,315 @@
+package tcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+	"github.com/mrlm-net/cure/pkg/tracer/formatter"
+)
+
+// socks5Server is a minimal SOCKS5 CONNECT proxy for tests.
+type socks5Server struct {
+	listener net.Listener
+	auth     *ProxyAuth  // required credentials; nil = no auth
+	requests chan string // host:port requested by each CONNECT
+}
+
+func newSOCKS5Server(t *testing.T, auth *ProxyAuth) *socks5Server {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	s := &socks5Server{listener: ln, auth: auth, requests: make(chan string, 1)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *socks5Server) addr() string { return s.listener.Addr().String() }
+
+func (s *socks5Server) serve(conn net.Conn) {
+	defer conn.Close()
+
+	var hdr [2]byte
+	if _, err := io.ReadFull(conn, hdr[:]); err != nil {
+		return
+	}
+	methods := make([]byte, hdr[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+	want := byte(socks5MethodNone)
+	if s.auth != nil {
+		want = socks5MethodPassword
+	}
+	if !bytes.Contains(methods, []byte{want}) {
+		conn.Write([]byte{socks5Version, socks5MethodNoAccept})
+		return
+	}
+	conn.Write([]byte{socks5Version, want})
+
+	if s.auth != nil {
+		var b [1]byte
+		io.ReadFull(conn, b[:]) // version
+		io.ReadFull(conn, b[:])
+		user := make([]byte, b[0])
+		io.ReadFull(conn, user)
+		io.ReadFull(conn, b[:])
+		pass := make([]byte, b[0])
+		io.ReadFull(conn, pass)
+		if string(user) != s.auth.User || string(pass) != s.auth.Password {
+			conn.Write([]byte{socks5AuthVersion, 0x01})
+			return
+		}
+		conn.Write([]byte{socks5AuthVersion, 0x00})
+	}
+
+	var req [4]byte
+	if _, err := io.ReadFull(conn, req[:]); err != nil {
+		return
+	}
+	var host string
+	switch req[3] {
+	case socks5AtypIPv4:
+		ip := make(net.IP, net.IPv4len)
+		io.ReadFull(conn, ip)
+		host = ip.String()
+	case socks5AtypDomain:
+		var n [1]byte
+		io.ReadFull(conn, n[:])
+		name := make([]byte, n[0])
+		io.ReadFull(conn, name)
+		host = string(name)
+	default:
+		return
+	}
+	var portBuf [2]byte
+	io.ReadFull(conn, portBuf[:])
+	target := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBuf[:]))))
+	s.requests <- target
+
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		conn.Write([]byte{socks5Version, 0x05, 0x00, socks5AtypIPv4, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+
+	bound := upstream.LocalAddr().(*net.TCPAddr)
+	reply := []byte{socks5Version, 0x00, 0x00, socks5AtypIPv4}
+	reply = append(reply, bound.IP.To4()...)
+	reply = binary.BigEndian.AppendUint16(reply, uint16(bound.Port))
+	conn.Write(reply)
+
+	go io.Copy(upstream, conn)
+	io.Copy(conn, upstream)
+}
+
+// newEchoServer starts a TCP server that echoes whatever it receives.
+func newEchoServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// parseEvents decodes NDJSON output into events keyed by type.
+func parseEvents(t *testing.T, out string) map[string]event.Event {
+	t.Helper()
+	events := make(map[string]event.Event)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var ev event.Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v, line = %q", err, line)
+		}
+		events[ev.Type] = ev
+	}
+	return events
+}
+
+func TestTraceAddr_SOCKS5(t *testing.T) {
+	echoAddr := newEchoServer(t)
+	_, echoPort, _ := net.SplitHostPort(echoAddr)
+
+	tests := []struct {
+		name        string
+		target      string
+		serverAuth  *ProxyAuth
+		clientAuth  *ProxyAuth
+		proxyDNS    bool
+		noTimeout   bool
+		wantRequest string
+		wantAuth    string
+		wantDNS     string
+	}{
+		{
+			name:        "no auth, local DNS",
+			target:      echoAddr,
+			wantRequest: echoAddr,
+			wantAuth:    "none",
+			wantDNS:     "local",
+		},
+		{
+			name:        "password auth, proxy DNS",
+			target:      net.JoinHostPort("localhost", echoPort),
+			serverAuth:  &ProxyAuth{User: "alice", Password: "s3cret"},
+			clientAuth:  &ProxyAuth{User: "alice", Password: "s3cret"},
+			proxyDNS:    true,
+			wantRequest: net.JoinHostPort("localhost", echoPort),
+			wantAuth:    "password",
+			wantDNS:     "proxy",
+		},
+		{
+			// A zero timeout leaves the handshake without a deadline
+			// rather than one already in the past.
+			name:        "no timeout",
+			target:      echoAddr,
+			noTimeout:   true,
+			wantRequest: echoAddr,
+			wantAuth:    "none",
+			wantDNS:     "local",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := newSOCKS5Server(t, tt.serverAuth)
+			timeout := 5 * time.Second
+			if tt.noTimeout {
+				timeout = 0
+			}
+
+			var buf bytes.Buffer
+			err := TraceAddr(context.Background(), tt.target,
+				WithEmitter(formatter.NewNDJSONEmitter(&buf)),
+				WithSOCKS5(proxy.addr(), tt.clientAuth),
+				WithProxyDNS(tt.proxyDNS),
+				WithDataString("ping"),
+				WithTimeout(timeout),
+			)
+			if err != nil {
+				t.Fatalf("TraceAddr() error = %v", err)
+			}
+
+			if got := <-proxy.requests; got != tt.wantRequest {
+				t.Errorf("proxy CONNECT target = %q, want %q", got, tt.wantRequest)
+			}
+
+			events := parseEvents(t, buf.String())
+			pc, ok := events["proxy_connect"]
+			if !ok {
+				t.Fatal("missing proxy_connect event")
+			}
+			if pc.Data["auth"] != tt.wantAuth {
+				t.Errorf("proxy_connect auth = %v, want %q", pc.Data["auth"], tt.wantAuth)
+			}
+			if pc.Data["dns"] != tt.wantDNS {
+				t.Errorf("proxy_connect dns = %v, want %q", pc.Data["dns"], tt.wantDNS)
+			}
+			// The echo server's IP literal is skipped rather than looked up.
+			_, looked := events["dns_start"]
+			_, skipped := events["dns_skipped"]
+			if local := looked || skipped; local == tt.proxyDNS {
+				t.Errorf("local DNS events present = %v, want %v", local, !tt.proxyDNS)
+			}
+			if got := events["tcp_receive"].Data["bytes"]; got != float64(len("ping")) {
+				t.Errorf("tcp_receive bytes = %v, want %d", got, len("ping"))
+			}
+		})
+	}
+}
+
+func TestTraceAddr_SOCKS5_Errors(t *testing.T) {
+	echoAddr := newEchoServer(t)
+
+	// Grab a free port and close it so the proxy dial is refused.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	closedAddr := ln.Addr().String()
+	ln.Close()
+
+	authProxy := newSOCKS5Server(t, &ProxyAuth{User: "alice", Password: "s3cret"})
+
+	tests := []struct {
+		name      string
+		proxyAddr string
+		auth      *ProxyAuth
+		wantErr   string
+	}{
+		{"unreachable proxy", closedAddr, nil, "unreachable"},
+		{"missing credentials", authProxy.addr(), nil, "handshake"},
+		{"wrong password", authProxy.addr(), &ProxyAuth{User: "alice", Password: "nope"}, "rejected username/password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := TraceAddr(context.Background(), echoAddr,
+				WithEmitter(formatter.NewNDJSONEmitter(&buf)),
+				WithSOCKS5(tt.proxyAddr, tt.auth),
+				WithTimeout(2*time.Second),
+			)
+			if err == nil {
+				t.Fatal("TraceAddr() error = nil, want error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("TraceAddr() error = %q, want it to contain %q", err, tt.wantErr)
+			}
+
+			pc, ok := parseEvents(t, buf.String())["proxy_connect"]
+			if !ok {
+				t.Fatal("missing proxy_connect event")
+			}
+			if _, ok := pc.Data["error"]; !ok {
+				t.Error("proxy_connect event missing error field")
+			}
+		})
+	}
+}
+
+func TestTraceAddr_SOCKS5_ZonedTarget(t *testing.T) {
+	proxy := newSOCKS5Server(t, nil)
+	err := TraceAddr(context.Background(), "[fe80::1%eth0]:80",
+		WithEmitter(formatter.NewNDJSONEmitter(io.Discard)),
+		WithSOCKS5(proxy.addr(), nil),
+		WithTimeout(2*time.Second),
+	)
+	if err == nil || !strings.Contains(err.Error(), "zoned address") {
+		t.Errorf("TraceAddr() error = %v, want zoned address error", err)
+	}
+}
@@ -0,0 +1,139 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+)
+
+// startTLSProtocols maps the protocols accepted by WithStartTLS to the
+// exchange that asks the server to switch to TLS.
+var startTLSProtocols = map[string]func(r *textproto.Reader, w io.Writer) error{
+	"imap": startTLSIMAP,
+	"smtp": startTLSSMTP,
+}
+
+// upgradeTLS runs the WithStartTLS exchange, if any, on conn and then a TLS
+// handshake over it, emitting starttls, tls_handshake_start and
+// tls_handshake_done. serverName is the default for the SNI and certificate
+// check. Both steps are bounded by cfg.timeout, if set, and ctx. It returns the encrypted
+// connection.
+func upgradeTLS(ctx context.Context, cfg *traceConfig, traceID, serverName string, conn net.Conn) (net.Conn, error) {
+	conn.SetDeadline(handshakeDeadline(ctx, cfg.timeout))
+	defer conn.SetDeadline(time.Time{})
+
+	if cfg.startTLS != "" {
+		start := event.Now(cfg.clock)
+		// A fresh reader is safe: the server sends nothing after its
+		// go-ahead until the handshake starts.
+		err := startTLSProtocols[cfg.startTLS](textproto.NewReader(bufio.NewReader(conn)), conn)
+		duration := event.Since(cfg.clock, start)
+		cfg.phases.Add("starttls", duration.Milliseconds())
+		data := map[string]interface{}{
+			"protocol":    cfg.startTLS,
+			"duration_ms": duration.Milliseconds(),
+		}
+		if err != nil {
+			data["error"] = err.Error()
+			emit(cfg.emitter, "starttls", traceID, data)
+			return nil, fmt.Errorf("STARTTLS (%s) failed: %w", cfg.startTLS, err)
+		}
+		emit(cfg.emitter, "starttls", traceID, data)
+	}
+
+	tlsCfg := &tls.Config{}
+	if cfg.tlsConfig != nil {
+		tlsCfg = cfg.tlsConfig.Clone()
+	}
+	if tlsCfg.ServerName == "" {
+		tlsCfg.ServerName = serverName
+	}
+	tconn := tls.Client(conn, tlsCfg)
+
+	start := event.Now(cfg.clock)
+	emitEvent(cfg.emitter, event.TLSHandshakeStart(traceID))
+	err := tconn.HandshakeContext(ctx)
+	duration := event.Since(cfg.clock, start)
+	cfg.phases.Add("tls", duration.Milliseconds())
+	state := tconn.ConnectionState()
+	ev := event.TLSHandshakeDone(traceID, tls.VersionName(state.Version), duration)
+	ev.Data["server_name"] = tlsCfg.ServerName
+	if err != nil {
+		ev.Fail(err.Error())
+		emitEvent(cfg.emitter, ev)
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	ev.Data["cipher_suite"] = tls.CipherSuiteName(state.CipherSuite)
+	if state.NegotiatedProtocol != "" {
+		ev.Data["alpn"] = state.NegotiatedProtocol
+	}
+	emitEvent(cfg.emitter, ev)
+	return tconn, nil
+}
+
+// startTLSSMTP reads the SMTP greeting, sends EHLO, checks that STARTTLS is
+// offered, and sends it (RFC 3207).
+func startTLSSMTP(r *textproto.Reader, w io.Writer) error {
+	if _, _, err := r.ReadResponse(220); err != nil {
+		return fmt.Errorf("greeting: %w", err)
+	}
+	if _, err := io.WriteString(w, "EHLO localhost\r\n"); err != nil {
+		return err
+	}
+	_, msg, err := r.ReadResponse(250)
+	if err != nil {
+		return fmt.Errorf("EHLO: %w", err)
+	}
+	offered := false
+	for _, line := range strings.Split(msg, "\n") {
+		if f := strings.Fields(line); len(f) > 0 && strings.EqualFold(f[0], "STARTTLS") {
+			offered = true
+		}
+	}
+	if !offered {
+		return fmt.Errorf("server does not offer STARTTLS")
+	}
+	if _, err := io.WriteString(w, "STARTTLS\r\n"); err != nil {
+		return err
+	}
+	if _, _, err := r.ReadResponse(220); err != nil {
+		return fmt.Errorf("STARTTLS: %w", err)
+	}
+	return nil
+}
+
+// startTLSIMAP reads the IMAP greeting and sends a tagged STARTTLS command
+// (RFC 3501), skipping untagged replies until the tagged one.
+func startTLSIMAP(r *textproto.Reader, w io.Writer) error {
+	line, err := r.ReadLine()
+	if err != nil {
+		return fmt.Errorf("greeting: %w", err)
+	}
+	if !strings.HasPrefix(line, "* OK") {
+		return fmt.Errorf("greeting: unexpected %q", line)
+	}
+	if _, err := io.WriteString(w, "a1 STARTTLS\r\n"); err != nil {
+		return err
+	}
+	for {
+		line, err := r.ReadLine()
+		if err != nil {
+			return fmt.Errorf("STARTTLS: %w", err)
+		}
+		if !strings.HasPrefix(line, "a1 ") {
+			continue
+		}
+		if !strings.HasPrefix(line, "a1 OK") {
+			return fmt.Errorf("STARTTLS: %s", strings.TrimPrefix(line, "a1 "))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,165 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mrlm-net/cure/pkg/tracer/event"
+)
+
+// testTLSCert returns the certificate of an httptest TLS server, valid for
+// 127.0.0.1, and a pool that trusts it.
+func testTLSCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	srv := httptest.NewTLSServer(nil)
+	defer srv.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	return srv.TLS.Certificates[0], pool
+}
+
+// serveTLS starts a server that runs preamble on each plain connection, then
+// a TLS handshake, and echoes the first read back over TLS. It returns the
+// server's address.
+func serveTLS(t *testing.T, cert tls.Certificate, preamble func(r *bufio.Reader, conn net.Conn) bool) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if preamble != nil && !preamble(bufio.NewReader(conn), conn) {
+					return
+				}
+				tconn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}})
+				buf := make([]byte, 1024)
+				n, err := tconn.Read(buf)
+				if err != nil {
+					return
+				}
+				tconn.Write(buf[:n])
+				tconn.Read(buf)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// smtpPreamble answers like an SMTP server, offering STARTTLS when offer is
+// set.
+func smtpPreamble(offer bool) func(r *bufio.Reader, conn net.Conn) bool {
+	return func(r *bufio.Reader, conn net.Conn) bool {
+		conn.Write([]byte("220 mail.test ESMTP\r\n"))
+		if line, _ := r.ReadString('\n'); !strings.HasPrefix(line, "EHLO ") {
+			return false
+		}
+		if !offer {
+			conn.Write([]byte("250-mail.test\r\n250 8BITMIME\r\n"))
+			r.ReadString('\n')
+			return false
+		}
+		conn.Write([]byte("250-mail.test\r\n250-8BITMIME\r\n250 STARTTLS\r\n"))
+		if line, _ := r.ReadString('\n'); line != "STARTTLS\r\n" {
+			return false
+		}
+		conn.Write([]byte("220 Ready to start TLS\r\n"))
+		return true
+	}
+}
+
+// imapPreamble answers like an IMAP server.
+func imapPreamble(r *bufio.Reader, conn net.Conn) bool {
+	conn.Write([]byte("* OK IMAP4rev1 ready\r\n"))
+	line, _ := r.ReadString('\n')
+	tag, cmd, _ := strings.Cut(strings.TrimSpace(line), " ")
+	if cmd != "STARTTLS" {
+		return false
+	}
+	conn.Write([]byte("* CAPABILITY IMAP4rev1\r\n" + tag + " OK Begin TLS negotiation now\r\n"))
+	return true
+}
+
+func TestTraceAddr_TLS(t *testing.T) {
+	cert, pool := testTLSCert(t)
+	tests := []struct {
+		name         string
+		preamble     func(r *bufio.Reader, conn net.Conn) bool
+		opts         []Option
+		wantStartTLS bool
+		wantErr      string
+	}{
+		{name: "direct", opts: []Option{WithTLS(true)}},
+		{name: "smtp", preamble: smtpPreamble(true), opts: []Option{WithStartTLS("smtp")}, wantStartTLS: true},
+		{name: "smtp without timeout", preamble: smtpPreamble(true), opts: []Option{WithStartTLS("smtp"), WithTimeout(0)}, wantStartTLS: true},
+		{name: "imap", preamble: imapPreamble, opts: []Option{WithStartTLS("imap")}, wantStartTLS: true},
+		{name: "smtp without STARTTLS", preamble: smtpPreamble(false), opts: []Option{WithStartTLS("smtp")}, wantErr: "does not offer STARTTLS"},
+		{name: "untrusted", opts: []Option{WithTLS(true), WithTLSConfig(&tls.Config{})}, wantErr: "TLS handshake failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := serveTLS(t, cert, tt.preamble)
+			em := &testEmitter{}
+			opts := append([]Option{
+				WithEmitter(em),
+				WithDataString("ping"),
+				WithTLSConfig(&tls.Config{RootCAs: pool}),
+			}, tt.opts...)
+			err := TraceAddr(context.Background(), addr, opts...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("TraceAddr() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TraceAddr() error = %v", err)
+			}
+
+			byType := make(map[string]event.Event)
+			var types []string
+			for _, ev := range em.events {
+				byType[ev.Type] = ev
+				types = append(types, ev.Type)
+			}
+			if _, ok := byType["starttls"]; ok != tt.wantStartTLS {
+				t.Errorf("starttls event present = %v, want %v (events: %v)", ok, tt.wantStartTLS, types)
+			}
+			done, ok := byType[event.TypeTLSHandshakeDone]
+			if !ok {
+				t.Fatalf("missing tls_handshake_done (events: %v)", types)
+			}
+			if done.Failed || done.Data["version"] == "" || done.Data["server_name"] != "127.0.0.1" {
+				t.Errorf("tls_handshake_done data = %v", done.Data)
+			}
+			if recv := byType["tcp_receive"]; recv.Data["bytes"] != len("ping") {
+				t.Errorf("tcp_receive bytes = %v, want the echo over TLS", recv.Data["bytes"])
+			}
+			phases, _ := byType[event.TraceSummary].Data["phases"].(map[string]interface{})
+			if _, ok := phases["tls"]; !ok {
+				t.Errorf("trace_summary phases = %v, want tls", phases)
+			}
+		})
+	}
+}
+
+func TestWithStartTLS_Unsupported(t *testing.T) {
+	err := TraceAddr(context.Background(), "127.0.0.1:25", WithStartTLS("pop3"), WithDryRun(true))
+	if err == nil || !strings.Contains(err.Error(), `unsupported STARTTLS protocol "pop3"`) {
+		t.Errorf("TraceAddr() error = %v, want unsupported protocol", err)
+	}
+}
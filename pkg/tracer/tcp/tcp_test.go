@@ -4,13 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"maps"
 	"net"
+	"runtime"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/mrlm-net/cure/pkg/tracer/event"
 	"github.com/mrlm-net/cure/pkg/tracer/formatter"
+	"github.com/mrlm-net/cure/pkg/tracer/netns"
+	"github.com/mrlm-net/cure/pkg/tracer/resolvconf"
 )
 
 func TestTraceAddr_Success(t *testing.T) {
@@ -112,6 +119,45 @@ func TestTraceAddr_DryRun(t *testing.T) {
 	}
 }
 
+func TestTraceAddr_DryRun_DNS(t *testing.T) {
+	tests := []struct {
+		addr string
+		want []string
+		not  string
+	}{
+		{addr: "example.com:443", want: []string{event.TypeDNSStart, event.TypeDNSDone}, not: event.TypeDNSSkipped},
+		{addr: "192.0.2.1:443", want: []string{event.TypeDNSSkipped}, not: event.TypeDNSStart},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			events, err := Collect(context.Background(), tt.addr, WithDryRun(true))
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			seen := map[string]bool{}
+			var summary event.Event
+			for _, ev := range events {
+				seen[ev.Type] = true
+				if ev.Type == event.TraceSummary {
+					summary = ev
+				}
+			}
+			for _, typ := range tt.want {
+				if !seen[typ] {
+					t.Errorf("no %s event", typ)
+				}
+			}
+			if seen[tt.not] {
+				t.Errorf("unexpected %s event", tt.not)
+			}
+			phases, _ := summary.Data["phases"].(map[string]interface{})
+			if _, ok := phases["dns"]; ok != (tt.not == event.TypeDNSSkipped) {
+				t.Errorf("summary phases = %v, want a dns phase only when resolved", summary.Data["phases"])
+			}
+		})
+	}
+}
+
 func TestTraceAddr_SendData(t *testing.T) {
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
@@ -150,3 +196,893 @@ func TestTraceAddr_SendData(t *testing.T) {
 		t.Error("timeout waiting for received data")
 	}
 }
+
+func TestTraceAddr_RTTProbe(t *testing.T) {
+	orig := rttProbeTimeout
+	rttProbeTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { rttProbeTimeout = orig })
+
+	tests := []struct {
+		name          string
+		echo          bool
+		wantEstimated bool
+	}{
+		{"echo server measures round trip", true, false},
+		{"silent server falls back to connect time", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("net.Listen() error = %v", err)
+			}
+			defer listener.Close()
+
+			done := make(chan struct{})
+			defer close(done)
+			go func() {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				if tt.echo {
+					buf := make([]byte, 1)
+					if n, _ := conn.Read(buf); n > 0 {
+						conn.Write(buf[:n])
+					}
+				}
+				<-done
+			}()
+
+			var buf bytes.Buffer
+			em := formatter.NewNDJSONEmitter(&buf)
+			err = TraceAddr(context.Background(), listener.Addr().String(),
+				WithEmitter(em),
+				WithRTTProbe(true),
+				WithTimeout(5*time.Second),
+			)
+			if err != nil {
+				t.Fatalf("TraceAddr() error = %v", err)
+			}
+
+			var rtt *event.Event
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				var ev event.Event
+				if err := json.Unmarshal([]byte(line), &ev); err != nil {
+					t.Fatalf("json.Unmarshal() error = %v", err)
+				}
+				if ev.Type == "tcp_rtt" {
+					rtt = &ev
+				}
+			}
+			if rtt == nil {
+				t.Fatal("missing tcp_rtt event")
+			}
+			if _, ok := rtt.Data["rtt_ms"].(float64); !ok {
+				t.Errorf("rtt_ms = %v, want number", rtt.Data["rtt_ms"])
+			}
+			if got := rtt.Data["estimated"]; got != tt.wantEstimated {
+				t.Errorf("estimated = %v, want %v", got, tt.wantEstimated)
+			}
+		})
+	}
+}
+
+func TestTraceAddr_RTTProbeKeepsBanner(t *testing.T) {
+	const banner = "220 ready\r\n"
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte(banner))
+		<-done
+	}()
+
+	var buf bytes.Buffer
+	err = TraceAddr(context.Background(), listener.Addr().String(),
+		WithEmitter(formatter.NewNDJSONEmitter(&buf)),
+		WithRTTProbe(true),
+		WithDataString("QUIT\r\n"),
+		WithTimeout(5*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("TraceAddr() error = %v", err)
+	}
+
+	events := parseEvents(t, buf.String())
+	if got := events["tcp_receive"].Data["bytes"]; got != float64(len(banner)) {
+		t.Errorf("tcp_receive bytes = %v, want the whole banner (%d)", got, len(banner))
+	}
+}
+
+func TestTraceAddr_KeepAlive(t *testing.T) {
+	tests := []struct {
+		name          string
+		closeAfterOne bool // server closes each connection after one echo
+		wantSucceeded int
+		wantDrops     int
+		wantConnects  int
+	}{
+		{"stable connection", false, 3, 0, 1},
+		{"drop and reconnect", true, 2, 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("net.Listen() error = %v", err)
+			}
+			defer listener.Close()
+
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+					go func() {
+						defer conn.Close()
+						buf := make([]byte, 1)
+						for {
+							n, err := conn.Read(buf)
+							if err != nil {
+								return
+							}
+							conn.Write(buf[:n])
+							if tt.closeAfterOne {
+								return
+							}
+						}
+					}()
+				}
+			}()
+
+			em := &testEmitter{}
+			err = TraceAddr(context.Background(), listener.Addr().String(),
+				WithEmitter(em),
+				WithKeepAlive(50*time.Millisecond, 3),
+				WithTimeout(5*time.Second),
+			)
+			if err != nil {
+				t.Fatalf("TraceAddr() error = %v", err)
+			}
+
+			var probes, connects int
+			var summary *event.Event
+			for i, ev := range em.events {
+				switch ev.Type {
+				case "tcp_probe":
+					probes++
+				case "tcp_connect_done":
+					connects++
+				case "tcp_summary":
+					summary = &em.events[i]
+				}
+			}
+			if probes != 3 {
+				t.Errorf("got %d tcp_probe events, want 3", probes)
+			}
+			if connects != tt.wantConnects {
+				t.Errorf("got %d connects, want %d", connects, tt.wantConnects)
+			}
+			if summary == nil {
+				t.Fatal("missing tcp_summary event")
+			}
+			if got := summary.Data["succeeded"]; got != tt.wantSucceeded {
+				t.Errorf("tcp_summary succeeded = %v, want %d", got, tt.wantSucceeded)
+			}
+			if got := summary.Data["drops"]; got != tt.wantDrops {
+				t.Errorf("tcp_summary drops = %v, want %d", got, tt.wantDrops)
+			}
+		})
+	}
+}
+
+func TestTraceAddr_KeepAlive_Cancelled(t *testing.T) {
+	addr := newEchoServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	em := &testEmitter{}
+	err := TraceAddr(ctx, addr, WithEmitter(em), WithKeepAlive(20*time.Millisecond, 0))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("TraceAddr() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if n := len(em.events); n < 2 || em.events[n-2].Type != "tcp_close" {
+		t.Errorf("events do not end with tcp_close before trace_summary")
+	}
+	if last := em.events[len(em.events)-1]; last.Type != event.TraceSummary || last.Data["ok"] != false {
+		t.Errorf("last event = %q (ok=%v), want %s with ok=false", last.Type, last.Data["ok"], event.TraceSummary)
+	}
+	var hasSummary bool
+	for _, ev := range em.events {
+		hasSummary = hasSummary || ev.Type == "tcp_summary"
+	}
+	if !hasSummary {
+		t.Error("missing tcp_summary event")
+	}
+}
+
+func TestTraceAddr_WithTraceID(t *testing.T) {
+	em := &testEmitter{}
+	err := TraceAddr(context.Background(), "127.0.0.1:9",
+		WithEmitter(em),
+		WithDryRun(true),
+		WithTraceID("req-1234"),
+	)
+	if err != nil {
+		t.Fatalf("TraceAddr() error = %v", err)
+	}
+	if len(em.events) == 0 {
+		t.Fatal("no events emitted")
+	}
+	for _, ev := range em.events {
+		if ev.TraceID != "req-1234" {
+			t.Errorf("%s TraceID = %q, want %q", ev.Type, ev.TraceID, "req-1234")
+		}
+	}
+
+	err = TraceAddr(context.Background(), "127.0.0.1:9", WithDryRun(true), WithTraceID(""))
+	if !errors.Is(err, event.ErrEmptyTraceID) {
+		t.Errorf("TraceAddr() with empty trace ID error = %v, want %v", err, event.ErrEmptyTraceID)
+	}
+}
+
+// testEmitter captures emitted events for inspection.
+type testEmitter struct{ events []event.Event }
+
+func (e *testEmitter) Emit(ev event.Event) error { e.events = append(e.events, ev); return nil }
+func (e *testEmitter) Close() error              { return nil }
+
+// stepClock is an event.Clock that advances by step on every reading, so
+// each measured duration is an exact multiple of step.
+type stepClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+func (c *stepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(c.step)
+	return c.now
+}
+
+func TestTraceAddr_TraceSummary(t *testing.T) {
+	echoAddr := newEchoServer(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	closedAddr := ln.Addr().String()
+	ln.Close()
+
+	tests := []struct {
+		name       string
+		addr       string
+		wantOK     bool
+		wantPhases map[string]int64
+		wantTotal  int64
+	}{
+		// Every phase spans two clock readings; the total spans them all.
+		// The addresses are IP literals, so there is no dns phase.
+		{"success", echoAddr, true, map[string]int64{"connect": 10, "send": 10, "receive": 10}, 70},
+		{"connection refused", closedAddr, false, map[string]int64{"connect": 10}, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := &testEmitter{}
+			err := TraceAddr(context.Background(), tt.addr,
+				WithEmitter(em),
+				WithClock(&stepClock{step: 10 * time.Millisecond}),
+				WithDataString("ping"),
+				WithTimeout(2*time.Second),
+			)
+			if (err == nil) != tt.wantOK {
+				t.Fatalf("TraceAddr() error = %v, want ok = %v", err, tt.wantOK)
+			}
+
+			last := em.events[len(em.events)-1]
+			if last.Type != event.TraceSummary {
+				t.Fatalf("last event = %q, want %s", last.Type, event.TraceSummary)
+			}
+			if last.Data["ok"] != tt.wantOK {
+				t.Errorf("trace_summary ok = %v, want %v", last.Data["ok"], tt.wantOK)
+			}
+			phases, _ := last.Data["phases"].(map[string]interface{})
+			if len(phases) != len(tt.wantPhases) {
+				t.Errorf("trace_summary phases = %v, want %v", phases, tt.wantPhases)
+			}
+			for name, ms := range tt.wantPhases {
+				if phases[name] != ms {
+					t.Errorf("trace_summary phase %q = %v, want %d", name, phases[name], ms)
+				}
+			}
+			if last.Data["total_ms"] != tt.wantTotal {
+				t.Errorf("trace_summary total_ms = %v, want %d", last.Data["total_ms"], tt.wantTotal)
+			}
+
+			for _, ev := range em.events {
+				if ev.Type == event.TypeTCPConnectDone && ev.Failed == tt.wantOK {
+					t.Errorf("tcp_connect_done Failed = %v, Error = %q, want Failed = %v", ev.Failed, ev.Error, !tt.wantOK)
+				}
+			}
+		})
+	}
+}
+
+// failAfterWriter accepts the first n writes, then fails every write.
+type failAfterWriter struct{ n int }
+
+func (w *failAfterWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("broken pipe")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestTraceAddr_EmitError(t *testing.T) {
+	addr := newEchoServer(t)
+
+	// A keep-alive loop without a count would run until cancelled; the
+	// failed emit must stop it.
+	done := make(chan error, 1)
+	go func() {
+		em := formatter.NewNDJSONEmitter(&failAfterWriter{n: 1})
+		done <- TraceAddr(context.Background(), addr, WithEmitter(em), WithKeepAlive(10*time.Millisecond, 0))
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "emit failed: broken pipe") {
+			t.Errorf("TraceAddr() error = %v, want emit failed: broken pipe", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("TraceAddr() kept running after the emitter failed")
+	}
+}
+
+func TestTraceAddr_Resolver(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	em := &testEmitter{}
+	if err := TraceAddr(context.Background(), net.JoinHostPort("localhost", port), WithEmitter(em)); err != nil {
+		t.Skipf("localhost not reachable in this environment: %v", err)
+	}
+
+	want := resolvconf.Nameserver()
+	for _, ev := range em.events {
+		if ev.Type != "dns_start" && ev.Type != "dns_done" {
+			continue
+		}
+		got, ok := ev.Data["resolver"]
+		if want == "" && ok {
+			t.Errorf("%s resolver = %v, want none when it is unknown", ev.Type, got)
+		} else if want != "" && got != want {
+			t.Errorf("%s resolver = %v, want %q", ev.Type, got, want)
+		}
+	}
+}
+
+// hangLookup replaces lookupHost with a resolver that never answers, only
+// returning once its context is done.
+func hangLookup(t *testing.T) {
+	t.Helper()
+	orig := lookupHost
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	t.Cleanup(func() { lookupHost = orig })
+}
+
+func TestTraceAddr_DNSTimeout(t *testing.T) {
+	hangLookup(t)
+
+	em := &testEmitter{}
+	err := TraceAddr(context.Background(), "hung.example:53",
+		WithEmitter(em),
+		WithDNSTimeout(20*time.Millisecond),
+	)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("TraceAddr() error = %v, want DeadlineExceeded", err)
+	}
+
+	var types []string
+	for _, ev := range em.events {
+		types = append(types, ev.Type)
+	}
+	want := []string{"dns_start", "dns_timeout", event.TraceSummary}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v, want %v", types, want)
+	}
+	data := em.events[1].Data
+	if data["host"] != "hung.example" || data["timeout_ms"] != int64(20) {
+		t.Errorf("dns_timeout data = %v", data)
+	}
+}
+
+func TestTraceAddr_DNSCancelledNotTimeout(t *testing.T) {
+	hangLookup(t)
+
+	// The caller's context ends during the lookup: that is a cancellation,
+	// reported as a plain dns_done failure, not a DNS timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	em := &testEmitter{}
+	err := TraceAddr(ctx, "hung.example:53",
+		WithEmitter(em),
+		WithDNSTimeout(time.Minute),
+	)
+	if err == nil {
+		t.Fatal("TraceAddr() error = nil, want lookup failure")
+	}
+	for _, ev := range em.events {
+		if ev.Type == "dns_timeout" {
+			t.Fatal("dns_timeout emitted for a cancelled context")
+		}
+		if ev.Type == "dns_done" && ev.Data["error"] == nil {
+			t.Errorf("dns_done without error: %v", ev.Data)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	em := &testEmitter{}
+	events, err := Collect(context.Background(), "example.com:443", WithDryRun(true), WithEmitter(em))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(events) == 0 {
+		t.Fatal("Collect() returned no events")
+	}
+	if len(em.events) != len(events) {
+		t.Errorf("WithEmitter emitter got %d events, Collect returned %d", len(em.events), len(events))
+	}
+	if last := events[len(events)-1].Type; last != event.TraceSummary {
+		t.Errorf("last event = %q, want %q", last, event.TraceSummary)
+	}
+}
+
+func TestTraceAddr_Netns(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+
+	// Tracing from the test's own namespace behaves like a plain trace.
+	em := &testEmitter{}
+	err = TraceAddr(context.Background(), listener.Addr().String(), WithEmitter(em), WithNetns("/proc/self/ns/net"))
+	if runtime.GOOS != "linux" {
+		if !errors.Is(err, netns.ErrUnsupported) {
+			t.Fatalf("TraceAddr() error = %v, want netns.ErrUnsupported", err)
+		}
+		return
+	}
+	if errors.Is(err, syscall.EPERM) {
+		t.Skipf("cannot enter a network namespace: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("TraceAddr() error = %v", err)
+	}
+	if len(em.events) == 0 || em.events[0].Type != "netns" {
+		t.Fatalf("events = %v, want netns first", em.events)
+	}
+	if got := em.events[0].Data["path"]; got != "/proc/self/ns/net" {
+		t.Errorf("netns path = %v, want /proc/self/ns/net", got)
+	}
+	var connected bool
+	for _, ev := range em.events {
+		connected = connected || ev.Type == "tcp_connect_done" && ev.Data["error"] == nil
+	}
+	if !connected {
+		t.Error("no successful tcp_connect_done from inside the namespace")
+	}
+}
+
+func TestTraceAddr_NetnsMissing(t *testing.T) {
+	em := &testEmitter{}
+	err := TraceAddr(context.Background(), "127.0.0.1:1", WithEmitter(em), WithNetns("/nonexistent/ns/net"))
+	if err == nil {
+		t.Fatal("TraceAddr() error = nil, want an error for a missing namespace")
+	}
+	if len(em.events) == 0 || em.events[0].Type != "netns" || em.events[0].Data["error"] == nil {
+		t.Errorf("events = %v, want a netns event with an error first", em.events)
+	}
+	for _, ev := range em.events {
+		if ev.Type == "tcp_connect_start" {
+			t.Error("connect attempted after the namespace could not be entered")
+		}
+	}
+}
+
+func TestWithLabels(t *testing.T) {
+	labels := map[string]string{"env": "prod", "region": "eu"}
+	events, err := Collect(context.Background(), "example.com:443", WithDryRun(true), WithLabels(labels))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(events) == 0 {
+		t.Fatal("Collect() returned no events")
+	}
+	for _, ev := range events {
+		if !maps.Equal(ev.Labels, labels) {
+			t.Errorf("%s labels = %v, want %v", ev.Type, ev.Labels, labels)
+		}
+	}
+}
+
+func TestWithMaxReadBytes(t *testing.T) {
+	// serve starts a server that answers every connection with chunk, and
+	// with repeat keeps writing it until the client goes away. It returns the
+	// server's address.
+	serve := func(t *testing.T, chunk string, repeat bool) string {
+		t.Helper()
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("net.Listen() error = %v", err)
+		}
+		t.Cleanup(func() { listener.Close() })
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					conn.Read(make([]byte, 1024))
+					for {
+						if _, err := conn.Write([]byte(chunk)); err != nil || !repeat {
+							break
+						}
+						time.Sleep(time.Millisecond)
+					}
+					conn.Read(make([]byte, 1))
+				}()
+			}
+		}()
+		return listener.Addr().String()
+	}
+
+	tests := []struct {
+		name      string
+		chunk     string
+		repeat    bool
+		opts      []Option
+		wantBytes int
+		wantTrunc bool
+	}{
+		{"raw read capped", strings.Repeat("x", 100), false, []Option{WithMaxReadBytes(10)}, 10, true},
+		{"raw read at cap", strings.Repeat("x", 10), false, []Option{WithMaxReadBytes(10)}, 10, false},
+		{"header read capped", "X-Chatty: yes\r\n", true, []Option{WithParseHTTP(true), WithMaxReadBytes(40)}, 40, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := &testEmitter{}
+			opts := append([]Option{WithEmitter(em), WithDataString("hello")}, tt.opts...)
+			if err := TraceAddr(context.Background(), serve(t, tt.chunk, tt.repeat), opts...); err != nil {
+				t.Fatalf("TraceAddr() error = %v", err)
+			}
+			var recv *event.Event
+			for i := range em.events {
+				if em.events[i].Type == "tcp_receive" {
+					recv = &em.events[i]
+				}
+			}
+			if recv == nil {
+				t.Fatal("missing tcp_receive event")
+			}
+			if recv.Data["bytes"] != tt.wantBytes {
+				t.Errorf("tcp_receive bytes = %v, want %d", recv.Data["bytes"], tt.wantBytes)
+			}
+			if trunc, _ := recv.Data["truncated"].(bool); trunc != tt.wantTrunc {
+				t.Errorf("tcp_receive truncated = %v, want %v", recv.Data["truncated"], tt.wantTrunc)
+			}
+		})
+	}
+}
+
+func TestWithTraceRetry(t *testing.T) {
+	// Reserve a port, then free it so the first attempts are refused.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	started := make(chan net.Listener, 1)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			started <- nil
+			return
+		}
+		started <- ln
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	defer func() {
+		if ln := <-started; ln != nil {
+			ln.Close()
+		}
+	}()
+
+	events, err := Collect(context.Background(), addr, WithTraceRetry(5), WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	var retries int
+	for _, ev := range events {
+		if ev.Type == event.TraceRetry {
+			retries++
+			if ev.Data["reason"] != "connection_refused" {
+				t.Errorf("trace_retry reason = %v, want connection_refused", ev.Data["reason"])
+			}
+		}
+	}
+	if retries == 0 {
+		t.Error("no trace_retry event before the successful attempt")
+	}
+	if last := events[len(events)-1]; last.Type != event.TraceSummary || last.Data["ok"] != true {
+		t.Errorf("last event = %v, want a successful trace_summary", last)
+	}
+}
+
+func TestWithTraceRetry_Permanent(t *testing.T) {
+	events, err := Collect(context.Background(), "no-port", WithTraceRetry(5))
+	if err == nil {
+		t.Fatal("Collect() error = nil, want invalid address")
+	}
+	for _, ev := range events {
+		if ev.Type == event.TraceRetry {
+			t.Errorf("permanent error was retried: %v", ev)
+		}
+	}
+}
+
+func TestWithHexdump(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Read(make([]byte, 1024))
+		conn.Write([]byte{0xde, 0xad, 0xbe, 0xef, 'O', 'K'})
+	}()
+
+	em := &testEmitter{}
+	err = TraceAddr(context.Background(), listener.Addr().String(),
+		WithEmitter(em),
+		WithDataString("\x00\x01hello"),
+		WithHexdump(true),
+		WithHexdumpBytes(4),
+	)
+	if err != nil {
+		t.Fatalf("TraceAddr() error = %v", err)
+	}
+
+	want := map[string]string{
+		"tcp_send":    "00000000  00 01 68 65                                       |..he|\n",
+		"tcp_receive": "00000000  de ad be ef                                       |....|\n",
+	}
+	for _, ev := range em.events {
+		dump, ok := want[ev.Type]
+		if !ok {
+			continue
+		}
+		delete(want, ev.Type)
+		if ev.Data["hexdump"] != dump {
+			t.Errorf("%s hexdump = %q, want %q", ev.Type, ev.Data["hexdump"], dump)
+		}
+		if ev.Data["hexdump_truncated"] != true {
+			t.Errorf("%s hexdump_truncated = %v, want true", ev.Type, ev.Data["hexdump_truncated"])
+		}
+	}
+	for typ := range want {
+		t.Errorf("missing %s event", typ)
+	}
+}
+
+func TestTraceAddr_IPLiteralSkipsDNS(t *testing.T) {
+	// fakeLookup answers every lookup with 127.0.0.1 and counts the calls.
+	var lookups int
+	orig := lookupHost
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		lookups++
+		return []string{"127.0.0.1"}, nil
+	}
+	t.Cleanup(func() { lookupHost = orig })
+
+	tests := []struct {
+		name        string
+		network     string
+		host        string
+		wantSkipped bool
+	}{
+		{"IPv4 literal", "tcp4", "127.0.0.1", true},
+		{"IPv6 literal", "tcp6", "::1", true},
+		{"hostname", "tcp4", "db.example", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listen := tt.host
+			if !tt.wantSkipped {
+				listen = "127.0.0.1"
+			}
+			listener, err := net.Listen(tt.network, net.JoinHostPort(listen, "0"))
+			if err != nil {
+				t.Skipf("cannot listen on %s: %v", listen, err)
+			}
+			defer listener.Close()
+			go func() {
+				if conn, err := listener.Accept(); err == nil {
+					conn.Close()
+				}
+			}()
+			_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+			// The dial resolves a hostname again on its own, so only the
+			// IP literals are expected to connect.
+			lookups = 0
+			em := &testEmitter{}
+			err = TraceAddr(context.Background(), net.JoinHostPort(tt.host, port), WithEmitter(em))
+			if err != nil && tt.wantSkipped {
+				t.Fatalf("TraceAddr() error = %v", err)
+			}
+
+			types := make(map[string]event.Event)
+			for _, ev := range em.events {
+				types[ev.Type] = ev
+			}
+			skipped, ok := types[event.TypeDNSSkipped]
+			if ok != tt.wantSkipped {
+				t.Fatalf("dns_skipped present = %v, want %v", ok, tt.wantSkipped)
+			}
+			_, started := types[event.TypeDNSStart]
+			if started == tt.wantSkipped {
+				t.Errorf("dns_start present = %v, want %v", started, !tt.wantSkipped)
+			}
+			if tt.wantSkipped {
+				if skipped.Data["reason"] != "ip_literal" || skipped.Data["host"] != tt.host {
+					t.Errorf("dns_skipped data = %v, want host %s and reason ip_literal", skipped.Data, tt.host)
+				}
+				if lookups != 0 {
+					t.Errorf("lookupHost called %d times for an IP literal", lookups)
+				}
+				phases, _ := types[event.TraceSummary].Data["phases"].(map[string]interface{})
+				if _, ok := phases["dns"]; ok {
+					t.Errorf("trace_summary has a dns phase for an IP literal: %v", phases)
+				}
+			} else if lookups != 1 {
+				t.Errorf("lookupHost called %d times for a hostname, want 1", lookups)
+			}
+		})
+	}
+}
+
+// loopbackZone returns the name of the loopback interface, for use as the
+// zone of a zoned IPv6 literal such as "::1%lo", skipping the test if
+// there is none.
+func loopbackZone(t *testing.T) string {
+	t.Helper()
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Skipf("cannot list interfaces: %v", err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 && iface.Flags&net.FlagUp != 0 {
+			return iface.Name
+		}
+	}
+	t.Skip("no loopback interface")
+	return ""
+}
+
+func TestTraceAddr_ZonedIPv6(t *testing.T) {
+	zone := loopbackZone(t)
+	listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("cannot listen on ::1: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	orig := lookupHost
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		t.Errorf("lookupHost(%q) called for a zoned IP literal", host)
+		return nil, errors.New("unexpected lookup")
+	}
+	t.Cleanup(func() { lookupHost = orig })
+
+	host := "::1%" + zone
+	addr := net.JoinHostPort(host, port)
+	em := &testEmitter{}
+	if err := TraceAddr(context.Background(), addr, WithEmitter(em)); err != nil {
+		t.Fatalf("TraceAddr(%s) error = %v", addr, err)
+	}
+
+	types := make(map[string]event.Event)
+	for _, ev := range em.events {
+		types[ev.Type] = ev
+	}
+	if got := types[event.TypeDNSSkipped].Data["host"]; got != host {
+		t.Errorf("dns_skipped host = %v, want %s", got, host)
+	}
+	if got := types[event.TypeTCPConnectStart].Data["addr"]; got != addr {
+		t.Errorf("tcp_connect_start addr = %v, want %s with the zone", got, addr)
+	}
+	if _, ok := types[event.TypeTCPConnectDone]; !ok {
+		t.Error("missing tcp_connect_done event")
+	}
+}
+
+func TestHandshakeDeadline(t *testing.T) {
+	if d := handshakeDeadline(context.Background(), 0); !d.IsZero() {
+		t.Errorf("handshakeDeadline(no timeout) = %v, want zero", d)
+	}
+	if d := handshakeDeadline(context.Background(), time.Minute); time.Until(d) <= 0 {
+		t.Errorf("handshakeDeadline(1m) = %v, want in the future", d)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	want, _ := ctx.Deadline()
+	for _, timeout := range []time.Duration{0, time.Minute} {
+		if d := handshakeDeadline(ctx, timeout); !d.Equal(want) {
+			t.Errorf("handshakeDeadline(ctx, %v) = %v, want ctx's %v", timeout, d, want)
+		}
+	}
+}
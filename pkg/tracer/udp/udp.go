@@ -2,27 +2,35 @@ package udp
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"net"
+	"net/netip"
 	"time"
 
+	"github.com/mrlm-net/cure/pkg/tracer/dns"
 	"github.com/mrlm-net/cure/pkg/tracer/event"
+	"github.com/mrlm-net/cure/pkg/tracer/resolvconf"
 )
 
 // TraceAddr traces a UDP exchange with addr (host:port format).
 //
 // Events emitted:
 //   - dns_start, dns_done
-//   - udp_send
-//   - udp_receive (if response received)
+//   - dns_timeout (instead of dns_done if WithDNSTimeout expires)
+//   - dns_skipped (instead of dns_start and dns_done when the host is an IP literal; "reason": "ip_literal")
+//   - udp_connect (addr, local_addr, remote_addr)
+//   - udp_send ("hexdump" with WithHexdump)
+//   - udp_receive (if response received; "truncated" when WithMaxReadBytes cut it short; "hexdump" with WithHexdump)
+//   - udp_dns_answer (if WithDNSQuery is set and a response was received)
+//   - trace_retry (before each retry of the whole trace, see WithTraceRetry)
+//   - trace_summary (always last, with dns/send/receive durations)
 //
 // Example:
 //
 //	err := udp.TraceAddr(context.Background(), "1.1.1.1:53",
 //	    udp.WithEmitter(em),
-//	    udp.WithDataString("\x00\x00\x01\x00..."),
+//	    udp.WithDNSQuery("example.com", "AAAA"),
 //	)
 func TraceAddr(ctx context.Context, addr string, opts ...Option) error {
 	cfg := &traceConfig{
@@ -30,60 +38,117 @@ func TraceAddr(ctx context.Context, addr string, opts ...Option) error {
 		dryRun:     false,
 		data:       "",
 		recvBuffer: 4096,
+
+		maxReadBytes: DefaultMaxReadBytes,
 	}
 	for _, opt := range opts {
 		opt(cfg)
 	}
 
-	traceID := generateTraceID()
+	traceID := cfg.traceID
+	if cfg.traceIDSet && traceID == "" {
+		return event.ErrEmptyTraceID
+	}
+	if traceID == "" {
+		traceID = event.NewTraceID()
+	}
 
-	if cfg.dryRun {
-		return emitDryRunEvents(cfg.emitter, traceID, addr)
+	if cfg.dnsName != "" {
+		if err := cfg.buildDNSQuery(); err != nil {
+			return err
+		}
 	}
 
-	// Parse host and port
-	host, _, err := net.SplitHostPort(addr)
-	if err != nil {
-		return fmt.Errorf("invalid address %q: %w", addr, err)
+	if len(cfg.labels) > 0 {
+		cfg.emitter = event.NewLabelEmitter(cfg.emitter, cfg.labels)
 	}
 
-	// DNS resolution
-	dnsStart := time.Now()
-	emit(cfg.emitter, "dns_start", traceID, map[string]interface{}{
-		"host": host,
+	// Stop tracing as soon as the output fails; see event.Guard.
+	ctx, guard := event.Guard(ctx, cfg.emitter)
+	cfg.emitter = guard
+
+	if cfg.dryRun {
+		return guard.Check(emitDryRunEvents(cfg, traceID, addr))
+	}
+
+	return guard.Check(event.RetryTransient(ctx, cfg.emitter, traceID, cfg.traceRetry, func() error {
+		start := event.Now(cfg.clock)
+		cfg.phases = event.Phases{}
+		err := traceAddr(ctx, cfg, traceID, addr)
+		emit(cfg.emitter, event.TraceSummary, traceID, event.SummaryData(cfg.phases, event.Since(cfg.clock, start), err == nil))
+		return err
+	}))
+}
+
+// Collect runs [TraceAddr] and returns the events it emitted along with its
+// error, so Go programs and tests can inspect a trace without parsing
+// NDJSON. On failure the events up to the failure are returned. An emitter
+// set with [WithEmitter] still receives every event as it happens, so a
+// trace can be streamed and inspected at once.
+//
+// Example:
+//
+//	events, err := udp.Collect(ctx, "1.1.1.1:53")
+//	for _, ev := range events {
+//	    if ev.Type == event.TraceSummary {
+//	        fmt.Println(ev.Data["total_ms"])
+//	    }
+//	}
+func Collect(ctx context.Context, addr string, opts ...Option) ([]event.Event, error) {
+	var rec *event.SliceEmitter
+	opts = append(opts[:len(opts):len(opts)], func(cfg *traceConfig) {
+		rec = event.NewSliceEmitter(cfg.emitter)
+		cfg.emitter = rec
 	})
+	err := TraceAddr(ctx, addr, opts...)
+	return rec.Events(), err
+}
 
-	ips, err := net.DefaultResolver.LookupHost(ctx, host)
-	dnsDuration := time.Since(dnsStart).Milliseconds()
+// traceAddr performs the traced exchange. Phase durations are recorded in
+// cfg.phases for the trace_summary event.
+func traceAddr(ctx context.Context, cfg *traceConfig, traceID, addr string) error {
+	// Parse host and port
+	host, _, err := net.SplitHostPort(addr)
 	if err != nil {
-		emit(cfg.emitter, "dns_done", traceID, map[string]interface{}{
-			"error":       err.Error(),
-			"duration_ms": dnsDuration,
-		})
-		return fmt.Errorf("DNS lookup failed: %w", err)
+		return fmt.Errorf("invalid address %q: %w", addr, err)
 	}
 
-	var ip string
-	if len(ips) > 0 {
-		ip = ips[0]
+	// An IP literal needs no lookup, so no DNS time is reported for it.
+	// netip, unlike net.ParseIP, accepts a zoned IPv6 literal such as
+	// fe80::1%eth0, which is then dialled with its zone as given.
+	if _, err := netip.ParseAddr(host); err == nil {
+		emitEvent(cfg.emitter, event.DNSSkipped(traceID, host, "ip_literal"))
+	} else if err := resolve(ctx, cfg, traceID, host); err != nil {
+		return err
 	}
-	emit(cfg.emitter, "dns_done", traceID, map[string]interface{}{
-		"ip":          ip,
-		"duration_ms": dnsDuration,
-	})
 
 	// Open UDP connection
 	conn, err := net.Dial("udp", addr)
 	if err != nil {
+		emit(cfg.emitter, "udp_connect", traceID, map[string]interface{}{
+			"addr":  addr,
+			"error": err.Error(),
+		})
 		return fmt.Errorf("UDP dial failed: %w", err)
 	}
 	defer conn.Close()
 
+	// UDP is connectionless; the dial only binds a local socket, so these
+	// addresses show the source port and interface chosen by the kernel.
+	// addr is the address as given, which keeps an IPv6 zone the kernel
+	// may not report back for remote_addr.
+	emit(cfg.emitter, "udp_connect", traceID, map[string]interface{}{
+		"addr":        addr,
+		"local_addr":  conn.LocalAddr().String(),
+		"remote_addr": conn.RemoteAddr().String(),
+	})
+
 	// Send data
 	if cfg.data != "" {
-		sendStart := time.Now()
+		sendStart := event.Now(cfg.clock)
 		n, err := conn.Write([]byte(cfg.data))
-		sendDuration := time.Since(sendStart).Milliseconds()
+		sendDuration := event.Since(cfg.clock, sendStart).Milliseconds()
+		cfg.phases.Add("send", sendDuration)
 		if err != nil {
 			emit(cfg.emitter, "udp_send", traceID, map[string]interface{}{
 				"error":       err.Error(),
@@ -91,30 +156,95 @@ func TraceAddr(ctx context.Context, addr string, opts ...Option) error {
 			})
 			return fmt.Errorf("UDP send failed: %w", err)
 		}
-		emit(cfg.emitter, "udp_send", traceID, map[string]interface{}{
+		sendData := map[string]interface{}{
 			"bytes":       n,
 			"duration_ms": sendDuration,
-		})
+		}
+		if cfg.hexdump {
+			event.AddHexdump(sendData, []byte(cfg.data), cfg.hexdumpBytes)
+		}
+		emit(cfg.emitter, "udp_send", traceID, sendData)
 
 		// Try to receive response
-		recvStart := time.Now()
-		buf := make([]byte, cfg.recvBuffer)
+		recvStart := event.Now(cfg.clock)
+		// One byte past the cap is read so a datagram of exactly the cap is
+		// not reported as truncated.
+		buf := make([]byte, min(cfg.recvBuffer, cfg.maxReadBytes+1))
 		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
 		n, err = conn.Read(buf)
-		recvDuration := time.Since(recvStart).Milliseconds()
+		truncated := n > cfg.maxReadBytes
+		if truncated {
+			n = cfg.maxReadBytes
+		}
+		recvDuration := event.Since(cfg.clock, recvStart).Milliseconds()
+		cfg.phases.Add("receive", recvDuration)
 		if err != nil {
 			emit(cfg.emitter, "udp_receive", traceID, map[string]interface{}{
 				"error":       err.Error(),
 				"duration_ms": recvDuration,
 			})
 		} else {
-			emit(cfg.emitter, "udp_receive", traceID, map[string]interface{}{
+			data := map[string]interface{}{
 				"bytes":       n,
 				"duration_ms": recvDuration,
-			})
+			}
+			if truncated {
+				data["truncated"] = true
+			}
+			if cfg.hexdump {
+				event.AddHexdump(data, buf[:n], cfg.hexdumpBytes)
+			}
+			emit(cfg.emitter, "udp_receive", traceID, data)
+			if cfg.dnsQuery != nil {
+				emit(cfg.emitter, "udp_dns_answer", traceID, dnsAnswerData(cfg.dnsQuery, buf[:n]))
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolve looks up host, emitting dns_start and then dns_done, or
+// dns_timeout when WithDNSTimeout expires, and records the "dns" phase.
+func resolve(ctx context.Context, cfg *traceConfig, traceID, host string) error {
+	dnsStart := event.Now(cfg.clock)
+	startEv := event.DNSStart(traceID, host)
+	resolvconf.AddResolver(startEv.Data, "")
+	emitEvent(cfg.emitter, startEv)
+
+	lookupCtx := ctx
+	if cfg.dnsTimeout > 0 {
+		var cancel context.CancelFunc
+		lookupCtx, cancel = context.WithTimeout(ctx, cfg.dnsTimeout)
+		defer cancel()
+	}
+	ips, err := lookupHost(lookupCtx, host)
+	dnsDuration := event.Since(cfg.clock, dnsStart)
+	cfg.phases.Add("dns", dnsDuration.Milliseconds())
+	if err != nil && ctx.Err() == nil && errors.Is(lookupCtx.Err(), context.DeadlineExceeded) {
+		timeoutData := map[string]interface{}{
+			"host":        host,
+			"timeout_ms":  cfg.dnsTimeout.Milliseconds(),
+			"duration_ms": dnsDuration.Milliseconds(),
 		}
+		resolvconf.AddResolver(timeoutData, "")
+		emit(cfg.emitter, "dns_timeout", traceID, timeoutData)
+		return fmt.Errorf("DNS lookup of %s timed out after %s: %w", host, cfg.dnsTimeout, context.DeadlineExceeded)
+	}
+	if err != nil {
+		doneEv := event.DNSFailed(traceID, err, dnsDuration)
+		resolvconf.AddResolver(doneEv.Data, "")
+		emitEvent(cfg.emitter, doneEv)
+		return fmt.Errorf("DNS lookup failed: %w", err)
 	}
 
+	var ip string
+	if len(ips) > 0 {
+		ip = ips[0]
+	}
+	doneEv := event.DNSDone(traceID, ip, dnsDuration)
+	resolvconf.AddResolver(doneEv.Data, "")
+	emitEvent(cfg.emitter, doneEv)
 	return nil
 }
 
@@ -126,6 +256,30 @@ type traceConfig struct {
 	dryRun     bool
 	data       string
 	recvBuffer int
+	dnsTimeout time.Duration
+
+	maxReadBytes int
+
+	hexdump      bool
+	hexdumpBytes int // default 0 = event.DefaultHexdumpBytes
+
+	traceRetry int // attempts of the whole trace; default 0 = one
+
+	traceID    string
+	traceIDSet bool
+
+	labels map[string]string
+
+	clock event.Clock // set by WithClock; nil means the system clock
+
+	// dnsName and dnsType are set by WithDNSQuery; dnsQuery is the packet
+	// built from them by TraceAddr.
+	dnsName  string
+	dnsType  string
+	dnsQuery []byte
+
+	// phases collects durations for trace_summary; set by TraceAddr.
+	phases event.Phases
 }
 
 // WithEmitter sets the event emitter.
@@ -135,6 +289,54 @@ func WithEmitter(em event.Emitter) Option {
 	}
 }
 
+// WithTraceRetry runs the whole trace up to attempts times while it fails
+// with a transient error (see [event.TransientReason]). UDP has no
+// handshake, so that is mostly a DNS lookup that fails temporarily or
+// outlasts WithDNSTimeout, or a send refused because an earlier datagram
+// drew an ICMP port unreachable. A missing or refused reply does not fail
+// the trace, as udp_receive reports it, so it is not retried. Permanent
+// errors, such as an invalid address or an unknown host, fail at once.
+// Before each retry a trace_retry event reports the "attempt" that failed,
+// "max_attempts", the "reason" and "error", and the "wait_ms" before the
+// next attempt, which starts at 250ms and doubles up to 5s. Cancelling the
+// context ends the wait. Each attempt emits its own events and
+// trace_summary. Values below 2 disable retries, which is the default.
+func WithTraceRetry(attempts int) Option {
+	return func(cfg *traceConfig) {
+		cfg.traceRetry = attempts
+	}
+}
+
+// WithTraceID sets the trace ID carried by every event, for example to
+// correlate a trace with an existing request log. TraceAddr returns
+// [event.ErrEmptyTraceID] if id is empty. Default: a random ID from
+// [event.NewTraceID].
+func WithTraceID(id string) Option {
+	return func(cfg *traceConfig) {
+		cfg.traceID = id
+		cfg.traceIDSet = true
+	}
+}
+
+// WithClock sets the clock that times the dns, send, and receive phases of
+// trace_summary and the durations of their events, so tests can assert
+// exact values. The read deadline for a reply still uses the system clock.
+// nil means [event.SystemClock], which is the default.
+func WithClock(c event.Clock) Option {
+	return func(cfg *traceConfig) {
+		cfg.clock = c
+	}
+}
+
+// WithLabels attaches labels, such as {"service": "ntp"}, to every event
+// of the exchange, from dns_start to trace_summary. labels is copied; see
+// [event.LabelEmitter]. Default: none.
+func WithLabels(labels map[string]string) Option {
+	return func(cfg *traceConfig) {
+		cfg.labels = labels
+	}
+}
+
 // WithDryRun enables dry-run mode.
 func WithDryRun(enabled bool) Option {
 	return func(cfg *traceConfig) {
@@ -149,6 +351,62 @@ func WithDataString(data string) Option {
 	}
 }
 
+// WithDNSQuery sends a DNS query for name and qtype ("A", "AAAA", "CNAME",
+// "MX", "NS", or "TXT"; "" means "A"), so a resolver can be traced without
+// hand-crafting the packet. It replaces any data set with [WithDataString].
+// A received reply is decoded into a udp_dns_answer event with "rcode",
+// "truncated", and "answers", each with "name", "type", "ttl", and "data",
+// or with "error" if it is not a valid reply to the query. TraceAddr
+// returns an error for an invalid name or unsupported qtype.
+func WithDNSQuery(name, qtype string) Option {
+	return func(cfg *traceConfig) {
+		cfg.dnsName = name
+		cfg.dnsType = qtype
+	}
+}
+
+// buildDNSQuery encodes the WithDNSQuery packet and sets it as the data to
+// send.
+func (cfg *traceConfig) buildDNSQuery() error {
+	qtype := dns.TypeA
+	if cfg.dnsType != "" {
+		var err error
+		if qtype, err = dns.ParseRecordType(cfg.dnsType); err != nil {
+			return fmt.Errorf("invalid DNS query: %w", err)
+		}
+	}
+	query, err := dns.NewQuery(cfg.dnsName, qtype)
+	if err != nil {
+		return fmt.Errorf("invalid DNS query: %w", err)
+	}
+	cfg.dnsQuery = query
+	cfg.data = string(query)
+	return nil
+}
+
+// dnsAnswerData decodes reply as the answer to query for the
+// udp_dns_answer event.
+func dnsAnswerData(query, reply []byte) map[string]interface{} {
+	parsed, err := dns.ParseReply(query, reply)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	answers := make([]map[string]interface{}, 0, len(parsed.Answers))
+	for _, rr := range parsed.Answers {
+		answers = append(answers, map[string]interface{}{
+			"name": rr.Name,
+			"type": rr.Type.String(),
+			"ttl":  rr.TTL,
+			"data": rr.Data,
+		})
+	}
+	return map[string]interface{}{
+		"rcode":     parsed.Rcode,
+		"truncated": parsed.Truncated,
+		"answers":   answers,
+	}
+}
+
 // WithRecvBuffer sets the receive buffer size. Default: 4096 bytes.
 func WithRecvBuffer(size int) Option {
 	return func(cfg *traceConfig) {
@@ -156,33 +414,105 @@ func WithRecvBuffer(size int) Option {
 	}
 }
 
-func generateTraceID() string {
-	b := make([]byte, 8)
-	if _, err := rand.Read(b); err != nil {
-		// Fallback to timestamp-based ID if crypto/rand fails.
-		return hex.EncodeToString([]byte(fmt.Sprintf("%08x", time.Now().UnixNano())))
+// DefaultMaxReadBytes is the cap [WithMaxReadBytes] applies unless set. It
+// is larger than any UDP payload, so by default only WithRecvBuffer limits
+// a reply.
+const DefaultMaxReadBytes = 64 << 10
+
+// WithMaxReadBytes caps how much of a reply is read at n bytes, whatever
+// WithRecvBuffer asks for, so a large receive buffer cannot make the tracer
+// allocate without bound. A longer reply is cut at n and udp_receive
+// reports "truncated": true and "bytes" of n. n <= 0 restores the default,
+// [DefaultMaxReadBytes].
+func WithMaxReadBytes(n int) Option {
+	return func(cfg *traceConfig) {
+		if n <= 0 {
+			n = DefaultMaxReadBytes
+		}
+		cfg.maxReadBytes = n
+	}
+}
+
+// WithHexdump adds a "hexdump" field to udp_send and udp_receive with a hex
+// and ASCII dump of the datagrams sent and received, in the format of
+// [encoding/hex.Dump], for inspecting binary protocols. Only the first
+// bytes are dumped, 256 unless changed with [WithHexdumpBytes], and
+// "hexdump_truncated" is set when a datagram was longer. Default: false.
+func WithHexdump(enabled bool) Option {
+	return func(cfg *traceConfig) {
+		cfg.hexdump = enabled
+	}
+}
+
+// WithHexdumpBytes caps how many bytes of each datagram [WithHexdump]
+// dumps. n <= 0 restores the default, [event.DefaultHexdumpBytes].
+func WithHexdumpBytes(n int) Option {
+	return func(cfg *traceConfig) {
+		cfg.hexdumpBytes = n
+	}
+}
+
+// WithDNSTimeout bounds the DNS lookup on its own, separately from the
+// overall deadline in ctx. When d passes before the resolver answers, a
+// dns_timeout event ("host", "timeout_ms", "duration_ms", "resolver") is
+// emitted in place of dns_done and TraceAddr returns an error wrapping
+// [context.DeadlineExceeded]. No datagram is sent in that case, so a hung
+// resolver is not mistaken for a server that never replies, which UDP
+// otherwise cannot tell from a lost packet. d <= 0 leaves the lookup
+// bounded by ctx only, which is the default.
+func WithDNSTimeout(d time.Duration) Option {
+	return func(cfg *traceConfig) {
+		cfg.dnsTimeout = d
 	}
-	return hex.EncodeToString(b)
 }
 
+// lookupHost resolves host names. A variable so tests can simulate a hung
+// resolver.
+var lookupHost = net.DefaultResolver.LookupHost
+
 // emit is a nil-safe helper that calls em.Emit only when em is non-nil.
 // Centralising the nil-check removes one branch per call site and lowers
 // the cyclomatic complexity of TraceAddr.
 func emit(em event.Emitter, name, traceID string, data map[string]interface{}) {
+	emitEvent(em, event.NewEvent(name, traceID, data))
+}
+
+// emitEvent emits ev when em is set.
+func emitEvent(em event.Emitter, ev event.Event) {
 	if em != nil {
-		em.Emit(event.NewEvent(name, traceID, data))
+		em.Emit(ev)
 	}
 }
 
-func emitDryRunEvents(em event.Emitter, traceID, addr string) error {
+func emitDryRunEvents(cfg *traceConfig, traceID, addr string) error {
+	em := cfg.emitter
 	if em == nil {
 		return nil
 	}
 
-	em.Emit(event.NewEvent("dns_start", traceID, map[string]interface{}{"host": "1.1.1.1"}))
-	em.Emit(event.NewEvent("dns_done", traceID, map[string]interface{}{"ip": "1.1.1.1", "duration_ms": 10}))
+	phases := event.Phases{"send": 2, "receive": 20}
+	total := 22 * time.Millisecond
+	// As in a real trace, an IP literal is not looked up.
+	host, _, _ := net.SplitHostPort(addr)
+	if _, err := netip.ParseAddr(host); err == nil {
+		em.Emit(event.DNSSkipped(traceID, host, "ip_literal"))
+	} else {
+		em.Emit(event.DNSStart(traceID, host))
+		em.Emit(event.DNSDone(traceID, "1.1.1.1", 10*time.Millisecond))
+		phases["dns"] = 10
+		total += 10 * time.Millisecond
+	}
+	em.Emit(event.NewEvent("udp_connect", traceID, map[string]interface{}{"local_addr": "192.0.2.10:54321", "remote_addr": "1.1.1.1:53"}))
 	em.Emit(event.NewEvent("udp_send", traceID, map[string]interface{}{"bytes": 50, "duration_ms": 2}))
 	em.Emit(event.NewEvent("udp_receive", traceID, map[string]interface{}{"bytes": 100, "duration_ms": 20}))
+	if cfg.dnsQuery != nil {
+		em.Emit(event.NewEvent("udp_dns_answer", traceID, map[string]interface{}{
+			"rcode":     "NOERROR",
+			"truncated": false,
+			"answers":   []map[string]interface{}{},
+		}))
+	}
+	em.Emit(event.NewEvent(event.TraceSummary, traceID, event.SummaryData(phases, total, true)))
 
 	return nil
 }
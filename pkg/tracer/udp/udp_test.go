@@ -3,10 +3,16 @@ package udp
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"maps"
 	"net"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/mrlm-net/cure/pkg/tracer/event"
 	"github.com/mrlm-net/cure/pkg/tracer/formatter"
@@ -102,3 +108,571 @@ func TestTraceAddr_DryRun(t *testing.T) {
 		}
 	}
 }
+
+func TestTraceAddr_DryRun_DNS(t *testing.T) {
+	tests := []struct {
+		addr string
+		want []string
+		not  string
+	}{
+		{addr: "example.com:53", want: []string{event.TypeDNSStart, event.TypeDNSDone}, not: event.TypeDNSSkipped},
+		{addr: "1.1.1.1:53", want: []string{event.TypeDNSSkipped}, not: event.TypeDNSStart},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			events, err := Collect(context.Background(), tt.addr, WithDryRun(true))
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			seen := map[string]bool{}
+			var summary event.Event
+			for _, ev := range events {
+				seen[ev.Type] = true
+				if ev.Type == event.TraceSummary {
+					summary = ev
+				}
+			}
+			for _, typ := range tt.want {
+				if !seen[typ] {
+					t.Errorf("no %s event", typ)
+				}
+			}
+			if seen[tt.not] {
+				t.Errorf("unexpected %s event", tt.not)
+			}
+			phases, _ := summary.Data["phases"].(map[string]interface{})
+			if _, ok := phases["dns"]; ok != (tt.not == event.TypeDNSSkipped) {
+				t.Errorf("summary phases = %v, want a dns phase only when resolved", summary.Data["phases"])
+			}
+		})
+	}
+}
+
+func TestTraceAddr_WithTraceID(t *testing.T) {
+	em := &testEmitter{}
+	err := TraceAddr(context.Background(), "127.0.0.1:9",
+		WithEmitter(em),
+		WithDryRun(true),
+		WithTraceID("req-1234"),
+	)
+	if err != nil {
+		t.Fatalf("TraceAddr() error = %v", err)
+	}
+	if len(em.events) == 0 {
+		t.Fatal("no events emitted")
+	}
+	for _, ev := range em.events {
+		if ev.TraceID != "req-1234" {
+			t.Errorf("%s TraceID = %q, want %q", ev.Type, ev.TraceID, "req-1234")
+		}
+	}
+
+	err = TraceAddr(context.Background(), "127.0.0.1:9", WithDryRun(true), WithTraceID(""))
+	if !errors.Is(err, event.ErrEmptyTraceID) {
+		t.Errorf("TraceAddr() with empty trace ID error = %v, want %v", err, event.ErrEmptyTraceID)
+	}
+}
+
+// testEmitter captures emitted events for inspection.
+type testEmitter struct{ events []event.Event }
+
+func (e *testEmitter) Emit(ev event.Event) error { e.events = append(e.events, ev); return nil }
+func (e *testEmitter) Close() error              { return nil }
+
+func TestTraceAddr_ConnectAddrs(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket() error = %v", err)
+	}
+	defer conn.Close()
+	addr := conn.LocalAddr().String()
+
+	tests := []struct {
+		name   string
+		dryRun bool
+	}{
+		{"live", false},
+		{"dry run", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := &testEmitter{}
+			if err := TraceAddr(context.Background(), addr, WithEmitter(em), WithDryRun(tt.dryRun)); err != nil {
+				t.Fatalf("TraceAddr() error = %v", err)
+			}
+
+			var connect *event.Event
+			for i, ev := range em.events {
+				if ev.Type == "udp_connect" {
+					connect = &em.events[i]
+				}
+			}
+			if connect == nil {
+				t.Fatal("missing udp_connect event")
+			}
+			local, _ := connect.Data["local_addr"].(string)
+			if _, _, err := net.SplitHostPort(local); err != nil {
+				t.Errorf("udp_connect local_addr = %q, want host:port", local)
+			}
+			if !tt.dryRun && connect.Data["remote_addr"] != addr {
+				t.Errorf("udp_connect remote_addr = %v, want %q", connect.Data["remote_addr"], addr)
+			}
+		})
+	}
+}
+
+// stepClock is an event.Clock that advances by step on every reading, so
+// each measured duration is an exact multiple of step.
+type stepClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+func (c *stepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(c.step)
+	return c.now
+}
+
+func TestTraceAddr_TraceSummary(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket() error = %v", err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		if _, addr, err := conn.ReadFrom(buf); err == nil {
+			conn.WriteTo([]byte("pong"), addr)
+		}
+	}()
+
+	tests := []struct {
+		name       string
+		addr       string
+		wantOK     bool
+		wantPhases map[string]int64
+		wantTotal  int64
+	}{
+		// Every phase spans two clock readings; the total spans them all.
+		// The address is an IP literal, so there is no dns phase.
+		{"success", conn.LocalAddr().String(), true, map[string]int64{"send": 10, "receive": 10}, 50},
+		{"invalid address", "no-port", false, map[string]int64{}, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := &testEmitter{}
+			err := TraceAddr(context.Background(), tt.addr, WithEmitter(em), WithDataString("ping"),
+				WithClock(&stepClock{step: 10 * time.Millisecond}))
+			if (err == nil) != tt.wantOK {
+				t.Fatalf("TraceAddr() error = %v, want ok = %v", err, tt.wantOK)
+			}
+
+			last := em.events[len(em.events)-1]
+			if last.Type != event.TraceSummary {
+				t.Fatalf("last event = %q, want %s", last.Type, event.TraceSummary)
+			}
+			if last.Data["ok"] != tt.wantOK {
+				t.Errorf("trace_summary ok = %v, want %v", last.Data["ok"], tt.wantOK)
+			}
+			phases, _ := last.Data["phases"].(map[string]interface{})
+			if len(phases) != len(tt.wantPhases) {
+				t.Errorf("trace_summary phases = %v, want %v", phases, tt.wantPhases)
+			}
+			for name, ms := range tt.wantPhases {
+				if phases[name] != ms {
+					t.Errorf("trace_summary phase %q = %v, want %d", name, phases[name], ms)
+				}
+			}
+			if last.Data["total_ms"] != tt.wantTotal {
+				t.Errorf("trace_summary total_ms = %v, want %d", last.Data["total_ms"], tt.wantTotal)
+			}
+		})
+	}
+}
+
+// hangLookup replaces lookupHost with a resolver that never answers, only
+// returning once its context is done.
+func hangLookup(t *testing.T) {
+	t.Helper()
+	orig := lookupHost
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	t.Cleanup(func() { lookupHost = orig })
+}
+
+func TestTraceAddr_DNSTimeout(t *testing.T) {
+	hangLookup(t)
+
+	em := &testEmitter{}
+	err := TraceAddr(context.Background(), "hung.example:53",
+		WithEmitter(em),
+		WithDNSTimeout(20*time.Millisecond),
+	)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("TraceAddr() error = %v, want DeadlineExceeded", err)
+	}
+
+	var types []string
+	for _, ev := range em.events {
+		types = append(types, ev.Type)
+	}
+	want := []string{"dns_start", "dns_timeout", event.TraceSummary}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v, want %v", types, want)
+	}
+	data := em.events[1].Data
+	if data["host"] != "hung.example" || data["timeout_ms"] != int64(20) {
+		t.Errorf("dns_timeout data = %v", data)
+	}
+}
+
+func TestTraceAddr_DNSCancelledNotTimeout(t *testing.T) {
+	hangLookup(t)
+
+	// The caller's context ends during the lookup: that is a cancellation,
+	// reported as a plain dns_done failure, not a DNS timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	em := &testEmitter{}
+	err := TraceAddr(ctx, "hung.example:53",
+		WithEmitter(em),
+		WithDNSTimeout(time.Minute),
+	)
+	if err == nil {
+		t.Fatal("TraceAddr() error = nil, want lookup failure")
+	}
+	for _, ev := range em.events {
+		if ev.Type == "dns_timeout" {
+			t.Fatal("dns_timeout emitted for a cancelled context")
+		}
+		if ev.Type == "dns_done" && ev.Data["error"] == nil {
+			t.Errorf("dns_done without error: %v", ev.Data)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	em := &testEmitter{}
+	events, err := Collect(context.Background(), "1.1.1.1:53", WithDryRun(true), WithEmitter(em))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(events) == 0 {
+		t.Fatal("Collect() returned no events")
+	}
+	if len(em.events) != len(events) {
+		t.Errorf("WithEmitter emitter got %d events, Collect returned %d", len(em.events), len(events))
+	}
+	if last := events[len(events)-1].Type; last != event.TraceSummary {
+		t.Errorf("last event = %q, want %q", last, event.TraceSummary)
+	}
+}
+
+func TestWithLabels(t *testing.T) {
+	labels := map[string]string{"env": "prod", "region": "eu"}
+	events, err := Collect(context.Background(), "1.1.1.1:53", WithDryRun(true), WithLabels(labels))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(events) == 0 {
+		t.Fatal("Collect() returned no events")
+	}
+	for _, ev := range events {
+		if !maps.Equal(ev.Labels, labels) {
+			t.Errorf("%s labels = %v, want %v", ev.Type, ev.Labels, labels)
+		}
+	}
+}
+
+// startDNSResponder answers every DNS query on a local UDP socket with one
+// A record for the question, and returns its address.
+func startDNSResponder(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			q := buf[:n]
+			if n < 12 {
+				continue
+			}
+			// Echo the question, which ends at the first zero byte after
+			// the header plus QTYPE and QCLASS.
+			qend := 12
+			for qend < n && q[qend] != 0 {
+				qend += int(q[qend]) + 1
+			}
+			qend += 5
+			if qend > n {
+				continue
+			}
+			msg := append([]byte(nil), q[:qend]...)
+			binary.BigEndian.PutUint16(msg[2:], 0x8180) // response, RD, RA
+			binary.BigEndian.PutUint16(msg[6:], 1)      // ANCOUNT
+			binary.BigEndian.PutUint16(msg[10:], 0)     // ARCOUNT
+			msg = append(msg, 0xc0, 0x0c)               // name: pointer to the question
+			msg = binary.BigEndian.AppendUint16(msg, 1) // A
+			msg = binary.BigEndian.AppendUint16(msg, 1) // IN
+			msg = binary.BigEndian.AppendUint32(msg, 60)
+			msg = binary.BigEndian.AppendUint16(msg, 4)
+			msg = append(msg, 192, 0, 2, 7)
+			conn.WriteTo(msg, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestWithDNSQuery(t *testing.T) {
+	addr := startDNSResponder(t)
+
+	em := &testEmitter{}
+	err := TraceAddr(context.Background(), addr,
+		WithEmitter(em),
+		WithDataString("ignored"),
+		WithDNSQuery("example.com", "A"),
+	)
+	if err != nil {
+		t.Fatalf("TraceAddr() error = %v", err)
+	}
+
+	var answer *event.Event
+	for i, ev := range em.events {
+		if ev.Type == "udp_dns_answer" {
+			answer = &em.events[i]
+		}
+	}
+	if answer == nil {
+		t.Fatalf("missing udp_dns_answer event in %v", em.events)
+	}
+	if answer.Data["rcode"] != "NOERROR" || answer.Data["truncated"] != false {
+		t.Errorf("udp_dns_answer = %v, want NOERROR, not truncated", answer.Data)
+	}
+	answers, _ := answer.Data["answers"].([]map[string]interface{})
+	want := []map[string]interface{}{{"name": "example.com.", "type": "A", "ttl": uint32(60), "data": "192.0.2.7"}}
+	if !reflect.DeepEqual(answers, want) {
+		t.Errorf("answers = %v, want %v", answers, want)
+	}
+
+	for _, tt := range []struct{ name, qtype string }{
+		{"example.com", "SRV"},
+		{"bad..name", "A"},
+	} {
+		err := TraceAddr(context.Background(), addr, WithDNSQuery(tt.name, tt.qtype))
+		if err == nil || !strings.Contains(err.Error(), "invalid DNS query") {
+			t.Errorf("TraceAddr(WithDNSQuery(%q, %q)) error = %v, want invalid DNS query", tt.name, tt.qtype, err)
+		}
+	}
+}
+
+func TestWithDNSQuery_NotDNS(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket() error = %v", err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 512)
+		if _, addr, err := conn.ReadFrom(buf); err == nil {
+			conn.WriteTo([]byte("pong"), addr)
+		}
+	}()
+
+	em := &testEmitter{}
+	if err := TraceAddr(context.Background(), conn.LocalAddr().String(), WithEmitter(em), WithDNSQuery("example.com", "")); err != nil {
+		t.Fatalf("TraceAddr() error = %v", err)
+	}
+	for _, ev := range em.events {
+		if ev.Type == "udp_dns_answer" {
+			if _, ok := ev.Data["error"]; !ok {
+				t.Errorf("udp_dns_answer = %v, want error for a non-DNS reply", ev.Data)
+			}
+			return
+		}
+	}
+	t.Error("missing udp_dns_answer event")
+}
+
+func TestWithMaxReadBytes(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket() error = %v", err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			_, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			conn.WriteTo(bytes.Repeat([]byte("x"), 100), addr)
+		}
+	}()
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantBytes int
+		wantTrunc bool
+	}{
+		{"default", nil, 100, false},
+		{"capped", []Option{WithMaxReadBytes(10)}, 10, true},
+		{"at cap", []Option{WithMaxReadBytes(100)}, 100, false},
+		{"cap below a large buffer", []Option{WithRecvBuffer(1 << 30), WithMaxReadBytes(64)}, 64, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := &testEmitter{}
+			opts := append([]Option{WithEmitter(em), WithDataString("ping")}, tt.opts...)
+			if err := TraceAddr(context.Background(), conn.LocalAddr().String(), opts...); err != nil {
+				t.Fatalf("TraceAddr() error = %v", err)
+			}
+			var recv *event.Event
+			for i := range em.events {
+				if em.events[i].Type == "udp_receive" {
+					recv = &em.events[i]
+				}
+			}
+			if recv == nil {
+				t.Fatal("missing udp_receive event")
+			}
+			if recv.Data["bytes"] != tt.wantBytes {
+				t.Errorf("udp_receive bytes = %v, want %d", recv.Data["bytes"], tt.wantBytes)
+			}
+			if trunc, _ := recv.Data["truncated"].(bool); trunc != tt.wantTrunc {
+				t.Errorf("udp_receive truncated = %v, want %v", recv.Data["truncated"], tt.wantTrunc)
+			}
+		})
+	}
+}
+
+func TestWithHexdump(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket() error = %v", err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		_, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		conn.WriteTo([]byte{0xca, 0xfe, 'h', 'i'}, addr)
+	}()
+
+	em := &testEmitter{}
+	err = TraceAddr(context.Background(), conn.LocalAddr().String(),
+		WithEmitter(em),
+		WithDataString("\x7fping"),
+		WithHexdump(true),
+	)
+	if err != nil {
+		t.Fatalf("TraceAddr() error = %v", err)
+	}
+
+	want := map[string]string{
+		"udp_send":    "00000000  7f 70 69 6e 67                                    |.ping|\n",
+		"udp_receive": "00000000  ca fe 68 69                                       |..hi|\n",
+	}
+	for _, ev := range em.events {
+		dump, ok := want[ev.Type]
+		if !ok {
+			continue
+		}
+		delete(want, ev.Type)
+		if ev.Data["hexdump"] != dump {
+			t.Errorf("%s hexdump = %q, want %q", ev.Type, ev.Data["hexdump"], dump)
+		}
+		if _, ok := ev.Data["hexdump_truncated"]; ok {
+			t.Errorf("%s hexdump_truncated set for a short payload", ev.Type)
+		}
+	}
+	for typ := range want {
+		t.Errorf("missing %s event", typ)
+	}
+}
+
+func TestTraceAddr_IPLiteralSkipsDNS(t *testing.T) {
+	events, err := Collect(context.Background(), "127.0.0.1:9", WithDataString(""))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(events) == 0 || events[0].Type != event.TypeDNSSkipped {
+		t.Fatalf("events = %v, want dns_skipped first", events)
+	}
+	if events[0].Data["reason"] != "ip_literal" || events[0].Data["host"] != "127.0.0.1" {
+		t.Errorf("dns_skipped data = %v", events[0].Data)
+	}
+	for _, ev := range events {
+		if ev.Type == event.TypeDNSStart {
+			t.Error("dns_start emitted for an IP literal")
+		}
+	}
+}
+
+func TestTraceAddr_ZonedIPv6(t *testing.T) {
+	var zone string
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 && iface.Flags&net.FlagUp != 0 {
+			zone = iface.Name
+			break
+		}
+	}
+	if zone == "" {
+		t.Skip("no loopback interface")
+	}
+	pc, err := net.ListenPacket("udp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("cannot listen on ::1: %v", err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 64)
+		if n, from, err := pc.ReadFrom(buf); err == nil {
+			pc.WriteTo(buf[:n], from)
+		}
+	}()
+	_, port, _ := net.SplitHostPort(pc.LocalAddr().String())
+
+	host := "::1%" + zone
+	addr := net.JoinHostPort(host, port)
+	events, err := Collect(context.Background(), addr, WithDataString("ping"))
+	if err != nil {
+		t.Fatalf("Collect(%s) error = %v", addr, err)
+	}
+
+	types := make(map[string]event.Event)
+	for _, ev := range events {
+		types[ev.Type] = ev
+	}
+	if _, ok := types[event.TypeDNSStart]; ok {
+		t.Error("dns_start emitted for a zoned IP literal")
+	}
+	if got := types[event.TypeDNSSkipped].Data["host"]; got != host {
+		t.Errorf("dns_skipped host = %v, want %s", got, host)
+	}
+	if got := types["udp_connect"].Data["addr"]; got != addr {
+		t.Errorf("udp_connect addr = %v, want %s with the zone", got, addr)
+	}
+	if got := types["udp_receive"].Data["bytes"]; got != 4 {
+		t.Errorf("udp_receive bytes = %v, want the 4-byte echo", got)
+	}
+}